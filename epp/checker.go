@@ -0,0 +1,242 @@
+package epp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/types"
+)
+
+// Variables that are visible in all templates without being declared
+var globalVariables = map[string]bool{
+	`facts`:        true,
+	`server_facts`: true,
+	`settings`:     true,
+	`trusted`:      true,
+}
+
+// Checker checks calls to the epp() and inline_epp() functions against the signatures of the templates
+// that they render
+type Checker struct {
+	templates map[string]*parser.LambdaExpression
+	aliases   types.Aliases
+}
+
+// NewChecker creates a checker that resolves the type aliases declared in the given programs when it
+// checks the types of template parameters
+func NewChecker(programs ...*parser.Program) *Checker {
+	return &Checker{make(map[string]*parser.LambdaExpression), types.AliasesOf(programs...)}
+}
+
+// AddTemplate makes the template known under the given name, i.e. the name that epp() is called with
+// such as "mymod/x.epp". It returns false if the expression is not a template.
+func (c *Checker) AddTemplate(name string, expr parser.Expression) bool {
+	template, ok := templateOf(expr)
+	if ok {
+		c.templates[name] = template
+	}
+	return ok
+}
+
+// Signature returns the signature of the template with the given name
+func (c *Checker) Signature(name string) (*Signature, bool) {
+	if template, ok := c.templates[name]; ok {
+		return signatureOf(template), true
+	}
+	return nil, false
+}
+
+// CheckTemplates checks that the templates that declare parameters only reference variables that are
+// declared as parameters, assigned in the template, or are global such as $facts. Qualified variables
+// like $::x and $mymod::x are not checked.
+func (c *Checker) CheckTemplates() []issue.Reported {
+	names := make([]string, 0, len(c.templates))
+	for name := range c.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	issues := make([]issue.Reported, 0)
+	for _, name := range names {
+		label := fmt.Sprintf(`template '%s'`, name)
+		for _, ve := range undeclaredVariables(c.templates[name]) {
+			vn, _ := ve.Name()
+			issues = append(issues, issue.NewReported(EPP_UNDECLARED_VARIABLE, issue.SEVERITY_WARNING,
+				issue.H{`template`: label, `name`: vn}, ve))
+		}
+	}
+	return issues
+}
+
+// CheckCalls checks all calls to epp() and inline_epp() in the given expression. A call to epp() is
+// checked when the template name is a literal string and the template is known. A call to inline_epp()
+// is checked when the template source is a literal string. The parameters are checked when they are
+// given as a literal hash, or omitted. It is an error to omit a required parameter, to pass a parameter
+// that the template does not declare, and to pass a literal value that does not match the type of the
+// parameter.
+func (c *Checker) CheckCalls(e parser.Expression) []issue.Reported {
+	issues := make([]issue.Reported, 0)
+	visit := func(path []parser.Expression, e parser.Expression) {
+		if call, ok := e.(*parser.CallNamedFunctionExpression); ok {
+			issues = append(issues, c.checkCall(call)...)
+		}
+	}
+	visit(nil, e)
+	e.AllContents(nil, visit)
+	return issues
+}
+
+func (c *Checker) checkCall(call *parser.CallNamedFunctionExpression) []issue.Reported {
+	fn, ok := call.Functor().(*parser.QualifiedName)
+	if !ok || len(call.Arguments()) == 0 {
+		return nil
+	}
+	arg, ok := literal.ToLiteral(call.Arguments()[0])
+	if !ok {
+		return nil
+	}
+	str, ok := arg.(string)
+	if !ok {
+		return nil
+	}
+
+	var template *parser.LambdaExpression
+	var label string
+	switch fn.Name() {
+	case `epp`:
+		if template, ok = c.templates[str]; !ok {
+			return nil
+		}
+		label = fmt.Sprintf(`template '%s'`, str)
+	case `inline_epp`:
+		expr, err := parser.CreateParser(parser.PARSER_EPP_MODE).Parse(call.File(), str, false)
+		if err != nil {
+			return nil
+		}
+		if template, ok = templateOf(expr); !ok {
+			return nil
+		}
+		label = `inline template`
+	default:
+		return nil
+	}
+
+	sig := signatureOf(template)
+	if !sig.Specified {
+		return nil
+	}
+
+	given := make(map[string]bool)
+	issues := make([]issue.Reported, 0)
+	if len(call.Arguments()) > 1 {
+		hash, ok := call.Arguments()[1].(*parser.LiteralHash)
+		if !ok {
+			// Parameters are not known until the call is evaluated
+			return nil
+		}
+		for _, entry := range hash.Entries() {
+			ke := entry.(*parser.KeyedEntry)
+			key, ok := literal.ToLiteral(ke.Key())
+			if !ok {
+				continue
+			}
+			name, ok := key.(string)
+			if !ok {
+				continue
+			}
+			given[name] = true
+			p, ok := sig.Parameter(name)
+			if !ok {
+				issues = append(issues, issue.NewReported(EPP_UNKNOWN_PARAMETER, issue.SEVERITY_ERROR,
+					issue.H{`template`: label, `param`: name, `suggestions`: parser.Suggestions(name, sig.ParameterNames())}, ke.Key()))
+				continue
+			}
+			if reason := c.mismatch(p, ke.Value()); reason != `` {
+				issues = append(issues, issue.NewReported(EPP_PARAMETER_TYPE_MISMATCH, issue.SEVERITY_ERROR,
+					issue.H{`template`: label, `param`: name, `reason`: reason}, ke.Value()))
+			}
+		}
+	}
+
+	for _, p := range sig.Parameters {
+		if p.Required() && !given[p.Name] {
+			issues = append(issues, issue.NewReported(EPP_MISSING_PARAMETER, issue.SEVERITY_ERROR,
+				issue.H{`template`: label, `param`: p.Name}, call))
+		}
+	}
+	return issues
+}
+
+// mismatch describes why the value expression does not match the type of the parameter. An empty
+// string is returned when the parameter has no type, when the value is not a literal, and when the
+// value matches.
+func (c *Checker) mismatch(p *Parameter, value parser.Expression) string {
+	if p.Type == nil {
+		return ``
+	}
+	v, ok := literal.ToTypedLiteral(value)
+	if !ok {
+		return ``
+	}
+	t, err := types.FromExpression(p.Type, c.aliases)
+	if err != nil {
+		return ``
+	}
+	return types.Mismatch(t, v)
+}
+
+// undeclaredVariables returns the first reference to each unqualified variable in a template with a
+// parameter list that is neither a parameter, a variable assigned in the template, a parameter of a
+// lambda in the template, nor a global variable
+func undeclaredVariables(template *parser.LambdaExpression) []*parser.VariableExpression {
+	if !template.Body().(*parser.EppExpression).ParametersSpecified() {
+		return nil
+	}
+
+	declared := make(map[string]bool)
+	for _, p := range template.Parameters() {
+		declared[p.(*parser.Parameter).Name()] = true
+	}
+	references := make([]*parser.VariableExpression, 0)
+	template.Body().AllContents(nil, func(path []parser.Expression, e parser.Expression) {
+		switch e.(type) {
+		case *parser.AssignmentExpression:
+			declareAssigned(e.(*parser.AssignmentExpression).Lhs(), declared)
+		case *parser.LambdaExpression:
+			for _, p := range e.(*parser.LambdaExpression).Parameters() {
+				declared[p.(*parser.Parameter).Name()] = true
+			}
+		case *parser.VariableExpression:
+			references = append(references, e.(*parser.VariableExpression))
+		}
+	})
+
+	result := make([]*parser.VariableExpression, 0)
+	reported := make(map[string]bool)
+	for _, ve := range references {
+		name, ok := ve.Name()
+		if !ok || strings.Contains(name, `::`) || declared[name] || globalVariables[name] || reported[name] {
+			continue
+		}
+		reported[name] = true
+		result = append(result, ve)
+	}
+	return result
+}
+
+func declareAssigned(lhs parser.Expression, declared map[string]bool) {
+	switch lhs.(type) {
+	case *parser.VariableExpression:
+		if name, ok := lhs.(*parser.VariableExpression).Name(); ok {
+			declared[name] = true
+		}
+	case *parser.LiteralList:
+		for _, e := range lhs.(*parser.LiteralList).Elements() {
+			declareAssigned(e, declared)
+		}
+	}
+}
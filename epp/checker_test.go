@@ -0,0 +1,131 @@
+package epp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+var config = issue.Unindent(`
+  <%- | String $host,
+    Foo::Port $port = 80,
+    Array[String] $users = [],
+  | -%>
+  <% $users.each |$u| { -%>
+  user <%= $u %>@<%= $host %>:<%= $port %> <%= $timeout %> <%= $facts['os'] %> <%= $::x %>
+  <% } -%>
+  <% $local = 1 -%><%= $local %> <%= $timeout %>`)
+
+func TestSignatureOf(t *testing.T) {
+	sig, ok := SignatureOf(parseEPP(t, config))
+	if !ok {
+		t.Fatal(`expected a signature`)
+	}
+	if s := sig.String(); s != `|String $host, Foo::Port $port = 80, Array[String] $users = []|` {
+		t.Errorf(`unexpected signature %s`, s)
+	}
+	if p, _ := sig.Parameter(`host`); p == nil || !p.Required() {
+		t.Error(`expected $host to be required`)
+	}
+	if p, _ := sig.Parameter(`port`); p == nil || p.Required() {
+		t.Error(`expected $port to be optional`)
+	}
+
+	sig, _ = SignatureOf(parseEPP(t, `no parameters <%= $x %>`))
+	if sig.Specified || sig.String() != `` {
+		t.Errorf(`expected no parameters to be specified, got %s`, sig)
+	}
+	sig, _ = SignatureOf(parseEPP(t, `<%||%>no parameters`))
+	if !sig.Specified || sig.String() != `||` {
+		t.Errorf(`expected an empty parameter list, got %s`, sig)
+	}
+
+	if _, ok = SignatureOf(parseProgram(t, `1`)); ok {
+		t.Error(`expected a program to have no signature`)
+	}
+}
+
+func TestTemplateName(t *testing.T) {
+	for file, expected := range map[string]string{
+		`modules/mymod/templates/x.epp`:     `mymod/x.epp`,
+		`/etc/mymod/templates/sub/conf.epp`: `mymod/sub/conf.epp`,
+		`mymod/manifests/init.pp`:           ``,
+	} {
+		if name, _ := TemplateName(file); name != expected {
+			t.Errorf(`expected '%s' for %s, got '%s'`, expected, file, name)
+		}
+	}
+}
+
+func TestCheckTemplates(t *testing.T) {
+	c := NewChecker()
+	if !c.AddTemplate(`foo/config.epp`, parseEPP(t, config)) {
+		t.Fatal(`expected a template`)
+	}
+	c.AddTemplate(`foo/free.epp`, parseEPP(t, `<%= $anything %>`))
+
+	issues := c.CheckTemplates()
+	expectIssues(t, issues,
+		`The template 'foo/config.epp' references the variable '$timeout' which is not declared as a parameter`)
+	if issues[0].Location().Line() != 6 || issues[0].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`expected a warning on line 6, got %s`, issues[0])
+	}
+}
+
+func TestCheckCalls(t *testing.T) {
+	c := NewChecker(parseProgram(t, `type Foo::Port = Integer[1, 65535]`))
+	c.AddTemplate(`foo/config.epp`, parseEPP(t, config))
+	c.AddTemplate(`foo/free.epp`, parseEPP(t, `<%= $anything %>`))
+
+	issues := c.CheckCalls(parseProgram(t, issue.Unindent(`
+    $a = epp('foo/config.epp', {host => 'h', port => 8080})
+    $b = epp('foo/config.epp', {host => 'h', prot => 8080, users => ['a', 1]})
+    $c = epp('foo/config.epp', {port => 0})
+    $d = epp('foo/config.epp', $params)
+    $e = epp('foo/free.epp', {x => 1})
+    $f = epp('foo/unknown.epp', {x => 1})
+    file { '/tmp/x': content => epp('foo/config.epp') }
+    $g = inline_epp('<%| Integer $n |%><%= $n %>', {n => 'one'})
+    $h = epp('foo/config.epp', {"${k}" => 1, prot => 8080})`)))
+	expectIssues(t, issues,
+		`The template 'foo/config.epp' has no parameter named 'prot'. Did you mean 'port'?`,
+		`Parameter 'users' of the template 'foo/config.epp' index 1 expects a String value, got Integer`,
+		`Parameter 'port' of the template 'foo/config.epp' expects an Integer[1, 65535] value, got 0`,
+		`The template 'foo/config.epp' expects a value for parameter '$host'`,
+		`The template 'foo/config.epp' expects a value for parameter '$host'`,
+		`Parameter 'n' of the inline template expects an Integer value, got String`,
+		`The template 'foo/config.epp' has no parameter named 'prot'. Did you mean 'port'?`,
+		`The template 'foo/config.epp' expects a value for parameter '$host'`)
+
+	if line := issues[0].Location().Line(); line != 2 {
+		t.Errorf(`expected the unknown parameter on line 2, got %d`, line)
+	}
+	if line := issues[4].Location().Line(); line != 7 {
+		t.Errorf(`expected the missing parameter on line 7, got %d`, line)
+	}
+}
+
+func expectIssues(t *testing.T, issues []issue.Reported, expected ...string) {
+	t.Helper()
+	actual := make([]string, len(issues))
+	for i, ri := range issues {
+		actual[i] = ri.Error()
+		if loc := strings.Index(actual[i], ` (file:`); loc > 0 {
+			actual[i] = actual[i][:loc]
+		}
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected issues:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+func parseProgram(t *testing.T, source string) *parser.Program {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`test.pp`, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr.(*parser.Program)
+}
@@ -0,0 +1,27 @@
+package epp
+
+import (
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+const (
+	EPP_MISSING_PARAMETER       = `EPP_MISSING_PARAMETER`
+	EPP_NOT_A_TEMPLATE          = `EPP_NOT_A_TEMPLATE`
+	EPP_PARAMETER_TYPE_MISMATCH = `EPP_PARAMETER_TYPE_MISMATCH`
+	EPP_UNDECLARED_VARIABLE     = `EPP_UNDECLARED_VARIABLE`
+	EPP_UNKNOWN_PARAMETER       = `EPP_UNKNOWN_PARAMETER`
+)
+
+func init() {
+	issue.Hard(EPP_MISSING_PARAMETER, `The %{template} expects a value for parameter '$%{param}'`)
+
+	issue.Hard2(EPP_NOT_A_TEMPLATE, `%{expression} is not an EPP template`, issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard(EPP_PARAMETER_TYPE_MISMATCH, `Parameter '%{param}' of the %{template} %{reason}`)
+
+	issue.Soft(EPP_UNDECLARED_VARIABLE, `The %{template} references the variable '$%{name}' which is not declared as a parameter`)
+
+	issue.Hard2(EPP_UNKNOWN_PARAMETER, `The %{template} has no parameter named '%{param}'.%{suggestions}`,
+		issue.HF{`suggestions`: parser.DidYouMean})
+}
@@ -0,0 +1,78 @@
+// Package epp renders EPP templates without a Puppet server. Templates are evaluated by the
+// interpreter package, so they may only use the parts of the language that do not operate on a catalog.
+package epp
+
+import (
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/interpreter"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Render renders the template that the parser produced from EPP source, i.e. a program that was parsed
+// with the parser.PARSER_EPP_MODE option or the lambda that it contains, and returns the rendered text.
+//
+// The params are assigned to the parameters that the template declares in its |$x, $y| parameter list
+// and are checked against the declared types. A parameter that has no value in params gets its
+// default value, and it is an error if it has no default. When the template declares no parameters,
+// each entry in params is assigned to a variable of the same name.
+//
+// The parameter values are Go values that are converted the same way as by literal.FromValue, e.g. a
+// map becomes a hash and a struct becomes a hash of its fields. Whitespace trimming with <%- and -%>
+// is performed by the parser.
+func Render(expr parser.Expression, params map[string]interface{}) (string, error) {
+	template, ok := templateOf(expr)
+	if !ok {
+		return ``, issue.NewReported(EPP_NOT_A_TEMPLATE, issue.SEVERITY_ERROR, issue.H{`expression`: expr}, expr)
+	}
+
+	values := make(map[string]interface{}, len(params))
+	for name, param := range params {
+		value, err := toValue(param)
+		if err != nil {
+			return ``, err
+		}
+		values[name] = value
+	}
+
+	i := interpreter.NewInterpreter()
+	if program, ok := expr.(*parser.Program); ok {
+		i.Define(program)
+	}
+	return i.Render(template, values, nil)
+}
+
+// templateOf returns the lambda that holds the template body
+func templateOf(expr parser.Expression) (*parser.LambdaExpression, bool) {
+	switch expr.(type) {
+	case *parser.Program:
+		return templateOf(expr.(*parser.Program).Body())
+	case *parser.BlockExpression:
+		stmts := expr.(*parser.BlockExpression).Statements()
+		if len(stmts) == 1 {
+			return templateOf(stmts[0])
+		}
+	case *parser.LambdaExpression:
+		le := expr.(*parser.LambdaExpression)
+		if _, ok := le.Body().(*parser.EppExpression); ok {
+			return le, true
+		}
+	}
+	return nil, false
+}
+
+// toValue converts a Go value into the value that the interpreter uses for the Puppet expression that
+// represents it
+func toValue(param interface{}) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ri, ok := r.(issue.Reported); ok {
+				err = ri
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	value, _ = literal.ToTypedLiteral(literal.FromValue(param))
+	return
+}
@@ -0,0 +1,108 @@
+package epp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestRender(t *testing.T) {
+	type user struct {
+		Name  string
+		Admin bool
+	}
+
+	for _, tc := range []struct {
+		source   string
+		params   map[string]interface{}
+		expected string
+	}{
+		{`plain text`, nil, `plain text`},
+		{`Hello <%= $name %>!`, map[string]interface{}{`name`: `world`}, `Hello world!`},
+		{`<%| String $name, Integer $count = 2 |%><%= $name %>=<%= $count %>`, map[string]interface{}{`name`: `x`}, `x=2`},
+		{`<%| $a, $b = $a * 2 |%><%= $b %>`, map[string]interface{}{`a`: 3}, `6`},
+		{
+			issue.Unindent(`
+				<%- | Array[String] $hosts | -%>
+				<% $hosts.each |$h| { -%>
+				server <%= $h %>
+				<% } -%>
+				done`),
+			map[string]interface{}{`hosts`: []string{`a`, `b`}},
+			"server a\nserver b\ndone",
+		},
+		{
+			issue.Unindent(`
+				<%- | Hash $users | -%>
+				<%- $users.each |$k, $u| { -%>
+				<%= $k %>: <%= $u['name'] %><% if $u['admin'] { %> (admin)<% } %>
+				<%- } -%>`),
+			map[string]interface{}{`users`: map[string]user{`u1`: {`Ann`, true}, `u2`: {`Bob`, false}}},
+			"u1: Ann (admin)\nu2: Bob\n",
+		},
+		{`a <%# comment -%>  b`, nil, `a   b`},
+		{`<%- $x = 3 -%> <%= $x + 1 %> <%% %%>`, nil, `4 <% %>`},
+		{`<%= [1, 'a', undef, {b => 2}] %>`, nil, `[1, 'a', undef, {'b' => 2}]`},
+		{`<% $s = [1, 2].map |$x| { $x * 10 } -%><%= $s.join(', ') %>`, nil, `10, 20`},
+		{`<%= case $n { 1: { one } default: { many } } %>`, map[string]interface{}{`n`: 1}, `one`},
+	} {
+		actual, err := Render(parseEPP(t, tc.source), tc.params)
+		if err != nil {
+			t.Errorf(`unexpected error for %s: %s`, tc.source, err)
+			continue
+		}
+		if actual != tc.expected {
+			t.Errorf(`expected %q for %s, got %q`, tc.expected, tc.source, actual)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	for _, tc := range []struct {
+		source   string
+		params   map[string]interface{}
+		expected string
+	}{
+		{`<%| String $name |%>`, map[string]interface{}{`name`: 1},
+			`Parameter 'name' of the template expects a String value, got Integer (file: test.epp, line: 1, column: 1)`},
+		{`<%| String $name |%>`, nil,
+			`The template expects a value for parameter '$name' (file: test.epp, line: 1, column: 1)`},
+		{`<%| $name |%>`, map[string]interface{}{`name`: 1, `nmae`: 2},
+			`The template has no parameter named 'nmae' (file: test.epp, line: 1, column: 1)`},
+		{`<%= $x %>`, nil, `Unknown variable: '$x' (file: test.epp, line: 1, column: 5)`},
+		{`<% file { '/tmp/x': } %>`, nil,
+			`The catalog operation 'Resource Statement' is only available when compiling a catalog (file: test.epp, line: 1, column: 4)`},
+		{`<% include foo %>`, nil,
+			`The catalog operation 'include' is only available when compiling a catalog (file: test.epp, line: 1, column: 4)`},
+		{`x`, map[string]interface{}{`ch`: make(chan int)}, `The Go value`},
+	} {
+		_, err := Render(parseEPP(t, tc.source), tc.params)
+		if err == nil {
+			t.Errorf(`expected an error for %s`, tc.source)
+			continue
+		}
+		if actual := err.Error(); actual != tc.expected && !(tc.expected == `The Go value` && len(actual) > len(tc.expected) && actual[:len(tc.expected)] == tc.expected) {
+			t.Errorf(`expected '%s' for %s, got '%s'`, tc.expected, tc.source, actual)
+		}
+	}
+
+	expr, err := parser.CreateParser().Parse(`test.pp`, `1 + 2`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Render(expr, nil)
+	if err == nil || err.Error() != `A Program is not an EPP template (file: test.pp, line: 1, column: 1)` {
+		t.Errorf(`unexpected error %v`, err)
+	}
+}
+
+func parseEPP(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	expr, err := parser.CreateParser(parser.PARSER_EPP_MODE).Parse(`test.epp`, source, false)
+	if err != nil {
+		t.Fatal(fmt.Sprintf(`%s: %s`, source, err))
+	}
+	return expr
+}
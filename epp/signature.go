@@ -0,0 +1,115 @@
+package epp
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type (
+	// Parameter is a parameter declared in the |$x, $y| parameter list of a template
+	Parameter struct {
+		Name string
+
+		// Type is the declared type, or nil when no type is declared
+		Type parser.Expression
+
+		// Default is the default value expression, or nil when the parameter has no default
+		Default parser.Expression
+	}
+
+	// Signature is the parameter list of a template
+	Signature struct {
+		// Specified is false when the template has no parameter list. Such a template accepts any
+		// parameters.
+		Specified  bool
+		Parameters []*Parameter
+	}
+)
+
+// SignatureOf returns the signature of the template that the parser produced from EPP source, i.e. a
+// program that was parsed with the parser.PARSER_EPP_MODE option or the lambda that it contains. The
+// returned boolean is false when the expression is not a template.
+func SignatureOf(expr parser.Expression) (*Signature, bool) {
+	template, ok := templateOf(expr)
+	if !ok {
+		return nil, false
+	}
+	return signatureOf(template), true
+}
+
+func signatureOf(template *parser.LambdaExpression) *Signature {
+	s := &Signature{
+		Specified:  template.Body().(*parser.EppExpression).ParametersSpecified(),
+		Parameters: make([]*Parameter, len(template.Parameters()))}
+	for i, pe := range template.Parameters() {
+		p := pe.(*parser.Parameter)
+		s.Parameters[i] = &Parameter{Name: p.Name(), Type: p.Type(), Default: p.Value()}
+	}
+	return s
+}
+
+// Required returns true when the parameter has no default value
+func (p *Parameter) Required() bool {
+	return p.Default == nil
+}
+
+// Parameter returns the parameter with the given name
+func (s *Signature) Parameter(name string) (*Parameter, bool) {
+	for _, p := range s.Parameters {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// ParameterNames returns the names of the parameters in the order that they are declared
+func (s *Signature) ParameterNames() []string {
+	names := make([]string, len(s.Parameters))
+	for i, p := range s.Parameters {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// String returns the parameter list in Puppet syntax, e.g. "|String $name, Integer $count = 2|". It is
+// empty when no parameter list is specified.
+func (s *Signature) String() string {
+	if !s.Specified {
+		return ``
+	}
+	b := bytes.NewBufferString(`|`)
+	for i, p := range s.Parameters {
+		if i > 0 {
+			b.WriteString(`, `)
+		}
+		if p.Type != nil {
+			b.WriteString(p.Type.String())
+			b.WriteByte(' ')
+		}
+		b.WriteByte('$')
+		b.WriteString(p.Name)
+		if p.Default != nil {
+			b.WriteString(` = `)
+			b.WriteString(p.Default.String())
+		}
+	}
+	b.WriteByte('|')
+	return b.String()
+}
+
+// TemplateName returns the name that the epp() function uses for a template file in a module, e.g.
+// "mymod/sub/x.epp" for the file "modules/mymod/templates/sub/x.epp". The returned boolean is false
+// when the file is not in the templates directory of a module.
+func TemplateName(file string) (string, bool) {
+	segments := strings.Split(filepath.ToSlash(file), `/`)
+	for i := len(segments) - 2; i > 0; i-- {
+		if segments[i] == `templates` {
+			return strings.Join(append([]string{segments[i-1]}, segments[i+1:]...), `/`), true
+		}
+	}
+	return ``, false
+}
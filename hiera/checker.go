@@ -0,0 +1,105 @@
+// Package hiera checks Hiera data against the Puppet code that consumes it.
+package hiera
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/schema"
+	"github.com/lyraproj/puppet-parser/types"
+	"github.com/lyraproj/puppet-parser/yaml"
+)
+
+// Checker checks Hiera data files against the parameters of a set of classes
+type Checker struct {
+	classes map[string]*parser.HostClassDefinition
+	schemas map[string]*schema.Schema
+}
+
+// NewChecker creates a checker for the classes found in the given programs. Type aliases declared in
+// the programs are used when deriving the schemas of the class parameters.
+func NewChecker(programs ...*parser.Program) *Checker {
+	c := &Checker{make(map[string]*parser.HostClassDefinition), make(map[string]*schema.Schema)}
+	aliases := types.AliasesOf(programs...)
+	for _, program := range programs {
+		for _, d := range program.Definitions() {
+			if hc, ok := d.(*parser.HostClassDefinition); ok {
+				name := strings.ToLower(hc.Name())
+				c.classes[name] = hc
+				c.schemas[name] = schema.ClassSchema(hc, aliases)
+			}
+		}
+	}
+	return c
+}
+
+// CheckData checks the top level keys of the given Hiera data. A key of the form 'classname::param' is
+// checked when the class is known. The class must have a parameter with that name and the value must
+// match the type of the parameter. Interpolated strings are not checked since their values are not
+// known until lookup.
+func (c *Checker) CheckData(file, content string) []issue.Reported {
+	locator := parser.NewLocator(file, content)
+	issues := make([]issue.Reported, 0)
+	data, err := yaml.Parse(content)
+	if err != nil {
+		se := err.(*yaml.SyntaxError)
+		return append(issues, issue.NewReported(HIERA_YAML_SYNTAX_ERROR, issue.SEVERITY_ERROR,
+			issue.H{`message`: se.Message}, parser.NewLocation(locator, se.Offset, 0)))
+	}
+	if data.Kind != yaml.MAPPING {
+		return issues
+	}
+
+	for i, key := range data.Keys {
+		name, ok := key.Value.(string)
+		if !ok {
+			continue
+		}
+		name = strings.TrimPrefix(name, `::`)
+		sep := strings.LastIndex(name, `::`)
+		if sep < 0 {
+			continue
+		}
+		className := strings.ToLower(name[:sep])
+		hc, ok := c.classes[className]
+		if !ok {
+			continue
+		}
+		paramName := name[sep+2:]
+		s := c.schemas[className]
+		ps, ok := s.Properties[paramName]
+		if !ok {
+			names := make([]string, 0, len(s.Properties))
+			for n := range s.Properties {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			issues = append(issues, issue.NewReported(HIERA_UNKNOWN_PARAMETER, issue.SEVERITY_WARNING,
+				issue.H{`class`: hc.Name(), `param`: paramName, `suggestions`: parser.Suggestions(paramName, names)},
+				parser.NewLocation(locator, key.Offset, key.Length)))
+			continue
+		}
+		if v := ps.Validate(data.Values[i], isInterpolated); v != nil {
+			issues = append(issues, issue.NewReported(HIERA_TYPE_MISMATCH, issue.SEVERITY_ERROR,
+				issue.H{`key`: name, `type`: parameterType(hc, paramName), `reason`: v.Reason},
+				parser.NewLocation(locator, v.Node.Offset, v.Node.Length)))
+		}
+	}
+	return issues
+}
+
+func parameterType(hc *parser.HostClassDefinition, name string) string {
+	for _, p := range hc.Parameters() {
+		if param := p.(*parser.Parameter); param.Name() == name && param.Type() != nil {
+			return param.Type().String()
+		}
+	}
+	return `Any`
+}
+
+func isInterpolated(n *yaml.Node) bool {
+	str, ok := n.Value.(string)
+	return ok && strings.Contains(str, `%{`)
+}
@@ -0,0 +1,86 @@
+package hiera
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+var manifest = issue.Unindent(`
+  type Foo::Port = Integer[1, 65535]
+
+  class foo(
+    Foo::Port $port = 80,
+    Enum['present', 'absent'] $ensure = 'present',
+    Array[String] $users = [],
+    Optional[Struct[{name => String, Optional[uid] => Integer}]] $owner = undef,
+  ) {}`)
+
+func TestCheckData(t *testing.T) {
+	issues := checkData(t, issue.Unindent(`
+    ---
+    foo::port: 70000
+    foo::ensure: running
+    foo::users: [a, 1]
+    foo::owner: {name: root, gid: 0}
+    foo::prot: 80
+    bar::port: x
+    lookup_options: {}`))
+	expectIssues(t, issues,
+		`The value of 'foo::port' does not match the type Foo::Port of the class parameter: 70000 is greater than the maximum 65535`,
+		`The value of 'foo::ensure' does not match the type Enum['present', 'absent'] of the class parameter: expected one of 'present', 'absent', got 'running'`,
+		`The value of 'foo::users' does not match the type Array[String] of the class parameter: expected string, got integer`,
+		`The value of 'foo::owner' does not match the type Optional[Struct[{name => String, Optional[uid] => Integer}]] of the class parameter: unexpected key 'gid'`,
+		`Class 'foo' has no parameter named 'prot'. Did you mean 'port'?`)
+
+	if line := issues[2].Location().Line(); line != 4 {
+		t.Errorf(`expected issue on line 4, got %d`, line)
+	}
+	if issues[4].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`expected unknown parameter to be a warning`)
+	}
+}
+
+func TestCheckValidData(t *testing.T) {
+	expectIssues(t, checkData(t, issue.Unindent(`
+    foo::port: "%{lookup('port')}"
+    '::foo::ensure': absent
+    foo::owner: ~
+    foo::users:
+      - alice
+      - bob`)))
+}
+
+func TestCheckSyntaxError(t *testing.T) {
+	issues := checkData(t, "foo::port: [1, 2\n")
+	expectIssues(t, issues, `YAML syntax error: unexpected end of input in flow collection`)
+	if issues[0].Code() != HIERA_YAML_SYNTAX_ERROR {
+		t.Errorf(`unexpected code %s`, issues[0].Code())
+	}
+}
+
+func checkData(t *testing.T, data string) []issue.Reported {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`init.pp`, manifest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewChecker(expr.(*parser.Program)).CheckData(`common.yaml`, data)
+}
+
+func expectIssues(t *testing.T, issues []issue.Reported, expected ...string) {
+	t.Helper()
+	if len(issues) != len(expected) {
+		for _, i := range issues {
+			t.Log(i.Error())
+		}
+		t.Fatalf(`expected %d issues, got %d`, len(expected), len(issues))
+	}
+	for i, reported := range issues {
+		if actual := reported.Error(); !strings.HasPrefix(actual, expected[i]+` (file: common.yaml`) {
+			t.Errorf("expected issue '%s', got '%s'", expected[i], actual)
+		}
+	}
+}
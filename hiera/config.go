@@ -0,0 +1,331 @@
+package hiera
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/yaml"
+)
+
+// The keys of a version 5 hiera.yaml
+var CONFIG_KEYS = []string{`default_hierarchy`, `defaults`, `hierarchy`, `plan_hierarchy`, `version`}
+
+// The keys of the defaults in a version 5 hiera.yaml
+var DEFAULTS_KEYS = []string{`data_dig`, `data_hash`, `datadir`, `lookup_key`, `options`}
+
+// The keys of an entry in the hierarchy of a version 5 hiera.yaml
+var LEVEL_KEYS = []string{`data_dig`, `data_hash`, `datadir`, `glob`, `globs`, `lookup_key`, `mapped_paths`,
+	`name`, `options`, `path`, `paths`, `uri`, `uris`}
+
+// The keys of a hierarchy entry that tell where its data is found. Only one of them can be used.
+var LOCATION_KEYS = []string{`path`, `paths`, `glob`, `globs`, `uri`, `uris`, `mapped_paths`}
+
+// The hierarchies of a version 5 hiera.yaml
+var hierarchyKeys = []string{`hierarchy`, `default_hierarchy`, `plan_hierarchy`}
+
+type configChecker struct {
+	locator *parser.Locator
+	issues  []issue.Reported
+}
+
+// CheckConfig checks the version 5 Hiera configuration in the given hiera.yaml content and the YAML
+// data files of its hierarchies. The keys of the configuration are checked, and so are the
+// interpolations in its paths, where interpolation functions are not allowed. The data files are found
+// relative to the directory of the configuration file. Paths that contain interpolations are matched
+// against all files. Files that do not exist are skipped, like Hiera does. The data files that were
+// checked are returned together with the issues found.
+func CheckConfig(file, content string) ([]issue.Reported, []string) {
+	c := &configChecker{parser.NewLocator(file, content), make([]issue.Reported, 0)}
+	config, err := yaml.Parse(content)
+	if err != nil {
+		se := err.(*yaml.SyntaxError)
+		c.accept(HIERA_YAML_SYNTAX_ERROR, issue.H{`message`: se.Message}, se.Offset, 0)
+		return c.issues, nil
+	}
+	if version := config.Get(`version`); version == nil || version.Value != int64(5) {
+		v := interface{}(`none`)
+		offset := 0
+		if version != nil {
+			v = version.Value
+			offset = version.Offset
+		}
+		c.accept(HIERA_UNSUPPORTED_VERSION, issue.H{`version`: v}, offset, 0)
+		return c.issues, nil
+	}
+	c.checkKeys(config, `the configuration`, CONFIG_KEYS)
+
+	defaults := map[string]string{`datadir`: `data`, `data_hash`: `yaml_data`}
+	if dn := config.Get(`defaults`); dn != nil && c.expectKind(dn, `defaults`, `the configuration`, yaml.MAPPING) {
+		c.checkKeys(dn, `defaults`, DEFAULTS_KEYS)
+		backend := false
+		for i, k := range dn.Keys {
+			key := fmt.Sprint(k.Value)
+			switch key {
+			case `datadir`:
+				if str, ok := c.checkPath(dn.Values[i], key, `defaults`); ok {
+					defaults[key] = str
+				}
+			case `data_hash`, `lookup_key`, `data_dig`:
+				if !backend {
+					delete(defaults, `data_hash`)
+					backend = true
+				}
+				defaults[key] = fmt.Sprint(dn.Values[i].Value)
+			}
+		}
+	}
+
+	patterns := make([]string, 0)
+	for _, hk := range hierarchyKeys {
+		if hn := config.Get(hk); hn != nil && c.expectKind(hn, hk, `the configuration`, yaml.SEQUENCE) {
+			patterns = append(patterns, c.checkHierarchy(hk, hn, defaults)...)
+		}
+	}
+	dir := filepath.Dir(file)
+	files := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	for _, df := range files {
+		content, err := ioutil.ReadFile(df)
+		if err != nil {
+			continue
+		}
+		c.issues = append(c.issues, CheckInterpolations(df, string(content))...)
+	}
+	return c.issues, files
+}
+
+// checkHierarchy checks the entries of a hierarchy and returns the glob patterns, relative to the
+// directory of the configuration, that match the YAML data files of the entries
+func (c *configChecker) checkHierarchy(name string, hierarchy *yaml.Node, defaults map[string]string) []string {
+	patterns := make([]string, 0)
+	names := make(map[string]bool)
+	for _, level := range hierarchy.Items {
+		if !c.expectKind(level, `entry`, name, yaml.MAPPING) {
+			continue
+		}
+		levelName := ``
+		if nn := level.Get(`name`); nn != nil {
+			levelName = fmt.Sprint(nn.Value)
+			if names[levelName] {
+				c.accept(HIERA_DUPLICATE_LEVEL, issue.H{`name`: levelName}, nn.Offset, nn.Length)
+			}
+			names[levelName] = true
+		} else {
+			c.accept(HIERA_MISSING_LEVEL_NAME, issue.H{`context`: name}, level.Offset, 0)
+		}
+		context := fmt.Sprintf(`hierarchy entry '%s'`, levelName)
+		c.checkKeys(level, context, LEVEL_KEYS)
+
+		datadir := defaults[`datadir`]
+		if dn := level.Get(`datadir`); dn != nil {
+			if str, ok := c.checkPath(dn, `datadir`, context); ok {
+				datadir = str
+			}
+		}
+
+		yamlData := defaults[`data_hash`] == `yaml_data` || defaults[`lookup_key`] == `eyaml_lookup_key`
+		if dh, lk, dd := level.Get(`data_hash`), level.Get(`lookup_key`), level.Get(`data_dig`); dh != nil || lk != nil || dd != nil {
+			yamlData = dh != nil && dh.Value == `yaml_data` || lk != nil && lk.Value == `eyaml_lookup_key`
+		}
+
+		locations := 0
+		for _, lk := range LOCATION_KEYS {
+			ln := level.Get(lk)
+			if ln == nil {
+				continue
+			}
+			locations++
+			if locations == 2 {
+				c.accept(HIERA_MULTIPLE_LOCATIONS, issue.H{`name`: levelName}, ln.Offset, ln.Length)
+			}
+
+			var paths []*yaml.Node
+			switch lk {
+			case `path`, `glob`, `uri`:
+				paths = []*yaml.Node{ln}
+			case `mapped_paths`:
+				if !c.expectKind(ln, lk, context, yaml.SEQUENCE) {
+					continue
+				}
+				if len(ln.Items) != 3 {
+					c.accept(HIERA_INVALID_CONFIG_VALUE, issue.H{`key`: lk, `context`: context,
+						`expected`: `a list with a variable, a key, and a path`}, ln.Offset, ln.Length)
+					continue
+				}
+				paths = ln.Items[2:]
+			default:
+				if !c.expectKind(ln, lk, context, yaml.SEQUENCE) {
+					continue
+				}
+				paths = ln.Items
+			}
+			for _, pn := range paths {
+				path, ok := c.checkPath(pn, lk, context)
+				if ok && yamlData && lk != `uri` && lk != `uris` {
+					patterns = append(patterns, filepath.Join(globOf(datadir), globOf(path)))
+				}
+			}
+		}
+	}
+	return patterns
+}
+
+// checkPath checks that the node is a string with valid interpolations and returns the string
+func (c *configChecker) checkPath(n *yaml.Node, key, context string) (string, bool) {
+	str, ok := n.Value.(string)
+	if n.Kind != yaml.SCALAR || !ok {
+		c.accept(HIERA_INVALID_CONFIG_VALUE, issue.H{`key`: key, `context`: context, `expected`: `a string`}, n.Offset, n.Length)
+		return ``, false
+	}
+	interpolation, err := ParseInterpolation(str, false)
+	if err != nil {
+		c.issues = append(c.issues, err.Reported(stringLocation(c.locator, n, str, err.Offset)))
+		return ``, false
+	}
+	for _, uk := range interpolation.UnknownFactKeys() {
+		c.issues = append(c.issues, uk.Reported(stringLocation(c.locator, n, str, uk.Offset)))
+	}
+	return str, true
+}
+
+func (c *configChecker) checkKeys(n *yaml.Node, context string, valid []string) {
+	for _, k := range n.Keys {
+		key := fmt.Sprint(k.Value)
+		found := false
+		for _, v := range valid {
+			if v == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.issues = append(c.issues, issue.NewReported(HIERA_UNKNOWN_CONFIG_KEY, issue.SEVERITY_WARNING,
+				issue.H{`key`: key, `context`: context, `suggestions`: parser.Suggestions(key, valid)},
+				parser.NewLocation(c.locator, k.Offset, k.Length)))
+		}
+	}
+}
+
+func (c *configChecker) expectKind(n *yaml.Node, key, context string, kind yaml.Kind) bool {
+	if n.Kind == kind {
+		return true
+	}
+	expected := `a mapping`
+	if kind == yaml.SEQUENCE {
+		expected = `a list`
+	}
+	c.accept(HIERA_INVALID_CONFIG_VALUE, issue.H{`key`: key, `context`: context, `expected`: expected}, n.Offset, n.Length)
+	return false
+}
+
+func (c *configChecker) accept(code issue.Code, args issue.H, offset, length int) {
+	severity := issue.SEVERITY_ERROR
+	if code == HIERA_UNSUPPORTED_VERSION {
+		severity = issue.SEVERITY_WARNING
+	}
+	c.issues = append(c.issues, issue.NewReported(code, severity, args, parser.NewLocation(c.locator, offset, length)))
+}
+
+// CheckInterpolations checks the interpolations in all keys and string values of the given Hiera data
+func CheckInterpolations(file, content string) []issue.Reported {
+	locator := parser.NewLocator(file, content)
+	issues := make([]issue.Reported, 0)
+	data, err := yaml.Parse(content)
+	if err != nil {
+		se := err.(*yaml.SyntaxError)
+		return append(issues, issue.NewReported(HIERA_YAML_SYNTAX_ERROR, issue.SEVERITY_ERROR,
+			issue.H{`message`: se.Message}, parser.NewLocation(locator, se.Offset, 0)))
+	}
+
+	// Aliases make the same node appear more than once
+	visited := make(map[*yaml.Node]bool)
+	var check func(n *yaml.Node)
+	check = func(n *yaml.Node) {
+		if visited[n] {
+			return
+		}
+		visited[n] = true
+		switch n.Kind {
+		case yaml.SEQUENCE:
+			for _, item := range n.Items {
+				check(item)
+			}
+		case yaml.MAPPING:
+			for i, k := range n.Keys {
+				check(k)
+				check(n.Values[i])
+			}
+		default:
+			if str, ok := n.Value.(string); ok && strings.Contains(str, `%{`) {
+				interpolation, err := ParseInterpolation(str, true)
+				if err != nil {
+					issues = append(issues, err.Reported(stringLocation(locator, n, str, err.Offset)))
+					return
+				}
+				for _, uk := range interpolation.UnknownFactKeys() {
+					issues = append(issues, uk.Reported(stringLocation(locator, n, str, uk.Offset)))
+				}
+			}
+		}
+	}
+	check(data)
+	return issues
+}
+
+// stringLocation returns the location in the source of the byte at the given offset in the string value
+// of a scalar. The location is the start of the scalar when the scalar contains escapes or line breaks
+// that make it impossible to map the offset.
+func stringLocation(locator *parser.Locator, n *yaml.Node, str string, offset int) issue.Location {
+	source := locator.String()
+	if n.Offset+n.Length <= len(source) {
+		raw := source[n.Offset : n.Offset+n.Length]
+		if raw == str {
+			return parser.NewLocation(locator, n.Offset+offset, 0)
+		}
+		if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[1:len(raw)-1] == str {
+			return parser.NewLocation(locator, n.Offset+1+offset, 0)
+		}
+	}
+	return parser.NewLocation(locator, n.Offset, n.Length)
+}
+
+// globOf returns a glob pattern where each interpolation in the given path is replaced by '*', unless
+// it interpolates an empty string
+func globOf(path string) string {
+	interpolation, err := ParseInterpolation(path, false)
+	if err != nil {
+		return path
+	}
+	b := make([]string, len(interpolation.Parts))
+	for i, p := range interpolation.Parts {
+		if e, ok := p.(*Expression); ok {
+			if e.Key != nil {
+				b[i] = `*`
+			}
+		} else {
+			b[i] = p.String()
+		}
+	}
+	return strings.Join(b, ``)
+}
@@ -0,0 +1,182 @@
+package hiera
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestCheckConfig(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		`hiera.yaml`: issue.Unindent(`
+      ---
+      version: 5
+      defaults:
+        datadir: data
+        data_hash: yaml_data
+      hierarchy:
+        - name: Per node
+          path: "nodes/%{trusted.certname}.yaml"
+        - name: Per OS
+          paths:
+            - "os/%{facts.os.family}.yaml"
+            - "os/%{lookup('x')}.yaml"
+        - name: Per OS
+          glob: "common/*.yaml"
+          datadir: other
+        - name: Secrets
+          lookup_key: eyaml_lookup_key
+          path: secrets.eyaml
+        - name: Json
+          data_hash: json_data
+          path: common.json
+        - name: Common
+          path: common.yaml
+          uri: "http://example.com"
+          optoins: {}`),
+		`data/nodes/a.example.com.yaml`: issue.Unindent(`
+      ---
+      foo::port: "%{lookup('port')}"
+      foo::host: "%{facts.networking..fqdn}"`),
+		`data/nodes/b.example.com.yaml`: `foo::list: "x %{alias('list')}"`,
+		`data/common.yaml`:              `'%{lookups("x")}': 1`,
+		`data/common.json`:              `{"x": "%{facts..x}"}`,
+		`data/secrets.eyaml`:            `x: "%{::} %{scope('a..b')}"`,
+		`other/common/a.yaml`:           "x: |\n  %{facts.}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	issues, files := checkConfig(t, dir)
+	expectMessages(t, issues,
+		`Interpolation functions are not allowed in the Hiera configuration, got %{lookup('x')} (file: hiera.yaml, line: 12, column: 15)`,
+		`Hierarchy name 'Per OS' is defined more than once (file: hiera.yaml, line: 13, column: 11)`,
+		`Unknown key 'optoins' in hierarchy entry 'Common'. Did you mean 'options'? (file: hiera.yaml, line: 25, column: 5)`,
+		`Only one of path, paths, glob, globs, uri, uris, and mapped_paths can be defined in hierarchy 'Common' (file: hiera.yaml, line: 24, column: 10)`,
+		`Syntax error in interpolation %{facts.networking..fqdn}: empty key segment (file: data/nodes/a.example.com.yaml, line: 3, column: 32)`,
+		`'alias' interpolation is only permitted if the expression is equal to the entire string (file: data/nodes/b.example.com.yaml, line: 1, column: 15)`,
+		`Syntax error in interpolation %{facts.}: empty key segment (file: other/common/a.yaml, line: 1, column: 4)`,
+		`Syntax error in interpolation %{scope('a..b')}: empty key segment (file: data/secrets.eyaml, line: 1, column: 22)`,
+		`Unknown interpolation function 'lookups'. Did you mean 'lookup'? (file: data/common.yaml, line: 1, column: 4)`)
+
+	relative := make([]string, len(files))
+	for i, f := range files {
+		relative[i], _ = filepath.Rel(dir, f)
+	}
+	if s := strings.Join(relative, `, `); s != `data/nodes/a.example.com.yaml, data/nodes/b.example.com.yaml, other/common/a.yaml, data/secrets.eyaml, data/common.yaml` {
+		t.Errorf(`unexpected data files %s`, s)
+	}
+}
+
+func TestCheckConfigVersion(t *testing.T) {
+	dir := writeFiles(t, map[string]string{`hiera.yaml`: "---\n:backends:\n  - yaml\n"})
+	defer os.RemoveAll(dir)
+
+	issues, files := checkConfig(t, dir)
+	expectMessages(t, issues, `Only version 5 of the Hiera configuration can be checked, got version none (file: hiera.yaml, line: 1, column: 1)`)
+	if issues[0].Severity() != issue.SEVERITY_WARNING || len(files) != 0 {
+		t.Errorf(`expected a warning and no files`)
+	}
+}
+
+func TestCheckConfigFactKeys(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		`hiera.yaml`: issue.Unindent(`
+      ---
+      version: 5
+      hierarchy:
+        - name: Per node
+          path: "nodes/%{trusted.certname}.yaml"
+        - name: Per role
+          path: "roles/%{facts.role}.yaml"
+        - name: Per OS
+          path: "os/%{facts.os.famly}.yaml"`),
+	})
+	defer os.RemoveAll(dir)
+
+	issues, _ := checkConfig(t, dir)
+	expectMessages(t, issues,
+		`Unknown key 'famly' in 'facts.os'. Did you mean 'family'? (file: hiera.yaml, line: 9, column: 26)`)
+	if issues[0].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`expected a warning`)
+	}
+}
+
+func TestCheckInterpolations(t *testing.T) {
+	expectMessages(t, CheckInterpolations(`common.yaml`, issue.Unindent(`
+    a: &x "%{facts.os.}"
+    b: *x
+    c:
+      - '%{lookup(''x'')}'
+      - 'it''s %{facts..os}'
+      - %{}`)),
+		`Syntax error in interpolation %{facts.os.}: empty key segment (file: common.yaml, line: 1, column: 19)`,
+		`Syntax error in interpolation %{facts..os}: empty key segment (file: common.yaml, line: 5, column: 5)`)
+
+	expectMessages(t, CheckInterpolations(`common.yaml`, issue.Unindent(`
+    a: "%{facts.os.release.mayor} %{::trusted.certnam}"
+    b: "%{facts.role.x} %{facts.networking.interfaces.eth0.ip} %{facts.processors.models.0}"
+    c: "%{facts.kernel.x} %{scope('facts.networking.hostnme')} %{lookup('facts.os.x')}"`)),
+		`Unknown key 'mayor' in 'facts.os.release'. Did you mean 'major'? (file: common.yaml, line: 1, column: 24)`,
+		`Unknown key 'certnam' in '::trusted'. Did you mean 'certname'? (file: common.yaml, line: 1, column: 43)`,
+		`Unknown key 'x' in 'facts.kernel'. (file: common.yaml, line: 3, column: 20)`,
+		`Unknown key 'hostnme' in 'facts.networking'. Did you mean 'hostname'? (file: common.yaml, line: 3, column: 49)`)
+}
+
+func checkConfig(t *testing.T, dir string) ([]issue.Reported, []string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	content, err := ioutil.ReadFile(`hiera.yaml`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, files := CheckConfig(`hiera.yaml`, string(content))
+	for i, f := range files {
+		files[i] = filepath.Join(dir, f)
+	}
+	return issues, files
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir(``, `hiera`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func expectMessages(t *testing.T, issues []issue.Reported, expected ...string) {
+	t.Helper()
+	actual := make([]string, len(issues))
+	for i, ri := range issues {
+		actual[i] = ri.Error()
+	}
+	ok := len(actual) == len(expected)
+	for i := 0; ok && i < len(actual); i++ {
+		ok = strings.HasPrefix(actual[i], expected[i])
+	}
+	if !ok {
+		t.Errorf("expected issues:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
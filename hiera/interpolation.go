@@ -0,0 +1,330 @@
+package hiera
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/validator"
+)
+
+// The functions that can be called in an interpolation expression, e.g. %{lookup('key')}
+var INTERPOLATION_FUNCTIONS = []string{`alias`, `hiera`, `literal`, `lookup`, `scope`}
+
+// Expressions that interpolate an empty string. They are used to escape a '%{' sequence.
+var emptyInterpolations = map[string]bool{``: true, `::`: true, `""`: true, `''`: true, `"::"`: true, `'::'`: true}
+
+// Matches a call of an interpolation function. Puppet does not allow white space in the call.
+var functionCall = regexp.MustCompile(`\A(\w+)\((?:"([^"]+)"|'([^']+)')\)\z`)
+
+// Matches the start of something that was meant to be a call of an interpolation function
+var functionStart = regexp.MustCompile(`\A(\w+)\s*\(`)
+
+// Matches a variable name, with an optional leading '::'
+var variableName = regexp.MustCompile(`\A(?:::)?(?:[a-z_]\w*::)*[a-z_]\w*\z`)
+
+type (
+	// Interpolation is a string that may contain interpolation expressions such as %{facts.os.family}
+	Interpolation struct {
+		// Parts are the *Text and *Expression parts of the string in the order that they appear
+		Parts []Part
+	}
+
+	// Part is a part of an interpolated string
+	Part interface {
+		// String returns the source of the part
+		String() string
+	}
+
+	// Text is a part of an interpolated string that is not interpolated
+	Text struct {
+		Value  string
+		Offset int
+	}
+
+	// Expression is a %{...} part of an interpolated string
+	Expression struct {
+		// Function is the name of the interpolation function, or empty when the expression is a
+		// variable
+		Function string
+
+		// Argument is the quoted argument of the function, or the dotted key of a variable
+		Argument string
+
+		// Key is the dotted key of a variable, or the key that is looked up by the alias, hiera, lookup,
+		// and scope functions. It is nil for the literal function and for expressions that interpolate an
+		// empty string, such as %{}.
+		Key []*Segment
+
+		// Source is the source of the expression, including the %{ and }
+		Source string
+
+		// Offset is the byte offset of the expression in the interpolated string
+		Offset int
+	}
+
+	// Segment is a segment of a dotted key
+	Segment struct {
+		Value  string
+		Quoted bool
+
+		// Offset and Length denote the segment, including quotes, in the interpolated string
+		Offset int
+		Length int
+	}
+
+	// InterpolationError is returned when an interpolated string cannot be parsed
+	InterpolationError struct {
+		Code issue.Code
+		Args issue.H
+
+		// Offset is the byte offset of the error in the interpolated string
+		Offset int
+	}
+)
+
+// ParseInterpolation parses the interpolation expressions in the given string. The rules are the ones
+// that Puppet uses when it interpolates Hiera data. Strings that Puppet would silently interpolate as
+// an empty string, such as a malformed function call or a key with an empty segment, are errors. Calls
+// to interpolation functions are errors unless allowFunctions is true.
+func ParseInterpolation(str string, allowFunctions bool) (*Interpolation, *InterpolationError) {
+	result := &Interpolation{make([]Part, 0, 1)}
+	pos := 0
+	for {
+		start := strings.Index(str[pos:], `%{`)
+		if start < 0 {
+			break
+		}
+		start += pos
+		if start > pos {
+			result.Parts = append(result.Parts, &Text{str[pos:start], pos})
+		}
+		end := strings.IndexByte(str[start:], '}')
+		if end < 0 {
+			return nil, &InterpolationError{HIERA_INTERPOLATION_SYNTAX_ERROR,
+				issue.H{`expression`: str[start:], `message`: `the expression is not terminated by '}'`}, start}
+		}
+		end += start + 1
+		expr, err := parseExpression(str[start:end], start, allowFunctions)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Function == `alias` && end-start != len(str) {
+			return nil, &InterpolationError{HIERA_ALIAS_NOT_ENTIRE_STRING, issue.H{}, start}
+		}
+		result.Parts = append(result.Parts, expr)
+		pos = end
+	}
+	if pos < len(str) {
+		result.Parts = append(result.Parts, &Text{str[pos:], pos})
+	}
+	return result, nil
+}
+
+// parseExpression parses an expression of the form %{...} found at the given offset
+func parseExpression(source string, offset int, allowFunctions bool) (*Expression, *InterpolationError) {
+	expr := &Expression{Source: source, Offset: offset}
+	content := source[2 : len(source)-1]
+
+	// Leading and trailing spaces are insignificant
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	start := offset + 2 + len(content) - len(trimmed)
+	trimmed = strings.TrimRight(trimmed, " \t\r\n")
+	if emptyInterpolations[trimmed] {
+		return expr, nil
+	}
+
+	if m := functionCall.FindStringSubmatch(trimmed); m != nil {
+		if !allowFunctions {
+			return nil, &InterpolationError{HIERA_FUNCTION_NOT_ALLOWED, issue.H{`expression`: source}, start}
+		}
+		expr.Function = m[1]
+		if !isInterpolationFunction(expr.Function) {
+			return nil, &InterpolationError{HIERA_UNKNOWN_INTERPOLATION_FUNCTION, issue.H{`name`: expr.Function,
+				`suggestions`: parser.Suggestions(expr.Function, INTERPOLATION_FUNCTIONS)}, start}
+		}
+		expr.Argument = m[2] + m[3]
+		if expr.Function != `literal` {
+			key, err := parseKey(expr.Argument, start+len(expr.Function)+2, source)
+			if err != nil {
+				return nil, err
+			}
+			expr.Key = key
+		}
+		return expr, nil
+	}
+
+	if m := functionStart.FindStringSubmatch(trimmed); m != nil {
+		// Puppet would look up a variable with a name like "lookup ('x')" and interpolate an empty string
+		if !allowFunctions {
+			return nil, &InterpolationError{HIERA_FUNCTION_NOT_ALLOWED, issue.H{`expression`: source}, start}
+		}
+		if !isInterpolationFunction(m[1]) {
+			return nil, &InterpolationError{HIERA_UNKNOWN_INTERPOLATION_FUNCTION, issue.H{`name`: m[1],
+				`suggestions`: parser.Suggestions(m[1], INTERPOLATION_FUNCTIONS)}, start}
+		}
+		return nil, &InterpolationError{HIERA_INTERPOLATION_SYNTAX_ERROR, issue.H{`expression`: source,
+			`message`: `the argument must be one quoted string without surrounding spaces, e.g. ` + m[1] + `('key')`}, start}
+	}
+
+	expr.Argument = trimmed
+	key, err := parseKey(trimmed, start, source)
+	if err != nil {
+		return nil, err
+	}
+	if name := key[0].Value; key[0].Quoted || !variableName.MatchString(name) {
+		return nil, &InterpolationError{HIERA_INTERPOLATION_SYNTAX_ERROR, issue.H{`expression`: source,
+			`message`: `'` + name + `' is not a valid variable name`}, start}
+	}
+	expr.Key = key
+	return expr, nil
+}
+
+// parseKey splits a dotted key into segments. Segments that contain dots must be quoted. The offset is
+// the offset of the key in the interpolated string.
+func parseKey(key string, offset int, source string) ([]*Segment, *InterpolationError) {
+	fail := func(pos int, message string) *InterpolationError {
+		return &InterpolationError{HIERA_INTERPOLATION_SYNTAX_ERROR, issue.H{`expression`: source, `message`: message}, offset + pos}
+	}
+
+	segments := make([]*Segment, 0, 1)
+	pos := 0
+	for {
+		for pos < len(key) && key[pos] == ' ' {
+			pos++
+		}
+		start := pos
+		var segment *Segment
+		if pos < len(key) && (key[pos] == '"' || key[pos] == '\'') {
+			end := strings.IndexByte(key[pos+1:], key[pos])
+			if end < 0 {
+				return nil, fail(pos, `unterminated quoted key segment`)
+			}
+			if end == 0 {
+				return nil, fail(pos, `empty key segment`)
+			}
+			pos += end + 2
+			segment = &Segment{key[start+1 : pos-1], true, offset + start, pos - start}
+			for pos < len(key) && key[pos] == ' ' {
+				pos++
+			}
+		} else {
+			for pos < len(key) && key[pos] != '.' {
+				if key[pos] == '"' || key[pos] == '\'' {
+					return nil, fail(pos, `a quote must start a key segment`)
+				}
+				pos++
+			}
+			value := strings.TrimRight(key[start:pos], ` `)
+			if value == `` {
+				return nil, fail(start, `empty key segment`)
+			}
+			segment = &Segment{value, false, offset + start, len(value)}
+		}
+		segments = append(segments, segment)
+		if pos == len(key) {
+			return segments, nil
+		}
+		if key[pos] != '.' {
+			return nil, fail(pos, `expected '.' after a quoted key segment`)
+		}
+		pos++
+		if pos == len(key) {
+			return nil, fail(pos, `empty key segment`)
+		}
+	}
+}
+
+func isInterpolationFunction(name string) bool {
+	for _, f := range INTERPOLATION_FUNCTIONS {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Expressions returns the interpolation expressions of the string
+func (i *Interpolation) Expressions() []*Expression {
+	exprs := make([]*Expression, 0, len(i.Parts))
+	for _, p := range i.Parts {
+		if expr, ok := p.(*Expression); ok {
+			exprs = append(exprs, expr)
+		}
+	}
+	return exprs
+}
+
+func (t *Text) String() string {
+	return t.Value
+}
+
+func (e *Expression) String() string {
+	return e.Source
+}
+
+// UnknownFactKeys returns an error for each key of a fact, or of $trusted, that an expression references
+// but that is not known to exist, such as 'famly' in %{facts.os.famly}. Custom facts are not checked and
+// neither are the keys of hashes that vary between nodes, such as the network interfaces.
+func (i *Interpolation) UnknownFactKeys() []*InterpolationError {
+	errs := make([]*InterpolationError, 0)
+	for _, expr := range i.Expressions() {
+		if expr.Function != `` && expr.Function != `scope` || len(expr.Key) < 2 {
+			continue
+		}
+		var keys validator.FactKeys
+		switch strings.TrimPrefix(expr.Key[0].Value, `::`) {
+		case `facts`:
+			var ok bool
+			if keys, ok = validator.FACT_KEYS.Lookup(expr.Key[1].Value); !ok {
+				continue
+			}
+			if err := unknownKey(expr.Key[0].Value+`.`+expr.Key[1].Value, expr.Key[2:], keys); err != nil {
+				errs = append(errs, err)
+			}
+		case `trusted`:
+			if err := unknownKey(expr.Key[0].Value, expr.Key[1:], validator.TRUSTED_KEYS); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// unknownKey returns an error for the first of the given segments that is not a known key
+func unknownKey(path string, segments []*Segment, keys validator.FactKeys) *InterpolationError {
+	for _, s := range segments {
+		next, ok := keys.Lookup(s.Value)
+		if !ok {
+			return &InterpolationError{HIERA_UNKNOWN_FACT_KEY, issue.H{`key`: s.Value, `path`: path,
+				`suggestions`: parser.Suggestions(s.Value, keys.Names())}, s.Offset}
+		}
+		path += `.` + s.Value
+		keys = next
+	}
+	return nil
+}
+
+// Index returns the integer value of a segment that is an index into an array
+func (s *Segment) Index() (int, bool) {
+	if s.Quoted {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s.Value)
+	return n, err == nil
+}
+
+func (e *InterpolationError) Error() string {
+	return e.Reported(nil).Error()
+}
+
+// Reported returns the error as an issue at the given location
+func (e *InterpolationError) Reported(location issue.Location) issue.Reported {
+	severity := issue.SEVERITY_ERROR
+	if e.Code == HIERA_UNKNOWN_FACT_KEY {
+		severity = issue.SEVERITY_WARNING
+	}
+	return issue.NewReported(e.Code, severity, e.Args, location)
+}
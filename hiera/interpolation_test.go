@@ -0,0 +1,109 @@
+package hiera
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInterpolation(t *testing.T) {
+	in, err := ParseInterpolation(`nodes/%{facts.os.family}/%{ trusted."cert.name" }.yaml`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(in.Parts) != 5 {
+		t.Fatalf(`expected 5 parts, got %d`, len(in.Parts))
+	}
+	exprs := in.Expressions()
+	if len(exprs) != 2 {
+		t.Fatalf(`expected 2 expressions, got %d`, len(exprs))
+	}
+	if keyString(exprs[0]) != `facts|os|family` || exprs[0].Function != `` || exprs[0].Offset != 6 {
+		t.Errorf(`unexpected expression %s: %s at %d`, exprs[0], keyString(exprs[0]), exprs[0].Offset)
+	}
+	if keyString(exprs[1]) != `trusted|cert.name` || !exprs[1].Key[1].Quoted || exprs[1].Key[1].Offset != 36 {
+		t.Errorf(`unexpected expression %s: %s`, exprs[1], keyString(exprs[1]))
+	}
+	parts := make([]string, len(in.Parts))
+	for i, p := range in.Parts {
+		parts[i] = p.String()
+	}
+	if s := strings.Join(parts, ``); s != `nodes/%{facts.os.family}/%{ trusted."cert.name" }.yaml` {
+		t.Errorf(`parts do not make up the source: %s`, s)
+	}
+
+	for str, expected := range map[string]string{
+		`%{lookup('a::b.0')}`:   `lookup a::b|0`,
+		`%{hiera("x")}`:         `hiera x`,
+		`%{alias('list')}`:      `alias list`,
+		`%{scope('::fqdn')}`:    `scope ::fqdn`,
+		`%{literal('%')}{x}`:    `literal `,
+		`100%{}{x}`:             ` `,
+		`%{::}%{''}`:            ` `,
+		`%{::networking.ip}`:    ` ::networking|ip`,
+		`no interpolation %(x)`: ``,
+	} {
+		in, err := ParseInterpolation(str, true)
+		if err != nil {
+			t.Errorf(`unexpected error in %s: %s`, str, err)
+			continue
+		}
+		actual := make([]string, 0)
+		for _, expr := range in.Expressions() {
+			actual = append(actual, strings.TrimSpace(expr.Function+` `+keyString(expr)))
+		}
+		if strings.TrimSpace(strings.Join(actual, ` `)) != strings.TrimSpace(expected) {
+			t.Errorf(`expected '%s' for %s, got '%s'`, expected, str, strings.Join(actual, ` `))
+		}
+	}
+
+	if seg := exprs[0].Key[0]; seg.Value != `facts` {
+		t.Errorf(`unexpected segment %s`, seg.Value)
+	}
+	in, _ = ParseInterpolation(`%{lookup('a.1')}`, true)
+	if n, ok := in.Expressions()[0].Key[1].Index(); !ok || n != 1 {
+		t.Errorf(`expected index 1`)
+	}
+}
+
+func TestParseInterpolationErrors(t *testing.T) {
+	for _, tc := range []struct {
+		str      string
+		expected string
+		offset   int
+	}{
+		{`a %{facts.os`, `Syntax error in interpolation %{facts.os: the expression is not terminated by '}'`, 2},
+		{`%{lookups('x')}`, `Unknown interpolation function 'lookups'. Did you mean 'lookup'?`, 2},
+		{`%{lookup( 'x' )}`, `Syntax error in interpolation %{lookup( 'x' )}: the argument must be one quoted string without surrounding spaces, e.g. lookup('key')`, 2},
+		{`%{lookup(x)}`, `Syntax error in interpolation %{lookup(x)}: the argument must be one quoted string without surrounding spaces, e.g. lookup('key')`, 2},
+		{`x%{alias('y')}`, `'alias' interpolation is only permitted if the expression is equal to the entire string`, 1},
+		{`%{facts..os}`, `Syntax error in interpolation %{facts..os}: empty key segment`, 8},
+		{`%{facts.os.}`, `Syntax error in interpolation %{facts.os.}: empty key segment`, 11},
+		{`%{facts['os']}`, `Syntax error in interpolation %{facts['os']}: a quote must start a key segment`, 8},
+		{`%{facts."os}`, `Syntax error in interpolation %{facts."os}: unterminated quoted key segment`, 8},
+		{`%{facts."os"x}`, `Syntax error in interpolation %{facts."os"x}: expected '.' after a quoted key segment`, 12},
+		{`%{$facts.os}`, `Syntax error in interpolation %{$facts.os}: '$facts' is not a valid variable name`, 2},
+		{`%{lookup('a..b')}`, `Syntax error in interpolation %{lookup('a..b')}: empty key segment`, 12},
+	} {
+		_, err := ParseInterpolation(tc.str, true)
+		if err == nil {
+			t.Errorf(`expected an error in %s`, tc.str)
+			continue
+		}
+		if err.Error() != tc.expected || err.Offset != tc.offset {
+			t.Errorf("expected '%s' at %d, got '%s' at %d", tc.expected, tc.offset, err.Error(), err.Offset)
+		}
+	}
+
+	_, err := ParseInterpolation(`%{lookup('x')}`, false)
+	if err == nil || err.Code != HIERA_FUNCTION_NOT_ALLOWED {
+		t.Errorf(`expected function to not be allowed, got %v`, err)
+	}
+}
+
+func keyString(expr *Expression) string {
+	segments := make([]string, len(expr.Key))
+	for i, s := range expr.Key {
+		segments[i] = s.Value
+	}
+	return strings.Join(segments, `|`)
+}
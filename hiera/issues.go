@@ -0,0 +1,57 @@
+package hiera
+
+import (
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+const (
+	HIERA_ALIAS_NOT_ENTIRE_STRING        = `HIERA_ALIAS_NOT_ENTIRE_STRING`
+	HIERA_DUPLICATE_LEVEL                = `HIERA_DUPLICATE_LEVEL`
+	HIERA_FUNCTION_NOT_ALLOWED           = `HIERA_FUNCTION_NOT_ALLOWED`
+	HIERA_INTERPOLATION_SYNTAX_ERROR     = `HIERA_INTERPOLATION_SYNTAX_ERROR`
+	HIERA_INVALID_CONFIG_VALUE           = `HIERA_INVALID_CONFIG_VALUE`
+	HIERA_MISSING_LEVEL_NAME             = `HIERA_MISSING_LEVEL_NAME`
+	HIERA_MULTIPLE_LOCATIONS             = `HIERA_MULTIPLE_LOCATIONS`
+	HIERA_TYPE_MISMATCH                  = `HIERA_TYPE_MISMATCH`
+	HIERA_UNKNOWN_CONFIG_KEY             = `HIERA_UNKNOWN_CONFIG_KEY`
+	HIERA_UNKNOWN_FACT_KEY               = `HIERA_UNKNOWN_FACT_KEY`
+	HIERA_UNKNOWN_INTERPOLATION_FUNCTION = `HIERA_UNKNOWN_INTERPOLATION_FUNCTION`
+	HIERA_UNKNOWN_PARAMETER              = `HIERA_UNKNOWN_PARAMETER`
+	HIERA_UNSUPPORTED_VERSION            = `HIERA_UNSUPPORTED_VERSION`
+	HIERA_YAML_SYNTAX_ERROR              = `HIERA_YAML_SYNTAX_ERROR`
+)
+
+func init() {
+	issue.Hard(HIERA_ALIAS_NOT_ENTIRE_STRING, `'alias' interpolation is only permitted if the expression is equal to the entire string`)
+
+	issue.Hard(HIERA_DUPLICATE_LEVEL, `Hierarchy name '%{name}' is defined more than once`)
+
+	issue.Hard(HIERA_FUNCTION_NOT_ALLOWED, `Interpolation functions are not allowed in the Hiera configuration, got %{expression}`)
+
+	issue.Hard(HIERA_INTERPOLATION_SYNTAX_ERROR, `Syntax error in interpolation %{expression}: %{message}`)
+
+	issue.Hard(HIERA_INVALID_CONFIG_VALUE, `The value of '%{key}' in %{context} must be %{expected}`)
+
+	issue.Hard(HIERA_MISSING_LEVEL_NAME, `Hierarchy entry in %{context} has no name`)
+
+	issue.Hard(HIERA_MULTIPLE_LOCATIONS, `Only one of path, paths, glob, globs, uri, uris, and mapped_paths can be defined in hierarchy '%{name}'`)
+
+	issue.Hard(HIERA_TYPE_MISMATCH, `The value of '%{key}' does not match the type %{type} of the class parameter: %{reason}`)
+
+	issue.Soft2(HIERA_UNKNOWN_CONFIG_KEY, `Unknown key '%{key}' in %{context}.%{suggestions}`,
+		issue.HF{`suggestions`: parser.DidYouMean})
+
+	issue.Soft2(HIERA_UNKNOWN_FACT_KEY, `Unknown key '%{key}' in '%{path}'.%{suggestions}`,
+		issue.HF{`suggestions`: parser.DidYouMean})
+
+	issue.Hard2(HIERA_UNKNOWN_INTERPOLATION_FUNCTION, `Unknown interpolation function '%{name}'.%{suggestions}`,
+		issue.HF{`suggestions`: parser.DidYouMean})
+
+	issue.Soft2(HIERA_UNKNOWN_PARAMETER, `Class '%{class}' has no parameter named '%{param}'.%{suggestions}`,
+		issue.HF{`suggestions`: parser.DidYouMean})
+
+	issue.Soft(HIERA_UNSUPPORTED_VERSION, `Only version 5 of the Hiera configuration can be checked, got version %{version}`)
+
+	issue.Hard(HIERA_YAML_SYNTAX_ERROR, `YAML syntax error: %{message}`)
+}
@@ -0,0 +1,544 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/types"
+)
+
+// The functions that every interpreter starts out with
+var builtins = map[string]Function{
+	`abs`:         abs,
+	`assert_type`: assertType,
+	`capitalize`:  stringFunction(`capitalize`, capitalize),
+	`downcase`:    stringFunction(`downcase`, strings.ToLower),
+	`each`:        each,
+	`empty`:       empty,
+	`fail`:        fail,
+	`filter`:      filter,
+	`flatten`:     flattenFunction,
+	`join`:        join,
+	`keys`:        keys,
+	`length`:      size(`length`),
+	`lest`:        lest,
+	`map`:         mapFunction,
+	`max`:         extreme(`max`, `>`),
+	`min`:         extreme(`min`, `<`),
+	`reduce`:      reduce,
+	`size`:        size(`size`),
+	`sort`:        sortFunction,
+	`split`:       split,
+	`then`:        then,
+	`unique`:      unique,
+	`upcase`:      stringFunction(`upcase`, strings.ToUpper),
+	`values`:      values,
+	`with`:        with,
+}
+
+func abs(args []interface{}, _ Callable) (interface{}, error) {
+	if err := argCount(`abs`, args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch n := args[0].(type) {
+	case int64:
+		if n < 0 {
+			return -n, nil
+		}
+		return n, nil
+	case float64:
+		if n < 0 {
+			return -n, nil
+		}
+		return n, nil
+	}
+	return nil, argMismatch(`abs`, `value`, `a Numeric`, args[0])
+}
+
+func assertType(args []interface{}, block Callable) (interface{}, error) {
+	if err := argCount(`assert_type`, args, 2, 2); err != nil {
+		return nil, err
+	}
+	t, ok := args[0].(types.Type)
+	if !ok {
+		return nil, argMismatch(`assert_type`, `type`, `a Type`, args[0])
+	}
+	reason := types.Mismatch(t, args[1])
+	if reason == `` {
+		return args[1], nil
+	}
+	if block != nil {
+		return block.Call(t, typeName(args[1]))
+	}
+	return nil, issue.NewReported(INTERPRETER_ASSERT_TYPE_MISMATCH, issue.SEVERITY_ERROR, issue.H{`reason`: reason}, nil)
+}
+
+func capitalize(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+func each(args []interface{}, block Callable) (interface{}, error) {
+	err := iterate(`each`, args, block, func(value interface{}) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return args[0], nil
+}
+
+func empty(args []interface{}, _ Callable) (interface{}, error) {
+	if err := argCount(`empty`, args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return true, nil
+	case string:
+		return v == ``, nil
+	case []interface{}:
+		return len(v) == 0, nil
+	case *literal.Hash:
+		return v.Len() == 0, nil
+	case int64, float64:
+		return false, nil
+	}
+	return nil, argMismatch(`empty`, `value`, `a Collection, a String, or a Numeric`, args[0])
+}
+
+func fail(args []interface{}, _ Callable) (interface{}, error) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = literal.ToString(arg)
+	}
+	return nil, issue.NewReported(INTERPRETER_FAILED, issue.SEVERITY_ERROR, issue.H{`message`: strings.Join(strs, ` `)}, nil)
+}
+
+func filter(args []interface{}, block Callable) (interface{}, error) {
+	var selected []interface{}
+	err := iterate(`filter`, args, block, func(value interface{}) error {
+		selected = append(selected, literal.IsTruthy(value))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch collection := args[0].(type) {
+	case *literal.Hash:
+		result := literal.NewHash(0)
+		for i, e := range collection.Entries() {
+			if i < len(selected) && selected[i] == true {
+				result.Put(e.Key, e.Value)
+			}
+		}
+		return result, nil
+	default:
+		elements, _, _ := elementsOf(`filter`, collection)
+		result := make([]interface{}, 0, len(selected))
+		for i, e := range elements {
+			if i < len(selected) && selected[i] == true {
+				result = append(result, e.value)
+			}
+		}
+		return result, nil
+	}
+}
+
+func flattenFunction(args []interface{}, _ Callable) (interface{}, error) {
+	return flatten(make([]interface{}, 0, len(args)), args), nil
+}
+
+func flatten(result, values []interface{}) []interface{} {
+	for _, v := range values {
+		if a, ok := v.([]interface{}); ok {
+			result = flatten(result, a)
+		} else {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func join(args []interface{}, _ Callable) (interface{}, error) {
+	if err := argCount(`join`, args, 1, 2); err != nil {
+		return nil, err
+	}
+	values, ok := args[0].([]interface{})
+	if !ok {
+		return nil, argMismatch(`join`, `values`, `an Array`, args[0])
+	}
+	separator := ``
+	if len(args) > 1 {
+		if separator, ok = args[1].(string); !ok {
+			return nil, argMismatch(`join`, `separator`, `a String`, args[1])
+		}
+	}
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = literal.ToString(v)
+	}
+	return strings.Join(strs, separator), nil
+}
+
+func keys(args []interface{}, _ Callable) (interface{}, error) {
+	if err := argCount(`keys`, args, 1, 1); err != nil {
+		return nil, err
+	}
+	h, ok := args[0].(*literal.Hash)
+	if !ok {
+		return nil, argMismatch(`keys`, `hash`, `a Hash`, args[0])
+	}
+	return h.Keys(), nil
+}
+
+func lest(args []interface{}, block Callable) (interface{}, error) {
+	if err := argCount(`lest`, args, 1, 1); err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, missingBlock(`lest`)
+	}
+	if args[0] == nil {
+		return block.Call()
+	}
+	return args[0], nil
+}
+
+func mapFunction(args []interface{}, block Callable) (interface{}, error) {
+	result := make([]interface{}, 0)
+	err := iterate(`map`, args, block, func(value interface{}) error {
+		result = append(result, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// extreme returns a function that returns the argument for which the operator holds when it is
+// compared to all other arguments
+func extreme(name, op string) Function {
+	return func(args []interface{}, _ Callable) (interface{}, error) {
+		if err := argCount(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			holds, err := literal.Compare(nil, op, arg, result)
+			if err != nil {
+				return nil, err
+			}
+			if holds {
+				result = arg
+			}
+		}
+		return result, nil
+	}
+}
+
+func reduce(args []interface{}, block Callable) (interface{}, error) {
+	if err := argCount(`reduce`, args, 1, 2); err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, missingBlock(`reduce`)
+	}
+	elements, isHash, err := elementsOf(`reduce`, args[0])
+	if err != nil {
+		return nil, err
+	}
+	var memo interface{}
+	if len(args) > 1 {
+		memo = args[1]
+	} else if len(elements) > 0 {
+		memo = elementValue(elements[0], isHash)
+		elements = elements[1:]
+	}
+	for _, e := range elements {
+		next, err := block.Call(memo, elementValue(e, isHash))
+		if err != nil {
+			if isBreak(err) {
+				break
+			}
+			return nil, err
+		}
+		memo = next
+	}
+	return memo, nil
+}
+
+// size returns a function that returns the number of characters in a string or the number of
+// elements in an array or a hash
+func size(name string) Function {
+	return func(args []interface{}, _ Callable) (interface{}, error) {
+		if err := argCount(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		switch v := args[0].(type) {
+		case string:
+			return int64(utf8.RuneCountInString(v)), nil
+		case []interface{}:
+			return int64(len(v)), nil
+		case *literal.Hash:
+			return int64(v.Len()), nil
+		}
+		return nil, argMismatch(name, `value`, `a Collection or a String`, args[0])
+	}
+}
+
+func sortFunction(args []interface{}, block Callable) (interface{}, error) {
+	if err := argCount(`sort`, args, 1, 1); err != nil {
+		return nil, err
+	}
+	values, ok := args[0].([]interface{})
+	if !ok {
+		return nil, argMismatch(`sort`, `values`, `an Array`, args[0])
+	}
+	result := append([]interface{}{}, values...)
+	var err error
+	sort.SliceStable(result, func(a, b int) bool {
+		if err != nil {
+			return false
+		}
+		if block == nil {
+			var less bool
+			less, err = literal.Compare(nil, `<`, result[a], result[b])
+			return less
+		}
+		var c interface{}
+		if c, err = block.Call(result[a], result[b]); err != nil {
+			return false
+		}
+		n, ok := c.(int64)
+		if !ok {
+			err = fmt.Errorf(`the block must return an Integer, got %s`, typeName(c))
+		}
+		return n < 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func split(args []interface{}, _ Callable) (interface{}, error) {
+	if err := argCount(`split`, args, 2, 2); err != nil {
+		return nil, err
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, argMismatch(`split`, `string`, `a String`, args[0])
+	}
+	var parts []string
+	switch pattern := args[1].(type) {
+	case string:
+		parts = strings.Split(s, pattern)
+	case literal.Regexp:
+		re, err := compileRegexp(pattern.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		parts = re.Split(s, -1)
+	default:
+		return nil, argMismatch(`split`, `pattern`, `a String or a Regexp`, args[1])
+	}
+	result := make([]interface{}, len(parts))
+	for i, p := range parts {
+		result[i] = p
+	}
+	return result, nil
+}
+
+// stringFunction returns a function that applies the conversion to a string, or to each string in an
+// array
+func stringFunction(name string, convert func(string) string) Function {
+	var apply func(interface{}) (interface{}, error)
+	apply = func(value interface{}) (interface{}, error) {
+		switch v := value.(type) {
+		case string:
+			return convert(v), nil
+		case []interface{}:
+			result := make([]interface{}, len(v))
+			for i, e := range v {
+				c, err := apply(e)
+				if err != nil {
+					return nil, err
+				}
+				result[i] = c
+			}
+			return result, nil
+		}
+		return nil, argMismatch(name, `value`, `a String or an Array`, value)
+	}
+	return func(args []interface{}, _ Callable) (interface{}, error) {
+		if err := argCount(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		return apply(args[0])
+	}
+}
+
+func then(args []interface{}, block Callable) (interface{}, error) {
+	if err := argCount(`then`, args, 1, 1); err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, missingBlock(`then`)
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	return block.Call(args[0])
+}
+
+func unique(args []interface{}, _ Callable) (interface{}, error) {
+	if err := argCount(`unique`, args, 1, 1); err != nil {
+		return nil, err
+	}
+	values, ok := args[0].([]interface{})
+	if !ok {
+		return nil, argMismatch(`unique`, `values`, `an Array`, args[0])
+	}
+	result := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if !containsEqual(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+func values(args []interface{}, _ Callable) (interface{}, error) {
+	if err := argCount(`values`, args, 1, 1); err != nil {
+		return nil, err
+	}
+	h, ok := args[0].(*literal.Hash)
+	if !ok {
+		return nil, argMismatch(`values`, `hash`, `a Hash`, args[0])
+	}
+	result := make([]interface{}, h.Len())
+	for i, e := range h.Entries() {
+		result[i] = e.Value
+	}
+	return result, nil
+}
+
+func with(args []interface{}, block Callable) (interface{}, error) {
+	if block == nil {
+		return nil, missingBlock(`with`)
+	}
+	return block.Call(args...)
+}
+
+func containsEqual(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if literal.Equals(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// element is an element of a collection that a function iterates over. The key is the index of an
+// array element.
+type element struct {
+	key   interface{}
+	value interface{}
+}
+
+// elementsOf returns the elements of an array, the entries of a hash, or the integers from zero up to
+// a given integer. The returned boolean is true for a hash.
+func elementsOf(name string, collection interface{}) ([]element, bool, error) {
+	switch c := collection.(type) {
+	case []interface{}:
+		elements := make([]element, len(c))
+		for i, v := range c {
+			elements[i] = element{int64(i), v}
+		}
+		return elements, false, nil
+	case *literal.Hash:
+		elements := make([]element, c.Len())
+		for i, e := range c.Entries() {
+			elements[i] = element{e.Key, e.Value}
+		}
+		return elements, true, nil
+	case int64:
+		elements := make([]element, 0, c)
+		for i := int64(0); i < c; i++ {
+			elements = append(elements, element{i, i})
+		}
+		return elements, false, nil
+	}
+	return nil, false, argMismatch(name, `collection`, `an Array, a Hash, or an Integer`, collection)
+}
+
+// elementValue returns the value of an element, which is a [key, value] pair for a hash entry
+func elementValue(e element, isHash bool) interface{} {
+	if isHash {
+		return []interface{}{e.key, e.value}
+	}
+	return e.value
+}
+
+// iterate calls the block with each element of the collection given in the first argument and passes
+// the value that the block returns to the receiver. A block that accepts two parameters is called
+// with the index and value of an array element, or the key and value of a hash entry. A block that
+// accepts one parameter is called with the value of an array element, or a [key, value] pair for a
+// hash entry. The iteration ends when the block calls break().
+func iterate(name string, args []interface{}, block Callable, receiver func(value interface{}) error) error {
+	if err := argCount(name, args, 1, 1); err != nil {
+		return err
+	}
+	if block == nil {
+		return missingBlock(name)
+	}
+	elements, isHash, err := elementsOf(name, args[0])
+	if err != nil {
+		return err
+	}
+	_, max := block.ParameterCount()
+	for _, e := range elements {
+		var value interface{}
+		if max == 1 {
+			value, err = block.Call(elementValue(e, isHash))
+		} else {
+			value, err = block.Call(e.key, e.value)
+		}
+		if err != nil {
+			if isBreak(err) {
+				return nil
+			}
+			return err
+		}
+		if err = receiver(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func argCount(name string, args []interface{}, min, max int) error {
+	if len(args) < min || max >= 0 && len(args) > max {
+		return issue.NewReported(INTERPRETER_ARGUMENT_COUNT_MISMATCH, issue.SEVERITY_ERROR,
+			issue.H{`name`: fmt.Sprintf(`function '%s'`, name), `expected`: expectedCount(min, max), `actual`: len(args)}, nil)
+	}
+	return nil
+}
+
+func argMismatch(name, param, expected string, value interface{}) error {
+	return issue.NewReported(INTERPRETER_ARGUMENT_TYPE_MISMATCH, issue.SEVERITY_ERROR, issue.H{
+		`param`:  param,
+		`name`:   fmt.Sprintf(`function '%s'`, name),
+		`reason`: fmt.Sprintf(`expects %s value, got %s`, expected, typeName(value))}, nil)
+}
+
+func missingBlock(name string) error {
+	return issue.NewReported(INTERPRETER_MISSING_BLOCK, issue.SEVERITY_ERROR, issue.H{`name`: name}, nil)
+}
@@ -0,0 +1,242 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/types"
+)
+
+// call calls the named function. Functions defined in Puppet take precedence over functions implemented
+// in Go. The expression is the call that is evaluated, or nil when the function is called from Go.
+func (i *Interpreter) call(e parser.Expression, name string, args []interface{}, block Callable, s *Scope) interface{} {
+	name = strings.ToLower(name)
+	switch name {
+	case `return`:
+		panic(&returnSignal{firstArg(args)})
+	case `next`:
+		panic(&nextSignal{firstArg(args)})
+	case `break`:
+		panic(&breakSignal{e})
+	}
+
+	if catalogFunctions[name] {
+		panic(evalIssue(INTERPRETER_CATALOG_OPERATION_NOT_SUPPORTED, e, issue.H{`operation`: name}))
+	}
+
+	if fd, ok := i.definitions[name]; ok {
+		return i.callDefinition(e, fd, args, block, s)
+	}
+
+	if f, ok := i.functions[name]; ok {
+		return i.callGo(e, name, f, args, block)
+	}
+	panic(evalIssue(INTERPRETER_UNKNOWN_FUNCTION, e, issue.H{`name`: name, `suggestions`: parser.Suggestions(name, i.FunctionNames())}))
+}
+
+func (i *Interpreter) callDefinition(e parser.Expression, fd *parser.FunctionDefinition, args []interface{}, block Callable, s *Scope) interface{} {
+	label := fmt.Sprintf(`function '%s'`, fd.Name())
+	params := fd.Parameters()
+	if block != nil {
+		// A function defined in Puppet receives the lambda in its last parameter
+		args = append(args, block)
+	}
+
+	// Functions only see the variables of the top scope
+	scope := NewScope(s.Top())
+	i.bind(e, label, params, args, scope)
+	value := i.evalBody(fd.Body(), scope)
+	if fd.ReturnType() != nil {
+		if reason := types.Mismatch(i.typeOf(fd.ReturnType()), value); reason != `` {
+			panic(evalIssue(INTERPRETER_RETURN_TYPE_MISMATCH, e, issue.H{`name`: label, `reason`: reason}))
+		}
+	}
+	return value
+}
+
+func (i *Interpreter) callGo(e parser.Expression, name string, f Function, args []interface{}, block Callable) interface{} {
+	value, err := f(args, block)
+	if err == nil {
+		return value
+	}
+	switch err.(type) {
+	case *signalError:
+		panic(err.(*signalError).signal)
+	case issue.Reported:
+		ri := err.(issue.Reported)
+		if ri.Location() == nil && e != nil {
+			ri = ri.OffsetByLocation(e)
+		}
+		panic(ri)
+	}
+	panic(evalIssue(INTERPRETER_FUNCTION_ERROR, e, issue.H{`name`: name, `message`: err.Error()}))
+}
+
+// bind assigns the arguments to the parameters in the given scope. Parameters that have no argument
+// get their default value, which is evaluated in the scope so that it can refer to the parameters
+// that precede it.
+func (i *Interpreter) bind(e parser.Expression, label string, params []parser.Expression, args []interface{}, scope *Scope) {
+	min, max := parameterCount(params)
+	if len(args) < min || max >= 0 && len(args) > max {
+		panic(evalIssue(INTERPRETER_ARGUMENT_COUNT_MISMATCH, e, issue.H{`name`: label, `expected`: expectedCount(min, max), `actual`: len(args)}))
+	}
+	for idx, pe := range params {
+		p := pe.(*parser.Parameter)
+		var value interface{}
+		switch {
+		case p.CapturesRest():
+			if idx < len(args) {
+				value = append([]interface{}{}, args[idx:]...)
+			} else {
+				value = []interface{}{}
+			}
+		case idx < len(args):
+			value = args[idx]
+		default:
+			value = i.eval(p.Value(), scope)
+		}
+		if p.Type() != nil {
+			t := i.typeOf(p.Type())
+			if p.CapturesRest() {
+				t = types.NewArray(t)
+			}
+			if reason := types.Mismatch(t, value); reason != `` {
+				panic(evalIssue(INTERPRETER_ARGUMENT_TYPE_MISMATCH, e, issue.H{`param`: p.Name(), `name`: label, `reason`: reason}))
+			}
+		}
+		scope.Set(p.Name(), value)
+	}
+}
+
+// bindNamed assigns the named arguments to the parameters of a template in the given scope. All
+// arguments are assigned to variables when the template has no parameters.
+func (i *Interpreter) bindNamed(e *parser.LambdaExpression, params []parser.Expression, args map[string]interface{}, scope *Scope) {
+	if ee, ok := e.Body().(*parser.EppExpression); ok && !ee.ParametersSpecified() {
+		for name, value := range args {
+			scope.Set(name, value)
+		}
+		return
+	}
+
+	declared := make(map[string]bool, len(params))
+	for _, pe := range params {
+		p := pe.(*parser.Parameter)
+		declared[p.Name()] = true
+		value, ok := args[p.Name()]
+		if !ok {
+			if p.Value() == nil {
+				panic(evalIssue(INTERPRETER_MISSING_PARAMETER, e, issue.H{`param`: p.Name()}))
+			}
+			value = i.eval(p.Value(), scope)
+		}
+		if p.Type() != nil {
+			if reason := types.Mismatch(i.typeOf(p.Type()), value); reason != `` {
+				panic(evalIssue(INTERPRETER_ARGUMENT_TYPE_MISMATCH, e, issue.H{`param`: p.Name(), `name`: `template`, `reason`: reason}))
+			}
+		}
+		scope.Set(p.Name(), value)
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		if !declared[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		panic(evalIssue(INTERPRETER_UNKNOWN_PARAMETER, e, issue.H{`param`: names[0]}))
+	}
+}
+
+// parameterCount returns the minimum and maximum number of arguments that the parameters accept. The
+// maximum is -1 when the last parameter captures the rest of the arguments.
+func parameterCount(params []parser.Expression) (min, max int) {
+	for _, pe := range params {
+		p := pe.(*parser.Parameter)
+		if p.CapturesRest() {
+			return min, -1
+		}
+		if p.Value() == nil {
+			min = max + 1
+		}
+		max++
+	}
+	return
+}
+
+func expectedCount(min, max int) string {
+	switch {
+	case max < 0:
+		return fmt.Sprintf(`at least %s`, arguments(min))
+	case min == max:
+		return arguments(min)
+	}
+	return fmt.Sprintf(`between %d and %s`, min, arguments(max))
+}
+
+func arguments(count int) string {
+	if count == 1 {
+		return `1 argument`
+	}
+	return fmt.Sprintf(`%d arguments`, count)
+}
+
+func firstArg(args []interface{}) interface{} {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return nil
+}
+
+// lambda is a LambdaExpression that is bound to the scope in which it was created
+type lambda struct {
+	interpreter *Interpreter
+	expr        *parser.LambdaExpression
+	scope       *Scope
+}
+
+// lambda returns the Callable for the given lambda expression, or nil if the expression is nil
+func (i *Interpreter) lambda(e parser.Expression, s *Scope) Callable {
+	if le, ok := e.(*parser.LambdaExpression); ok {
+		return &lambda{i, le, s}
+	}
+	return nil
+}
+
+func (l *lambda) Call(args ...interface{}) (interface{}, error) {
+	i := l.interpreter
+	return i.protect(func() interface{} {
+		scope := NewScope(l.scope)
+		i.bind(l.expr, `lambda`, l.expr.Parameters(), args, scope)
+		value := i.evalLambdaBody(l.expr.Body(), scope)
+		if l.expr.ReturnType() != nil {
+			if reason := types.Mismatch(i.typeOf(l.expr.ReturnType()), value); reason != `` {
+				panic(evalIssue(INTERPRETER_RETURN_TYPE_MISMATCH, l.expr, issue.H{`name`: `lambda`, `reason`: reason}))
+			}
+		}
+		return value
+	})
+}
+
+func (l *lambda) ParameterCount() (min, max int) {
+	return parameterCount(l.expr.Parameters())
+}
+
+// evalLambdaBody evaluates the body of a lambda. A call to next() leaves the lambda while a call to
+// return() leaves the function that the lambda is declared in.
+func (i *Interpreter) evalLambdaBody(e parser.Expression, scope *Scope) (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ns, ok := r.(*nextSignal); ok {
+				value = ns.value
+				return
+			}
+			panic(r)
+		}
+	}()
+	return i.eval(e, scope)
+}
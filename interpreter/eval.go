@@ -0,0 +1,428 @@
+package interpreter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/types"
+)
+
+// Functions that operate on the catalog
+var catalogFunctions = map[string]bool{
+	`contain`:          true,
+	`create_resources`: true,
+	`defined`:          true,
+	`hiera_include`:    true,
+	`include`:          true,
+	`realize`:          true,
+	`require`:          true,
+	`tag`:              true,
+	`tagged`:           true,
+}
+
+func evalIssue(code issue.Code, e parser.Expression, args issue.H) issue.Reported {
+	return issue.NewReported(code, issue.SEVERITY_ERROR, args, e)
+}
+
+// check panics with the given error unless it is nil
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (i *Interpreter) eval(e parser.Expression, s *Scope) interface{} {
+	switch e.(type) {
+	case nil, *parser.Nop:
+		return nil
+	case *parser.Program:
+		program := e.(*parser.Program)
+		i.Define(program)
+		return i.eval(program.Body(), s)
+	case *parser.BlockExpression:
+		var value interface{}
+		for _, stmt := range e.(*parser.BlockExpression).Statements() {
+			value = i.eval(stmt, s)
+		}
+		return value
+	case *parser.ParenthesizedExpression:
+		return i.eval(e.(*parser.ParenthesizedExpression).Expr(), s)
+	case *parser.LiteralList:
+		return i.evalUnfolded(e.(*parser.LiteralList).Elements(), s)
+	case *parser.LiteralHash:
+		entries := e.(*parser.LiteralHash).Entries()
+		result := literal.NewHash(len(entries))
+		for _, entry := range entries {
+			ke := entry.(*parser.KeyedEntry)
+			result.Put(i.eval(ke.Key(), s), i.eval(ke.Value(), s))
+		}
+		return result
+	case *parser.ConcatenatedString:
+		b := strings.Builder{}
+		for _, segment := range e.(*parser.ConcatenatedString).Segments() {
+			b.WriteString(literal.ToString(i.eval(segment, s)))
+		}
+		return b.String()
+	case *parser.TextExpression:
+		return literal.ToString(i.eval(e.(*parser.TextExpression).Expr(), s))
+	case *parser.HeredocExpression:
+		return i.eval(e.(*parser.HeredocExpression).Text(), s)
+	case *parser.RegexpExpression:
+		return literal.Regexp{Pattern: e.(*parser.RegexpExpression).PatternString()}
+	case *parser.QualifiedReference:
+		return i.typeOf(e)
+	case *parser.AccessExpression:
+		ae := e.(*parser.AccessExpression)
+		if _, ok := ae.Operand().(*parser.QualifiedReference); ok {
+			return i.typeOf(e)
+		}
+		operand := i.eval(ae.Operand(), s)
+		value, err := literal.Access(ae, operand, i.evalUnfolded(ae.Keys(), s))
+		check(err)
+		return value
+	case *parser.VariableExpression:
+		return i.variable(e.(*parser.VariableExpression), s)
+	case *parser.AssignmentExpression:
+		return i.assign(e.(*parser.AssignmentExpression), s)
+	case *parser.NotExpression:
+		return !literal.IsTruthy(i.eval(e.(*parser.NotExpression).Expr(), s))
+	case *parser.AndExpression:
+		ae := e.(*parser.AndExpression)
+		return literal.IsTruthy(i.eval(ae.Lhs(), s)) && literal.IsTruthy(i.eval(ae.Rhs(), s))
+	case *parser.OrExpression:
+		oe := e.(*parser.OrExpression)
+		return literal.IsTruthy(i.eval(oe.Lhs(), s)) || literal.IsTruthy(i.eval(oe.Rhs(), s))
+	case *parser.UnaryMinusExpression:
+		value, err := literal.Negate(e, i.eval(e.(*parser.UnaryMinusExpression).Expr(), s))
+		check(err)
+		return value
+	case *parser.ArithmeticExpression:
+		ae := e.(*parser.ArithmeticExpression)
+		value, err := literal.Arithmetic(ae, ae.Operator(), i.eval(ae.Lhs(), s), i.eval(ae.Rhs(), s))
+		check(err)
+		return value
+	case *parser.ComparisonExpression:
+		ce := e.(*parser.ComparisonExpression)
+		value, err := literal.Compare(ce, ce.Operator(), i.eval(ce.Lhs(), s), i.eval(ce.Rhs(), s))
+		check(err)
+		return value
+	case *parser.InExpression:
+		ie := e.(*parser.InExpression)
+		return literal.In(i.eval(ie.Lhs(), s), i.eval(ie.Rhs(), s))
+	case *parser.MatchExpression:
+		me := e.(*parser.MatchExpression)
+		lhs := i.eval(me.Lhs(), s)
+		rhs := i.eval(me.Rhs(), s)
+		switch rhs.(type) {
+		case string, literal.Regexp, types.Type:
+		default:
+			panic(evalIssue(literal.LITERAL_OPERATOR_NOT_APPLICABLE_WHEN, me, issue.H{`operator`: me.Operator(), `left`: typeName(lhs), `right`: typeName(rhs)}))
+		}
+		matched := i.matches(me, lhs, rhs, s)
+		if me.Operator() == `!~` {
+			return !matched
+		}
+		return matched
+	case *parser.UnlessExpression:
+		ue := e.(*parser.UnlessExpression)
+		if !literal.IsTruthy(i.eval(ue.Test(), s)) {
+			return i.eval(ue.Then(), s)
+		}
+		return i.eval(ue.Else(), s)
+	case *parser.IfExpression:
+		ie := e.(*parser.IfExpression)
+		if literal.IsTruthy(i.eval(ie.Test(), s)) {
+			return i.eval(ie.Then(), s)
+		}
+		return i.eval(ie.Else(), s)
+	case *parser.CaseExpression:
+		return i.evalCase(e.(*parser.CaseExpression), s)
+	case *parser.SelectorExpression:
+		return i.evalSelector(e.(*parser.SelectorExpression), s)
+	case *parser.CallNamedFunctionExpression:
+		ce := e.(*parser.CallNamedFunctionExpression)
+		name, ok := ce.Functor().(*parser.QualifiedName)
+		if !ok {
+			break
+		}
+		return i.call(ce, name.Name(), i.evalUnfolded(ce.Arguments(), s), i.lambda(ce.Lambda(), s), s)
+	case *parser.CallMethodExpression:
+		ce := e.(*parser.CallMethodExpression)
+		na, ok := ce.Functor().(*parser.NamedAccessExpression)
+		if !ok {
+			break
+		}
+		name, ok := na.Rhs().(*parser.QualifiedName)
+		if !ok {
+			break
+		}
+		args := append([]interface{}{i.eval(na.Lhs(), s)}, i.evalUnfolded(ce.Arguments(), s)...)
+		return i.call(ce, name.Name(), args, i.lambda(ce.Lambda(), s), s)
+	case *parser.LambdaExpression:
+		return i.lambda(e, s)
+	case *parser.FunctionDefinition:
+		fd := e.(*parser.FunctionDefinition)
+		i.definitions[strings.ToLower(fd.Name())] = fd
+		return nil
+	case *parser.EppExpression:
+		return i.eval(e.(*parser.EppExpression).Body(), s)
+	case *parser.RenderStringExpression:
+		if i.output == nil {
+			break
+		}
+		i.output.WriteString(e.(*parser.RenderStringExpression).StringValue())
+		return nil
+	case *parser.RenderExpression:
+		if i.output == nil {
+			break
+		}
+		i.output.WriteString(literal.ToString(i.eval(e.(*parser.RenderExpression).Expr(), s)))
+		return nil
+	case *parser.TypeAlias:
+		// Defined when the program is evaluated
+		return nil
+	case *parser.ResourceExpression, *parser.ResourceDefaultsExpression, *parser.ResourceOverrideExpression,
+		*parser.CollectExpression, *parser.RelationshipExpression, *parser.HostClassDefinition,
+		*parser.ResourceTypeDefinition, *parser.NodeDefinition, *parser.Application, *parser.SiteDefinition,
+		*parser.CapabilityMapping:
+		panic(evalIssue(INTERPRETER_CATALOG_OPERATION_NOT_SUPPORTED, e, issue.H{`operation`: e}))
+	case parser.LiteralValue:
+		return e.(parser.LiteralValue).Value()
+	}
+	panic(evalIssue(INTERPRETER_UNSUPPORTED_EXPRESSION, e, issue.H{`expression`: e}))
+}
+
+// evalUnfolded evaluates the expressions. The elements of an array that is unfolded using the splat
+// operator '*' are added individually to the result.
+func (i *Interpreter) evalUnfolded(exprs []parser.Expression, s *Scope) []interface{} {
+	result := make([]interface{}, 0, len(exprs))
+	for _, e := range exprs {
+		if ue, ok := e.(*parser.UnfoldExpression); ok {
+			value := i.eval(ue.Expr(), s)
+			if values, ok := value.([]interface{}); ok {
+				result = append(result, values...)
+			} else {
+				result = append(result, value)
+			}
+			continue
+		}
+		result = append(result, i.eval(e, s))
+	}
+	return result
+}
+
+func (i *Interpreter) variable(e *parser.VariableExpression, s *Scope) interface{} {
+	if index, ok := e.Index(); ok {
+		value, _ := s.match(index)
+		return value
+	}
+	name, _ := e.Name()
+	value, ok := s.Get(name)
+	if !ok {
+		panic(evalIssue(INTERPRETER_UNKNOWN_VARIABLE, e, issue.H{`name`: name}))
+	}
+	return value
+}
+
+func (i *Interpreter) assign(e *parser.AssignmentExpression, s *Scope) interface{} {
+	if e.Operator() != `=` {
+		panic(evalIssue(INTERPRETER_UNSUPPORTED_EXPRESSION, e, issue.H{`expression`: e}))
+	}
+	value := i.eval(e.Rhs(), s)
+	switch e.Lhs().(type) {
+	case *parser.VariableExpression:
+		i.assignVariable(e.Lhs().(*parser.VariableExpression), value, s)
+	case *parser.LiteralList:
+		vars := e.Lhs().(*parser.LiteralList).Elements()
+		switch value.(type) {
+		case []interface{}:
+			values := value.([]interface{})
+			if len(values) != len(vars) {
+				panic(evalIssue(INTERPRETER_ASSIGNMENT_COUNT_MISMATCH, e, issue.H{`expected`: len(vars), `actual`: len(values)}))
+			}
+			for idx, v := range vars {
+				i.assignVariable(v, values[idx], s)
+			}
+		case *literal.Hash:
+			for _, v := range vars {
+				ve, ok := v.(*parser.VariableExpression)
+				if !ok {
+					panic(evalIssue(INTERPRETER_ILLEGAL_ASSIGNMENT, v, issue.H{`expression`: v}))
+				}
+				name, _ := ve.Name()
+				entry, found := value.(*literal.Hash).Get(name)
+				if !found {
+					panic(evalIssue(INTERPRETER_ASSIGNMENT_KEY_MISSING, v, issue.H{`name`: name}))
+				}
+				i.assignVariable(ve, entry, s)
+			}
+		default:
+			panic(evalIssue(INTERPRETER_ASSIGNMENT_COUNT_MISMATCH, e, issue.H{`expected`: len(vars), `actual`: 1}))
+		}
+	default:
+		panic(evalIssue(INTERPRETER_ILLEGAL_ASSIGNMENT, e.Lhs(), issue.H{`expression`: e.Lhs()}))
+	}
+	return value
+}
+
+func (i *Interpreter) assignVariable(e parser.Expression, value interface{}, s *Scope) {
+	ve, ok := e.(*parser.VariableExpression)
+	if !ok {
+		panic(evalIssue(INTERPRETER_ILLEGAL_ASSIGNMENT, e, issue.H{`expression`: e}))
+	}
+	name, ok := ve.Name()
+	if !ok {
+		// Numeric variables are set by matching regular expressions
+		panic(evalIssue(INTERPRETER_ILLEGAL_ASSIGNMENT, e, issue.H{`expression`: e}))
+	}
+	if !s.Set(name, value) {
+		panic(evalIssue(INTERPRETER_ILLEGAL_REASSIGNMENT, e, issue.H{`name`: name}))
+	}
+}
+
+func (i *Interpreter) evalCase(e *parser.CaseExpression, s *Scope) interface{} {
+	test := i.eval(e.Test(), s)
+	var defaultOption *parser.CaseOption
+	for _, oe := range e.Options() {
+		option := oe.(*parser.CaseOption)
+		for _, ve := range option.Values() {
+			if _, ok := ve.(*parser.LiteralDefault); ok {
+				defaultOption = option
+				continue
+			}
+			for _, candidate := range i.evalUnfolded([]parser.Expression{ve}, s) {
+				if i.matches(ve, test, candidate, s) {
+					return i.eval(option.Then(), s)
+				}
+			}
+		}
+	}
+	if defaultOption != nil {
+		return i.eval(defaultOption.Then(), s)
+	}
+	return nil
+}
+
+func (i *Interpreter) evalSelector(e *parser.SelectorExpression, s *Scope) interface{} {
+	test := i.eval(e.Lhs(), s)
+	var defaultEntry *parser.SelectorEntry
+	for _, se := range e.Selectors() {
+		entry := se.(*parser.SelectorEntry)
+		if _, ok := entry.Matching().(*parser.LiteralDefault); ok {
+			defaultEntry = entry
+			continue
+		}
+		for _, candidate := range i.evalUnfolded([]parser.Expression{entry.Matching()}, s) {
+			if i.matches(entry.Matching(), test, candidate, s) {
+				return i.eval(entry.Value(), s)
+			}
+		}
+	}
+	if defaultEntry != nil {
+		return i.eval(defaultEntry.Value(), s)
+	}
+	panic(evalIssue(INTERPRETER_NO_MATCHING_SELECTOR, e, issue.H{`value`: valueString(test)}))
+}
+
+// matches returns true if the value matches the given option of a case or selector expression, or the
+// right hand side of a match expression. A string matches a regular expression, a value matches a type
+// that it is an instance of, and arrays match when all their elements match. Other values match when
+// they are equal. The groups of a matching regular expression are assigned to the numeric variables of
+// the scope.
+func (i *Interpreter) matches(e parser.Expression, value, option interface{}, s *Scope) bool {
+	switch option.(type) {
+	case literal.Regexp, string:
+		pattern, isRegexp := option.(literal.Regexp)
+		if !isRegexp {
+			if _, ok := e.(*parser.MatchExpression); !ok {
+				// A string option matches an equal string
+				return literal.Equals(value, option)
+			}
+			pattern = literal.Regexp{Pattern: option.(string)}
+		}
+		str, ok := value.(string)
+		if !ok {
+			if me, ok := e.(*parser.MatchExpression); ok {
+				panic(evalIssue(literal.LITERAL_OPERATOR_NOT_APPLICABLE, e, issue.H{`operator`: me.Operator(), `operand`: typeName(value)}))
+			}
+			return false
+		}
+		re := i.compile(e, pattern.Pattern)
+		indexes := re.FindStringSubmatchIndex(str)
+		if indexes == nil {
+			return false
+		}
+		groups := make([]string, len(indexes)/2)
+		found := make([]bool, len(groups))
+		for g := range groups {
+			if indexes[2*g] >= 0 {
+				groups[g] = str[indexes[2*g]:indexes[2*g+1]]
+				found[g] = true
+			}
+		}
+		s.setMatches(groups, found)
+		return true
+	case types.Type:
+		return types.IsInstance(option.(types.Type), value)
+	case []interface{}:
+		values, ok := value.([]interface{})
+		options := option.([]interface{})
+		if !ok || len(values) != len(options) {
+			return false
+		}
+		for idx, o := range options {
+			if !i.matches(e, values[idx], o, s) {
+				return false
+			}
+		}
+		return true
+	}
+	return literal.Equals(value, option)
+}
+
+// compile compiles the pattern of a regular expression. Patterns that have been compiled before are
+// reused.
+func (i *Interpreter) compile(e parser.Expression, pattern string) *regexp.Regexp {
+	if re, ok := i.regexps[pattern]; ok {
+		return re
+	}
+	re, err := compileRegexp(pattern)
+	if err != nil {
+		panic(err.(issue.Reported).OffsetByLocation(e))
+	}
+	i.regexps[pattern] = re
+	return re
+}
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, issue.NewReported(INTERPRETER_INVALID_REGEXP, issue.SEVERITY_ERROR, issue.H{`pattern`: pattern, `detail`: err.Error()}, nil)
+	}
+	return re, nil
+}
+
+func (i *Interpreter) typeOf(e parser.Expression) types.Type {
+	t, err := types.FromExpression(e, i.aliases)
+	check(err)
+	return t
+}
+
+// valueString returns a string that shows the value in an issue message
+func valueString(value interface{}) string {
+	if _, ok := value.(string); ok {
+		return literal.ToSource(value)
+	}
+	return literal.ToString(value)
+}
+
+// typeName returns the name of the type of the value, e.g. "Integer" or "Type"
+func typeName(value interface{}) string {
+	if _, ok := value.(types.Type); ok {
+		return `Type`
+	}
+	return literal.TypeName(value)
+}
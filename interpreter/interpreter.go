@@ -0,0 +1,228 @@
+// Package interpreter evaluates a subset of the Puppet language. It can run functions, selectors, case
+// expressions, and iterations over arrays and hashes, but it cannot compile a catalog. Expressions that
+// declare classes or resources, or that otherwise operate on a catalog, result in an error.
+//
+// Values are represented the same way as the values that literal.ToTypedLiteral produces, i.e. undef
+// is nil, and hashes are *literal.Hash. Types are represented by types.Type.
+package interpreter
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/types"
+)
+
+// Function is a function implemented in Go. The block is nil unless the function is called with a
+// lambda. A function that returns an error which is not an issue.Reported fails with an
+// INTERPRETER_FUNCTION_ERROR.
+type Function func(args []interface{}, block Callable) (interface{}, error)
+
+// Callable is a lambda or a function that a Function can call
+type Callable interface {
+	// Call calls the callable with the given arguments. The error returned by a lambda that calls
+	// break() or return() must be returned by the Function that called it.
+	Call(args ...interface{}) (interface{}, error)
+
+	// ParameterCount returns the minimum and maximum number of arguments that the callable accepts.
+	// The maximum is -1 when the number of arguments is unlimited.
+	ParameterCount() (min, max int)
+}
+
+// Interpreter evaluates expressions using functions defined in Puppet and functions implemented in Go
+type Interpreter struct {
+	functions   map[string]Function
+	definitions map[string]*parser.FunctionDefinition
+	aliases     types.Aliases
+	regexps     map[string]*regexp.Regexp
+
+	// output receives the text that an EPP template renders
+	output *bytes.Buffer
+}
+
+// NewInterpreter creates an interpreter with the built in functions registered
+func NewInterpreter() *Interpreter {
+	i := &Interpreter{
+		functions:   make(map[string]Function),
+		definitions: make(map[string]*parser.FunctionDefinition),
+		aliases:     make(types.Aliases),
+		regexps:     make(map[string]*regexp.Regexp),
+	}
+	for name, f := range builtins {
+		i.Register(name, f)
+	}
+	return i
+}
+
+// Register makes a function implemented in Go available under the given name. It replaces a built in
+// function with the same name.
+func (i *Interpreter) Register(name string, f Function) {
+	i.functions[strings.ToLower(name)] = f
+}
+
+// Define makes the functions and type aliases of the given program available, e.g. the function in a
+// file from a module's functions directory. Other definitions are ignored.
+func (i *Interpreter) Define(program *parser.Program) {
+	for name, alias := range types.AliasesOf(program) {
+		i.aliases[name] = alias
+	}
+	for _, d := range program.Definitions() {
+		if fd, ok := d.(*parser.FunctionDefinition); ok {
+			i.definitions[strings.ToLower(fd.Name())] = fd
+		}
+	}
+}
+
+// Evaluate evaluates the expression in the given scope and returns the value of the expression. The
+// functions and type aliases of a program are defined before its body is evaluated. A nil scope is
+// the same as a new top scope.
+//
+// An issue.Reported error that is located at the expression that failed is returned when the
+// evaluation fails.
+func (i *Interpreter) Evaluate(e parser.Expression, scope *Scope) (interface{}, error) {
+	if scope == nil {
+		scope = NewScope(nil)
+	}
+	return i.protect(func() interface{} {
+		defer func() {
+			if r := recover(); r != nil {
+				if br, ok := r.(*breakSignal); ok {
+					panic(issue.NewReported(INTERPRETER_ILLEGAL_BREAK, issue.SEVERITY_ERROR, issue.NO_ARGS, br.at))
+				}
+				panic(r)
+			}
+		}()
+		return i.evalBody(e, scope)
+	})
+}
+
+// Call calls the function with the given name. The function is either defined in Puppet or a function
+// implemented in Go.
+func (i *Interpreter) Call(name string, args ...interface{}) (interface{}, error) {
+	return i.protect(func() interface{} {
+		return i.call(nil, name, args, nil, NewScope(nil))
+	})
+}
+
+// Render renders an EPP template, i.e. the lambda that the parser produces in EPP mode, and returns the
+// rendered text. The template parameters are assigned from the given map. A parameter that is missing
+// from the map gets its default value. When the template declares no parameters, all entries of the
+// map are assigned to variables of the same name.
+//
+// The template is evaluated in a scope that is nested in the given scope. A nil scope is the same as a
+// new top scope.
+func (i *Interpreter) Render(template *parser.LambdaExpression, params map[string]interface{}, scope *Scope) (string, error) {
+	if scope == nil {
+		scope = NewScope(nil)
+	}
+	value, err := i.protect(func() interface{} {
+		saved := i.output
+		i.output = bytes.NewBufferString(``)
+		defer func() { i.output = saved }()
+
+		scope = NewScope(scope)
+		i.bindNamed(template, template.Parameters(), params, scope)
+		i.evalBody(template.Body(), scope)
+		return i.output.String()
+	})
+	if err != nil {
+		return ``, err
+	}
+	return value.(string), nil
+}
+
+// FunctionNames returns the sorted names of all functions that the interpreter can call
+func (i *Interpreter) FunctionNames() []string {
+	names := make([]string, 0, len(i.functions)+len(i.definitions))
+	for name := range i.functions {
+		names = append(names, name)
+	}
+	for name := range i.definitions {
+		if _, ok := i.functions[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// evalBody evaluates an expression that return() and next() may leave early
+func (i *Interpreter) evalBody(e parser.Expression, scope *Scope) (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r.(type) {
+			case *returnSignal:
+				value = r.(*returnSignal).value
+			case *nextSignal:
+				value = r.(*nextSignal).value
+			default:
+				panic(r)
+			}
+		}
+	}()
+	return i.eval(e, scope)
+}
+
+// protect calls the function and returns the issue.Reported that it panics with as an error. Signals
+// from return(), next(), and break() are returned as a *signalError.
+func (i *Interpreter) protect(f func() interface{}) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r.(type) {
+			case issue.Reported:
+				err = r.(issue.Reported)
+			case *returnSignal, *nextSignal, *breakSignal:
+				err = &signalError{r}
+			default:
+				panic(r)
+			}
+			value = nil
+		}
+	}()
+	value = f()
+	return
+}
+
+// returnSignal is the panic value of a call to return()
+type returnSignal struct {
+	value interface{}
+}
+
+// nextSignal is the panic value of a call to next()
+type nextSignal struct {
+	value interface{}
+}
+
+// breakSignal is the panic value of a call to break()
+type breakSignal struct {
+	at parser.Expression
+}
+
+// signalError conveys a signal from return(), next(), or break() through a Function
+type signalError struct {
+	signal interface{}
+}
+
+func (e *signalError) Error() string {
+	switch e.signal.(type) {
+	case *returnSignal:
+		return `return() called outside of a function`
+	case *nextSignal:
+		return `next() called outside of a lambda or function`
+	default:
+		return `break() called outside of an iteration`
+	}
+}
+
+// isBreak returns true if the error was returned from a lambda that called break()
+func isBreak(err error) bool {
+	if se, ok := err.(*signalError); ok {
+		_, ok = se.signal.(*breakSignal)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestEvaluate(t *testing.T) {
+	for _, tc := range []struct {
+		source   string
+		expected interface{}
+	}{
+		{`$a = 1 $b = $a + 2 "${a}-${b}"`, `1-3`},
+		{`[$a, $b] = [1, 2] $b - $a`, int64(1)},
+		{`[$a, $b] = {b => 1, a => 2} $a`, int64(2)},
+		{`$x = [1, 2] [0, *$x]`, []interface{}{int64(0), int64(1), int64(2)}},
+		{`{a => 1, 2 => b}`, hashOf(`a`, int64(1), int64(2), `b`)},
+		{`if 1 > 2 { a } elsif true { b } else { c }`, `b`},
+		{`unless false { a }`, `a`},
+		{`if false { a }`, nil},
+		{`case 3 { 1, 2: { a } [3]: { b } default: { c } }`, `c`},
+		{`case 'xyz' { /y(.)/: { [$0, $1] } }`, []interface{}{`yz`, `z`}},
+		{`case [1, a] { [Integer, String]: { typed } }`, `typed`},
+		{`case 'A' { a: { yes } }`, `yes`},
+		{`5 ? { Integer[0, 3] => small, Integer => big }`, `big`},
+		{`x ? { y => 1, default => 2 }`, int64(2)},
+		{`'abc' =~ /^a(b)/ and $1 == b`, true},
+		{`'abc' !~ 'x'`, true},
+		{`1 =~ Integer`, true},
+		{`Integer == Integer`, true},
+		{`String != String`, false},
+		{`Integer[1] == Integer[2]`, false},
+		{`Integer in [Integer]`, true},
+		{`{Integer => 1}[Integer]`, int64(1)},
+		{`{Integer => 1, Integer => 2}.size`, int64(1)},
+		{`[1, 2, 3].map |$x| { $x * 2 }`, []interface{}{int64(2), int64(4), int64(6)}},
+		{`[1, 2, 3].map |$i, $x| { $i }`, []interface{}{int64(0), int64(1), int64(2)}},
+		{`{a => 1, b => 2}.map |$k, $v| { "${k}${v}" }`, []interface{}{`a1`, `b2`}},
+		{`{a => 1, b => 2}.map |$e| { $e[0] }`, []interface{}{`a`, `b`}},
+		{`map(3) |$x| { $x }`, []interface{}{int64(0), int64(1), int64(2)}},
+		{`[1, 2, 3, 4].filter |$x| { $x % 2 == 0 }`, []interface{}{int64(2), int64(4)}},
+		{`{a => 1, b => 2}.filter |$k, $v| { $v > 1 }`, hashOf(`b`, int64(2))},
+		{`[1, 2, 3].reduce |$m, $x| { $m + $x }`, int64(6)},
+		{`[1, 2, 3].reduce(10) |$m, $x| { $m + $x }`, int64(16)},
+		{`$s = [] [1, 2].each |$x| { $s + $x }`, []interface{}{int64(1), int64(2)}},
+		{`[1, 2, 3, 4].map |$x| { if $x == 3 { break() } $x }`, []interface{}{int64(1), int64(2)}},
+		{`[1, 2, 3].map |$x| { if $x == 2 { next(0) } $x }`, []interface{}{int64(1), int64(0), int64(3)}},
+		{`with(1, 2) |$a, $b| { $a + $b }`, int64(3)},
+		{`undef.then |$x| { $x + 1 }.lest | | { none }`, `none`},
+		{`[b, a, c].sort.join(',')`, `a,b,c`},
+		{`[1, 2, 3].sort |$a, $b| { $b - $a }`, []interface{}{int64(3), int64(2), int64(1)}},
+		{`flatten([1, [2, [3]]], 4)`, []interface{}{int64(1), int64(2), int64(3), int64(4)}},
+		{`[a, b, a].unique`, []interface{}{`a`, `b`}},
+		{`'a,b'.split(',').map |$x| { $x.upcase }`, []interface{}{`A`, `B`}},
+		{`'a1b2c'.split(/\d/)`, []interface{}{`a`, `b`, `c`}},
+		{`'hello'.capitalize`, `Hello`},
+		{`[{a => 1}.keys, {a => 1}.values, size('abc'), length([1])]`, []interface{}{[]interface{}{`a`}, []interface{}{int64(1)}, int64(3), int64(1)}},
+		{`[empty(''), empty([1]), min(3, 1, 2), max(3, 1, 2), abs(-3)]`, []interface{}{true, false, int64(1), int64(3), int64(3)}},
+		{`assert_type(String, 'a')`, `a`},
+		{`assert_type(String, 1) |$expected, $actual| { $actual }`, `Integer`},
+		{`function add(Integer $a, Integer $b = 1) { $a + $b } add(2) + add(2, 3)`, int64(8)},
+		{`function sum(*$n) { $n.reduce(0) |$m, $x| { $m + $x } } sum(1, 2, 3)`, int64(6)},
+		{`function first($a) { $a.each |$x| { return($x) } none } first([7, 8])`, int64(7)},
+		{`type Small = Integer[0, 5] 3 =~ Small`, true},
+		{`$x = 1 function f() { $::x } f()`, int64(1)},
+	} {
+		value, err := NewInterpreter().Evaluate(parse(t, tc.source), nil)
+		if err != nil {
+			t.Errorf(`unexpected error for %s: %s`, tc.source, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, tc.expected) {
+			t.Errorf(`expected %#v for %s, got %#v`, tc.expected, tc.source, value)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	for _, tc := range []struct {
+		source   string
+		expected string
+		line     int
+		pos      int
+	}{
+		{`$x`, `Unknown variable: '$x'`, 1, 1},
+		{`$x = 1 $x = 2`, `Cannot reassign variable '$x'`, 1, 8},
+		{`[$a, $b] = [1]`, `The number of variables (2) does not match the number of assigned values (1)`, 1, 1},
+		{`[$a] = {b => 1}`, `The assigned hash has no value for variable '$a'`, 1, 2},
+		{`foo(1)`, `Unknown function: 'foo'.`, 1, 1},
+		{`flaten([1])`, `Unknown function: 'flaten'. Did you mean 'flatten'?`, 1, 1},
+		{`include foo`, `The catalog operation 'include' is only available when compiling a catalog`, 1, 1},
+		{`file { '/tmp/x': }`, `The catalog operation 'Resource Statement' is only available when compiling a catalog`, 1, 1},
+		{`class foo {}`, `The catalog operation 'Host Class Definition' is only available when compiling a catalog`, 1, 1},
+		{`x ? { y => 1 }`, `No matching entry for selector parameter with value 'x'`, 1, 1},
+		{`break()`, `break() can only be used in a lambda given to an iterating function`, 1, 1},
+		{`1 + 'a'`, `Operator '+' is not applicable to an Integer when right side is a String`, 1, 1},
+		{`'a' =~ /(/`, `Invalid regular expression /(/: error parsing regexp: missing closing ): ` + "`(`", 1, 1},
+		{`fail('no', 1)`, `no 1`, 1, 1},
+		{`size(1)`, `Parameter 'value' of the function 'size' expects a Collection or a String value, got Integer`, 1, 1},
+		{`size()`, `The function 'size' expects 1 argument, got 0`, 1, 1},
+		{`[1].each`, `Function 'each' expects a block`, 1, 1},
+		{`assert_type(String, 1)`, `assert_type(): expects a String value, got Integer`, 1, 1},
+		{`function f(Integer $a) { $a } f(a)`, `Parameter 'a' of the function 'f' expects an Integer value, got String`, 1, 31},
+		{`function f($a, $b = 1) { $a } f()`, `The function 'f' expects between 1 and 2 arguments, got 0`, 1, 31},
+		{`function f() >> String { 1 } f()`, `The return value of the function 'f' expects a String value, got Integer`, 1, 30},
+		{`[1].map |$x, $y, $z| { $x }`, `The lambda expects 3 arguments, got 2`, 1, 9},
+	} {
+		_, err := NewInterpreter().Evaluate(parse(t, tc.source), nil)
+		if err == nil {
+			t.Errorf(`expected an error for %s`, tc.source)
+			continue
+		}
+		if actual := err.Error(); actual != fmt.Sprintf(`%s (file: test.pp, line: %d, column: %d)`, tc.expected, tc.line, tc.pos) {
+			t.Errorf(`expected '%s' for %s, got '%s'`, tc.expected, tc.source, actual)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	i := NewInterpreter()
+	i.Register(`double`, func(args []interface{}, block Callable) (interface{}, error) {
+		n, ok := args[0].(int64)
+		if !ok {
+			return nil, errors.New(`not an integer`)
+		}
+		if block != nil {
+			return block.Call(n * 2)
+		}
+		return n * 2, nil
+	})
+
+	value, err := i.Evaluate(parse(t, `double(2) + double(3) |$x| { $x + 1 }`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != int64(11) {
+		t.Errorf(`expected 11, got %#v`, value)
+	}
+
+	_, err = i.Evaluate(parse(t, `double(a)`), nil)
+	if err == nil || err.Error() != `Function 'double' failed: not an integer (file: test.pp, line: 1, column: 1)` {
+		t.Errorf(`unexpected error %v`, err)
+	}
+
+	_, err = i.Evaluate(parse(t, `function f() { double(a) } f()`), nil)
+	if err == nil || !strings.HasPrefix(err.Error(), `Function 'double' failed`) {
+		t.Errorf(`unexpected error %v`, err)
+	}
+}
+
+func TestCallAndScope(t *testing.T) {
+	i := NewInterpreter()
+	i.Define(parse(t, `function greet(String $who) >> String { "${greeting}, ${who}" }`).(*parser.Program))
+
+	scope := NewScope(nil)
+	scope.Set(`greeting`, `Hello`)
+	value, err := i.Evaluate(parse(t, `greet('world')`), scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != `Hello, world` {
+		t.Errorf(`expected 'Hello, world', got %#v`, value)
+	}
+
+	if _, err = i.Call(`greet`, int64(1)); err == nil || err.Error() != `Parameter 'who' of the function 'greet' expects a String value, got Integer` {
+		t.Errorf(`unexpected error %v`, err)
+	}
+
+	value, err = i.Call(`upcase`, []interface{}{`a`, `b`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(value, []interface{}{`A`, `B`}) {
+		t.Errorf(`expected ['A', 'B'], got %#v`, value)
+	}
+
+	local := NewScope(scope)
+	if !local.Set(`greeting`, `Hi`) || scope.Set(`greeting`, `Hi`) {
+		t.Error(`a variable may be shadowed but not reassigned`)
+	}
+	if v, _ := local.Get(`::greeting`); v != `Hello` {
+		t.Errorf(`expected top scope variable to be 'Hello', got %#v`, v)
+	}
+}
+
+func TestFunctionNames(t *testing.T) {
+	i := NewInterpreter()
+	i.Define(parse(t, `function mymod::helper() {}`).(*parser.Program))
+	names := i.FunctionNames()
+	if !contains(names, `mymod::helper`) || !contains(names, `each`) {
+		t.Errorf(`unexpected function names %v`, names)
+	}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parse(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`test.pp`, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr
+}
+
+func hashOf(keysAndValues ...interface{}) *literal.Hash {
+	h := literal.NewHash(len(keysAndValues) / 2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		h.Put(keysAndValues[i], keysAndValues[i+1])
+	}
+	return h
+}
@@ -0,0 +1,76 @@
+package interpreter
+
+import (
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+const (
+	INTERPRETER_ARGUMENT_COUNT_MISMATCH         = `INTERPRETER_ARGUMENT_COUNT_MISMATCH`
+	INTERPRETER_ARGUMENT_TYPE_MISMATCH          = `INTERPRETER_ARGUMENT_TYPE_MISMATCH`
+	INTERPRETER_ASSERT_TYPE_MISMATCH            = `INTERPRETER_ASSERT_TYPE_MISMATCH`
+	INTERPRETER_ASSIGNMENT_COUNT_MISMATCH       = `INTERPRETER_ASSIGNMENT_COUNT_MISMATCH`
+	INTERPRETER_ASSIGNMENT_KEY_MISSING          = `INTERPRETER_ASSIGNMENT_KEY_MISSING`
+	INTERPRETER_CATALOG_OPERATION_NOT_SUPPORTED = `INTERPRETER_CATALOG_OPERATION_NOT_SUPPORTED`
+	INTERPRETER_FAILED                          = `INTERPRETER_FAILED`
+	INTERPRETER_FUNCTION_ERROR                  = `INTERPRETER_FUNCTION_ERROR`
+	INTERPRETER_ILLEGAL_ASSIGNMENT              = `INTERPRETER_ILLEGAL_ASSIGNMENT`
+	INTERPRETER_ILLEGAL_BREAK                   = `INTERPRETER_ILLEGAL_BREAK`
+	INTERPRETER_ILLEGAL_REASSIGNMENT            = `INTERPRETER_ILLEGAL_REASSIGNMENT`
+	INTERPRETER_INVALID_REGEXP                  = `INTERPRETER_INVALID_REGEXP`
+	INTERPRETER_MISSING_BLOCK                   = `INTERPRETER_MISSING_BLOCK`
+	INTERPRETER_MISSING_PARAMETER               = `INTERPRETER_MISSING_PARAMETER`
+	INTERPRETER_NO_MATCHING_SELECTOR            = `INTERPRETER_NO_MATCHING_SELECTOR`
+	INTERPRETER_RETURN_TYPE_MISMATCH            = `INTERPRETER_RETURN_TYPE_MISMATCH`
+	INTERPRETER_UNKNOWN_FUNCTION                = `INTERPRETER_UNKNOWN_FUNCTION`
+	INTERPRETER_UNKNOWN_PARAMETER               = `INTERPRETER_UNKNOWN_PARAMETER`
+	INTERPRETER_UNKNOWN_VARIABLE                = `INTERPRETER_UNKNOWN_VARIABLE`
+	INTERPRETER_UNSUPPORTED_EXPRESSION          = `INTERPRETER_UNSUPPORTED_EXPRESSION`
+)
+
+func init() {
+	issue.Hard(INTERPRETER_ARGUMENT_COUNT_MISMATCH, `The %{name} expects %{expected}, got %{actual}`)
+
+	issue.Hard(INTERPRETER_ARGUMENT_TYPE_MISMATCH, `Parameter '%{param}' of the %{name} %{reason}`)
+
+	issue.Hard(INTERPRETER_ASSERT_TYPE_MISMATCH, `assert_type(): %{reason}`)
+
+	issue.Hard(INTERPRETER_ASSIGNMENT_COUNT_MISMATCH,
+		`The number of variables (%{expected}) does not match the number of assigned values (%{actual})`)
+
+	issue.Hard(INTERPRETER_ASSIGNMENT_KEY_MISSING, `The assigned hash has no value for variable '$%{name}'`)
+
+	issue.Hard2(INTERPRETER_CATALOG_OPERATION_NOT_SUPPORTED,
+		`The catalog operation '%{operation}' is only available when compiling a catalog`,
+		issue.HF{`operation`: issue.Label})
+
+	issue.Hard(INTERPRETER_FAILED, `%{message}`)
+
+	issue.Hard(INTERPRETER_FUNCTION_ERROR, `Function '%{name}' failed: %{message}`)
+
+	issue.Hard2(INTERPRETER_ILLEGAL_ASSIGNMENT, `%{expression} cannot be assigned to`, issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard(INTERPRETER_ILLEGAL_BREAK, `break() can only be used in a lambda given to an iterating function`)
+
+	issue.Hard(INTERPRETER_ILLEGAL_REASSIGNMENT, `Cannot reassign variable '$%{name}'`)
+
+	issue.Hard(INTERPRETER_INVALID_REGEXP, `Invalid regular expression /%{pattern}/: %{detail}`)
+
+	issue.Hard(INTERPRETER_MISSING_BLOCK, `Function '%{name}' expects a block`)
+
+	issue.Hard(INTERPRETER_MISSING_PARAMETER, `The template expects a value for parameter '$%{param}'`)
+
+	issue.Hard(INTERPRETER_NO_MATCHING_SELECTOR, `No matching entry for selector parameter with value %{value}`)
+
+	issue.Hard(INTERPRETER_RETURN_TYPE_MISMATCH, `The return value of the %{name} %{reason}`)
+
+	issue.Hard2(INTERPRETER_UNKNOWN_FUNCTION, `Unknown function: '%{name}'.%{suggestions}`,
+		issue.HF{`suggestions`: parser.DidYouMean})
+
+	issue.Hard(INTERPRETER_UNKNOWN_PARAMETER, `The template has no parameter named '%{param}'`)
+
+	issue.Hard(INTERPRETER_UNKNOWN_VARIABLE, `Unknown variable: '$%{name}'`)
+
+	issue.Hard2(INTERPRETER_UNSUPPORTED_EXPRESSION, `%{expression} is not supported by the interpreter`,
+		issue.HF{`expression`: issue.UcAnOrA})
+}
@@ -0,0 +1,75 @@
+package interpreter
+
+import "strings"
+
+// Scope holds the variables that are visible to the expressions that are evaluated in it. A scope sees
+// the variables of its parent scopes. A variable can only be assigned once in a scope but it may
+// shadow a variable of a parent scope.
+type Scope struct {
+	parent    *Scope
+	variables map[string]interface{}
+	matches   []interface{}
+}
+
+// NewScope creates a scope that is nested in the given parent scope. A nil parent creates a top scope.
+func NewScope(parent *Scope) *Scope {
+	return &Scope{parent: parent, variables: make(map[string]interface{})}
+}
+
+// Get returns the value of the variable with the given name and true, or nil and false if no such
+// variable is visible from the scope. A name that starts with '::' denotes a variable in the top scope.
+func (s *Scope) Get(name string) (interface{}, bool) {
+	if strings.HasPrefix(name, `::`) {
+		return s.Top().Get(name[2:])
+	}
+	for c := s; c != nil; c = c.parent {
+		if value, ok := c.variables[name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// Set assigns a value to a variable in the scope. It returns false if the variable is already assigned
+// in this scope.
+func (s *Scope) Set(name string, value interface{}) bool {
+	if strings.HasPrefix(name, `::`) {
+		return s.Top().Set(name[2:], value)
+	}
+	if _, ok := s.variables[name]; ok {
+		return false
+	}
+	s.variables[name] = value
+	return true
+}
+
+// Top returns the top scope
+func (s *Scope) Top() *Scope {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+// match returns the value of the numeric variable, i.e. a group of the last successful regular
+// expression match in the scope or its parents
+func (s *Scope) match(index int64) (interface{}, bool) {
+	for c := s; c != nil; c = c.parent {
+		if c.matches != nil {
+			if index < int64(len(c.matches)) {
+				return c.matches[index], true
+			}
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Scope) setMatches(groups []string, found []bool) {
+	s.matches = make([]interface{}, len(groups))
+	for i, g := range groups {
+		if found[i] {
+			s.matches[i] = g
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package literal
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+var (
+	defaultType       = reflect.TypeOf(parser.DEFAULT_INSTANCE)
+	hashType          = reflect.TypeOf(&Hash{})
+	regexpType        = reflect.TypeOf(Regexp{})
+	typeReferenceType = reflect.TypeOf(TypeReference{})
+)
+
+// Decode assigns the value of a literal expression to the Go value that target points to. Literal hashes
+// are decoded into structs, maps, or *Hash, literal arrays into slices, and other literals into values
+// of a matching kind. An interface{} receives the value that ToTypedLiteral would produce.
+//
+// The keys of a hash are matched against the struct fields using the `puppet` field tag. A field without
+// a tag matches a key that is equal to the field name when case and underscores are disregarded, so
+// the key max_size matches the field MaxSize. A field with the tag `puppet:"-"` is never matched.
+//
+// An issue.Reported error, located at the offending sub-expression, is returned when the expression is
+// not a literal or when a value cannot be assigned.
+func Decode(e parser.Expression, target interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ri, ok := r.(issue.Reported); ok {
+				err = ri
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return issue.NewReported(LITERAL_DECODE_UNSUPPORTED_TYPE, issue.SEVERITY_ERROR, issue.H{`type`: fmt.Sprintf(`%T`, target)}, e)
+	}
+	if p, ok := e.(*parser.Program); ok {
+		e = p.Body()
+	}
+	if b, ok := e.(*parser.BlockExpression); ok && len(b.Statements()) == 1 {
+		e = b.Statements()[0]
+	}
+	decode(e, ``, v.Elem())
+	return
+}
+
+func decode(e parser.Expression, path string, v reflect.Value) {
+	if he, ok := e.(*parser.HeredocExpression); ok {
+		e = he.Text()
+	}
+	t := v.Type()
+	switch t {
+	case hashType:
+		v.Set(reflect.ValueOf(decodeHash(e, path)))
+		return
+	case defaultType, regexpType, typeReferenceType:
+		value := scalarValue(e)
+		if reflect.TypeOf(value) != t {
+			panic(decodeMismatch(e, path, t, value))
+		}
+		v.Set(reflect.ValueOf(value))
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		value := decodeAny(e, path)
+		if value == nil {
+			v.Set(reflect.Zero(t))
+			return
+		}
+		rv := reflect.ValueOf(value)
+		if !rv.Type().AssignableTo(t) {
+			panic(decodeMismatch(e, path, t, value))
+		}
+		v.Set(rv)
+	case reflect.Ptr:
+		if isUndef(e) {
+			v.Set(reflect.Zero(t))
+			return
+		}
+		pv := reflect.New(t.Elem())
+		decode(e, path, pv.Elem())
+		v.Set(pv)
+	case reflect.Slice:
+		if isUndef(e) {
+			v.Set(reflect.Zero(t))
+			return
+		}
+		ll, ok := e.(*parser.LiteralList)
+		if !ok {
+			panic(decodeMismatch(e, path, t, nil))
+		}
+		elements := ll.Elements()
+		sv := reflect.MakeSlice(t, len(elements), len(elements))
+		for i, elem := range elements {
+			decode(elem, fmt.Sprintf(`%s[%d]`, path, i), sv.Index(i))
+		}
+		v.Set(sv)
+	case reflect.Map:
+		if isUndef(e) {
+			v.Set(reflect.Zero(t))
+			return
+		}
+		lh, ok := e.(*parser.LiteralHash)
+		if !ok {
+			panic(decodeMismatch(e, path, t, nil))
+		}
+		mv := reflect.MakeMapWithSize(t, len(lh.Entries()))
+		for _, entry := range lh.Entries() {
+			ke := entry.(*parser.KeyedEntry)
+			kv := reflect.New(t.Key()).Elem()
+			decode(ke.Key(), path, kv)
+			vv := reflect.New(t.Elem()).Elem()
+			decode(ke.Value(), keyPath(path, ke.Key()), vv)
+			mv.SetMapIndex(kv, vv)
+		}
+		v.Set(mv)
+	case reflect.Struct:
+		lh, ok := e.(*parser.LiteralHash)
+		if !ok {
+			panic(decodeMismatch(e, path, t, nil))
+		}
+		for _, entry := range lh.Entries() {
+			ke := entry.(*parser.KeyedEntry)
+			name, ok := scalarValue(ke.Key()).(string)
+			if !ok {
+				panic(decodeMismatch(ke.Key(), path, reflect.TypeOf(``), scalarValue(ke.Key())))
+			}
+			field, found := fieldIndex(t, name)
+			if !found {
+				panic(issue.NewReported(LITERAL_DECODE_UNKNOWN_KEY, issue.SEVERITY_ERROR, issue.H{`path`: pathLabel(path), `key`: name}, ke.Key()))
+			}
+			decode(ke.Value(), keyPath(path, ke.Key()), v.Field(field))
+		}
+	case reflect.Bool, reflect.String:
+		value := scalarValue(e)
+		rv := reflect.ValueOf(value)
+		if value == nil || rv.Kind() != t.Kind() {
+			panic(decodeMismatch(e, path, t, value))
+		}
+		v.Set(rv.Convert(t))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := scalarValue(e).(int64)
+		if !ok {
+			panic(decodeMismatch(e, path, t, scalarValue(e)))
+		}
+		if v.OverflowInt(i) {
+			panic(decodeOutOfRange(e, path, t, i))
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := scalarValue(e).(int64)
+		if !ok {
+			panic(decodeMismatch(e, path, t, scalarValue(e)))
+		}
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			panic(decodeOutOfRange(e, path, t, i))
+		}
+		v.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch value := scalarValue(e).(type) {
+		case int64:
+			f = float64(value)
+		case float64:
+			f = value
+		default:
+			panic(decodeMismatch(e, path, t, value))
+		}
+		if t.Kind() == reflect.Float32 && math.Abs(f) > math.MaxFloat32 {
+			panic(decodeOutOfRange(e, path, t, f))
+		}
+		v.SetFloat(f)
+	default:
+		panic(issue.NewReported(LITERAL_DECODE_UNSUPPORTED_TYPE, issue.SEVERITY_ERROR, issue.H{`type`: t.String()}, e))
+	}
+}
+
+// decodeAny returns the typed literal value of the expression
+func decodeAny(e parser.Expression, path string) interface{} {
+	switch e.(type) {
+	case *parser.LiteralList:
+		elements := e.(*parser.LiteralList).Elements()
+		result := make([]interface{}, len(elements))
+		for i, elem := range elements {
+			result[i] = decodeAny(elem, fmt.Sprintf(`%s[%d]`, path, i))
+		}
+		return result
+	case *parser.LiteralHash:
+		return decodeHash(e, path)
+	case *parser.HeredocExpression:
+		return decodeAny(e.(*parser.HeredocExpression).Text(), path)
+	}
+	return scalarValue(e)
+}
+
+func decodeHash(e parser.Expression, path string) *Hash {
+	if he, ok := e.(*parser.HeredocExpression); ok {
+		e = he.Text()
+	}
+	lh, ok := e.(*parser.LiteralHash)
+	if !ok {
+		panic(decodeMismatch(e, path, hashType, nil))
+	}
+	result := NewHash(len(lh.Entries()))
+	for _, entry := range lh.Entries() {
+		ke := entry.(*parser.KeyedEntry)
+		result.Put(decodeAny(ke.Key(), path), decodeAny(ke.Value(), keyPath(path, ke.Key())))
+	}
+	return result
+}
+
+// scalarValue returns the typed literal value of an expression that is not an array or a hash. Arrays and
+// hashes are represented by their empty values so that mismatches can be described.
+func scalarValue(e parser.Expression) interface{} {
+	switch e.(type) {
+	case *parser.LiteralList:
+		return []interface{}{}
+	case *parser.LiteralHash:
+		return NewHash(0)
+	}
+	value, ok := ToTypedLiteral(e)
+	if !ok {
+		panic(issue.NewReported(LITERAL_NOT_A_LITERAL, issue.SEVERITY_ERROR, issue.H{`expression`: e}, e))
+	}
+	return value
+}
+
+func isUndef(e parser.Expression) bool {
+	_, ok := e.(*parser.LiteralUndef)
+	return ok
+}
+
+// fieldIndex returns the index of the exported field of the struct that matches the given key
+func fieldIndex(t reflect.Type, key string) (int, bool) {
+	normalizedKey := normalizeName(key)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != `` {
+			// Not exported
+			continue
+		}
+		if tag, ok := f.Tag.Lookup(`puppet`); ok {
+			if tag == key {
+				return i, true
+			}
+			continue
+		}
+		if normalizeName(f.Name) == normalizedKey {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Replace(name, `_`, ``, -1))
+}
+
+func keyPath(path string, key parser.Expression) string {
+	name := fmt.Sprint(scalarValue(key))
+	if path == `` {
+		return name
+	}
+	return path + `.` + name
+}
+
+func pathLabel(path string) string {
+	if path == `` {
+		return `The value`
+	}
+	return fmt.Sprintf(`The value of '%s'`, path)
+}
+
+// goTypeName returns the Puppet name of the kind of values that can be decoded into the given type
+func goTypeName(t reflect.Type) string {
+	switch t {
+	case defaultType:
+		return `Default`
+	case hashType:
+		return `Hash`
+	case regexpType:
+		return `Regexp`
+	case typeReferenceType:
+		return `Type`
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return `Boolean`
+	case reflect.String:
+		return `String`
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return `Integer`
+	case reflect.Float32, reflect.Float64:
+		return `Float`
+	case reflect.Slice:
+		return `Array`
+	case reflect.Map, reflect.Struct:
+		return `Hash`
+	case reflect.Ptr:
+		return goTypeName(t.Elem())
+	}
+	return t.String()
+}
+
+func decodeMismatch(e parser.Expression, path string, t reflect.Type, value interface{}) issue.Reported {
+	actual := TypeName(value)
+	if value == nil && !isUndef(e) {
+		actual = TypeName(scalarValue(e))
+	}
+	return issue.NewReported(LITERAL_DECODE_MISMATCH, issue.SEVERITY_ERROR,
+		issue.H{`path`: pathLabel(path), `expected`: goTypeName(t), `actual`: actual}, e)
+}
+
+func decodeOutOfRange(e parser.Expression, path string, t reflect.Type, value interface{}) issue.Reported {
+	return issue.NewReported(LITERAL_DECODE_OUT_OF_RANGE, issue.SEVERITY_ERROR,
+		issue.H{`path`: pathLabel(path), `value`: value, `type`: t.String()}, e)
+}
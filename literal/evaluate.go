@@ -0,0 +1,99 @@
+package literal
+
+import (
+	"bytes"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Evaluate folds an expression that consists of literals, and operators applied to literals, into a
+// constant value. The value is of the same kind as the values returned by ToTypedLiteral, so hashes
+// are ordered. Arithmetic, comparison, boolean operators, 'in', string interpolation, heredocs, and
+// access using [] are evaluated the way Puppet evaluates them.
+//
+// An issue.Reported error is returned when the expression is not constant or when an operation
+// cannot be applied. The error is located at the sub-expression that caused it.
+func Evaluate(e parser.Expression) (interface{}, error) {
+	return protect(func() interface{} { return evaluate(e) })
+}
+
+func evalIssue(code issue.Code, e parser.Expression, args issue.H) issue.Reported {
+	return issue.NewReported(code, issue.SEVERITY_ERROR, args, e)
+}
+
+func evaluate(e parser.Expression) interface{} {
+	switch e.(type) {
+	case *parser.Program:
+		return evaluate(e.(*parser.Program).Body())
+	case *parser.BlockExpression:
+		var value interface{}
+		for _, s := range e.(*parser.BlockExpression).Statements() {
+			value = evaluate(s)
+		}
+		return value
+	case *parser.ParenthesizedExpression:
+		return evaluate(e.(*parser.ParenthesizedExpression).Expr())
+	case *parser.LiteralList:
+		elements := e.(*parser.LiteralList).Elements()
+		result := make([]interface{}, len(elements))
+		for i, elem := range elements {
+			result[i] = evaluate(elem)
+		}
+		return result
+	case *parser.LiteralHash:
+		entries := e.(*parser.LiteralHash).Entries()
+		result := NewHash(len(entries))
+		for _, entry := range entries {
+			ke := entry.(*parser.KeyedEntry)
+			result.Put(evaluate(ke.Key()), evaluate(ke.Value()))
+		}
+		return result
+	case *parser.ConcatenatedString:
+		b := bytes.NewBufferString(``)
+		for _, s := range e.(*parser.ConcatenatedString).Segments() {
+			b.WriteString(ToString(evaluate(s)))
+		}
+		return b.String()
+	case *parser.TextExpression:
+		return ToString(evaluate(e.(*parser.TextExpression).Expr()))
+	case *parser.HeredocExpression:
+		return evaluate(e.(*parser.HeredocExpression).Text())
+	case *parser.NotExpression:
+		return !isTruthy(evaluate(e.(*parser.NotExpression).Expr()))
+	case *parser.AndExpression:
+		ae := e.(*parser.AndExpression)
+		return isTruthy(evaluate(ae.Lhs())) && isTruthy(evaluate(ae.Rhs()))
+	case *parser.OrExpression:
+		oe := e.(*parser.OrExpression)
+		return isTruthy(evaluate(oe.Lhs())) || isTruthy(evaluate(oe.Rhs()))
+	case *parser.UnaryMinusExpression:
+		return negate(e, evaluate(e.(*parser.UnaryMinusExpression).Expr()))
+	case *parser.ArithmeticExpression:
+		ae := e.(*parser.ArithmeticExpression)
+		return arithmetic(ae, ae.Operator(), evaluate(ae.Lhs()), evaluate(ae.Rhs()))
+	case *parser.ComparisonExpression:
+		ce := e.(*parser.ComparisonExpression)
+		return compare(ce, ce.Operator(), evaluate(ce.Lhs()), evaluate(ce.Rhs()))
+	case *parser.InExpression:
+		ie := e.(*parser.InExpression)
+		return isIn(evaluate(ie.Lhs()), evaluate(ie.Rhs()))
+	case *parser.AccessExpression:
+		ae := e.(*parser.AccessExpression)
+		if _, ok := ae.Operand().(*parser.QualifiedReference); ok {
+			// A parameterized type
+			break
+		}
+		keys := make([]interface{}, len(ae.Keys()))
+		for i, k := range ae.Keys() {
+			keys[i] = evaluate(k)
+		}
+		return access(ae, evaluate(ae.Operand()), keys)
+	case *parser.RegexpExpression:
+		// The value of a regexp cannot be told apart from a string
+		break
+	case parser.LiteralValue:
+		return e.(parser.LiteralValue).Value()
+	}
+	panic(evalIssue(LITERAL_NOT_CONSTANT, e, issue.H{`expression`: e}))
+}
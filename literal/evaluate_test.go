@@ -0,0 +1,128 @@
+package literal
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestEvaluate(t *testing.T) {
+	for _, tc := range []struct {
+		source   string
+		expected interface{}
+	}{
+		{`"${'a'}b"`, `ab`},
+		{`"a${1 + 2}b${[1, 'x', undef]}"`, `a3b[1, 'x', undef]`},
+		{`"${2.0}${undef}${default}"`, `2.0default`},
+		{`1 + 2`, int64(3)},
+		{`7 / 2`, int64(3)},
+		{`-7 / 2`, int64(-4)},
+		{`-7 % 3`, int64(2)},
+		{`7 / 2.0`, 3.5},
+		{`1 << 4`, int64(16)},
+		{`-(2 * 3)`, int64(-6)},
+		{`[1, 2] + [3]`, []interface{}{int64(1), int64(2), int64(3)}},
+		{`[1, 2] + 3`, []interface{}{int64(1), int64(2), int64(3)}},
+		{`[1, 2, 1] - 1`, []interface{}{int64(2)}},
+		{`[1] << [2]`, []interface{}{int64(1), []interface{}{int64(2)}}},
+		{`{a => 1} + {b => 2, a => 3}`, hashOf(`a`, int64(3), `b`, int64(2))},
+		{`{a => 1, b => 2} - [a]`, hashOf(`b`, int64(2))},
+		{`[0] + {b => 1, a => 2}`, []interface{}{int64(0), []interface{}{`b`, int64(1)}, []interface{}{`a`, int64(2)}}},
+		{`"${ {b => 1, a => [x]} }"`, `{'b' => 1, 'a' => ['x']}`},
+		{`{[1] => a}[[1]]`, `a`},
+		{`!true`, false},
+		{`!undef`, true},
+		{`true and undef`, false},
+		{`false or 'x'`, true},
+		{`'x' in ['x']`, true},
+		{`'X' in 'axb'`, true},
+		{`a in {a => 1}`, true},
+		{`3 in [1, 2]`, false},
+		{`'A' == 'a'`, true},
+		{`1 == 1.0`, true},
+		{`[1, 'a'] != [1, 'A']`, false},
+		{`1 < 2.5`, true},
+		{`'b' >= 'A'`, true},
+		{"@(END)\n  text\n  | END\n", "text\n"},
+		{`[1, 2, 3][1]`, int64(2)},
+		{`[1, 2, 3][-1]`, int64(3)},
+		{`[1, 2, 3][5]`, nil},
+		{`[1, 2, 3][1, 5]`, []interface{}{int64(2), int64(3)}},
+		{`[1, 2, 3, 4][1, -2]`, []interface{}{int64(2), int64(3)}},
+		{`'hello'[1, 3]`, `ell`},
+		{`{a => 1, b => 2}[b]`, int64(2)},
+		{`{a => 1, b => 2}[a, c, b]`, []interface{}{int64(1), int64(2)}},
+		{`(1 + 2) * 3`, int64(9)},
+		{`-9223372036854775808`, int64(math.MinInt64)},
+		{`-0x8000000000000000`, int64(math.MinInt64)},
+		{`-9223372036854775807 - 1`, int64(math.MinInt64)},
+		{`-9223372036854775808 / 1`, int64(math.MinInt64)},
+		{`-9223372036854775808 % -1`, int64(0)},
+		{`-(-9223372036854775807)`, int64(math.MaxInt64)},
+	} {
+		value, err := Evaluate(parse(t, tc.source))
+		if err != nil {
+			t.Errorf(`unexpected error for %s: %s`, tc.source, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, tc.expected) {
+			t.Errorf(`expected %#v for %s, got %#v`, tc.expected, tc.source, value)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	for _, tc := range []struct {
+		source   string
+		expected string
+		line     int
+		pos      int
+	}{
+		{`$x`, `A Variable is not constant`, 1, 1},
+		{`[1, 2 + $x]`, `A Variable is not constant`, 1, 9},
+		{`"a${foo()}"`, `A Function Call is not constant`, 1, 5},
+		{`/x/`, `A Regular Expression is not constant`, 1, 1},
+		{`Integer[1]`, `A '[]' expression is not constant`, 1, 1},
+		{`1 / 0`, `Division by zero`, 1, 1},
+		{`9223372036854775807 + 1`, `The result of the '+' operation is out of range for an Integer`, 1, 1},
+		{`-9223372036854775808 - 1`, `The result of the '-' operation is out of range for an Integer`, 1, 1},
+		{`-9223372036854775808 * -1`, `The result of the '*' operation is out of range for an Integer`, 1, 1},
+		{`-9223372036854775808 / -1`, `The result of the '/' operation is out of range for an Integer`, 1, 1},
+		{`-(-9223372036854775808)`, `The result of the '-' operation is out of range for an Integer`, 1, 1},
+		{`1 + 'a'`, `Operator '+' is not applicable to an Integer when right side is a String`, 1, 1},
+		{`'a' + 'b'`, `Operator '+' is not applicable to a String`, 1, 1},
+		{`-'a'`, `Operator '-' is not applicable to a String`, 1, 1},
+		{`1.5 % 2`, `Operator '%' is not applicable to a Float`, 1, 1},
+		{`[1]['a']`, `Array access expects an Integer key, got a String`, 1, 5},
+		{`true[0]`, `Operator '[]' is not applicable to a Boolean`, 1, 1},
+	} {
+		_, err := Evaluate(parse(t, tc.source))
+		if err == nil {
+			t.Errorf(`expected an error for %s`, tc.source)
+			continue
+		}
+		if actual := err.Error(); actual != fmt.Sprintf(`%s (file: test.pp, line: %d, column: %d)`, tc.expected, tc.line, tc.pos) {
+			t.Errorf(`expected '%s' for %s, got '%s'`, tc.expected, tc.source, actual)
+		}
+	}
+}
+
+func parse(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`test.pp`, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr
+}
+
+func hashOf(keysAndValues ...interface{}) *Hash {
+	h := NewHash(len(keysAndValues) / 2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		h.Put(keysAndValues[i], keysAndValues[i+1])
+	}
+	return h
+}
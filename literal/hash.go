@@ -0,0 +1,80 @@
+package literal
+
+import "reflect"
+
+// HashEntry is an entry of a Hash
+type HashEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// Hash is a hash that retains the order in which its entries were added. Puppet considers the order
+// of the entries of a hash significant.
+type Hash struct {
+	entries []HashEntry
+	index   map[interface{}]int
+}
+
+// NewHash creates an empty hash with room for the given number of entries
+func NewHash(capacity int) *Hash {
+	return &Hash{make([]HashEntry, 0, capacity), make(map[interface{}]int, capacity)}
+}
+
+// Entries returns the entries of the hash in the order they were added
+func (h *Hash) Entries() []HashEntry {
+	return h.entries
+}
+
+// Get returns the value for the given key and true, or nil and false when the key is not found
+func (h *Hash) Get(key interface{}) (interface{}, bool) {
+	if i := h.indexOf(key); i >= 0 {
+		return h.entries[i].Value, true
+	}
+	return nil, false
+}
+
+// Keys returns the keys of the hash in the order they were added
+func (h *Hash) Keys() []interface{} {
+	keys := make([]interface{}, len(h.entries))
+	for i, e := range h.entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Len returns the number of entries in the hash
+func (h *Hash) Len() int {
+	return len(h.entries)
+}
+
+// Put adds an entry to the hash. An entry with an equal key that is already present is given the new
+// value but retains its position.
+func (h *Hash) Put(key, value interface{}) {
+	if i := h.indexOf(key); i >= 0 {
+		h.entries[i].Value = value
+		return
+	}
+	if isHashable(key) {
+		h.index[key] = len(h.entries)
+	}
+	h.entries = append(h.entries, HashEntry{key, value})
+}
+
+func (h *Hash) indexOf(key interface{}) int {
+	if isHashable(key) {
+		if i, ok := h.index[key]; ok {
+			return i
+		}
+		return -1
+	}
+	// Keys that cannot be used in a Go map are compared one by one
+	for i, e := range h.entries {
+		if isHashable(e.Key) {
+			continue
+		}
+		if equal, isType := equalTypes(e.Key, key); equal || !isType && reflect.DeepEqual(e.Key, key) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,48 @@
+package literal
+
+import "github.com/lyraproj/issue/issue"
+
+const (
+	LITERAL_DECODE_MISMATCH              = `LITERAL_DECODE_MISMATCH`
+	LITERAL_DECODE_OUT_OF_RANGE          = `LITERAL_DECODE_OUT_OF_RANGE`
+	LITERAL_DECODE_UNKNOWN_KEY           = `LITERAL_DECODE_UNKNOWN_KEY`
+	LITERAL_DECODE_UNSUPPORTED_TYPE      = `LITERAL_DECODE_UNSUPPORTED_TYPE`
+	LITERAL_DIVISION_BY_ZERO             = `LITERAL_DIVISION_BY_ZERO`
+	LITERAL_ILLEGAL_ACCESS               = `LITERAL_ILLEGAL_ACCESS`
+	LITERAL_INTEGER_OVERFLOW             = `LITERAL_INTEGER_OVERFLOW`
+	LITERAL_NOT_A_LITERAL                = `LITERAL_NOT_A_LITERAL`
+	LITERAL_NOT_CONSTANT                 = `LITERAL_NOT_CONSTANT`
+	LITERAL_OPERATOR_NOT_APPLICABLE      = `LITERAL_OPERATOR_NOT_APPLICABLE`
+	LITERAL_OPERATOR_NOT_APPLICABLE_WHEN = `LITERAL_OPERATOR_NOT_APPLICABLE_WHEN`
+	LITERAL_UNSUPPORTED_VALUE            = `LITERAL_UNSUPPORTED_VALUE`
+)
+
+func init() {
+	issue.Hard2(LITERAL_DECODE_MISMATCH, `%{path} expects %{expected}, got %{actual}`,
+		issue.HF{`expected`: issue.AnOrA, `actual`: issue.AnOrA})
+
+	issue.Hard(LITERAL_DECODE_OUT_OF_RANGE, `%{path} has a value of %{value} which is out of range for a Go %{type}`)
+
+	issue.Hard(LITERAL_DECODE_UNKNOWN_KEY, `%{path} has an unrecognized key '%{key}'`)
+
+	issue.Hard(LITERAL_DECODE_UNSUPPORTED_TYPE, `Values cannot be decoded into a Go %{type}`)
+
+	issue.Hard(LITERAL_DIVISION_BY_ZERO, `Division by zero`)
+
+	issue.Hard(LITERAL_ILLEGAL_ACCESS, `%{operand} access expects %{expected}, got %{actual}`)
+
+	issue.Hard(LITERAL_INTEGER_OVERFLOW, `The result of the '%{operator}' operation is out of range for an Integer`)
+
+	issue.Hard2(LITERAL_NOT_A_LITERAL, `%{expression} is not a literal`, issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard2(LITERAL_NOT_CONSTANT, `%{expression} is not constant`, issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard2(LITERAL_OPERATOR_NOT_APPLICABLE, `Operator '%{operator}' is not applicable to %{operand}`,
+		issue.HF{`operand`: issue.AnOrA})
+
+	issue.Hard2(LITERAL_OPERATOR_NOT_APPLICABLE_WHEN,
+		`Operator '%{operator}' is not applicable to %{left} when right side is %{right}`,
+		issue.HF{`left`: issue.AnOrA, `right`: issue.AnOrA})
+
+	issue.Hard(LITERAL_UNSUPPORTED_VALUE, `The Go value %{value} of type %{type} cannot be represented in Puppet`)
+}
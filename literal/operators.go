@@ -0,0 +1,659 @@
+package literal
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// The exported operations below apply Puppet operators to values of the kinds that Evaluate and
+// ToTypedLiteral produce. The given expression is the location of the returned issue.Reported error.
+
+// Arithmetic applies one of the operators +, -, *, /, %, <<, and >> to the values
+func Arithmetic(e parser.Expression, op string, lhs, rhs interface{}) (interface{}, error) {
+	return protect(func() interface{} { return arithmetic(e, op, lhs, rhs) })
+}
+
+// Compare applies one of the operators ==, !=, <, <=, >, and >= to the values
+func Compare(e parser.Expression, op string, lhs, rhs interface{}) (bool, error) {
+	result, err := protect(func() interface{} { return compare(e, op, lhs, rhs) })
+	return result == true, err
+}
+
+// Negate applies the unary minus operator to the value
+func Negate(e parser.Expression, value interface{}) (interface{}, error) {
+	return protect(func() interface{} { return negate(e, value) })
+}
+
+// Access returns the result of accessing the operand with the given keys using []
+func Access(e *parser.AccessExpression, operand interface{}, keys []interface{}) (interface{}, error) {
+	return protect(func() interface{} { return access(e, operand, keys) })
+}
+
+// In returns true if the left value is a case insensitive substring of the right string, an element of
+// the right array, or a key of the right hash
+func In(lhs, rhs interface{}) bool {
+	return isIn(lhs, rhs)
+}
+
+// IsTruthy returns false for undef and false, and true for all other values
+func IsTruthy(value interface{}) bool {
+	return isTruthy(value)
+}
+
+// protect calls the function and returns the issue.Reported that it panics with as an error
+func protect(f func() interface{}) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ri, ok := r.(issue.Reported); ok {
+				value = nil
+				err = ri
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	value = f()
+	return
+}
+
+// isTruthy returns false for undef and false, and true for all other values
+func isTruthy(value interface{}) bool {
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	return value != nil
+}
+
+func negate(e parser.Expression, value interface{}) interface{} {
+	switch value.(type) {
+	case int64:
+		i := value.(int64)
+		if i == math.MinInt64 {
+			panic(evalIssue(LITERAL_INTEGER_OVERFLOW, e, issue.H{`operator`: `-`}))
+		}
+		return -i
+	case float64:
+		return -value.(float64)
+	}
+	panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE, e, issue.H{`operator`: `-`, `operand`: TypeName(value)}))
+}
+
+func arithmetic(e parser.Expression, op string, lhs, rhs interface{}) interface{} {
+	switch lhs.(type) {
+	case int64, float64:
+		return numericArithmetic(e, op, lhs, rhs)
+	case []interface{}:
+		switch op {
+		case `+`:
+			return concat(lhs.([]interface{}), rhs)
+		case `-`:
+			return subtract(lhs.([]interface{}), rhs)
+		case `<<`:
+			return append(append(make([]interface{}, 0, len(lhs.([]interface{}))+1), lhs.([]interface{})...), rhs)
+		}
+	case *Hash:
+		switch op {
+		case `+`:
+			if rh, ok := rhs.(*Hash); ok {
+				return merge(lhs.(*Hash), rh)
+			}
+			panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE_WHEN, e, issue.H{`operator`: op, `left`: TypeName(lhs), `right`: TypeName(rhs)}))
+		case `-`:
+			return removeKeys(lhs.(*Hash), rhs)
+		}
+	}
+	panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE, e, issue.H{`operator`: op, `operand`: TypeName(lhs)}))
+}
+
+func numericArithmetic(e parser.Expression, op string, lhs, rhs interface{}) interface{} {
+	switch rhs.(type) {
+	case int64, float64:
+	default:
+		panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE_WHEN, e, issue.H{`operator`: op, `left`: TypeName(lhs), `right`: TypeName(rhs)}))
+	}
+
+	li, lok := lhs.(int64)
+	ri, rok := rhs.(int64)
+	if lok && rok {
+		return integerArithmetic(e, op, li, ri)
+	}
+	if op == `%` || op == `<<` || op == `>>` {
+		operand := lhs
+		if lok {
+			operand = rhs
+		}
+		panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE, e, issue.H{`operator`: op, `operand`: TypeName(operand)}))
+	}
+	lf, rf := toFloat(lhs), toFloat(rhs)
+	switch op {
+	case `+`:
+		return lf + rf
+	case `-`:
+		return lf - rf
+	case `*`:
+		return lf * rf
+	default:
+		if rf == 0 {
+			panic(evalIssue(LITERAL_DIVISION_BY_ZERO, e, issue.NO_ARGS))
+		}
+		return lf / rf
+	}
+}
+
+// integerArithmetic performs integer arithmetic the way Ruby does, i.e. division rounds towards negative
+// infinity and the result of modulo has the sign of the divisor. Overflows are reported.
+func integerArithmetic(e parser.Expression, op string, lhs, rhs int64) interface{} {
+	var result int64
+	overflow := false
+	switch op {
+	case `+`:
+		result = lhs + rhs
+		overflow = (rhs > 0 && result < lhs) || (rhs < 0 && result > lhs)
+	case `-`:
+		result = lhs - rhs
+		overflow = (rhs < 0 && result < lhs) || (rhs > 0 && result > lhs)
+	case `*`:
+		result = lhs * rhs
+		overflow = lhs != 0 && (result/lhs != rhs || (lhs == -1 && rhs == math.MinInt64) || (rhs == -1 && lhs == math.MinInt64))
+	case `/`, `%`:
+		if rhs == 0 {
+			panic(evalIssue(LITERAL_DIVISION_BY_ZERO, e, issue.NO_ARGS))
+		}
+		if lhs == math.MinInt64 && rhs == -1 {
+			if op == `%` {
+				return int64(0)
+			}
+			overflow = true
+			break
+		}
+		q, r := lhs/rhs, lhs%rhs
+		if r != 0 && (r < 0) != (rhs < 0) {
+			q--
+			r += rhs
+		}
+		if op == `/` {
+			result = q
+		} else {
+			result = r
+		}
+	case `<<`, `>>`:
+		if op == `>>` {
+			rhs = -rhs
+		}
+		switch {
+		case rhs >= 64:
+			overflow = lhs != 0
+		case rhs >= 0:
+			result = lhs << uint(rhs)
+			overflow = result>>uint(rhs) != lhs
+		case rhs > -64:
+			result = lhs >> uint(-rhs)
+		case lhs < 0:
+			result = -1
+		}
+	}
+	if overflow {
+		panic(evalIssue(LITERAL_INTEGER_OVERFLOW, e, issue.H{`operator`: op}))
+	}
+	return result
+}
+
+// concat returns the concatenation of the array and the given value. A hash is appended as an array
+// of key and value pairs and a value that is not an array is appended as a single element.
+func concat(lhs []interface{}, rhs interface{}) []interface{} {
+	result := append(make([]interface{}, 0, len(lhs)+1), lhs...)
+	switch rhs.(type) {
+	case []interface{}:
+		return append(result, rhs.([]interface{})...)
+	case *Hash:
+		for _, e := range rhs.(*Hash).Entries() {
+			result = append(result, []interface{}{e.Key, e.Value})
+		}
+		return result
+	default:
+		return append(result, rhs)
+	}
+}
+
+// subtract returns the elements of the array that are not equal to the given value, or to any of the
+// elements of the value when it is an array
+func subtract(lhs []interface{}, rhs interface{}) []interface{} {
+	removed, ok := rhs.([]interface{})
+	if !ok {
+		removed = []interface{}{rhs}
+	}
+	result := make([]interface{}, 0, len(lhs))
+	for _, v := range lhs {
+		if !contains(removed, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func merge(lhs, rhs *Hash) *Hash {
+	result := NewHash(lhs.Len() + rhs.Len())
+	for _, e := range lhs.Entries() {
+		result.Put(e.Key, e.Value)
+	}
+	for _, e := range rhs.Entries() {
+		result.Put(e.Key, e.Value)
+	}
+	return result
+}
+
+// removeKeys returns the hash without the given keys. The keys are given as a hash, an array, or a
+// single key.
+func removeKeys(lhs *Hash, rhs interface{}) *Hash {
+	removed, ok := rhs.(*Hash)
+	if !ok {
+		removed = NewHash(1)
+		if keys, ok := rhs.([]interface{}); ok {
+			for _, k := range keys {
+				removed.Put(k, true)
+			}
+		} else {
+			removed.Put(rhs, true)
+		}
+	}
+	result := NewHash(lhs.Len())
+	for _, e := range lhs.Entries() {
+		if _, found := removed.Get(e.Key); !found {
+			result.Put(e.Key, e.Value)
+		}
+	}
+	return result
+}
+
+func compare(e parser.Expression, op string, lhs, rhs interface{}) bool {
+	switch op {
+	case `==`:
+		return Equals(lhs, rhs)
+	case `!=`:
+		return !Equals(lhs, rhs)
+	}
+
+	var c int
+	switch lhs.(type) {
+	case int64, float64:
+		switch rhs.(type) {
+		case int64, float64:
+			c = compareNumbers(lhs, rhs)
+		default:
+			panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE_WHEN, e, issue.H{`operator`: op, `left`: TypeName(lhs), `right`: TypeName(rhs)}))
+		}
+	case string:
+		rs, ok := rhs.(string)
+		if !ok {
+			panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE_WHEN, e, issue.H{`operator`: op, `left`: TypeName(lhs), `right`: TypeName(rhs)}))
+		}
+		c = strings.Compare(strings.ToLower(lhs.(string)), strings.ToLower(rs))
+	default:
+		panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE, e, issue.H{`operator`: op, `operand`: TypeName(lhs)}))
+	}
+	switch op {
+	case `<`:
+		return c < 0
+	case `<=`:
+		return c <= 0
+	case `>`:
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func compareNumbers(lhs, rhs interface{}) int {
+	li, lok := lhs.(int64)
+	ri, rok := rhs.(int64)
+	if lok && rok {
+		switch {
+		case li < ri:
+			return -1
+		case li > ri:
+			return 1
+		}
+		return 0
+	}
+	lf, rf := toFloat(lhs), toFloat(rhs)
+	switch {
+	case lf < rf:
+		return -1
+	case lf > rf:
+		return 1
+	}
+	return 0
+}
+
+// Equals compares two values the way Puppet does. Strings are compared without regard to case and
+// integers and floats are equal when their numeric values are equal. Types are equal when they are
+// written the same way.
+func Equals(a, b interface{}) bool {
+	switch a.(type) {
+	case string:
+		bs, ok := b.(string)
+		return ok && strings.EqualFold(a.(string), bs)
+	case int64, float64:
+		switch b.(type) {
+		case int64, float64:
+			return compareNumbers(a, b) == 0
+		}
+		return false
+	case []interface{}:
+		as := a.([]interface{})
+		bs, ok := b.([]interface{})
+		if !ok || len(as) != len(bs) {
+			return false
+		}
+		for i, v := range as {
+			if !Equals(v, bs[i]) {
+				return false
+			}
+		}
+		return true
+	case map[interface{}]interface{}:
+		am := a.(map[interface{}]interface{})
+		bm, ok := b.(map[interface{}]interface{})
+		if !ok || len(am) != len(bm) {
+			return false
+		}
+		for k, v := range am {
+			if bv, ok := bm[k]; !ok || !Equals(v, bv) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		ah := a.(*Hash)
+		bh, ok := b.(*Hash)
+		if !ok || ah.Len() != bh.Len() {
+			return false
+		}
+		for _, e := range ah.Entries() {
+			if bv, ok := bh.Get(e.Key); !ok || !Equals(e.Value, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	if equal, ok := equalTypes(a, b); ok {
+		return equal
+	}
+	return a == b
+}
+
+// equalTypes compares two values that are types, i.e. pointers that can be written in Puppet syntax.
+// It returns false for ok if one of the values is not a type.
+func equalTypes(a, b interface{}) (equal, ok bool) {
+	at, aok := a.(fmt.Stringer)
+	bt, bok := b.(fmt.Stringer)
+	if !(aok && bok && reflect.TypeOf(a).Kind() == reflect.Ptr) {
+		return false, false
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && at.String() == bt.String(), true
+}
+
+// isIn returns true if the left value is a substring of the right string, an element of the right
+// array, or a key of the right hash
+func isIn(lhs, rhs interface{}) bool {
+	switch rhs.(type) {
+	case string:
+		ls, ok := lhs.(string)
+		return ok && strings.Contains(strings.ToLower(rhs.(string)), strings.ToLower(ls))
+	case []interface{}:
+		return contains(rhs.([]interface{}), lhs)
+	case *Hash:
+		for _, k := range rhs.(*Hash).Keys() {
+			if Equals(lhs, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func access(e *parser.AccessExpression, operand interface{}, keys []interface{}) interface{} {
+	switch operand.(type) {
+	case []interface{}:
+		values := operand.([]interface{})
+		start, count := accessRange(e, `Array`, len(values), keys)
+		if len(keys) == 1 {
+			if start < 0 || start >= len(values) {
+				return nil
+			}
+			return values[start]
+		}
+		if count == 0 {
+			return []interface{}{}
+		}
+		return append([]interface{}{}, values[start:start+count]...)
+	case string:
+		runes := []rune(operand.(string))
+		start, count := accessRange(e, `String`, len(runes), keys)
+		if len(keys) == 1 {
+			if start < 0 || start >= len(runes) {
+				return ``
+			}
+			count = 1
+		}
+		if count == 0 {
+			return ``
+		}
+		return string(runes[start : start+count])
+	case *Hash:
+		entries := operand.(*Hash)
+		if len(keys) == 0 {
+			panic(evalIssue(LITERAL_ILLEGAL_ACCESS, e, issue.H{`operand`: `Hash`, `expected`: `at least 1 key`, `actual`: 0}))
+		}
+		if len(keys) == 1 {
+			v, _ := entries.Get(keys[0])
+			return v
+		}
+		// Keys that are not found are omitted
+		result := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			if v, ok := entries.Get(k); ok {
+				result = append(result, v)
+			}
+		}
+		return result
+	}
+	panic(evalIssue(LITERAL_OPERATOR_NOT_APPLICABLE, e, issue.H{`operator`: `[]`, `operand`: TypeName(operand)}))
+}
+
+// accessRange returns the start and count of an access to an array or string of the given size. With
+// one key, the start is the index, which is negative or beyond the size when it is out of range. With
+// two keys, the start and count are adjusted to fit within the size. A negative start is relative to the
+// end and a negative count denotes the index, relative to the end, of the last element included.
+func accessRange(e *parser.AccessExpression, operand string, size int, keys []interface{}) (start, count int) {
+	if len(keys) < 1 || len(keys) > 2 {
+		panic(evalIssue(LITERAL_ILLEGAL_ACCESS, e, issue.H{`operand`: operand, `expected`: `1 or 2 keys`, `actual`: len(keys)}))
+	}
+	indexes := make([]int, len(keys))
+	for i, k := range keys {
+		index, ok := k.(int64)
+		if !ok {
+			panic(evalIssue(LITERAL_ILLEGAL_ACCESS, e.Keys()[i], issue.H{`operand`: operand, `expected`: `an Integer key`, `actual`: issue.AnOrA(TypeName(k))}))
+		}
+		if index > math.MaxInt32 || index < math.MinInt32 {
+			index = math.MaxInt32
+		}
+		indexes[i] = int(index)
+	}
+
+	start = indexes[0]
+	if start < 0 {
+		start += size
+	}
+	if len(keys) == 1 {
+		return start, 1
+	}
+
+	count = indexes[1]
+	end := start + count
+	if count < 0 {
+		end = size + count + 1
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > size {
+		end = size
+	}
+	if end <= start {
+		return 0, 0
+	}
+	return start, end - start
+}
+
+// ToString converts a value into a string the way Puppet does when the value is interpolated
+func ToString(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return ``
+	case string:
+		return value.(string)
+	}
+	b := bytes.NewBufferString(``)
+	writeValue(b, value, false)
+	return b.String()
+}
+
+// writeValue writes the string form of the value. Strings are quoted when they are nested in an
+// array or a hash.
+func writeValue(b *bytes.Buffer, value interface{}, quoted bool) {
+	switch value.(type) {
+	case nil:
+		if quoted {
+			b.WriteString(`undef`)
+		}
+	case string:
+		if quoted {
+			b.WriteString(quoteString(value.(string)))
+		} else {
+			b.WriteString(value.(string))
+		}
+	case bool:
+		b.WriteString(strconv.FormatBool(value.(bool)))
+	case int64:
+		b.WriteString(strconv.FormatInt(value.(int64), 10))
+	case float64:
+		b.WriteString(formatFloat(value.(float64)))
+	case parser.Default:
+		b.WriteString(`default`)
+	case []interface{}:
+		b.WriteByte('[')
+		for i, v := range value.([]interface{}) {
+			if i > 0 {
+				b.WriteString(`, `)
+			}
+			writeValue(b, v, true)
+		}
+		b.WriteByte(']')
+	case map[interface{}]interface{}:
+		entries := value.(map[interface{}]interface{})
+		b.WriteByte('{')
+		for i, k := range sortedKeys(entries) {
+			if i > 0 {
+				b.WriteString(`, `)
+			}
+			writeValue(b, k, true)
+			b.WriteString(` => `)
+			writeValue(b, entries[k], true)
+		}
+		b.WriteByte('}')
+	case *Hash:
+		b.WriteByte('{')
+		for i, e := range value.(*Hash).Entries() {
+			if i > 0 {
+				b.WriteString(`, `)
+			}
+			writeValue(b, e.Key, true)
+			b.WriteString(` => `)
+			writeValue(b, e.Value, true)
+		}
+		b.WriteByte('}')
+	case fmt.Stringer:
+		// Regexp, TypeReference, and types from other packages
+		b.WriteString(value.(fmt.Stringer).String())
+	}
+}
+
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, `.eIN`) {
+		s += `.0`
+	}
+	return s
+}
+
+// TypeName returns the name of the Puppet type of the value, e.g. "Integer" or "Hash"
+func TypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return `Undef`
+	case bool:
+		return `Boolean`
+	case int64:
+		return `Integer`
+	case float64:
+		return `Float`
+	case string:
+		return `String`
+	case parser.Default:
+		return `Default`
+	case []interface{}:
+		return `Array`
+	case map[interface{}]interface{}, *Hash:
+		return `Hash`
+	case Regexp:
+		return `Regexp`
+	case TypeReference:
+		return `Type`
+	}
+	return `Any`
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if Equals(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHashable returns false for values that cannot be used as keys in a Go map, or that would be
+// compared by identity if they were
+func isHashable(value interface{}) bool {
+	switch value.(type) {
+	case []interface{}, map[interface{}]interface{}, *Hash:
+		return false
+	}
+	return reflect.TypeOf(value) == nil || reflect.TypeOf(value).Kind() != reflect.Ptr
+}
+
+func toFloat(value interface{}) float64 {
+	if i, ok := value.(int64); ok {
+		return float64(i)
+	}
+	return value.(float64)
+}
+
+// sortedKeys returns the keys of the hash in a stable order
+func sortedKeys(entries map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	return keys
+}
@@ -0,0 +1,350 @@
+package literal
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// maxInlineLength is the maximum length of an array or hash of scalars that is rendered on one line
+const maxInlineLength = 80
+
+// FromValue is the inverse of ToLiteral. It returns an expression that evaluates to the given Go value.
+// Scalars become literal strings, integers, floats, booleans, and undef, slices and arrays become
+// literal lists, and maps, *Hash, and structs become literal hashes. Pointers and interfaces are
+// followed. The values parser.Default, Regexp, and TypeReference become default, a regular expression,
+// and a type reference respectively.
+//
+// The keys of a map are sorted on their rendered source so that the result is deterministic. The keys
+// of a *Hash retain their order. A struct field is rendered with the name given by its `puppet` tag, or
+// with its name in snake case when it has no tag. Fields tagged with `puppet:"-"` and unexported
+// fields are omitted.
+//
+// The expression is positioned in the Puppet source that ToSource returns for the same value. FromValue
+// panics with an issue.Reported when the value, or a value that it contains, has no representation in
+// Puppet, e.g. a channel, a function, or a float that is not a number.
+func FromValue(value interface{}) parser.Expression {
+	r := &renderer{}
+	build := r.render(reflect.ValueOf(value), ``)
+	return build(parser.NewLocator(``, r.String()))
+}
+
+// ToSource returns the Puppet source of the expression that FromValue returns for the given value
+func ToSource(value interface{}) string {
+	r := &renderer{}
+	r.render(reflect.ValueOf(value), ``)
+	return r.String()
+}
+
+// builder creates an expression once the locator for the rendered source is known
+type builder func(locator *parser.Locator) parser.Expression
+
+type renderer struct {
+	bytes.Buffer
+}
+
+var factory = parser.DefaultFactory()
+
+// render writes the source of the value and returns a builder for its expression. The indent is the
+// indentation of the line on which the value starts.
+func (r *renderer) render(v reflect.Value, indent string) builder {
+	if !v.IsValid() {
+		return r.scalar(`undef`, factory.Undef)
+	}
+
+	switch v.Type() {
+	case defaultType:
+		return r.scalar(`default`, factory.Default)
+	case regexpType:
+		pattern := v.Interface().(Regexp).Pattern
+		return r.scalar(`/`+escapeRegexp(pattern)+`/`, func(l *parser.Locator, o, n int) parser.Expression {
+			return factory.Regexp(pattern, l, o, n)
+		})
+	case typeReferenceType:
+		name := v.Interface().(TypeReference).Name
+		return r.scalar(name, func(l *parser.Locator, o, n int) parser.Expression {
+			return factory.QualifiedReference(name, l, o, n)
+		})
+	case hashType:
+		h := v.Interface().(*Hash)
+		if h == nil {
+			return r.scalar(`undef`, factory.Undef)
+		}
+		entries := make([]entry, len(h.Entries()))
+		for i, e := range h.Entries() {
+			entries[i] = entry{reflect.ValueOf(e.Key), reflect.ValueOf(e.Value)}
+		}
+		return r.hash(entries, indent)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return r.scalar(`undef`, factory.Undef)
+		}
+		return r.render(v.Elem(), indent)
+	case reflect.Bool:
+		b := v.Bool()
+		return r.scalar(strconv.FormatBool(b), func(l *parser.Locator, o, n int) parser.Expression {
+			return factory.Boolean(b, l, o, n)
+		})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.integer(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if u > math.MaxInt64 {
+			panic(unsupportedValue(v))
+		}
+		return r.integer(int64(u))
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			panic(unsupportedValue(v))
+		}
+		return r.scalar(formatFloat(f), func(l *parser.Locator, o, n int) parser.Expression {
+			return factory.Float(f, l, o, n)
+		})
+	case reflect.String:
+		s := v.String()
+		return r.scalar(quoteString(s), func(l *parser.Locator, o, n int) parser.Expression {
+			return factory.String(s, l, o, n)
+		})
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return r.scalar(`undef`, factory.Undef)
+		}
+		elements := make([]reflect.Value, v.Len())
+		for i := range elements {
+			elements[i] = v.Index(i)
+		}
+		return r.array(elements, indent)
+	case reflect.Map:
+		if v.IsNil() {
+			return r.scalar(`undef`, factory.Undef)
+		}
+		entries := make([]entry, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			entries = append(entries, entry{k, v.MapIndex(k)})
+		}
+		// Sort on the rendered keys to get a deterministic result
+		keys := make(map[int]string, len(entries))
+		for i, e := range entries {
+			keys[i] = ToSource(e.key.Interface())
+		}
+		sort.Sort(&entrySorter{entries, keys})
+		return r.hash(entries, indent)
+	case reflect.Struct:
+		t := v.Type()
+		entries := make([]entry, 0, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != `` {
+				continue
+			}
+			name, ok := f.Tag.Lookup(`puppet`)
+			if !ok {
+				name = issue.CamelToSnakeCase(f.Name)
+			} else if name == `-` {
+				continue
+			}
+			entries = append(entries, entry{reflect.ValueOf(name), v.Field(i)})
+		}
+		return r.hash(entries, indent)
+	}
+	panic(unsupportedValue(v))
+}
+
+func (r *renderer) scalar(source string, create func(*parser.Locator, int, int) parser.Expression) builder {
+	offset := r.Len()
+	r.WriteString(source)
+	return func(locator *parser.Locator) parser.Expression {
+		return create(locator, offset, len(source))
+	}
+}
+
+func (r *renderer) integer(i int64) builder {
+	return r.scalar(strconv.FormatInt(i, 10), func(l *parser.Locator, o, n int) parser.Expression {
+		return factory.Integer(i, 10, l, o, n)
+	})
+}
+
+func (r *renderer) array(elements []reflect.Value, indent string) builder {
+	offset := r.Len()
+	multiline := !r.fitsOnLine(elements, nil)
+	builders := make([]builder, len(elements))
+	r.WriteByte('[')
+	for i, e := range elements {
+		elementIndent := r.separate(i, multiline, indent)
+		builders[i] = r.render(e, elementIndent)
+	}
+	r.close(']', len(elements), multiline, indent)
+	length := r.Len() - offset
+	return func(locator *parser.Locator) parser.Expression {
+		exprs := make([]parser.Expression, len(builders))
+		for i, b := range builders {
+			exprs[i] = b(locator)
+		}
+		return factory.Array(exprs, locator, offset, length)
+	}
+}
+
+type entry struct {
+	key   reflect.Value
+	value reflect.Value
+}
+
+func (r *renderer) hash(entries []entry, indent string) builder {
+	offset := r.Len()
+	keys := make([]reflect.Value, len(entries))
+	values := make([]reflect.Value, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+		values[i] = e.value
+	}
+	multiline := !r.fitsOnLine(keys, values)
+	keyBuilders := make([]builder, len(entries))
+	valueBuilders := make([]builder, len(entries))
+	entryOffsets := make([]int, len(entries))
+	entryLengths := make([]int, len(entries))
+	r.WriteByte('{')
+	for i := range entries {
+		entryIndent := r.separate(i, multiline, indent)
+		entryOffsets[i] = r.Len()
+		keyBuilders[i] = r.render(keys[i], entryIndent)
+		r.WriteString(` => `)
+		valueBuilders[i] = r.render(values[i], entryIndent)
+		entryLengths[i] = r.Len() - entryOffsets[i]
+	}
+	r.close('}', len(entries), multiline, indent)
+	length := r.Len() - offset
+	return func(locator *parser.Locator) parser.Expression {
+		exprs := make([]parser.Expression, len(entries))
+		for i := range entries {
+			exprs[i] = factory.KeyedEntry(keyBuilders[i](locator), valueBuilders[i](locator), locator, entryOffsets[i], entryLengths[i])
+		}
+		return factory.Hash(exprs, locator, offset, length)
+	}
+}
+
+// separate writes what precedes the element at the given index and returns the indentation of the
+// element
+func (r *renderer) separate(index int, multiline bool, indent string) string {
+	if multiline {
+		indent += `  `
+		if index > 0 {
+			r.WriteByte(',')
+		}
+		r.WriteByte('\n')
+		r.WriteString(indent)
+	} else if index > 0 {
+		r.WriteString(`, `)
+	}
+	return indent
+}
+
+// close writes the end of an array or a hash. Elements on separate lines are followed by a trailing comma.
+func (r *renderer) close(end byte, count int, multiline bool, indent string) {
+	if multiline && count > 0 {
+		r.WriteString(",\n")
+		r.WriteString(indent)
+	}
+	r.WriteByte(end)
+}
+
+// fitsOnLine returns true if the values are scalars, or empty collections, and the rendered values are
+// short enough to be written on one line. When values are given, the keys and values are rendered as
+// hash entries.
+func (r *renderer) fitsOnLine(keys []reflect.Value, values []reflect.Value) bool {
+	length := 0
+	for i, k := range keys {
+		if !isScalar(k) {
+			return false
+		}
+		length += len(ToSource(k.Interface())) + 2
+		if values != nil {
+			if !isScalar(values[i]) {
+				return false
+			}
+			length += len(ToSource(values[i].Interface())) + 4
+		}
+		if length > maxInlineLength {
+			return false
+		}
+	}
+	return true
+}
+
+// isScalar returns true if the value is rendered without line breaks
+func isScalar(v reflect.Value) bool {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Type() {
+	case defaultType, regexpType, typeReferenceType:
+		return true
+	case hashType:
+		return v.IsNil() || v.Interface().(*Hash).Len() == 0
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() == 0
+	case reflect.Struct:
+		return v.NumField() == 0
+	}
+	return true
+}
+
+type entrySorter struct {
+	entries []entry
+	keys    map[int]string
+}
+
+func (s *entrySorter) Len() int {
+	return len(s.entries)
+}
+
+func (s *entrySorter) Less(i, j int) bool {
+	return s.keys[i] < s.keys[j]
+}
+
+func (s *entrySorter) Swap(i, j int) {
+	s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// quoteString returns the string in single quotes. Backslashes and single quotes are escaped.
+func quoteString(s string) string {
+	return `'` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `'`, `\'`, -1) + `'`
+}
+
+// escapeRegexp escapes all slashes in the pattern that are not already escaped
+func escapeRegexp(pattern string) string {
+	b := bytes.NewBufferString(``)
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '/':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func unsupportedValue(v reflect.Value) issue.Reported {
+	return issue.NewReported(LITERAL_UNSUPPORTED_VALUE, issue.SEVERITY_ERROR, issue.H{`type`: v.Type().String(), `value`: fmt.Sprint(v.Interface())}, nil)
+}
@@ -0,0 +1,142 @@
+package literal
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type testPackage struct {
+	Name      string
+	Ensure    interface{}
+	Providers []string
+	Options   map[string]interface{}
+	Pinned    *bool
+	Secret    string `puppet:"-"`
+	Source    string `puppet:"source_url"`
+}
+
+func TestToSource(t *testing.T) {
+	ordered := NewHash(2)
+	ordered.Put(`z`, int64(1))
+	ordered.Put(`a`, []interface{}{})
+
+	for _, tc := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, `undef`},
+		{true, `true`},
+		{uint8(7), `7`},
+		{-12, `-12`},
+		{2.0, `2.0`},
+		{1e21, `1e+21`},
+		{`it's a \ test`, `'it\'s a \\ test'`},
+		{"two\nlines", "'two\nlines'"},
+		{parser.DEFAULT_INSTANCE, `default`},
+		{Regexp{`^a/b\/c$`}, `/^a\/b\/c$/`},
+		{TypeReference{`Stdlib::Port`}, `Stdlib::Port`},
+		{[]int{1, 2}, `[1, 2]`},
+		{[]string{}, `[]`},
+		{map[string]int{`b`: 2, `a`: 1}, `{'a' => 1, 'b' => 2}`},
+		{map[int]bool{10: true, 9: false}, `{10 => true, 9 => false}`},
+		{ordered, `{'z' => 1, 'a' => []}`},
+		{[][]int{{1}, {}}, "[\n  [1],\n  [],\n]"},
+		{
+			testPackage{Name: `nginx`, Ensure: parser.DEFAULT_INSTANCE, Providers: []string{`apt`}, Options: map[string]interface{}{`x`: []int{1}}, Source: `http://x`},
+			`{
+  'name' => 'nginx',
+  'ensure' => default,
+  'providers' => ['apt'],
+  'options' => {
+    'x' => [1],
+  },
+  'pinned' => undef,
+  'source_url' => 'http://x',
+}`,
+		},
+	} {
+		if actual := ToSource(tc.value); actual != tc.expected {
+			t.Errorf("expected\n%s\ngot\n%s", tc.expected, actual)
+		}
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	pinned := true
+	for _, value := range []interface{}{
+		`x`,
+		-1.5,
+		1e21,
+		int64(-3),
+		int64(math.MinInt64),
+		[]interface{}{nil, `a`, []int{1}, Regexp{`x`}, TypeReference{`Integer`}},
+		map[string][]string{`a`: {`b`, "c'\\"}},
+		testPackage{Name: `nginx`, Pinned: &pinned, Options: map[string]interface{}{`y`: map[string]int{}}},
+	} {
+		expr := FromValue(value)
+		source := ToSource(value)
+		if s := expr.String(); s != source {
+			t.Errorf("expected the expression to span\n%s\ngot\n%s", source, s)
+		}
+		expected := parseValue(t, source)
+		if actual, expected := expr.ToPN().String(), expected.ToPN().String(); actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	}
+}
+
+func TestFromValueRoundTrip(t *testing.T) {
+	pinned := false
+	original := testPackage{
+		Name:      `it's`,
+		Ensure:    `present`,
+		Providers: []string{`apt`, `yum`},
+		Options:   map[string]interface{}{`flags`: []interface{}{`-q`, int64(1)}},
+		Pinned:    &pinned,
+		Source:    `C:\temp`,
+	}
+	var decoded testPackage
+	if err := Decode(parseValue(t, ToSource(original)), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(original, decoded) {
+		t.Errorf(`expected %+v, got %+v`, original, decoded)
+	}
+}
+
+func TestIntegerRoundTrip(t *testing.T) {
+	for _, value := range []int64{0, -1, math.MaxInt64, math.MinInt64, math.MinInt64 + 1} {
+		source := ToSource(value)
+		if actual, err := Evaluate(parseValue(t, source)); err != nil {
+			t.Errorf(`unexpected error for %s: %s`, source, err)
+		} else if actual != value {
+			t.Errorf(`expected %s to evaluate to %d, got %v`, source, value, actual)
+		}
+	}
+}
+
+func TestFromValueUnsupported(t *testing.T) {
+	for value, expected := range map[interface{}]string{
+		math.NaN():                    `The Go value NaN of type float64 cannot be represented in Puppet`,
+		uint64(math.MaxUint64):        `The Go value 18446744073709551615 of type uint64 cannot be represented in Puppet`,
+		make(chan int):                `cannot be represented in Puppet`,
+		[1]interface{}{complex(1, 2)}: `The Go value (1+2i) of type complex128 cannot be represented in Puppet`,
+	} {
+		func() {
+			defer func() {
+				r := recover()
+				ri, ok := r.(issue.Reported)
+				if !ok {
+					t.Errorf(`expected an issue.Reported for %v, got %v`, value, r)
+				} else if msg := ri.Error(); len(msg) < len(expected) || msg[len(msg)-len(expected):] != expected {
+					t.Errorf(`expected '%s', got '%s'`, expected, msg)
+				}
+			}()
+			FromValue(value)
+		}()
+	}
+}
@@ -0,0 +1,68 @@
+package literal
+
+import "github.com/lyraproj/puppet-parser/parser"
+
+// Regexp is the typed literal value of a regular expression
+type Regexp struct {
+	Pattern string
+}
+
+func (r Regexp) String() string {
+	return `/` + r.Pattern + `/`
+}
+
+// TypeReference is the typed literal value of a reference to a type, e.g. String or Foo::Bar
+type TypeReference struct {
+	Name string
+}
+
+func (r TypeReference) String() string {
+	return r.Name
+}
+
+// ToTypedLiteral is like ToLiteral but retains more of the expression. A literal hash becomes an ordered
+// *Hash, the value of a regular expression is a Regexp, and a type reference becomes a TypeReference. The
+// value of default is parser.Default, just as with ToLiteral.
+func ToTypedLiteral(e parser.Expression) (value interface{}, ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			if err == notLiteral {
+				ok = false
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	value = toTypedLiteral(e)
+	ok = true
+	return
+}
+
+func toTypedLiteral(e parser.Expression) interface{} {
+	switch e.(type) {
+	case *parser.Program:
+		return toTypedLiteral(e.(*parser.Program).Body())
+	case *parser.LiteralList:
+		elements := e.(*parser.LiteralList).Elements()
+		result := make([]interface{}, len(elements))
+		for idx, elem := range elements {
+			result[idx] = toTypedLiteral(elem)
+		}
+		return result
+	case *parser.LiteralHash:
+		entries := e.(*parser.LiteralHash).Entries()
+		result := NewHash(len(entries))
+		for _, entry := range entries {
+			kh := entry.(*parser.KeyedEntry)
+			result.Put(toTypedLiteral(kh.Key()), toTypedLiteral(kh.Value()))
+		}
+		return result
+	case *parser.RegexpExpression:
+		return Regexp{e.(*parser.RegexpExpression).PatternString()}
+	case *parser.QualifiedReference:
+		return TypeReference{e.(*parser.QualifiedReference).Name()}
+	default:
+		return toLiteral(e)
+	}
+}
@@ -0,0 +1,146 @@
+package literal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestToTypedLiteral(t *testing.T) {
+	value, ok := ToTypedLiteral(parseValue(t, `{c => 1, a => [default, /x+/, String], b => {x => undef}, c => 2}`))
+	if !ok {
+		t.Fatal(`expected a literal`)
+	}
+	h, ok := value.(*Hash)
+	if !ok {
+		t.Fatalf(`expected a *Hash, got %T`, value)
+	}
+	if keys := h.Keys(); !reflect.DeepEqual(keys, []interface{}{`c`, `a`, `b`}) {
+		t.Errorf(`expected keys in source order, got %v`, keys)
+	}
+	if c, _ := h.Get(`c`); c != int64(2) {
+		t.Errorf(`expected a repeated key to keep the last value, got %v`, c)
+	}
+	a, _ := h.Get(`a`)
+	if expected := []interface{}{parser.DEFAULT_INSTANCE, Regexp{`x+`}, TypeReference{`String`}}; !reflect.DeepEqual(a, expected) {
+		t.Errorf(`expected %v, got %v`, expected, a)
+	}
+	b, _ := h.Get(`b`)
+	if bh, ok := b.(*Hash); !ok || bh.Len() != 1 {
+		t.Errorf(`expected a nested *Hash, got %v`, b)
+	}
+
+	if _, ok := ToTypedLiteral(parseValue(t, `[1, $x]`)); ok {
+		t.Error(`expected a variable to not be a literal`)
+	}
+}
+
+func TestHashArrayKeys(t *testing.T) {
+	h := NewHash(2)
+	h.Put([]interface{}{int64(1)}, `a`)
+	h.Put([]interface{}{int64(1)}, `b`)
+	if h.Len() != 1 {
+		t.Fatalf(`expected equal array keys to be merged, got %d entries`, h.Len())
+	}
+	if v, ok := h.Get([]interface{}{int64(1)}); !ok || v != `b` {
+		t.Errorf(`expected 'b', got %v`, v)
+	}
+}
+
+type testServer struct {
+	Host    string
+	Port    uint16
+	Weight  float64
+	Aliases []string
+}
+
+type testConfig struct {
+	Name       string `puppet:"title"`
+	MaxServers int    `puppet:"max_servers"`
+	Enabled    *bool
+	Servers    []testServer
+	Labels     map[string]string
+	Ensure     interface{}
+	Match      Regexp
+	Kind       TypeReference
+	Extra      *Hash
+	Ignored    string `puppet:"-"`
+	unexported string
+}
+
+func TestDecode(t *testing.T) {
+	var c testConfig
+	err := Decode(parseValue(t, `{
+  title => 'web',
+  max_servers => 3,
+  enabled => true,
+  servers => [{host => a, port => 80, weight => 1, aliases => [x, y]}],
+  labels => {role => frontend},
+  ensure => default,
+  match => /^w/,
+  kind => Integer,
+  extra => {b => 1, a => 2},
+}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabled := true
+	expected := testConfig{
+		Name:       `web`,
+		MaxServers: 3,
+		Enabled:    &enabled,
+		Servers:    []testServer{{Host: `a`, Port: 80, Weight: 1, Aliases: []string{`x`, `y`}}},
+		Labels:     map[string]string{`role`: `frontend`},
+		Ensure:     parser.DEFAULT_INSTANCE,
+		Match:      Regexp{`^w`},
+		Kind:       TypeReference{`Integer`},
+		Extra:      c.Extra,
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf(`expected %+v, got %+v`, expected, c)
+	}
+	if c.Extra == nil || !reflect.DeepEqual(c.Extra.Keys(), []interface{}{`b`, `a`}) {
+		t.Errorf(`expected an ordered hash, got %v`, c.Extra)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		source   string
+		expected string
+		line     int
+		pos      int
+	}{
+		{`{max_servers => 'x'}`, `The value of 'max_servers' expects an Integer, got a String`, 1, 17},
+		{`{servers => [{port => 70000}]}`, `The value of 'servers[0].port' has a value of 70000 which is out of range for a Go uint16`, 1, 23},
+		{`{servers => [{port => -1}]}`, `The value of 'servers[0].port' has a value of -1 which is out of range for a Go uint16`, 1, 23},
+		{`{servers => {}}`, `The value of 'servers' expects an Array, got a Hash`, 1, 13},
+		{`{ignored => 'x'}`, `The value has an unrecognized key 'ignored'`, 1, 2},
+		{`{labels => {role => $role}}`, `A Variable is not a literal`, 1, 21},
+		{`{match => 'x'}`, `The value of 'match' expects a Regexp, got a String`, 1, 11},
+		{`[1]`, `The value expects a Hash, got an Array`, 1, 1},
+	} {
+		var c testConfig
+		err := Decode(parseValue(t, tc.source), &c)
+		if err == nil {
+			t.Errorf(`expected an error for %s`, tc.source)
+			continue
+		}
+		if actual := err.Error(); actual != fmt.Sprintf(`%s (file: test.pp, line: %d, column: %d)`, tc.expected, tc.line, tc.pos+len(`$x = `)) {
+			t.Errorf(`expected '%s' for %s, got '%s'`, tc.expected, tc.source, actual)
+		}
+	}
+}
+
+// parseValue parses the source as the right hand side of an assignment so that a hash is not mistaken
+// for a block
+func parseValue(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	body := parse(t, `$x = `+source).(*parser.Program).Body()
+	if block, ok := body.(*parser.BlockExpression); ok {
+		body = block.Statements()[0]
+	}
+	return body.(*parser.AssignmentExpression).Rhs()
+}
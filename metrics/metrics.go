@@ -0,0 +1,334 @@
+// Package metrics computes size and complexity metrics for the definitions of a program.
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Names of the metrics in the order that they are reported
+const (
+	LINES      = `lines`
+	STATEMENTS = `statements`
+	RESOURCES  = `resources`
+	PARAMETERS = `parameters`
+	DEPTH      = `depth`
+	COMPLEXITY = `complexity`
+)
+
+var METRIC_NAMES = []string{LINES, STATEMENTS, RESOURCES, PARAMETERS, DEPTH, COMPLEXITY}
+
+// Thresholds that are reasonable for most code bases
+var DEFAULT_THRESHOLDS = Thresholds{
+	LINES:      300,
+	PARAMETERS: 20,
+	DEPTH:      4,
+	COMPLEXITY: 10,
+}
+
+type (
+	// DefinitionMetrics are the metrics of one definition. Code that is not contained in a definition is
+	// measured as a definition of kind 'main'.
+	DefinitionMetrics struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+		File string `json:"file"`
+		Line int    `json:"line"`
+
+		// Lines is the number of lines that contain code, i.e. lines that are neither blank nor comments
+		Lines int `json:"lines"`
+
+		// Statements is the number of statements in all blocks
+		Statements int `json:"statements"`
+
+		// Resources is the number of declared resources. Each body of a resource expression is counted
+		Resources int `json:"resources"`
+
+		Parameters int `json:"parameters"`
+
+		// Depth is the deepest nesting of conditionals, selectors, and lambdas
+		Depth int `json:"depth"`
+
+		// Complexity is the cyclomatic complexity. It starts at one and is incremented for each if, elsif,
+		// and unless, for each case and selector option that is not the default, and for each lambda
+		Complexity int `json:"complexity"`
+
+		// Expression is the measured definition, or the program for code of kind 'main'
+		Expression parser.Expression `json:"-"`
+	}
+
+	// Thresholds maps metric names to the highest acceptable value of that metric
+	Thresholds map[string]int
+)
+
+// Compute returns the metrics of all definitions in the given program. Definitions are measured
+// separately from the definitions and code that contain them.
+func Compute(program *parser.Program) []*DefinitionMetrics {
+	result := make([]*DefinitionMetrics, 0, len(program.Definitions())+1)
+
+	var statements []parser.Expression
+	if block, ok := program.Body().(*parser.BlockExpression); ok {
+		statements = block.Statements()
+	} else if program.Body() != nil {
+		statements = []parser.Expression{program.Body()}
+	}
+	main := &DefinitionMetrics{Kind: `main`, File: program.File(), Line: 1, Complexity: 1, Expression: program}
+
+	// The range of a statement may extend into the statement that follows it so each line is only
+	// counted once
+	lines := make(map[int]bool)
+	for _, s := range statements {
+		if _, ok := s.(parser.Definition); ok {
+			continue
+		}
+		if _, ok := s.(*parser.Nop); ok {
+			continue
+		}
+		main.Statements++
+		codeLines(s, lines)
+		main.measure(s, 0)
+	}
+	main.Lines = len(lines)
+	if main.Statements > 0 {
+		result = append(result, main)
+	}
+
+	for _, d := range program.Definitions() {
+		if m := definitionMetrics(d); m != nil {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Value returns the value of the metric with the given name
+func (m *DefinitionMetrics) Value(metric string) (value int, ok bool) {
+	ok = true
+	switch metric {
+	case LINES:
+		value = m.Lines
+	case STATEMENTS:
+		value = m.Statements
+	case RESOURCES:
+		value = m.Resources
+	case PARAMETERS:
+		value = m.Parameters
+	case DEPTH:
+		value = m.Depth
+	case COMPLEXITY:
+		value = m.Complexity
+	default:
+		ok = false
+	}
+	return
+}
+
+// Label returns a label for the measured definition such as "class 'foo'"
+func (m *DefinitionMetrics) Label() string {
+	if m.Kind == `main` {
+		return `top scope code`
+	}
+	return fmt.Sprintf(`%s '%s'`, m.Kind, m.Name)
+}
+
+// ParseThresholds parses a comma separated list of metric=value pairs. The string 'default' denotes the
+// DEFAULT_THRESHOLDS.
+func ParseThresholds(str string) (Thresholds, error) {
+	if str == `default` {
+		return DEFAULT_THRESHOLDS, nil
+	}
+	thresholds := make(Thresholds)
+	for _, pair := range strings.Split(str, `,`) {
+		pair = strings.TrimSpace(pair)
+		if pair == `` {
+			continue
+		}
+		eqIdx := strings.IndexByte(pair, '=')
+		if eqIdx < 0 {
+			return nil, fmt.Errorf(`invalid threshold '%s'. Expected <metric>=<value>`, pair)
+		}
+		name := strings.TrimSpace(pair[:eqIdx])
+		if _, ok := (&DefinitionMetrics{}).Value(name); !ok {
+			return nil, fmt.Errorf(`unknown metric '%s'. Expected one of %s`, name, strings.Join(METRIC_NAMES, `, `))
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(pair[eqIdx+1:]))
+		if err != nil {
+			return nil, fmt.Errorf(`invalid value for threshold '%s': %s`, name, err.Error())
+		}
+		thresholds[name] = value
+	}
+	return thresholds, nil
+}
+
+// Exceeded returns the names of the metrics of m that exceed their threshold, in the order of METRIC_NAMES
+func (t Thresholds) Exceeded(m *DefinitionMetrics) []string {
+	exceeded := make([]string, 0)
+	for _, name := range METRIC_NAMES {
+		if max, ok := t[name]; ok {
+			if value, _ := m.Value(name); value > max {
+				exceeded = append(exceeded, name)
+			}
+		}
+	}
+	return exceeded
+}
+
+func definitionMetrics(d parser.Definition) *DefinitionMetrics {
+	m := &DefinitionMetrics{File: d.File(), Line: d.Line(), Complexity: 1, Expression: d}
+	var body parser.Expression
+	switch d.(type) {
+	case *parser.NodeDefinition:
+		nd := d.(*parser.NodeDefinition)
+		names := make([]string, len(nd.HostMatches()))
+		for i, hm := range nd.HostMatches() {
+			names[i] = hm.String()
+		}
+		sort.Strings(names)
+		m.Kind = `node`
+		m.Name = strings.Join(names, `, `)
+		body = nd.Body()
+	case parser.NamedDefinition:
+		nd := d.(parser.NamedDefinition)
+		switch d.(type) {
+		case *parser.HostClassDefinition:
+			m.Kind = `class`
+		case *parser.ResourceTypeDefinition:
+			m.Kind = `define`
+		case *parser.PlanDefinition:
+			m.Kind = `plan`
+		case *parser.FunctionDefinition:
+			m.Kind = `function`
+		default:
+			return nil
+		}
+		m.Name = nd.Name()
+		m.Parameters = len(nd.Parameters())
+		body = nd.Body()
+	default:
+		return nil
+	}
+	lines := make(map[int]bool)
+	codeLines(d, lines)
+	m.Lines = len(lines)
+	m.Statements = countBody(body)
+	m.measure(body, 0)
+	return m
+}
+
+// measure walks the given expression and its contents, stopping at nested definitions, and updates the
+// metrics. The depth is the nesting depth of the expression.
+func (m *DefinitionMetrics) measure(e parser.Expression, depth int) {
+	if e == nil {
+		return
+	}
+	if _, ok := e.(parser.Definition); ok {
+		return
+	}
+
+	nested := depth
+	var elsif parser.Expression
+	switch e.(type) {
+	case *parser.BlockExpression:
+		m.Statements += len(e.(*parser.BlockExpression).Statements())
+	case *parser.ResourceBody:
+		m.Resources++
+	case *parser.IfExpression, *parser.UnlessExpression:
+		nested++
+		m.Complexity++
+		then, els := ifBranches(e)
+		m.Statements += countBody(then)
+		if _, ok := els.(*parser.IfExpression); ok {
+			elsif = els
+		} else {
+			m.Statements += countBody(els)
+		}
+	case *parser.CaseExpression:
+		nested++
+		for _, option := range e.(*parser.CaseExpression).Options() {
+			co := option.(*parser.CaseOption)
+			if !isDefaultOption(co.Values()...) {
+				m.Complexity++
+			}
+			m.Statements += countBody(co.Then())
+		}
+	case *parser.SelectorExpression:
+		nested++
+		for _, entry := range e.(*parser.SelectorExpression).Selectors() {
+			if !isDefaultOption(entry.(*parser.SelectorEntry).Matching()) {
+				m.Complexity++
+			}
+		}
+	case *parser.LambdaExpression:
+		nested++
+		m.Complexity++
+		m.Statements += countBody(e.(*parser.LambdaExpression).Body())
+	}
+	m.updateDepth(nested)
+
+	e.Contents(nil, func(path []parser.Expression, child parser.Expression) {
+		if child == elsif {
+			// An elsif is on the same level as the if that contains it
+			m.measure(child, depth)
+		} else {
+			m.measure(child, nested)
+		}
+	})
+}
+
+func (m *DefinitionMetrics) updateDepth(depth int) {
+	if depth > m.Depth {
+		m.Depth = depth
+	}
+}
+
+func ifBranches(e parser.Expression) (then, els parser.Expression) {
+	if ue, ok := e.(*parser.UnlessExpression); ok {
+		return ue.Then(), ue.Else()
+	}
+	ie := e.(*parser.IfExpression)
+	return ie.Then(), ie.Else()
+}
+
+// countBody returns the number of statements in a body that is not a block. The statements of a block
+// are counted when the block is measured.
+func countBody(body parser.Expression) int {
+	switch body.(type) {
+	case nil, *parser.BlockExpression, *parser.Nop:
+		return 0
+	default:
+		return 1
+	}
+}
+
+func isDefaultOption(values ...parser.Expression) bool {
+	for _, v := range values {
+		if _, ok := v.(*parser.LiteralDefault); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// codeLines adds the numbers of the lines that the expression spans to the given set, excluding blank
+// lines and lines that only contain a comment
+func codeLines(e parser.Expression, lines map[int]bool) {
+	locator := e.Locator()
+	source := locator.String()
+	start := e.ByteOffset()
+	end := start + e.ByteLength()
+	if end > len(source) {
+		end = len(source)
+	}
+	offset := start
+	for _, line := range strings.SplitAfter(source[start:end], "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != `` && !strings.HasPrefix(trimmed, `#`) {
+			lines[locator.LineForOffset(offset)] = true
+		}
+		offset += len(line)
+	}
+}
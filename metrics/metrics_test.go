@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+var source = issue.Unindent(`
+  # A class
+  class foo(String $a, Integer $b = 1) {
+    if $a == 'x' {
+      file { '/tmp/a': ensure => file }
+    } elsif $a == 'y' {
+      [1, 2].each |$i| {
+        notice($i ? { 1 => 'one', default => 'other' })
+      }
+    } else {
+      case $b {
+        1, 2: { notice('small') }
+        default: {}
+      }
+    }
+  }
+
+  define bar() {
+    user { ['a', 'b']: ensure => present }
+  }
+  notice('main')`)
+
+func TestCompute(t *testing.T) {
+	ms := compute(t, source)
+	if len(ms) != 3 {
+		t.Fatalf(`expected metrics for three definitions, got %d`, len(ms))
+	}
+	expectMetrics(t, ms[0], `main`, ``, 1, 1, 0, 0, 0, 1)
+	expectMetrics(t, ms[1], `class`, `foo`, 14, 6, 1, 2, 3, 6)
+	expectMetrics(t, ms[2], `define`, `bar`, 3, 1, 1, 0, 0, 1)
+}
+
+func TestBlankLines(t *testing.T) {
+	ms := compute(t, "$a = 1\n\n$b = 2\n\n\n$c = 3\n")
+	if len(ms) != 1 {
+		t.Fatalf(`expected metrics for main only, got %d`, len(ms))
+	}
+	if ms[0].Lines != 3 || ms[0].Statements != 3 {
+		t.Errorf(`expected three lines and statements, got %d lines and %d statements`, ms[0].Lines, ms[0].Statements)
+	}
+}
+
+func TestNestedDefinition(t *testing.T) {
+	ms := compute(t, "class foo {\n  if $x {\n    class bar {\n      if $y { }\n    }\n  }\n}")
+	if len(ms) != 2 {
+		t.Fatalf(`expected metrics for two definitions, got %d`, len(ms))
+	}
+	for _, m := range ms {
+		if m.Complexity != 2 || m.Depth != 1 {
+			t.Errorf(`unexpected metrics for %s: complexity %d, depth %d`, m.Label(), m.Complexity, m.Depth)
+		}
+	}
+}
+
+func TestParseThresholds(t *testing.T) {
+	th, err := ParseThresholds(`complexity=5, depth=2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(th) != 2 || th[COMPLEXITY] != 5 || th[DEPTH] != 2 {
+		t.Errorf(`unexpected thresholds %v`, th)
+	}
+	if _, err = ParseThresholds(`size=5`); err == nil {
+		t.Error(`expected error for unknown metric`)
+	}
+	if _, err = ParseThresholds(`depth`); err == nil {
+		t.Error(`expected error for missing value`)
+	}
+
+	exceeded := th.Exceeded(compute(t, source)[1])
+	if len(exceeded) != 2 || exceeded[0] != DEPTH || exceeded[1] != COMPLEXITY {
+		t.Errorf(`unexpected exceeded metrics %v`, exceeded)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	if err := Write(b, CSV, compute(t, source)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 || lines[0] != `kind,name,file,line,lines,statements,resources,parameters,depth,complexity` ||
+		lines[2] != `class,foo,x.pp,2,14,6,1,2,3,6` {
+		t.Errorf(`unexpected CSV output %s`, b.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	if err := Write(b, JSON, compute(t, `define bar() {}`)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"kind":"define","name":"bar","file":"x.pp","line":1,"lines":1,"statements":0,"resources":0,"parameters":0,"depth":0,"complexity":1}]`
+	if strings.TrimSpace(b.String()) != expected {
+		t.Errorf(`unexpected JSON output %s`, b.String())
+	}
+	if err := Write(b, Format(`xml`), nil); err == nil {
+		t.Error(`expected error for unknown format`)
+	}
+}
+
+func compute(t *testing.T, source string) []*DefinitionMetrics {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`x.pp`, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Compute(expr.(*parser.Program))
+}
+
+func expectMetrics(t *testing.T, m *DefinitionMetrics, kind, name string, lines, statements, resources, parameters, depth, complexity int) {
+	t.Helper()
+	actual := []int{m.Lines, m.Statements, m.Resources, m.Parameters, m.Depth, m.Complexity}
+	expected := []int{lines, statements, resources, parameters, depth, complexity}
+	if m.Kind != kind || m.Name != name {
+		t.Errorf(`expected %s '%s', got %s`, kind, name, m.Label())
+	}
+	for i, v := range expected {
+		if actual[i] != v {
+			t.Errorf(`expected %s of %s to be %d, got %d`, METRIC_NAMES[i], m.Label(), v, actual[i])
+		}
+	}
+}
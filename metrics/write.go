@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/lyraproj/puppet-parser/json"
+)
+
+// Format is the name of an output format for metrics
+type Format string
+
+const (
+	JSON = Format(`json`)
+	CSV  = Format(`csv`)
+)
+
+// Write writes the metrics in the given format
+func Write(w io.Writer, format Format, metrics []*DefinitionMetrics) error {
+	switch format {
+	case JSON:
+		return WriteJSON(w, metrics)
+	case CSV:
+		return WriteCSV(w, metrics)
+	default:
+		return fmt.Errorf(`unknown metrics format '%s'. Expected '%s' or '%s'`, format, JSON, CSV)
+	}
+}
+
+// WriteJSON writes the metrics as a JSON array with one object per definition
+func WriteJSON(w io.Writer, metrics []*DefinitionMetrics) error {
+	if metrics == nil {
+		metrics = []*DefinitionMetrics{}
+	}
+	json.ToJson(metrics, w)
+	return nil
+}
+
+// WriteCSV writes the metrics as comma separated values with one header row and one row per definition
+func WriteCSV(w io.Writer, metrics []*DefinitionMetrics) error {
+	cw := csv.NewWriter(w)
+	header := append([]string{`kind`, `name`, `file`, `line`}, METRIC_NAMES...)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, m := range metrics {
+		row := []string{m.Kind, m.Name, m.File, strconv.Itoa(m.Line)}
+		for _, name := range METRIC_NAMES {
+			value, _ := m.Value(name)
+			row = append(row, strconv.Itoa(value))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -8,12 +8,19 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/epp"
+	"github.com/lyraproj/puppet-parser/hiera"
 	"github.com/lyraproj/puppet-parser/json"
+	"github.com/lyraproj/puppet-parser/metrics"
 	"github.com/lyraproj/puppet-parser/parser"
 	"github.com/lyraproj/puppet-parser/pn"
+	"github.com/lyraproj/puppet-parser/reference"
+	"github.com/lyraproj/puppet-parser/report"
+	"github.com/lyraproj/puppet-parser/schema"
 	"github.com/lyraproj/puppet-parser/validator"
 )
 
@@ -23,6 +30,23 @@ var jsonOuput = flag.Bool("j", false, "json output")
 var strict = flag.String("s", `off`, "strict (off, warning, or error)")
 var tasks = flag.Bool("t", false, "tasks")
 var workflow = flag.Bool("w", false, "workflow")
+var color = flag.Bool("c", false, "colored issue output")
+var format = flag.String("f", ``, "issue report format (sarif, checkstyle, or junit)")
+var lint = flag.Bool("l", false, "check style, documentation, and regular expressions")
+var security = flag.Bool("security", false, "check for risky patterns")
+var metricsFormat = flag.String("metrics", ``, "write metrics of all definitions (json or csv)")
+var thresholds = flag.String("thresholds", ``, "warn when metrics exceed thresholds, e.g. complexity=10,depth=4 (or default)")
+var fix = flag.Bool("fix", false, "apply automatic fixes to the file (implies -l)")
+var docFormat = flag.String("doc", ``, "write a reference of all definitions in the file or module directory (markdown or json)")
+var classSchema = flag.Bool("schema", false, "write JSON schemas of the parameters of all classes in the file or module directory")
+var eppCalls = flag.Bool("epp", false, "check the epp() and inline_epp() calls in the file or module directory against the templates in the 'templates' directories")
+var hieraData = flag.String("hiera", ``, "check the class parameter keys and interpolations in the given Hiera data file, or the data files of the given hiera.yaml, against the classes in the file or module directory")
+
+// Thresholds given with the -thresholds flag
+var metricThresholds metrics.Thresholds
+
+// Maximum number of times that fixes are applied to the file. Each pass re-parses the result
+const maxFixPasses = 10
 
 func main() {
 	flag.Parse()
@@ -35,6 +59,23 @@ func main() {
 	}
 
 	fileName := args[0]
+	if *docFormat != `` {
+		writeReference(fileName)
+		return
+	}
+	if *classSchema {
+		emitJson(schema.ClassSchemas(parseModule(fileName)...))
+		return
+	}
+	if *hieraData != `` {
+		checkHieraData(fileName)
+		return
+	}
+	if *eppCalls {
+		checkTemplates(fileName)
+		return
+	}
+
 	content, err := ioutil.ReadFile(fileName)
 	if err != nil {
 		panic(err)
@@ -47,6 +88,14 @@ func main() {
 
 	strictness := validator.Strict(*strict)
 
+	if *thresholds != `` {
+		var err error
+		if metricThresholds, err = metrics.ParseThresholds(*thresholds); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+
 	parseOpts := []parser.Option{}
 	if strings.HasSuffix(fileName, `.epp`) {
 		parseOpts = append(parseOpts, parser.PARSER_EPP_MODE)
@@ -58,11 +107,41 @@ func main() {
 		parseOpts = append(parseOpts, parser.PARSER_WORKFLOW_ENABLED)
 	}
 
+	if *fix {
+		var count int
+		if content, count = fixSource(fileName, content, parseOpts, strictness); count > 0 {
+			info, err := os.Stat(fileName)
+			if err != nil {
+				panic(err)
+			}
+			if err = ioutil.WriteFile(fileName, content, info.Mode()); err != nil {
+				panic(err)
+			}
+			fmt.Fprintf(os.Stderr, "Applied %d fixes to %s\n", count, fileName)
+		}
+	}
+
 	expr, err := parser.CreateParser(parseOpts...).Parse(args[0], string(content), false)
+	if *format != `` {
+		emitReport(fileName, expr, err, strictness)
+		return
+	}
+
+	if *metricsFormat != `` && err == nil {
+		if err = metrics.Write(os.Stdout, metrics.Format(*metricsFormat), metrics.Compute(expr.(*parser.Program))); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	if *jsonOuput {
 		if err != nil {
 			if issue, ok := err.(issue.Reported); ok {
 				result[`issues`] = []interface{}{pn.ReportedToPN(issue).ToData()}
+				if suggestions := parser.SyntaxSuggestions(issue); len(suggestions) > 0 {
+					result[`suggestions`] = suggestionsToData(suggestions)
+				}
 			} else {
 				result[`error`] = err.Error()
 			}
@@ -71,11 +150,11 @@ func main() {
 			os.Exit(1)
 		}
 
-		v := validator.ValidatePuppet(expr, strictness)
-		if len(v.Issues()) > 0 {
+		reported := validate(expr, strictness)
+		if len(reported) > 0 {
 			severity := issue.Severity(issue.SEVERITY_IGNORE)
-			issues := make([]interface{}, len(v.Issues()))
-			for idx, issue := range v.Issues() {
+			issues := make([]interface{}, len(reported))
+			for idx, issue := range reported {
 				if issue.Severity() > severity {
 					severity = issue.Severity()
 				}
@@ -96,16 +175,20 @@ func main() {
 	}
 
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
+		if issue, ok := err.(issue.Reported); ok {
+			parser.WriteDiagnostic(os.Stderr, issue, *color)
+		} else {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
 		// Parse error is always SEVERITY_ERROR
 		os.Exit(1)
 	}
 
-	v := validator.ValidatePuppet(expr, strictness)
-	if len(v.Issues()) > 0 {
+	reported := validate(expr, strictness)
+	if len(reported) > 0 {
 		severity := issue.Severity(issue.SEVERITY_IGNORE)
-		for _, issue := range v.Issues() {
-			fmt.Fprintln(os.Stderr, issue.String())
+		for _, issue := range reported {
+			parser.WriteDiagnostic(os.Stderr, issue, *color)
 			if issue.Severity() > severity {
 				severity = issue.Severity()
 			}
@@ -122,6 +205,270 @@ func main() {
 	}
 }
 
+// writeReference writes the reference documentation of the given file, or of all .pp files found in the given
+// module directory
+func writeReference(path string) {
+	definitions := make([]*reference.Definition, 0)
+	for _, program := range parseModule(path) {
+		definitions = append(definitions, reference.Collect(program)...)
+	}
+	reference.Sort(definitions)
+	if err := reference.Write(os.Stdout, reference.Format(*docFormat), definitions); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+// checkHieraData checks the Hiera data file given with the -hiera flag against the classes of the given
+// file or module directory. When the flag names a hiera.yaml, the configuration is checked and so are
+// all the data files of its hierarchies.
+func checkHieraData(path string) {
+	content, err := ioutil.ReadFile(*hieraData)
+	if err != nil {
+		panic(err)
+	}
+	checker := hiera.NewChecker(parseModule(path)...)
+	var issues []issue.Reported
+	if filepath.Base(*hieraData) == `hiera.yaml` {
+		var files []string
+		issues, files = hiera.CheckConfig(*hieraData, string(content))
+		for _, file := range files {
+			if content, err = ioutil.ReadFile(file); err == nil {
+				issues = append(issues, withoutYamlSyntaxErrors(checker.CheckData(file, string(content)))...)
+			}
+		}
+	} else {
+		issues = append(checker.CheckData(*hieraData, string(content)), withoutYamlSyntaxErrors(hiera.CheckInterpolations(*hieraData, string(content)))...)
+	}
+	writeIssues(issues)
+}
+
+// checkTemplates checks the calls to epp() and inline_epp() in the given file or module directory against
+// the signatures of the templates found in its 'templates' directories. The variables that the templates
+// reference are checked too.
+func checkTemplates(path string) {
+	programs := parseModule(path)
+	checker := epp.NewChecker(programs...)
+	for _, file := range moduleFiles(path, `.epp`) {
+		if name, ok := epp.TemplateName(file); ok && strings.HasSuffix(file, `.epp`) {
+			checker.AddTemplate(name, parseFile(file, parser.PARSER_EPP_MODE))
+		}
+	}
+	issues := checker.CheckTemplates()
+	for _, program := range programs {
+		issues = append(issues, checker.CheckCalls(program)...)
+	}
+	writeIssues(issues)
+}
+
+// writeIssues writes the issues to stderr and exits if one of them is an error
+func writeIssues(issues []issue.Reported) {
+	severity := issue.Severity(issue.SEVERITY_IGNORE)
+	for _, i := range issues {
+		parser.WriteDiagnostic(os.Stderr, i, *color)
+		if i.Severity() > severity {
+			severity = i.Severity()
+		}
+	}
+	if severity == issue.SEVERITY_ERROR {
+		os.Exit(1)
+	}
+}
+
+// withoutYamlSyntaxErrors removes the YAML syntax errors that the other check of the same file already reported
+func withoutYamlSyntaxErrors(issues []issue.Reported) []issue.Reported {
+	result := issues[:0]
+	for _, i := range issues {
+		if i.Code() != hiera.HIERA_YAML_SYNTAX_ERROR {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// parseModule parses the given file, or all .pp files found in the given module directory. Files in a
+// 'plans' directory are parsed with tasks enabled. The program exits if a file cannot be parsed.
+func parseModule(path string) []*parser.Program {
+	files := moduleFiles(path, `.pp`)
+	programs := make([]*parser.Program, 0, len(files))
+	for _, file := range files {
+		var parseOpts []parser.Option
+		if *tasks || strings.Contains(filepath.ToSlash(file), `/plans/`) {
+			parseOpts = append(parseOpts, parser.PARSER_TASKS_ENABLED)
+		}
+		programs = append(programs, parseFile(file, parseOpts...))
+	}
+	return programs
+}
+
+// moduleFiles returns the given file, or all files with the given suffix found in the given module directory
+func moduleFiles(path string, suffix string) []string {
+	files := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		files = files[:0]
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && strings.HasSuffix(file, suffix) {
+				files = append(files, file)
+			}
+			return err
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+	return files
+}
+
+// parseFile parses the given file. The program exits if the file cannot be parsed.
+func parseFile(file string, parseOpts ...parser.Option) *parser.Program {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		panic(err)
+	}
+	expr, err := parser.CreateParser(parseOpts...).Parse(file, string(content), false)
+	if err != nil {
+		if issue, ok := err.(issue.Reported); ok {
+			parser.WriteDiagnostic(os.Stderr, issue, *color)
+		} else {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		os.Exit(1)
+	}
+	return expr.(*parser.Program)
+}
+
+// validate validates the expression and, when requested, checks its style, documentation, regular expressions,
+// security, and metrics
+func validate(expr parser.Expression, strictness validator.Strictness) []issue.Reported {
+	checker := validator.NewChecker(strictness)
+	if *lint || *fix {
+		// Functions from other modules are unknown, so unknown functions are only reported when linting
+		checker.Demote(validator.VALIDATE_UNKNOWN_FUNCTION, issue.SEVERITY_WARNING)
+	}
+	validator.Validate(checker, expr)
+	issues := checker.Issues()
+	if *lint || *fix {
+		issues = append(issues, validator.ValidateStyle(expr).Issues()...)
+		issues = append(issues, validator.ValidateDocs(expr).Issues()...)
+		issues = append(issues, validator.ValidateRegexps(expr).Issues()...)
+	}
+	if *security {
+		issues = append(issues, validator.ValidateSecurity(expr).Issues()...)
+	}
+	if metricThresholds != nil {
+		issues = append(issues, validator.ValidateMetrics(expr, metricThresholds).Issues()...)
+	}
+	return issues
+}
+
+// fixSource applies the automatic fixes of all issues found in the content and returns the result together
+// with the number of fixes that were applied. The result of each pass is parsed again and the pass is
+// discarded if that reveals a new syntax error.
+func fixSource(fileName string, content []byte, parseOpts []parser.Option, strictness validator.Strictness) ([]byte, int) {
+	source := string(content)
+	count := 0
+	for pass := 0; pass < maxFixPasses; pass++ {
+		var edits []*parser.Edit
+		expr, err := parser.CreateParser(parseOpts...).Parse(fileName, source, false)
+		if err != nil {
+			reported, ok := err.(issue.Reported)
+			if !ok {
+				break
+			}
+			edits = parser.IssueEdits(reported)
+		} else {
+			for _, reported := range validate(expr, strictness) {
+				edits = append(edits, parser.IssueEdits(reported)...)
+			}
+			if program, ok := expr.(*parser.Program); ok {
+				edits = codeEdits(edits, validator.CodeRanges(program))
+			}
+		}
+
+		result, applied := parser.ApplyEdits(source, edits)
+		if len(applied) == 0 {
+			break
+		}
+		if _, rerr := parser.CreateParser(parseOpts...).Parse(fileName, result, false); rerr != nil && !isProgress(err, rerr) {
+			fmt.Fprintf(os.Stderr, "Fixes not applied to %s: %s\n", fileName, rerr.Error())
+			break
+		}
+		source = result
+		count += len(applied)
+	}
+	return []byte(source), count
+}
+
+// codeEdits returns the edits that fall within the given code ranges. The output text of a template
+// must never change.
+func codeEdits(edits []*parser.Edit, code [][2]int) []*parser.Edit {
+	var result []*parser.Edit
+	for _, edit := range edits {
+		for _, c := range code {
+			if edit.Offset >= c[0] && edit.Offset+edit.Length <= c[1] {
+				result = append(result, edit)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// isProgress returns true when the syntax error after is found later in the source than the syntax error
+// before. It returns false if there was no syntax error before.
+func isProgress(before, after error) bool {
+	b, ok := before.(issue.Reported)
+	if !ok {
+		return false
+	}
+	a, ok := after.(issue.Reported)
+	if !ok {
+		return false
+	}
+	bl, al := b.Location(), a.Location()
+	return al.Line() > bl.Line() || al.Line() == bl.Line() && al.Pos() > bl.Pos()
+}
+
+func emitReport(fileName string, expr parser.Expression, err error, strictness validator.Strictness) {
+	var issues []issue.Reported
+	if err != nil {
+		reported, ok := err.(issue.Reported)
+		if !ok {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		issues = []issue.Reported{reported}
+	} else {
+		issues = validate(expr, strictness)
+	}
+
+	if err = report.Write(os.Stdout, report.Format(*format), []string{fileName}, issues); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	for _, i := range issues {
+		if i.Severity() == issue.SEVERITY_ERROR {
+			os.Exit(1)
+		}
+	}
+}
+
+func suggestionsToData(suggestions []*parser.SyntaxSuggestion) []interface{} {
+	data := make([]interface{}, len(suggestions))
+	for i, s := range suggestions {
+		sd := map[string]interface{}{`message`: s.Message}
+		if s.Edit != nil {
+			sd[`edit`] = map[string]interface{}{`offset`: s.Edit.Offset, `length`: s.Edit.Length, `text`: s.Edit.Text}
+		}
+		if s.Related != nil {
+			sd[`related`] = map[string]interface{}{`line`: s.Related.Line(), `column`: s.Related.Pos()}
+		}
+		data[i] = sd
+	}
+	return data
+}
+
 func emitJson(value interface{}) {
 	b := bytes.NewBufferString(``)
 	json.ToJson(value, b)
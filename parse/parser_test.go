@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+// TestMain runs the program instead of the tests when the test binary is started by runParse
+func TestMain(m *testing.M) {
+	if os.Getenv(`PARSE_TEST_MAIN`) == `1` {
+		os.Args = append([]string{`parse`}, strings.Split(os.Getenv(`PARSE_TEST_ARGS`), "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestUnknownFunctionWhenLinting(t *testing.T) {
+	dir := writeFiles(t, map[string]string{`init.pp`: issue.Unindent(`
+    # @summary Test
+    function foo() {
+    }
+    foo()
+    notice(no_such_function())
+    `)})
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, `init.pp`)
+
+	out, ok := runParse(t, `-v`, file)
+	if !ok || out != `` {
+		t.Errorf("expected no issues without -l, got:\n%s", out)
+	}
+
+	out, ok = runParse(t, `-v`, `-l`, file)
+	if !ok || !strings.Contains(out, `warning[VALIDATE_UNKNOWN_FUNCTION]: Unknown function: 'no_such_function'`) || strings.Contains(out, `'foo'`) {
+		t.Errorf("expected an unknown function warning with -l, got:\n%s", out)
+	}
+}
+
+func TestEppCalls(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		`mymod/manifests/init.pp`: issue.Unindent(`
+      class mymod {
+        file { '/etc/mymod.conf':
+          content => epp('mymod/conf.epp', {prot => 80}),
+        }
+      }
+      `),
+		`mymod/templates/conf.epp`: issue.Unindent(`
+      <%- | String $host, Integer $port = 80 | -%>
+      <%= $host %>:<%= $port %> <%= $timeout %>
+      `),
+	})
+	defer os.RemoveAll(dir)
+
+	out, ok := runParse(t, `-epp`, filepath.Join(dir, `mymod`))
+	if ok {
+		t.Error(`expected the check to fail`)
+	}
+	for _, expected := range []string{
+		`warning[EPP_UNDECLARED_VARIABLE]: The template 'mymod/conf.epp' references the variable '$timeout' which is not declared as a parameter`,
+		`error[EPP_UNKNOWN_PARAMETER]: The template 'mymod/conf.epp' has no parameter named 'prot'. Did you mean 'port'?`,
+		`error[EPP_MISSING_PARAMETER]: The template 'mymod/conf.epp' expects a value for parameter '$host'`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %s, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestFixTemplate(t *testing.T) {
+	template := "<%- | String $x | -%>\nHello \"world\"\t<%= \"x\" %>  \n"
+	dir := writeFiles(t, map[string]string{`t.epp`: template})
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, `t.epp`)
+	if out, ok := runParse(t, `-fix`, `-v`, file); !ok || strings.Contains(out, `STYLE_`) {
+		t.Errorf("expected only the code to be fixed, got:\n%s", out)
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "<%- | String $x | -%>\nHello \"world\"\t<%= 'x' %>  \n"
+	if string(content) != expected {
+		t.Errorf(`expected %q, got %q`, expected, string(content))
+	}
+}
+
+func TestHieraConfig(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		`mymod/manifests/init.pp`: issue.Unindent(`
+      class mymod(String $motd, Hash $settings) {
+      }
+      `),
+		`hiera.yaml`: issue.Unindent(`
+      ---
+      version: 5 # The only version that can be checked
+      hierarchy:
+        - name: Per OS
+          path: "os/%{facts.os.famly}.yaml"
+        - name: Common
+          path: common.yaml
+      `),
+		`data/common.yaml`: issue.Unindent(`
+      ---
+      defaults: &defaults
+        port: 80
+      mymod::motd: Welcome to
+        %{facts.networking.fqdn}
+      mymod::settings:
+        <<: *defaults
+        host: example.com
+      `),
+	})
+	defer os.RemoveAll(dir)
+
+	out, ok := runParse(t, `-hiera`, filepath.Join(dir, `hiera.yaml`), filepath.Join(dir, `mymod`))
+	if !ok || strings.Count(out, `warning[`) != 1 || !strings.Contains(out,
+		`warning[HIERA_UNKNOWN_FACT_KEY]: Unknown key 'famly' in 'facts.os'. Did you mean 'family'?`) {
+		t.Errorf("expected one unknown fact key warning, got:\n%s", out)
+	}
+}
+
+// runParse runs the program with the given arguments and returns what it wrote on stderr, and whether
+// it exited successfully
+func runParse(t *testing.T, args ...string) (string, bool) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), `PARSE_TEST_MAIN=1`, `PARSE_TEST_ARGS=`+strings.Join(args, "\n"))
+	stderr := bytes.NewBufferString(``)
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		t.Fatal(err)
+	}
+	return stderr.String(), err == nil
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir(``, `parse`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
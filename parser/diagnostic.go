@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+// SourceLocation is implemented by locations that can be mapped back to the source text. All
+// expressions and the locations of issues reported by the lexer implement this interface.
+type SourceLocation interface {
+	issue.Location
+	Locator() *Locator
+	ByteOffset() int
+	ByteLength() int
+}
+
+type span struct {
+	locator *Locator
+	offset  int
+	length  int
+}
+
+// NewLocation returns a location that spans length bytes starting at the given offset in the source of
+// the locator
+func NewLocation(locator *Locator, offset, length int) SourceLocation {
+	return &span{locator, offset, length}
+}
+
+func (s *span) File() string {
+	return s.locator.File()
+}
+
+func (s *span) Line() int {
+	return s.locator.LineForOffset(s.offset)
+}
+
+func (s *span) Pos() int {
+	return s.locator.PosOnLine(s.offset)
+}
+
+func (s *span) Locator() *Locator {
+	return s.locator
+}
+
+func (s *span) ByteOffset() int {
+	return s.offset
+}
+
+func (s *span) ByteLength() int {
+	return s.length
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+)
+
+// WriteDiagnostic writes a human friendly rendering of the given issue to the writer. The rendering
+// contains the severity, the message, the issue code, the location, and, when the location of the issue
+// can be mapped back to the source, the offending source line with the affected range underlined. ANSI
+// color escapes are used when color is true.
+func WriteDiagnostic(w io.Writer, reported issue.Reported, color bool) {
+	b := bytes.NewBufferString(``)
+	colorize := func(codes string, text string) {
+		if color {
+			b.WriteString(codes)
+			b.WriteString(text)
+			b.WriteString(ansiReset)
+		} else {
+			b.WriteString(text)
+		}
+	}
+
+	severityColor := ansiRed
+	if reported.Severity() != issue.SEVERITY_ERROR {
+		severityColor = ansiYellow
+	}
+	colorize(ansiBold+severityColor, fmt.Sprintf(`%s[%s]`, reported.Severity(), reported.Code()))
+	colorize(ansiBold, `: `+IssueMessage(reported))
+	b.WriteByte('\n')
+
+	loc := reported.Location()
+	if loc == nil {
+		w.Write(b.Bytes())
+		return
+	}
+
+	sl, ok := loc.(SourceLocation)
+	if !ok || sl.Locator() == nil {
+		if loc.Line() > 0 {
+			colorize(ansiBold+ansiBlue, ` --> `)
+			fmt.Fprintf(b, "%s:%d:%d\n", loc.File(), loc.Line(), loc.Pos())
+		}
+		w.Write(b.Bytes())
+		return
+	}
+
+	locator := sl.Locator()
+	line := loc.Line()
+	source, lineStart := locator.sourceLine(line)
+	lineNo := fmt.Sprintf(`%d`, line)
+	gutter := strings.Repeat(` `, len(lineNo))
+
+	colorize(ansiBold+ansiBlue, gutter+`--> `)
+	fmt.Fprintf(b, "%s:%d:%d\n", loc.File(), line, loc.Pos())
+	colorize(ansiBold+ansiBlue, gutter+" |\n")
+	colorize(ansiBold+ansiBlue, lineNo+` | `)
+	b.WriteString(source)
+	b.WriteByte('\n')
+	colorize(ansiBold+ansiBlue, gutter+` | `)
+
+	// Indent using the whitespace of the source line so that tabs in the source are retained
+	start := sl.ByteOffset() - lineStart
+	if start > len(source) {
+		start = len(source)
+	}
+	for _, c := range source[:start] {
+		if c == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+
+	// The underline spans the expression but never beyond the end of its first line
+	end := start + sl.ByteLength()
+	if end > len(source) {
+		end = len(source)
+	}
+	width := utf8.RuneCountInString(source[start:end])
+	if width < 1 {
+		width = 1
+	}
+	colorize(ansiBold+severityColor, `^`+strings.Repeat(`~`, width-1))
+	b.WriteByte('\n')
+	for _, s := range SyntaxSuggestions(reported) {
+		colorize(ansiBold+ansiBlue, gutter+` = `)
+		colorize(ansiBold, `help`)
+		b.WriteString(`: `)
+		b.WriteString(s.Message)
+		b.WriteByte('\n')
+	}
+	w.Write(b.Bytes())
+}
+
+// IssueMessage returns the message of the issue without the trailing location
+func IssueMessage(reported issue.Reported) string {
+	msg := reported.Error()
+	if loc := reported.Location(); loc != nil {
+		if ls := issue.LocationString(loc); ls != `` {
+			msg = strings.TrimSuffix(msg, ` `+ls)
+		}
+	}
+	return msg
+}
+
+// sourceLine returns the text of the given line, without the line terminator, and the byte offset of
+// the start of that line
+func (e *Locator) sourceLine(line int) (string, int) {
+	li := e.getLineIndex()
+	if line < 1 || line > len(li) {
+		return ``, len(e.string)
+	}
+	start := li[line-1]
+	end := len(e.string)
+	if line < len(li) {
+		end = li[line] - 1
+	}
+	if end < start {
+		end = start
+	}
+	return strings.TrimSuffix(e.string[start:end], "\r"), start
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestDiagnosticForExpression(t *testing.T) {
+	expr, err := CreateParser().Parse(`test.pp`, "$a = 1\n$b = foo(1, 2)\n", false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	call := expr.(*Program).Body().(*BlockExpression).Statements()[1].(*AssignmentExpression).Rhs()
+	reported := issue.NewReported(PARSE_ILLEGAL_EPP_PARAMETERS, issue.SEVERITY_WARNING, issue.NO_ARGS, call)
+	b := bytes.NewBufferString(``)
+	WriteDiagnostic(b, reported, false)
+	expected := issue.Unindent(`
+    warning[PARSE_ILLEGAL_EPP_PARAMETERS]: Ambiguous EPP parameter expression. Probably missing '<%-' before parameters to remove leading whitespace
+     --> test.pp:2:6
+      |
+    2 | $b = foo(1, 2)
+      |      ^~~~~~~~~
+    `)
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestDiagnosticForLexerIssue(t *testing.T) {
+	_, err := CreateParser().Parse(`test.pp`, "$a = 1\n\t$b = 'unterminated\n", false)
+	if err == nil {
+		t.Fatal(`expected parse error`)
+	}
+	b := bytes.NewBufferString(``)
+	WriteDiagnostic(b, err.(issue.Reported), false)
+	if !bytes.Contains(b.Bytes(), []byte("\n2 | \t$b = 'unterminated\n  | \t     ^\n")) {
+		t.Errorf("unexpected diagnostic:\n%s", b.String())
+	}
+}
+
+func TestDiagnosticColor(t *testing.T) {
+	reported := issue.NewReported(PARSE_ILLEGAL_EPP_PARAMETERS, issue.SEVERITY_ERROR, issue.NO_ARGS, nil)
+	b := bytes.NewBufferString(``)
+	WriteDiagnostic(b, reported, true)
+	if !bytes.HasPrefix(b.Bytes(), []byte(ansiBold+ansiRed+`error[PARSE_ILLEGAL_EPP_PARAMETERS]`+ansiReset)) {
+		t.Errorf("unexpected diagnostic: %q", b.String())
+	}
+}
+
+func TestDiagnosticWithSuggestions(t *testing.T) {
+	_, err := CreateParser().Parse(`test.pp`, `clas foo { }`, false)
+	if err == nil {
+		t.Fatal(`expected parse error`)
+	}
+	b := bytes.NewBufferString(``)
+	WriteDiagnostic(b, err.(issue.Reported), false)
+	if !bytes.HasSuffix(b.Bytes(), []byte("  = help: did you mean the keyword 'class'?\n")) {
+		t.Errorf("unexpected diagnostic:\n%s", b.String())
+	}
+}
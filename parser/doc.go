@@ -0,0 +1,271 @@
+package parser
+
+import (
+	"strings"
+)
+
+type (
+	// DocTag is a puppet-strings tag such as '@param' or '@example' found in a documentation comment
+	DocTag struct {
+		// Tag is the name of the tag without the leading '@'
+		Tag string
+
+		// Name is the parameter name of a '@param' or '@option' tag or the title of an '@example' tag
+		Name string
+
+		// Type is the type given within brackets, or an empty string if no type was given
+		Type string
+
+		// Text is the text of the tag. Lines are joined with a space except for '@example' tags where
+		// the text is the code of the example with the common indentation removed.
+		Text string
+
+		// Offset and Length denote the first line of the tag in the source
+		Offset int
+		Length int
+	}
+
+	// Doc is the documentation of a definition. It is read from the comment lines immediately preceding
+	// the definition.
+	Doc struct {
+		// Description is the text that precedes the first tag
+		Description string
+
+		Tags []*DocTag
+
+		// Offset and Length denote the full comment in the source
+		Offset int
+		Length int
+	}
+)
+
+// Tags that are followed by a name
+var namedDocTags = map[string]bool{
+	`option`: true,
+	`param`:  true,
+}
+
+// Summary returns the text of the '@summary' tag, or an empty string if there is no such tag
+func (d *Doc) Summary() string {
+	if t := d.tag(`summary`, ``); t != nil {
+		return t.Text
+	}
+	return ``
+}
+
+// Param returns the '@param' tag for the parameter with the given name, or nil if the parameter is not
+// documented
+func (d *Doc) Param(name string) *DocTag {
+	return d.tag(`param`, name)
+}
+
+// Return returns the '@return' tag, or nil if there is no such tag
+func (d *Doc) Return() *DocTag {
+	return d.tag(`return`, ``)
+}
+
+// TagsNamed returns all tags with the given name in the order they appear
+func (d *Doc) TagsNamed(tag string) []*DocTag {
+	tags := make([]*DocTag, 0)
+	for _, t := range d.Tags {
+		if t.Tag == tag {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func (d *Doc) tag(tag, name string) *DocTag {
+	for _, t := range d.Tags {
+		if t.Tag == tag && (name == `` || t.Name == name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// Doc returns the documentation of the class, or nil if the class is not documented
+func (e *HostClassDefinition) Doc() *Doc { return docOf(e) }
+
+// Doc returns the documentation of the defined type, or nil if the defined type is not documented
+func (e *ResourceTypeDefinition) Doc() *Doc { return docOf(e) }
+
+// Doc returns the documentation of the function, or nil if the function is not documented
+func (e *FunctionDefinition) Doc() *Doc { return docOf(e) }
+
+// Doc returns the documentation of the plan, or nil if the plan is not documented
+func (e *PlanDefinition) Doc() *Doc { return docOf(e) }
+
+// Doc returns the documentation of the type alias, or nil if the type alias is not documented
+func (e *TypeAlias) Doc() *Doc { return docOf(e) }
+
+// docOf finds the '#' comment lines that immediately precede the line where the expression starts and
+// parses them
+func docOf(e Expression) *Doc {
+	source := e.Locator().String()
+	lineStart := strings.LastIndexByte(source[:e.ByteOffset()], '\n') + 1
+	if strings.TrimSpace(source[lineStart:e.ByteOffset()]) != `` {
+		return nil
+	}
+
+	// Collect the start offsets of the comment lines, last line first
+	var starts []int
+	for end := lineStart - 1; end > 0; {
+		start := strings.LastIndexByte(source[:end], '\n') + 1
+		if !strings.HasPrefix(strings.TrimSpace(source[start:end]), `#`) {
+			break
+		}
+		starts = append(starts, start)
+		end = start - 1
+	}
+	if len(starts) == 0 {
+		return nil
+	}
+
+	lines := make([]docLine, len(starts))
+	for i, start := range starts {
+		end := strings.IndexByte(source[start:], '\n') + start
+		text := strings.TrimRight(source[start:end], "\r")
+		hash := strings.IndexByte(text, '#')
+		lines[len(starts)-1-i] = docLine{strings.TrimPrefix(text[hash+1:], ` `), start + hash, len(text) - hash}
+	}
+	doc := parseDoc(lines)
+	doc.Offset = lines[0].offset
+	doc.Length = lineStart - 1 - doc.Offset
+	return doc
+}
+
+// docLine is the text of one comment line without the leading '#' and the offset and length of the
+// comment in the source
+type docLine struct {
+	text   string
+	offset int
+	length int
+}
+
+func parseDoc(lines []docLine) *Doc {
+	doc := &Doc{Tags: make([]*DocTag, 0)}
+	description := make([]string, 0)
+	var tag *DocTag
+	var tagLines []string
+	finishTag := func() {
+		if tag == nil {
+			return
+		}
+		if tag.Tag == `example` {
+			tag.Text = strings.Join(dedent(tagLines), "\n")
+		} else {
+			tag.Text = strings.Join(strings.Fields(strings.Join(tagLines, ` `)), ` `)
+		}
+		doc.Tags = append(doc.Tags, tag)
+		tag = nil
+	}
+
+	for _, line := range lines {
+		if strings.HasPrefix(line.text, `@`) {
+			finishTag()
+			tag = &DocTag{Offset: line.offset, Length: line.length}
+			tagLines = make([]string, 0)
+			text := parseTagHead(tag, line.text[1:])
+			if tag.Tag == `example` {
+				tag.Name = text
+			} else if text != `` {
+				tagLines = append(tagLines, text)
+			}
+			continue
+		}
+		if tag != nil {
+			blank := strings.TrimSpace(line.text) == ``
+			if tag.Tag == `example` {
+				// The code of an example is indented and may contain blank lines
+				if blank || strings.HasPrefix(line.text, ` `) || strings.HasPrefix(line.text, "\t") {
+					tagLines = append(tagLines, line.text)
+					continue
+				}
+				finishTag()
+			} else if blank {
+				finishTag()
+			} else {
+				tagLines = append(tagLines, line.text)
+				continue
+			}
+		}
+		description = append(description, line.text)
+	}
+	finishTag()
+	doc.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return doc
+}
+
+// parseTagHead parses the tag name, the parameter name, and the type from the first line of a tag and
+// returns the remaining text. The type may be given before or after the parameter name.
+func parseTagHead(tag *DocTag, text string) string {
+	tag.Tag, text = nextDocWord(text)
+	text = parseDocType(tag, text)
+	if namedDocTags[tag.Tag] {
+		tag.Name, text = nextDocWord(text)
+		tag.Name = strings.TrimPrefix(tag.Name, `$`)
+		if tag.Type == `` {
+			text = parseDocType(tag, text)
+		}
+	}
+	return text
+}
+
+func parseDocType(tag *DocTag, text string) string {
+	if !strings.HasPrefix(text, `[`) {
+		return text
+	}
+	// Types may contain brackets, e.g. [Array[String]]
+	depth := 0
+	for i, c := range text {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				tag.Type = strings.TrimSpace(text[1:i])
+				return strings.TrimSpace(text[i+1:])
+			}
+		}
+	}
+	return text
+}
+
+func nextDocWord(text string) (word, rest string) {
+	text = strings.TrimSpace(text)
+	if idx := strings.IndexAny(text, " \t"); idx >= 0 {
+		return text[:idx], strings.TrimSpace(text[idx:])
+	}
+	return text, ``
+}
+
+// dedent removes the indentation that is common to all non blank lines and removes leading and trailing
+// blank lines
+func dedent(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == `` {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == `` {
+		lines = lines[:len(lines)-1]
+	}
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == `` {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		result[i] = strings.TrimRight(line, " \t")
+	}
+	return result
+}
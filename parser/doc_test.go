@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestDoc(t *testing.T) {
+	source := issue.Unindent(`
+    # @summary Manages the foo service
+    #
+    # Installs and configures foo.
+    # Works on all platforms.
+    #
+    # @example Basic usage
+    #   class { 'foo':
+    #     port => 8080,
+    #   }
+    #
+    # @param port
+    #   The port that foo
+    #   listens on
+    # @param [Array[String]] $hosts The hosts
+    # @param name [String] The name
+    # @api private
+    class foo(Integer $port, Array[String] $hosts, String $name) {}`)
+
+	doc := parseDefinition(t, source).(*HostClassDefinition).Doc()
+	if doc == nil {
+		t.Fatal(`expected class to be documented`)
+	}
+	if doc.Summary() != `Manages the foo service` {
+		t.Errorf(`unexpected summary %q`, doc.Summary())
+	}
+	if doc.Description != "Installs and configures foo.\nWorks on all platforms." {
+		t.Errorf(`unexpected description %q`, doc.Description)
+	}
+
+	examples := doc.TagsNamed(`example`)
+	if len(examples) != 1 || examples[0].Name != `Basic usage` || examples[0].Text != "class { 'foo':\n  port => 8080,\n}" {
+		t.Errorf(`unexpected examples %v`, examples)
+	}
+
+	expectDocParam(t, doc, `port`, ``, `The port that foo listens on`)
+	expectDocParam(t, doc, `hosts`, `Array[String]`, `The hosts`)
+	expectDocParam(t, doc, `name`, `String`, `The name`)
+	if api := doc.TagsNamed(`api`); len(api) != 1 || api[0].Text != `private` {
+		t.Errorf(`unexpected api tags %v`, api)
+	}
+	if line := doc.Tags[2].Offset; source[line:line+doc.Tags[2].Length] != `# @param port` {
+		t.Errorf(`unexpected tag offset %d`, line)
+	}
+}
+
+func TestDocReturn(t *testing.T) {
+	doc := parseDefinition(t, "# Adds one\n# @return [Integer] the sum\nfunction foo::inc(Integer $x) >> Integer { $x + 1 }").(*FunctionDefinition).Doc()
+	if doc == nil || doc.Return() == nil || doc.Return().Type != `Integer` || doc.Return().Text != `the sum` {
+		t.Fatalf(`unexpected return tag in %v`, doc)
+	}
+	if doc.Description != `Adds one` {
+		t.Errorf(`unexpected description %q`, doc.Description)
+	}
+}
+
+func TestNoDoc(t *testing.T) {
+	if doc := parseDefinition(t, "# Not adjacent\n\nclass foo {}").(*HostClassDefinition).Doc(); doc != nil {
+		t.Errorf(`expected no documentation, got %v`, doc)
+	}
+	if doc := parseDefinition(t, "$x = 1 # Not a doc comment\ntype Foo = String").(*TypeAlias).Doc(); doc != nil {
+		t.Errorf(`expected no documentation, got %v`, doc)
+	}
+	if doc := parseDefinition(t, "# A type\ntype Foo = String").(*TypeAlias).Doc(); doc == nil || doc.Description != `A type` {
+		t.Errorf(`unexpected documentation %v`, doc)
+	}
+}
+
+func expectDocParam(t *testing.T, doc *Doc, name, typ, text string) {
+	t.Helper()
+	p := doc.Param(name)
+	if p == nil {
+		t.Errorf(`expected @param tag for %s`, name)
+	} else if p.Type != typ || p.Text != text {
+		t.Errorf(`unexpected @param tag for %s: type %q, text %q`, name, p.Type, p.Text)
+	}
+}
+
+func parseDefinition(t *testing.T, source string) Definition {
+	t.Helper()
+	expr, err := CreateParser().Parse(``, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr.(*Program).Definitions()[0]
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+// Edit replaces Length bytes at Offset in the source with Text. A zero Length is an insertion.
+type Edit struct {
+	Offset int
+	Length int
+	Text   string
+}
+
+// IssueEdits returns the edits that will fix the given issue when applied to the source, or nil when
+// the issue cannot be fixed automatically
+func IssueEdits(reported issue.Reported) []*Edit {
+	edits, _ := reported.Argument(`edits`).([]*Edit)
+	return edits
+}
+
+// ApplyEdits applies the given edits to the source and returns the result together with the edits that
+// were applied. Edits are applied in order of their offset. An edit that overlaps an edit with a lower
+// offset is skipped. Such edits can be applied by a subsequent call using offsets that are relative to
+// the result.
+func ApplyEdits(source string, edits []*Edit) (string, []*Edit) {
+	sorted := make([]*Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	applied := make([]*Edit, 0, len(sorted))
+	result := make([]byte, 0, len(source))
+	pos := 0
+	var prev *Edit
+	for _, e := range sorted {
+		if e.Offset < 0 || e.Offset+e.Length > len(source) {
+			continue
+		}
+		if prev != nil {
+			if *e == *prev {
+				// The same edit may be produced by several issues
+				continue
+			}
+			if e.Offset < pos || e.Offset == prev.Offset && e.Length == 0 && prev.Length == 0 {
+				continue
+			}
+		}
+		result = append(result, source[pos:e.Offset]...)
+		result = append(result, e.Text...)
+		pos = e.Offset + e.Length
+		applied = append(applied, e)
+		prev = e
+	}
+	result = append(result, source[pos:]...)
+	return string(result), applied
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestApplyEdits(t *testing.T) {
+	result, applied := ApplyEdits(`abcdef`, []*Edit{
+		{Offset: 4, Length: 1, Text: `E`},
+		{Offset: 0, Length: 0, Text: `>`},
+		{Offset: 1, Length: 2, Text: ``},
+	})
+	if result != `>adEf` || len(applied) != 3 {
+		t.Errorf(`unexpected result %q with %d applied edits`, result, len(applied))
+	}
+}
+
+func TestApplyEditsSkipsOverlaps(t *testing.T) {
+	result, applied := ApplyEdits(`abcdef`, []*Edit{
+		{Offset: 1, Length: 3, Text: `X`},
+		{Offset: 2, Length: 1, Text: `Y`},
+		{Offset: 1, Length: 3, Text: `X`},
+		{Offset: 6, Length: 1, Text: `Z`},
+	})
+	if result != `aXef` || len(applied) != 1 {
+		t.Errorf(`unexpected result %q with %d applied edits`, result, len(applied))
+	}
+}
+
+func TestExtraneousCommaEdits(t *testing.T) {
+	source := "$a = 1, $b = 2, $c = 3"
+	_, err := CreateParser().Parse(``, source, false)
+	if err == nil {
+		t.Fatal(`expected parse error`)
+	}
+	reported := err.(issue.Reported)
+	if reported.Code() != PARSE_EXTRANEOUS_COMMA {
+		t.Fatalf(`expected %s, got %s`, PARSE_EXTRANEOUS_COMMA, reported.String())
+	}
+	result, _ := ApplyEdits(source, IssueEdits(reported))
+	if result != `$a = 1 $b = 2 $c = 3` {
+		t.Errorf(`unexpected result %q`, result)
+	}
+	if _, err = CreateParser().Parse(``, result, false); err != nil {
+		t.Errorf(`expected fixed source to parse, got %s`, err.Error())
+	}
+}
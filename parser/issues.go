@@ -14,6 +14,7 @@ const (
 	LEX_HEREDOC_DECL_UNTERMINATED         = `LEX_HEREDOC_DECL_UNTERMINATED`
 	LEX_HEREDOC_UNTERMINATED              = `LEX_HEREDOC_UNTERMINATED`
 	LEX_HEXDIGIT_EXPECTED                 = `LEX_HEXDIGIT_EXPECTED`
+	LEX_INTEGER_OUT_OF_RANGE              = `LEX_INTEGER_OUT_OF_RANGE`
 	LEX_INVALID_NAME                      = `LEX_INVALID_NAME`
 	LEX_INVALID_OPERATOR                  = `LEX_INVALID_OPERATOR`
 	LEX_INVALID_TYPE_NAME                 = `LEX_INVALID_TYPE_NAME`
@@ -67,6 +68,7 @@ func init() {
 	issue.Hard(LEX_HEREDOC_MULTIPLE_TAG, `more than one tag declaration in heredoc`)
 	issue.Hard(LEX_HEREDOC_UNTERMINATED, `unterminated heredoc`)
 	issue.Hard(LEX_HEXDIGIT_EXPECTED, `hexadecimal digit expected`)
+	issue.Hard(LEX_INTEGER_OUT_OF_RANGE, `integer is out of range`)
 	issue.Hard(LEX_INVALID_NAME, `invalid name`)
 	issue.Hard(LEX_INVALID_OPERATOR, `invalid operator '%{op}'`)
 	issue.Hard(LEX_INVALID_TYPE_NAME, `invalid type name`)
@@ -28,12 +28,24 @@ func (l *location) Pos() int {
 	return l.locator.PosOnLine(l.byteOffset)
 }
 
+func (l *location) Locator() *Locator {
+	return l.locator
+}
+
+func (l *location) ByteOffset() int {
+	return l.byteOffset
+}
+
+func (l *location) ByteLength() int {
+	return 0
+}
+
 func (ctx *context) parseIssue(issueCode issue.Code) issue.Reported {
-	return issue.NewReported(issueCode, issue.SEVERITY_ERROR, issue.NO_ARGS, &location{ctx.locator, ctx.Pos()})
+	return ctx.parseIssue2(issueCode, issue.NO_ARGS)
 }
 
 func (ctx *context) parseIssue2(issueCode issue.Code, args issue.H) issue.Reported {
-	return issue.NewReported(issueCode, issue.SEVERITY_ERROR, args, &location{ctx.locator, ctx.Pos()})
+	return issue.NewReported(issueCode, issue.SEVERITY_ERROR, ctx.withSuggestions(args), &location{ctx.locator, ctx.Pos()})
 }
 
 const (
@@ -356,7 +368,7 @@ func (ctx *context) nextToken() {
 
 	switch {
 	case '1' <= c && c <= '9':
-		ctx.skipDecimalDigits()
+		ctx.skipDecimalDigits(false)
 		c, sz = ctx.Peek()
 		if c == '.' || c == 'e' || c == 'E' {
 			ctx.Advance(sz)
@@ -366,8 +378,7 @@ func (ctx *context) nextToken() {
 		if unicode.IsLetter(c) {
 			panic(ctx.parseIssue(LEX_DIGIT_EXPECTED))
 		}
-		v, _ := strconv.ParseInt(ctx.From(start), 10, 64)
-		ctx.setTokenValue(TOKEN_INTEGER, v)
+		ctx.setTokenValue(TOKEN_INTEGER, ctx.parseInteger(ctx.From(start), 10))
 		ctx.radix = 10
 
 	case 'A' <= c && c <= 'Z':
@@ -672,8 +683,11 @@ func (ctx *context) nextToken() {
 				ctx.consumeQualifiedName(start, TOKEN_VARIABLE)
 			} else if isDecimalDigit(c) {
 				ctx.Advance(sz)
-				ctx.skipDecimalDigits()
-				ctx.tokenValue, _ = strconv.ParseInt(ctx.From(start+1), 10, 64)
+				ctx.skipDecimalDigits(false)
+				ctx.tokenValue = ctx.parseInteger(ctx.From(start+1), 10)
+				if _, ok := ctx.tokenValue.(uint64); ok {
+					panic(ctx.parseIssue(LEX_INTEGER_OUT_OF_RANGE))
+				}
 			} else if unicode.IsLetter(c) {
 				panic(ctx.parseIssue(LEX_INVALID_VARIABLE_NAME))
 			} else {
@@ -701,9 +715,8 @@ func (ctx *context) nextToken() {
 				if ctx.Pos() == hexStart || isLetter(c) {
 					panic(ctx.parseIssue(LEX_HEXDIGIT_EXPECTED))
 				}
-				v, _ := strconv.ParseInt(ctx.From(hexStart), 16, 64)
 				ctx.radix = 16
-				ctx.setTokenValue(TOKEN_INTEGER, v)
+				ctx.setTokenValue(TOKEN_INTEGER, ctx.parseInteger(ctx.From(hexStart), 16))
 
 			case '.', 'e', 'E':
 				// 0[.eE]<something>
@@ -720,9 +733,8 @@ func (ctx *context) nextToken() {
 					panic(ctx.parseIssue(LEX_OCTALDIGIT_EXPECTED))
 				}
 				if ctx.Pos() > octalStart {
-					v, _ := strconv.ParseInt(ctx.From(octalStart), 8, 64)
 					ctx.radix = 8
-					ctx.setTokenValue(TOKEN_INTEGER, v)
+					ctx.setTokenValue(TOKEN_INTEGER, ctx.parseInteger(ctx.From(octalStart), 8))
 				} else {
 					ctx.setTokenValue(TOKEN_INTEGER, int64(0))
 				}
@@ -957,7 +969,7 @@ outer:
 }
 
 func (ctx *context) consumeFloat(start int, d rune) {
-	if ctx.skipDecimalDigits() == 0 {
+	if ctx.skipDecimalDigits(d != '.') == 0 {
 		panic(ctx.parseIssue(LEX_DIGIT_EXPECTED))
 	}
 	c, n := ctx.Peek()
@@ -965,7 +977,7 @@ func (ctx *context) consumeFloat(start int, d rune) {
 		// Check for 'e'
 		if c == 'e' || c == 'E' {
 			ctx.Advance(n)
-			if ctx.skipDecimalDigits() == 0 {
+			if ctx.skipDecimalDigits(true) == 0 {
 				panic(ctx.parseIssue(LEX_DIGIT_EXPECTED))
 			}
 			c, n = ctx.Peek()
@@ -978,10 +990,35 @@ func (ctx *context) consumeFloat(start int, d rune) {
 	ctx.setTokenValue(TOKEN_FLOAT, v)
 }
 
-func (ctx *context) skipDecimalDigits() (digitCount int) {
+// parseInteger returns the int64 value of the given digits. The digits of 9223372036854775808 are only
+// valid when they are negated and yield an uint64 that the parser must negate or reject. Larger values
+// are out of range.
+func (ctx *context) parseInteger(digits string, radix int) interface{} {
+	v, err := strconv.ParseInt(digits, radix, 64)
+	if err == nil {
+		return v
+	}
+	if u, err := strconv.ParseUint(digits, radix, 64); err == nil && u == 1<<63 {
+		return u
+	}
+	panic(ctx.parseIssue(LEX_INTEGER_OUT_OF_RANGE))
+}
+
+// integerValue returns the value of the current integer token. The value is out of range unless
+// it has been negated.
+func (ctx *context) integerValue() int64 {
+	if v, ok := ctx.tokenValue.(int64); ok {
+		return v
+	}
+	panic(ctx.parseIssue(LEX_INTEGER_OUT_OF_RANGE))
+}
+
+// skipDecimalDigits skips decimal digits and returns the number of digits skipped. A leading sign is
+// skipped when signed is true, i.e. when the digits are the exponent of a float.
+func (ctx *context) skipDecimalDigits(signed bool) (digitCount int) {
 	digitCount = 0
 	c, n := ctx.Peek()
-	if c == '-' || c == '+' {
+	if signed && (c == '-' || c == '+') {
 		ctx.Advance(n)
 		c, n = ctx.Peek()
 	}
@@ -1020,12 +1057,12 @@ func (ctx *context) consumeDelimitedString(delimiter rune, delimiterStart int, i
 
 			case delimiter:
 				buf.WriteRune(delimiter)
-				ec, _ = ctx.Next()
+				ec, start = ctx.Next()
 				continue
 
 			default:
 				handler(buf, ctx, ec)
-				ec, _ = ctx.Next()
+				ec, start = ctx.Next()
 				continue
 			}
 
@@ -1040,7 +1077,7 @@ func (ctx *context) consumeDelimitedString(delimiter rune, delimiterStart int, i
 			fallthrough
 		default:
 			buf.WriteRune(ec)
-			ec, _ = ctx.Next()
+			ec, start = ctx.Next()
 		}
 	}
 }
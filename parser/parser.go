@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -22,6 +23,9 @@ type (
 	// For argument lists that are not within parameters
 	commaSeparatedList struct {
 		LiteralList
+
+		// Offsets of the separating commas
+		commas []int
 	}
 )
 
@@ -72,6 +76,13 @@ type Lexer interface {
 	AssertToken(token int)
 }
 
+// PositionedLexer is implemented by lexers that can tell their current position. The lexer returned
+// by NewSimpleLexer implements it.
+type PositionedLexer interface {
+	// Pos returns the current position of the lexer, i.e. the position just after the current token
+	Pos() int
+}
+
 type lexer struct {
 	context
 }
@@ -90,6 +101,7 @@ func NewSimpleLexer(filename string, source string) Lexer {
 		stringReader:          stringReader{text: source},
 		factory:               nil,
 		locator:               &Locator{string: source, file: filename},
+		nextLineStart:         -1,
 		handleBacktickStrings: false,
 		handleHexEscapes:      false,
 		tasks:                 false,
@@ -202,7 +214,8 @@ func (ctx *context) parseTopExpression(filename string, source string, singleExp
 			if _, ok := e.(*BlockExpression); !ok {
 				e = ctx.factory.Block([]Expression{e}, ctx.locator, 0, ctx.Pos())
 			}
-			return ctx.factory.EppExpression([]Expression{}, e, ctx.locator, 0, ctx.Pos())
+			// A template without a parameter list has no parameters specified
+			return ctx.factory.EppExpression(nil, e, ctx.locator, 0, ctx.Pos())
 		}
 
 		if ctx.currentToken == TOKEN_END {
@@ -233,7 +246,10 @@ func (ctx *context) parseTopExpression(filename string, source string, singleExp
 				expr = asEppLambda(ctx.factory.Block(ctx.transformCalls(expressions, 0), ctx.locator, 0, ctx.Pos()))
 				return
 			}
-			expressions = append(expressions, ctx.expression())
+			expressions = append(expressions, ctx.syntacticStatement())
+			if ctx.currentToken == TOKEN_SEMICOLON {
+				ctx.nextToken()
+			}
 		}
 	}
 
@@ -273,6 +289,14 @@ func (ctx *context) assertToken(token int) {
 	}
 }
 
+// suggestionArgs returns issue arguments that contain the given suggestions
+func (ctx *context) suggestionArgs(suggestions []*SyntaxSuggestion) issue.H {
+	if len(suggestions) == 0 {
+		return issue.H{}
+	}
+	return issue.H{`suggestions`: suggestions}
+}
+
 func (ctx *context) tokenString() string {
 	if ctx.tokenValue == nil {
 		return tokenMap[ctx.currentToken]
@@ -334,7 +358,11 @@ func (ctx *context) transformCalls(exprs []Expression, start int) (result []Expr
 			p := f.ByteOffset() + f.ByteLength()
 			l := ctx.locator
 			loc := issue.NewLocation(f.File(), l.LineForOffset(p), l.PosOnLine(p))
-			panic(issue.NewReported(PARSE_EXTRANEOUS_COMMA, issue.SEVERITY_ERROR, issue.NO_ARGS, loc))
+			edits := make([]*Edit, len(csl.commas))
+			for i, comma := range csl.commas {
+				edits[i] = &Edit{Offset: comma, Length: 1}
+			}
+			panic(issue.NewReported(PARSE_EXTRANEOUS_COMMA, issue.SEVERITY_ERROR, issue.H{`edits`: edits}, loc))
 		}
 	}
 	return
@@ -349,10 +377,14 @@ func (ctx *context) expressions(endToken int, producerFunc func() Expression) (e
 		exprs = append(exprs, producerFunc())
 		if ctx.currentToken != TOKEN_COMMA {
 			if ctx.currentToken != endToken {
-				ctx.SetPos(ctx.tokenStartPos)
-				panic(ctx.parseIssue2(PARSE_EXPECTED_ONE_OF_TOKENS, issue.H{
+				args := issue.H{
 					`expected`: fmt.Sprintf(`'%s' or '%s'`, tokenMap[TOKEN_COMMA], tokenMap[endToken]),
-					`actual`:   tokenMap[ctx.currentToken]}))
+					`actual`:   tokenMap[ctx.currentToken]}
+				if suggestions := ctx.missingSeparator(`,`); suggestions != nil {
+					args[`suggestions`] = suggestions
+				}
+				ctx.SetPos(ctx.tokenStartPos)
+				panic(ctx.parseIssue2(PARSE_EXPECTED_ONE_OF_TOKENS, args))
 			}
 			return
 		}
@@ -362,8 +394,10 @@ func (ctx *context) expressions(endToken int, producerFunc func() Expression) (e
 
 func (ctx *context) syntacticStatement() (expr Expression) {
 	var args []Expression
+	var commas []int
 	expr = ctx.relationship()
 	for ctx.currentToken == TOKEN_COMMA {
+		commas = append(commas, ctx.tokenStartPos)
 		ctx.nextToken()
 		if args == nil {
 			args = make([]Expression, 0, 2)
@@ -372,7 +406,7 @@ func (ctx *context) syntacticStatement() (expr Expression) {
 		args = append(args, ctx.relationship())
 	}
 	if args != nil {
-		expr = &commaSeparatedList{LiteralList{Positioned{ctx.locator, expr.ByteOffset(), ctx.Pos() - expr.ByteOffset()}, args}}
+		expr = &commaSeparatedList{LiteralList{Positioned{ctx.locator, expr.ByteOffset(), ctx.Pos() - expr.ByteOffset()}, args}, commas}
 	}
 	return
 }
@@ -641,7 +675,7 @@ func (ctx *context) arrayExpression() (elements []Expression) {
 func (ctx *context) keyedEntry() Expression {
 	key := ctx.hashEntry()
 	if ctx.currentToken != TOKEN_FARROW {
-		panic(ctx.parseIssue(PARSE_EXPECTED_FARROW_AFTER_KEY))
+		panic(ctx.parseIssue2(PARSE_EXPECTED_FARROW_AFTER_KEY, ctx.suggestionArgs(ctx.missingSeparator(`=>`))))
 	}
 	ctx.nextToken()
 	value := ctx.hashEntry()
@@ -658,10 +692,13 @@ func (ctx *context) unaryExpression() Expression {
 	case TOKEN_SUBTRACT:
 		if c, _ := ctx.Peek(); isDecimalDigit(c) {
 			ctx.nextToken()
-			if ctx.currentToken == TOKEN_INTEGER {
-				ctx.setTokenValue(ctx.currentToken, -ctx.tokenValue.(int64))
-			} else {
-				ctx.setTokenValue(ctx.currentToken, -ctx.tokenValue.(float64))
+			switch v := ctx.tokenValue.(type) {
+			case int64:
+				ctx.setTokenValue(ctx.currentToken, -v)
+			case uint64:
+				ctx.setTokenValue(ctx.currentToken, int64(math.MinInt64))
+			case float64:
+				ctx.setTokenValue(ctx.currentToken, -v)
 			}
 			expr := ctx.primaryExpression()
 			expr.updateOffsetAndLength(unaryStart, ctx.Pos()-unaryStart)
@@ -772,7 +809,7 @@ func (ctx *context) atomExpression() (expr Expression) {
 		ctx.nextToken()
 
 	case TOKEN_INTEGER:
-		expr = ctx.factory.Integer(ctx.tokenValue.(int64), ctx.radix, ctx.locator, atomStart, ctx.Pos()-atomStart)
+		expr = ctx.factory.Integer(ctx.integerValue(), ctx.radix, ctx.locator, atomStart, ctx.Pos()-atomStart)
 		ctx.nextToken()
 
 	case TOKEN_FLOAT:
@@ -846,7 +883,7 @@ func (ctx *context) atomExpression() (expr Expression) {
 		name := ctx.tokenString()
 		ctx.nextToken()
 		if ctx.currentToken == TOKEN_TYPE_NAME {
-			expr = ctx.typeAliasOrDefinition()
+			expr = ctx.typeAliasOrDefinition(atomStart)
 		} else {
 			// Not a type definition. Just treat the 'type' keyword as a qualfied name
 			expr = ctx.factory.QualifiedName(name, ctx.locator, atomStart, ctx.Pos()-atomStart)
@@ -1011,8 +1048,14 @@ func (ctx *context) resourceExpression(start int, first Expression, form Resourc
 					return
 				}
 			}
+			suggestions := keywordSuggestions(name, first.ByteOffset())
+			if len(suggestions) == 0 {
+				suggestions = []*SyntaxSuggestion{{
+					Message: fmt.Sprintf(`add a title, e.g. %s { 'title': ... }`, name),
+					Edit:    &Edit{Offset: bodiesStart, Text: ` 'title':`}}}
+			}
 			ctx.SetPos(start)
-			panic(ctx.parseIssue2(PARSE_RESOURCE_WITHOUT_TITLE, issue.H{`name`: name}))
+			panic(ctx.parseIssue2(PARSE_RESOURCE_WITHOUT_TITLE, issue.H{`name`: name, `suggestions`: suggestions}))
 		case `defaults`:
 			ctx.SetPos(bodiesStart)
 			ctx.nextToken()
@@ -1032,6 +1075,15 @@ func (ctx *context) resourceExpression(start int, first Expression, form Resourc
 		expr = ctx.factory.Resource(form, first, bodies, ctx.locator, start, ctx.Pos()-start)
 	}
 
+	switch ctx.currentToken {
+	case TOKEN_IDENTIFIER, TOKEN_STRING, TOKEN_MULTIPLY:
+		// Attribute operation that is not preceded by a comma
+		args := ctx.suggestionArgs(ctx.missingSeparator(`,`))
+		args[`expected`] = tokenMap[TOKEN_RC]
+		args[`actual`] = tokenMap[ctx.currentToken]
+		ctx.SetPos(ctx.tokenStartPos)
+		panic(ctx.parseIssue2(PARSE_EXPECTED_TOKEN, args))
+	}
 	ctx.assertToken(TOKEN_RC)
 	ctx.nextToken()
 	return
@@ -1112,7 +1164,7 @@ func (ctx *context) attributeOperation() (op Expression) {
 		ctx.nextToken()
 		return ctx.factory.AttributeOp(op, name, ctx.expression(), ctx.locator, start, ctx.Pos()-start)
 	default:
-		panic(ctx.parseIssue(PARSE_INVALID_ATTRIBUTE))
+		panic(ctx.parseIssue2(PARSE_INVALID_ATTRIBUTE, ctx.suggestionArgs(ctx.missingSeparator(`=>`))))
 	}
 }
 
@@ -1197,8 +1249,7 @@ func (ctx *context) collectExpression(lhs Expression) Expression {
 	return ctx.factory.Collect(lhs, collectQuery, attributeOps, ctx.locator, lhs.ByteOffset(), ctx.Pos()-lhs.ByteOffset())
 }
 
-func (ctx *context) typeAliasOrDefinition() Expression {
-	start := ctx.tokenStartPos
+func (ctx *context) typeAliasOrDefinition(start int) Expression {
 	typeExpr := ctx.parameterType()
 	fqr, ok := typeExpr.(*QualifiedReference)
 	if !ok {
@@ -1629,8 +1680,10 @@ func (ctx *context) functionDefinition() Expression {
 	ctx.assertToken(TOKEN_LC)
 	ctx.nextToken()
 	block := ctx.parse(TOKEN_RC, false)
+	// The end of the definition is the end of the closing brace
+	end := ctx.tokenStartPos + 1
 	ctx.nextToken() // consume TOKEN_RC
-	return ctx.addDefinition(ctx.factory.Function(name, parameterList, block, returnType, ctx.locator, start, ctx.Pos()-start))
+	return ctx.addDefinition(ctx.factory.Function(name, parameterList, block, returnType, ctx.locator, start, end-start))
 }
 
 func (ctx *context) planDefinition() Expression {
@@ -1667,11 +1720,12 @@ func (ctx *context) planDefinition() Expression {
 	ctx.assertToken(TOKEN_LC)
 	ctx.nextToken()
 	block := ctx.parse(TOKEN_RC, false)
+	end := ctx.tokenStartPos + 1
 	ctx.nextToken() // consume TOKEN_RC
 
 	// Pop namestack
 	ctx.nameStack = ctx.nameStack[:len(ctx.nameStack)-1]
-	return ctx.addDefinition(ctx.factory.Plan(name, parameterList, block, returnType, ctx.locator, start, ctx.Pos()-start))
+	return ctx.addDefinition(ctx.factory.Plan(name, parameterList, block, returnType, ctx.locator, start, end-start))
 }
 
 func (ctx *context) nodeDefinition() Expression {
@@ -1686,8 +1740,9 @@ func (ctx *context) nodeDefinition() Expression {
 	ctx.assertToken(TOKEN_LC)
 	ctx.nextToken()
 	block := ctx.parse(TOKEN_RC, false)
+	end := ctx.tokenStartPos + 1
 	ctx.nextToken()
-	return ctx.addDefinition(ctx.factory.Node(hostnames, nodeParent, block, ctx.locator, start, ctx.Pos()-start))
+	return ctx.addDefinition(ctx.factory.Node(hostnames, nodeParent, block, ctx.locator, start, end-start))
 }
 
 func (ctx *context) hostnames() (hostnames []Expression) {
@@ -1736,7 +1791,7 @@ func (ctx *context) dottedName() Expression {
 		case TOKEN_IDENTIFIER, TOKEN_TYPE_NAME:
 			names = append(names, ctx.tokenString())
 		case TOKEN_INTEGER:
-			names = append(names, strconv.FormatInt(ctx.tokenValue.(int64), 10))
+			names = append(names, strconv.FormatInt(ctx.integerValue(), 10))
 		case TOKEN_FLOAT:
 			names = append(names, strconv.FormatFloat(ctx.tokenValue.(float64), 'g', -1, 64))
 		default:
@@ -1906,11 +1961,12 @@ func (ctx *context) classExpression(start int) Expression {
 	ctx.assertToken(TOKEN_LC)
 	ctx.nextToken()
 	body := ctx.parse(TOKEN_RC, false)
+	end := ctx.tokenStartPos + 1
 	ctx.nextToken()
 
 	// Pop namestack
 	ctx.nameStack = ctx.nameStack[:len(ctx.nameStack)-1]
-	return ctx.addDefinition(ctx.factory.Class(ctx.qualifiedName(name), parameterList, parent, body, ctx.locator, start, ctx.Pos()-start))
+	return ctx.addDefinition(ctx.factory.Class(ctx.qualifiedName(name), parameterList, parent, body, ctx.locator, start, end-start))
 }
 
 func (ctx *context) className() (name string) {
@@ -1992,12 +2048,13 @@ func (ctx *context) resourceDefinition(resourceToken int) Expression {
 	ctx.assertToken(TOKEN_LC)
 	ctx.nextToken()
 	body := ctx.parse(TOKEN_RC, false)
+	end := ctx.tokenStartPos + 1
 	ctx.nextToken()
 	var def Expression
 	if resourceToken == TOKEN_APPLICATION {
-		def = ctx.factory.Application(name, parameterList, body, ctx.locator, start, ctx.Pos()-start)
+		def = ctx.factory.Application(name, parameterList, body, ctx.locator, start, end-start)
 	} else {
-		def = ctx.factory.Definition(name, parameterList, body, ctx.locator, start, ctx.Pos()-start)
+		def = ctx.factory.Definition(name, parameterList, body, ctx.locator, start, end-start)
 	}
 	return ctx.addDefinition(def)
 }
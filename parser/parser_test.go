@@ -3,6 +3,7 @@ package parser
 import (
 	"bytes"
 	"github.com/lyraproj/issue/issue"
+	"reflect"
 	"testing"
 )
 
@@ -21,10 +22,21 @@ func TestInteger(t *testing.T) {
 	expectDump(t, `+123`, `123`)
 	expectDump(t, `0XABC`, `(int {:radix 16 :value 2748})`)
 	expectDump(t, `0772`, `(int {:radix 8 :value 506})`)
+	expectDump(t, `1+2`, `(+ 1 2)`)
+	expectDump(t, `1-2`, `(- 1 2)`)
 	expectError(t, `3g`, `digit expected (line: 1, column: 2)`)
 	expectError(t, `3ö`, `digit expected (line: 1, column: 2)`)
 	expectError(t, `0x3g21`, `hexadecimal digit expected (line: 1, column: 4)`)
 	expectError(t, `078`, `octal digit expected (line: 1, column: 3)`)
+	expectDump(t, `9223372036854775807`, `9223372036854775807`)
+	expectDump(t, `-9223372036854775808`, `-9223372036854775808`)
+	expectDump(t, `-0x8000000000000000`, `(int {:radix 16 :value -9223372036854775808})`)
+	expectDump(t, `-(9223372036854775807)`, `(- (paren 9223372036854775807))`)
+	expectError(t, `9223372036854775808`, `integer is out of range (line: 1, column: 20)`)
+	expectError(t, `-9223372036854775809`, `integer is out of range (line: 1, column: 21)`)
+	expectError(t, `-(9223372036854775808)`, `integer is out of range (line: 1, column: 22)`)
+	expectError(t, `0x10000000000000000`, `integer is out of range (line: 1, column: 20)`)
+	expectError(t, `$9223372036854775808`, `integer is out of range (line: 1, column: 21)`)
 }
 
 func TestNegativeInteger(t *testing.T) {
@@ -40,6 +52,7 @@ func TestFloat(t *testing.T) {
 	expectDump(t, `12e-12`, `1.2e-11`)
 	expectDump(t, `12.23e12`, `1.223e+13`)
 	expectDump(t, `12.23e-12`, `1.223e-11`)
+	expectDump(t, `1.5+2`, `(+ 1.5 2)`)
 
 	expectError(t, `123.a`, `digit expected (line: 1, column: 5)`)
 	expectError(t, `123.4a`, `digit expected (line: 1, column: 6)`)
@@ -1955,6 +1968,11 @@ func TestEPP(t *testing.T) {
       <%-||-%> some <%- $x = 3 %> text`),
 		`(lambda {:body [(epp (render-s "some") (= (var "x") 3) (render-s " text"))]})`)
 
+	expectDumpEPP(t,
+		issue.Unindent(`
+      some <%- $x = 3; $y = 4 %> text`),
+		`(lambda {:body [(epp (render-s "some") (= (var "x") 3) (= (var "y") 4) (render-s " text"))]})`)
+
 	expectErrorEPP(t,
 		issue.Unindent(`
       <%-||-%> some <%- $x = 3 -% $y %> text`),
@@ -2152,3 +2170,47 @@ func parseExpression(t *testing.T, str string, parserOptions ...Option) Expressi
 	}
 	return expr
 }
+
+func TestSimpleLexer(t *testing.T) {
+	l := NewSimpleLexer(``, "$a = 1\n$b = 2\n")
+	starts := make([]int, 0)
+	for token := l.NextToken(); token != TOKEN_END; token = l.NextToken() {
+		starts = append(starts, l.TokenStartPos())
+	}
+	if !reflect.DeepEqual(starts, []int{0, 3, 5, 7, 10, 12}) {
+		t.Errorf(`unexpected token positions %v`, starts)
+	}
+	if pl, ok := l.(PositionedLexer); !ok || pl.Pos() != 14 {
+		t.Errorf(`expected lexer to end at position 14`)
+	}
+}
+
+func TestInterpolationPositions(t *testing.T) {
+	source := `"${a}-$b \n${c.x}"`
+	offsets := make([]int, 0)
+	parse(t, source).AllContents(nil, func(path []Expression, e Expression) {
+		if v, ok := e.(*VariableExpression); ok {
+			offsets = append(offsets, v.ByteOffset())
+		}
+	})
+	if !reflect.DeepEqual(offsets, []int{1, 6, 11}) {
+		t.Errorf(`unexpected variable positions %v`, offsets)
+	}
+}
+
+func TestDefinitionLength(t *testing.T) {
+	for _, source := range []string{
+		"class foo {\n  notice('x')\n}",
+		"define foo() {\n}",
+		"function foo() {\n  1\n}",
+		"node default {\n}",
+	} {
+		expr, err := CreateParser().Parse(``, source+"\nnotice('y')", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := expr.(*Program).Definitions()[0]; d.ByteLength() != len(source) {
+			t.Errorf(`expected length of %q to be %d, got %d`, source, len(source), d.ByteLength())
+		}
+	}
+}
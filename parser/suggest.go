@@ -0,0 +1,332 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+// Suggestions returns the candidates that are close enough to the given word to be likely
+// misspellings of it. The closest candidates are returned first.
+func Suggestions(word string, candidates []string) []string {
+	maxDistance := utf8.RuneCountInString(word) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	type match struct {
+		name     string
+		distance int
+	}
+	matches := make([]match, 0)
+	for _, c := range candidates {
+		if c == word {
+			continue
+		}
+		if d := EditDistance(word, c); d <= maxDistance {
+			matches = append(matches, match{c, d})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance == matches[j].distance {
+			return matches[i].name < matches[j].name
+		}
+		return matches[i].distance < matches[j].distance
+	})
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.name
+	}
+	return result
+}
+
+// EditDistance returns the Damerau-Levenshtein (optimal string alignment) distance between
+// the two strings, i.e. the number of insertions, deletions, substitutions, and transpositions
+// of adjacent characters required to turn a into b.
+func EditDistance(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+	la := len(ra)
+	lb := len(rb)
+	d := make([][]int, la+1)
+	for i := range d {
+		d[i] = make([]int, lb+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= lb; j++ {
+		d[0][j] = j
+	}
+	for i := 1; i <= la; i++ {
+		for j := 1; j <= lb; j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[la][lb]
+}
+
+// DidYouMean is an issue.ArgFormatter that formats a slice of suggestions into a sentence that
+// can be appended to an issue message. An empty string is produced when there are no suggestions.
+func DidYouMean(value interface{}) string {
+	suggestions, _ := value.([]string)
+	if len(suggestions) == 0 {
+		return ``
+	}
+	b := bytes.NewBufferString(` Did you mean `)
+	last := len(suggestions) - 1
+	for i, s := range suggestions {
+		if i > 0 {
+			if i == last {
+				b.WriteString(` or `)
+			} else {
+				b.WriteString(`, `)
+			}
+		}
+		b.WriteByte('\'')
+		b.WriteString(s)
+		b.WriteByte('\'')
+	}
+	b.WriteByte('?')
+	return b.String()
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// SyntaxSuggestion is a hint on how to correct a syntax error. Suggestions are attached to issues
+// reported by the parser and can be obtained using SyntaxSuggestions.
+type SyntaxSuggestion struct {
+	// Message is a human readable description of the suggestion
+	Message string
+
+	// Edit that applies the suggestion, or nil when the suggestion cannot be applied automatically
+	Edit *Edit
+
+	// Related is a location that is related to the error, such as the location of an unclosed brace, or nil
+	Related issue.Location
+}
+
+// statementKeywords are the keywords that are suggested when an identifier that starts a statement
+// is a likely misspelling of one of them
+var statementKeywords = []string{
+	`application`, `case`, `class`, `define`, `else`, `elsif`, `function`, `if`, `node`, `plan`, `site`, `type`, `unless`}
+
+var closingBracket = map[int]string{
+	TOKEN_LP: `)`, TOKEN_WSLP: `)`, TOKEN_LB: `]`, TOKEN_LISTSTART: `]`, TOKEN_LC: `}`, TOKEN_SELC: `}`}
+
+var openingBracket = map[int]string{
+	TOKEN_RP: `(`, TOKEN_RB: `[`, TOKEN_RC: `{`}
+
+// SyntaxSuggestions returns the suggestions attached to the given issue
+func SyntaxSuggestions(reported issue.Reported) []*SyntaxSuggestion {
+	suggestions, _ := reported.Argument(`suggestions`).([]*SyntaxSuggestion)
+	return suggestions
+}
+
+type scannedToken struct {
+	token int
+	value string
+	start int
+	end   int
+}
+
+// withSuggestions returns the given arguments amended with suggestions inferred from the source
+// unless the arguments already contain suggestions.
+func (ctx *context) withSuggestions(args issue.H) issue.H {
+	// The lexer used for inferring suggestions has no factory and must not infer suggestions itself
+	if ctx.factory == nil || ctx.eppMode {
+		return args
+	}
+	if _, ok := args[`suggestions`]; ok {
+		return args
+	}
+	suggestions := ctx.inferSuggestions(ctx.Pos())
+	if len(suggestions) == 0 {
+		return args
+	}
+	amended := make(issue.H, len(args)+1)
+	for k, v := range args {
+		amended[k] = v
+	}
+	amended[`suggestions`] = suggestions
+	return amended
+}
+
+// inferSuggestions rescans the source up to the token at the given offset and looks for unbalanced
+// brackets and statements that start with a misspelled keyword
+func (ctx *context) inferSuggestions(offset int) []*SyntaxSuggestion {
+	tokens := ctx.scanTokens(offset)
+	if len(tokens) == 0 {
+		return nil
+	}
+	suggestions := ctx.bracketSuggestions(tokens)
+
+	// Find the last token that starts a statement
+	for i := len(tokens) - 1; i >= 0; i-- {
+		t := tokens[i]
+		if i > 0 {
+			switch tokens[i-1].token {
+			case TOKEN_LC, TOKEN_RC, TOKEN_SEMICOLON:
+			default:
+				if ctx.locator.LineForOffset(tokens[i-1].end) == ctx.locator.LineForOffset(t.start) {
+					continue
+				}
+			}
+		}
+		if t.token == TOKEN_RC || t.token == TOKEN_END {
+			continue
+		}
+		if t.token == TOKEN_IDENTIFIER && (i+1 == len(tokens) || !followsName(tokens[i+1].token)) {
+			suggestions = append(suggestions, keywordSuggestions(t.value, t.start)...)
+		}
+		break
+	}
+	return suggestions
+}
+
+// followsName returns true if the token can follow a name that is used as something other than a keyword
+func followsName(token int) bool {
+	switch token {
+	case TOKEN_FARROW, TOKEN_PARROW, TOKEN_LP, TOKEN_ASSIGN, TOKEN_DOT, TOKEN_LB, TOKEN_COLON:
+		return true
+	}
+	return false
+}
+
+func keywordSuggestions(name string, offset int) []*SyntaxSuggestion {
+	keywords := Suggestions(name, statementKeywords)
+	suggestions := make([]*SyntaxSuggestion, len(keywords))
+	for i, kw := range keywords {
+		suggestions[i] = &SyntaxSuggestion{
+			Message: fmt.Sprintf(`did you mean the keyword '%s'?`, kw),
+			Edit:    &Edit{Offset: offset, Length: len(name), Text: kw}}
+	}
+	return suggestions
+}
+
+// bracketSuggestions finds the bracket that is left unbalanced by the last token
+func (ctx *context) bracketSuggestions(tokens []scannedToken) []*SyntaxSuggestion {
+	stack := make([]scannedToken, 0, 8)
+	last := len(tokens) - 1
+	for _, t := range tokens[:last] {
+		if _, ok := closingBracket[t.token]; ok {
+			stack = append(stack, t)
+		} else if _, ok := openingBracket[t.token]; ok && len(stack) > 0 {
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	t := tokens[last]
+	opening, isClosing := openingBracket[t.token]
+	switch {
+	case t.token == TOKEN_END && len(stack) > 0:
+		open := stack[len(stack)-1]
+		return []*SyntaxSuggestion{{
+			Message: fmt.Sprintf(`the '%s' at %s is never closed`, open.value, ctx.describeOffset(open.start)),
+			Edit:    &Edit{Offset: t.start, Text: closingBracket[open.token]},
+			Related: &location{ctx.locator, open.start}}}
+	case isClosing && len(stack) == 0:
+		return []*SyntaxSuggestion{{
+			Message: fmt.Sprintf(`the '%s' has no matching '%s'`, t.value, opening)}}
+	case isClosing && closingBracket[stack[len(stack)-1].token] != t.value:
+		open := stack[len(stack)-1]
+		return []*SyntaxSuggestion{{
+			Message: fmt.Sprintf(`the '%s' at %s is not closed before this '%s'`, open.value, ctx.describeOffset(open.start), t.value),
+			Edit:    &Edit{Offset: t.start, Text: closingBracket[open.token]},
+			Related: &location{ctx.locator, open.start}}}
+	}
+	return nil
+}
+
+func (ctx *context) describeOffset(offset int) string {
+	return fmt.Sprintf(`line %d, column %d`, ctx.locator.LineForOffset(offset), ctx.locator.PosOnLine(offset))
+}
+
+// scanTokens returns the tokens of the source up to and including the first token that ends after the
+// given offset. The scan stops silently at lexical errors.
+func (ctx *context) scanTokens(offset int) (tokens []scannedToken) {
+	tokens = make([]scannedToken, 0, 32)
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(issue.Reported); !ok {
+				panic(r)
+			}
+		}
+	}()
+
+	scanner := &context{
+		stringReader:  stringReader{text: ctx.locator.String()},
+		locator:       ctx.locator,
+		nextLineStart: -1,
+		tasks:         ctx.tasks,
+		workflow:      ctx.workflow}
+	for {
+		scanner.nextToken()
+		t := scannedToken{scanner.currentToken, tokenMap[scanner.currentToken], scanner.tokenStartPos, scanner.Pos()}
+		if name, ok := scanner.tokenValue.(string); ok && t.token == TOKEN_IDENTIFIER {
+			t.value = name
+		}
+		if t.token == TOKEN_END {
+			t.start = len(scanner.text)
+			t.end = t.start
+		}
+		tokens = append(tokens, t)
+		if t.token == TOKEN_END || t.end > offset {
+			return
+		}
+	}
+}
+
+// endOfPreviousToken returns the offset just after the token that precedes the given offset
+func (ctx *context) endOfPreviousToken(offset int) int {
+	source := ctx.locator.String()
+	for offset > 0 {
+		switch source[offset-1] {
+		case ' ', '\t', '\r', '\n':
+			offset--
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// missingSeparator returns a suggestion to insert the given separator before the current token or, when the
+// current token is a comma, to replace that comma with the separator. No suggestion is returned when the
+// current token cannot start an expression.
+func (ctx *context) missingSeparator(separator string) []*SyntaxSuggestion {
+	switch ctx.currentToken {
+	case TOKEN_END, TOKEN_RP, TOKEN_RB, TOKEN_RC, TOKEN_SEMICOLON, TOKEN_COLON:
+		return nil
+	case TOKEN_COMMA:
+		if separator == `,` {
+			return nil
+		}
+		start := ctx.endOfPreviousToken(ctx.tokenStartPos)
+		return []*SyntaxSuggestion{{
+			Message: fmt.Sprintf(`replace ',' with '%s'`, separator),
+			Edit:    &Edit{Offset: start, Length: ctx.tokenStartPos + 1 - start, Text: ` ` + separator}}}
+	}
+	text := separator
+	if separator != `,` {
+		text = ` ` + separator
+	}
+	return []*SyntaxSuggestion{{
+		Message: fmt.Sprintf(`insert a missing '%s'`, separator),
+		Edit:    &Edit{Offset: ctx.endOfPreviousToken(ctx.tokenStartPos), Text: text}}}
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		distance int
+	}{
+		{`mode`, `mode`, 0},
+		{`mdoe`, `mode`, 1},
+		{`onwer`, `owner`, 1},
+		{`ensure`, `ensur`, 1},
+		{`kitten`, `sitting`, 3},
+		{``, `abc`, 3},
+	} {
+		if d := EditDistance(tc.a, tc.b); d != tc.distance {
+			t.Errorf(`expected distance %d between '%s' and '%s', got %d`, tc.distance, tc.a, tc.b, d)
+		}
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	candidates := []string{`owner`, `group`, `mode`, `mod`}
+	if s := Suggestions(`mdoe`, candidates); !reflect.DeepEqual(s, []string{`mode`}) {
+		t.Errorf(`unexpected suggestions %v`, s)
+	}
+	if s := Suggestions(`content`, candidates); len(s) != 0 {
+		t.Errorf(`unexpected suggestions %v`, s)
+	}
+	if s := DidYouMean([]string{`a`, `b`, `c`}); s != ` Did you mean 'a', 'b' or 'c'?` {
+		t.Errorf(`unexpected text %s`, s)
+	}
+}
+
+func TestSyntaxSuggestions(t *testing.T) {
+	expectSuggestion(t, `if $x { 1 } esle { 2 }`, `did you mean the keyword 'else'?`, `if $x { 1 } else { 2 }`)
+	expectSuggestion(t, `clas foo { }`, `did you mean the keyword 'class'?`, `class foo { }`)
+	expectSuggestion(t, "$a = 1\nfucntion foo() { }", `did you mean the keyword 'function'?`, "$a = 1\nfunction foo() { }")
+	expectSuggestion(t, `$x = { 'a' => 1 'b' => 2 }`, `insert a missing ','`, `$x = { 'a' => 1, 'b' => 2 }`)
+	expectSuggestion(t, `$x = { 'a' 1 }`, `insert a missing '=>'`, `$x = { 'a' => 1 }`)
+	expectSuggestion(t, `$x = { 'a', 1 }`, `replace ',' with '=>'`, `$x = { 'a' => 1 }`)
+	expectSuggestion(t, `file { '/x': mode => 1 owner => 2 }`, `insert a missing ','`, `file { '/x': mode => 1, owner => 2 }`)
+	expectSuggestion(t, `file { '/x': mode 1 }`, `insert a missing '=>'`, `file { '/x': mode => 1 }`)
+	expectSuggestion(t, `file { mode => 1 }`, `add a title, e.g. file { 'title': ... }`, `file { 'title': mode => 1 }`)
+	expectSuggestion(t, "class foo {\n  if $x {\n    notice(1)\n  }\n", `the '{' at line 1, column 11 is never closed`, "class foo {\n  if $x {\n    notice(1)\n  }\n}")
+	expectSuggestion(t, `$x = [1, 2`, `the '[' at line 1, column 6 is never closed`, `$x = [1, 2]`)
+	expectSuggestion(t, `$x = 1 }`, `the '}' has no matching '{'`, ``)
+}
+
+func TestSyntaxSuggestionRelatedLocation(t *testing.T) {
+	_, err := CreateParser().Parse(`x.pp`, "notice(1,\n  2", false)
+	if err == nil {
+		t.Fatal(`expected parse error`)
+	}
+	suggestions := SyntaxSuggestions(err.(issue.Reported))
+	if len(suggestions) != 1 || suggestions[0].Related == nil {
+		t.Fatalf(`expected one suggestion with a related location, got %v`, suggestions)
+	}
+	if r := suggestions[0].Related; r.File() != `x.pp` || r.Line() != 1 || r.Pos() != 7 {
+		t.Errorf(`unexpected related location %s`, issue.LocationString(r))
+	}
+}
+
+func expectSuggestion(t *testing.T, source, message, corrected string) {
+	t.Helper()
+	_, err := CreateParser().Parse(``, source, false)
+	if err == nil {
+		t.Errorf(`expected parse error for %q`, source)
+		return
+	}
+	for _, s := range SyntaxSuggestions(err.(issue.Reported)) {
+		if s.Message != message {
+			continue
+		}
+		if s.Edit == nil {
+			if corrected != `` {
+				t.Errorf(`expected suggestion %q for %q to have an edit`, message, source)
+			}
+			return
+		}
+		result := source[:s.Edit.Offset] + s.Edit.Text + source[s.Edit.Offset+s.Edit.Length:]
+		if result != corrected {
+			t.Errorf(`expected edit of %q to produce %q, got %q`, source, corrected, result)
+		}
+		if _, err = CreateParser().Parse(``, result, false); err != nil {
+			t.Errorf(`expected edit of %q to produce valid source, got %s`, source, err.Error())
+		}
+		return
+	}
+	t.Errorf(`expected suggestion %q for %q, got %v`, message, source, SyntaxSuggestions(err.(issue.Reported)))
+}
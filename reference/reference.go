@@ -0,0 +1,235 @@
+// Package reference collects the documentation of the definitions of a module and writes it as a reference
+// in Markdown or JSON form.
+package reference
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Kinds of documented definitions in the order that they appear in a reference
+const (
+	CLASS        = `class`
+	DEFINED_TYPE = `defined_type`
+	FUNCTION     = `function`
+	TYPE_ALIAS   = `type_alias`
+	PLAN         = `plan`
+)
+
+var KINDS = []string{CLASS, DEFINED_TYPE, FUNCTION, TYPE_ALIAS, PLAN}
+
+type (
+	// Definition is the documentation of one class, defined type, function, type alias, or plan
+	Definition struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+		File string `json:"file"`
+		Line int    `json:"line"`
+
+		Summary     string `json:"summary,omitempty"`
+		Description string `json:"description,omitempty"`
+
+		// Signature is the declaration of the definition without its body, e.g.
+		// "function foo(String $a, Integer $b = 1) >> String"
+		Signature string `json:"signature"`
+
+		Parameters []*Parameter `json:"parameters,omitempty"`
+
+		// Return is only set for functions and plans
+		Return *Return `json:"return,omitempty"`
+
+		// AliasOf is the aliased type of a type alias
+		AliasOf string `json:"alias_of,omitempty"`
+
+		Examples []*Example `json:"examples,omitempty"`
+	}
+
+	// Parameter is the documentation of one parameter of a definition
+	Parameter struct {
+		Name string `json:"name"`
+
+		// Type is the declared type of the parameter, or the type of the '@param' tag when no type is
+		// declared. It is 'Any' when neither is given.
+		Type string `json:"type"`
+
+		// Default is the source of the default value expression
+		Default     string `json:"default,omitempty"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// Return is the documentation of the return value of a function or plan
+	Return struct {
+		Type        string `json:"type,omitempty"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// Example is the title and code of an '@example' tag
+	Example struct {
+		Title string `json:"title,omitempty"`
+		Code  string `json:"code"`
+	}
+)
+
+// Collect returns the documentation of all classes, defined types, functions, type aliases, and plans
+// in the given program, in the order that they appear.
+func Collect(program *parser.Program) []*Definition {
+	result := make([]*Definition, 0, len(program.Definitions()))
+	for _, d := range program.Definitions() {
+		if rd := definitionOf(d); rd != nil {
+			result = append(result, rd)
+		}
+	}
+	return result
+}
+
+// Sort sorts the definitions by kind in the order of KINDS and then by name
+func Sort(definitions []*Definition) {
+	sort.SliceStable(definitions, func(i, j int) bool {
+		ki, kj := kindIndex(definitions[i].Kind), kindIndex(definitions[j].Kind)
+		if ki != kj {
+			return ki < kj
+		}
+		return definitions[i].Name < definitions[j].Name
+	})
+}
+
+func kindIndex(kind string) int {
+	for i, k := range KINDS {
+		if k == kind {
+			return i
+		}
+	}
+	return len(KINDS)
+}
+
+func definitionOf(d parser.Definition) *Definition {
+	rd := &Definition{File: d.File(), Line: d.Line()}
+	var doc *parser.Doc
+	switch d.(type) {
+	case *parser.HostClassDefinition:
+		rd.Kind = CLASS
+		doc = d.(*parser.HostClassDefinition).Doc()
+	case *parser.ResourceTypeDefinition:
+		rd.Kind = DEFINED_TYPE
+		doc = d.(*parser.ResourceTypeDefinition).Doc()
+	case *parser.FunctionDefinition:
+		rd.Kind = FUNCTION
+		doc = d.(*parser.FunctionDefinition).Doc()
+	case *parser.PlanDefinition:
+		rd.Kind = PLAN
+		doc = d.(*parser.PlanDefinition).Doc()
+	case *parser.TypeAlias:
+		ta := d.(*parser.TypeAlias)
+		rd.Kind = TYPE_ALIAS
+		rd.Name = ta.Name()
+		rd.AliasOf = ta.Type().String()
+		rd.Signature = `type ` + rd.Name + ` = ` + rd.AliasOf
+		rd.addDoc(ta.Doc())
+		return rd
+	default:
+		return nil
+	}
+
+	nd := d.(parser.NamedDefinition)
+	rd.Name = nd.Name()
+	rd.Parameters = make([]*Parameter, len(nd.Parameters()))
+	for i, p := range nd.Parameters() {
+		rd.Parameters[i] = parameterOf(p.(*parser.Parameter), doc)
+	}
+	if rd.Kind == FUNCTION || rd.Kind == PLAN {
+		rd.Return = returnOf(d, doc)
+	}
+	rd.Signature = signatureOf(rd.Kind, nd)
+	rd.addDoc(doc)
+	return rd
+}
+
+func (rd *Definition) addDoc(doc *parser.Doc) {
+	if doc == nil {
+		return
+	}
+	rd.Summary = doc.Summary()
+	rd.Description = doc.Description
+	for _, tag := range doc.TagsNamed(`example`) {
+		rd.Examples = append(rd.Examples, &Example{Title: tag.Name, Code: tag.Text})
+	}
+}
+
+func parameterOf(param *parser.Parameter, doc *parser.Doc) *Parameter {
+	p := &Parameter{Name: param.Name(), Type: `Any`}
+	var tag *parser.DocTag
+	if doc != nil {
+		tag = doc.Param(param.Name())
+	}
+	if param.Type() != nil {
+		p.Type = param.Type().String()
+	} else if tag != nil && tag.Type != `` {
+		p.Type = tag.Type
+	}
+	if param.Value() != nil {
+		p.Default = param.Value().String()
+	}
+	if tag != nil {
+		p.Description = tag.Text
+	}
+	return p
+}
+
+// returnOf returns the documented return of a function or plan. The declared return type of a function
+// takes precedence over the type of the '@return' tag.
+func returnOf(d parser.Definition, doc *parser.Doc) *Return {
+	r := &Return{}
+	if doc != nil {
+		if tag := doc.Return(); tag != nil {
+			r.Type = tag.Type
+			r.Description = tag.Text
+		}
+	}
+	if fd, ok := d.(*parser.FunctionDefinition); ok && fd.ReturnType() != nil {
+		r.Type = fd.ReturnType().String()
+	}
+	if r.Type == `` && r.Description == `` {
+		return nil
+	}
+	return r
+}
+
+func signatureOf(kind string, nd parser.NamedDefinition) string {
+	b := bytes.NewBufferString(``)
+	switch kind {
+	case DEFINED_TYPE:
+		b.WriteString(`define `)
+	default:
+		b.WriteString(kind)
+		b.WriteByte(' ')
+	}
+	b.WriteString(nd.Name())
+	b.WriteByte('(')
+	for i, p := range nd.Parameters() {
+		if i > 0 {
+			b.WriteString(`, `)
+		}
+		param := p.(*parser.Parameter)
+		if param.Type() != nil {
+			b.WriteString(param.Type().String())
+			b.WriteByte(' ')
+		}
+		if param.CapturesRest() {
+			b.WriteByte('*')
+		}
+		b.WriteByte('$')
+		b.WriteString(param.Name())
+		if param.Value() != nil {
+			b.WriteString(` = `)
+			b.WriteString(param.Value().String())
+		}
+	}
+	b.WriteByte(')')
+	if fd, ok := nd.(*parser.FunctionDefinition); ok && fd.ReturnType() != nil {
+		b.WriteString(` >> `)
+		b.WriteString(fd.ReturnType().String())
+	}
+	return b.String()
+}
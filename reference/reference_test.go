@@ -0,0 +1,148 @@
+package reference
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+var source = issue.Unindent(`
+  # @summary Manages foo
+  #
+  # @example Basic usage
+  #   include foo
+  #
+  # @param port The port
+  #   to listen on.
+  # @param [String] name The name
+  class foo(Integer $port = 80, $name = 'x') {
+  }
+
+  type Foo::Port = Integer[1, 65535]
+
+  # @return [String] the result
+  function foo::bar(String $x, *$rest) { $x }
+
+  define foo::baz() {
+  }`)
+
+func TestCollect(t *testing.T) {
+	defs := collect(t, source)
+	if len(defs) != 4 {
+		t.Fatalf(`expected four definitions, got %d`, len(defs))
+	}
+
+	c := defs[0]
+	if c.Kind != CLASS || c.Name != `foo` || c.Summary != `Manages foo` {
+		t.Errorf(`unexpected class %s '%s' with summary '%s'`, c.Kind, c.Name, c.Summary)
+	}
+	if c.Signature != `class foo(Integer $port = 80, $name = 'x')` {
+		t.Errorf(`unexpected signature '%s'`, c.Signature)
+	}
+	if len(c.Parameters) != 2 {
+		t.Fatalf(`expected two parameters, got %d`, len(c.Parameters))
+	}
+	expectParameter(t, c.Parameters[0], `port`, `Integer`, `80`, `The port to listen on.`)
+	expectParameter(t, c.Parameters[1], `name`, `String`, `'x'`, `The name`)
+	if len(c.Examples) != 1 || c.Examples[0].Title != `Basic usage` || c.Examples[0].Code != `include foo` {
+		t.Errorf(`unexpected examples %v`, c.Examples)
+	}
+
+	if ta := defs[1]; ta.Kind != TYPE_ALIAS || ta.AliasOf != `Integer[1, 65535]` {
+		t.Errorf(`unexpected type alias %s aliasing '%s'`, ta.Kind, ta.AliasOf)
+	}
+
+	f := defs[2]
+	if f.Signature != `function foo::bar(String $x, *$rest)` {
+		t.Errorf(`unexpected signature '%s'`, f.Signature)
+	}
+	if f.Return == nil || f.Return.Type != `String` || f.Return.Description != `the result` {
+		t.Errorf(`unexpected return %v`, f.Return)
+	}
+	expectParameter(t, f.Parameters[1], `rest`, `Any`, ``, ``)
+
+	if d := defs[3]; d.Kind != DEFINED_TYPE || d.Signature != `define foo::baz()` {
+		t.Errorf(`unexpected defined type %s with signature '%s'`, d.Kind, d.Signature)
+	}
+}
+
+func TestDeclaredReturnType(t *testing.T) {
+	defs := collect(t, "# @return [Integer] the count\nfunction foo() >> String { 'a' }")
+	if r := defs[0].Return; r == nil || r.Type != `String` || r.Description != `the count` {
+		t.Errorf(`unexpected return %v`, r)
+	}
+	if s := defs[0].Signature; s != `function foo() >> String` {
+		t.Errorf(`unexpected signature '%s'`, s)
+	}
+}
+
+func TestSort(t *testing.T) {
+	defs := collect(t, source)
+	Sort(defs)
+	names := make([]string, len(defs))
+	for i, d := range defs {
+		names[i] = d.Name
+	}
+	if actual := strings.Join(names, `, `); actual != `foo, foo::baz, foo::bar, Foo::Port` {
+		t.Errorf(`unexpected order %s`, actual)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	defs := collect(t, source)
+	Sort(defs)
+	b := bytes.NewBufferString(``)
+	if err := Write(b, MARKDOWN, defs); err != nil {
+		t.Fatal(err)
+	}
+	md := b.String()
+	for _, expected := range []string{
+		"### Classes\n\n* [`foo`](#foo): Manages foo\n",
+		"### <a name=\"foo--bar\"></a>`foo::bar`\n",
+		"```puppet\nclass foo(Integer $port = 80, $name = 'x')\n```\n",
+		"##### `port`\n\nData type: `Integer`\n\nThe port to listen on.\n\nDefault value: `80`\n",
+		"Returns: `String` the result\n",
+		"Alias of `Integer[1, 65535]`\n",
+		"## Data types\n",
+	} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("expected markdown to contain %q, got\n%s", expected, md)
+		}
+	}
+	if strings.Contains(md, `## Plans`) {
+		t.Error(`expected no section for plans`)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	if err := Write(b, JSON, collect(t, `type Foo = String`)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"kind":"type_alias","name":"Foo","file":"test.pp","line":1,"signature":"type Foo = String","alias_of":"String"}]` + "\n"
+	if b.String() != expected {
+		t.Errorf("expected %s, got %s", expected, b.String())
+	}
+	if err := Write(b, Format(`html`), nil); err == nil {
+		t.Error(`expected error for unknown format`)
+	}
+}
+
+func collect(t *testing.T, str string) []*Definition {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`test.pp`, str, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Collect(expr.(*parser.Program))
+}
+
+func expectParameter(t *testing.T, p *Parameter, name, typ, dflt, description string) {
+	t.Helper()
+	if p.Name != name || p.Type != typ || p.Default != dflt || p.Description != description {
+		t.Errorf(`unexpected parameter %s %s = %s: '%s'`, p.Type, p.Name, p.Default, p.Description)
+	}
+}
@@ -0,0 +1,161 @@
+package reference
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/json"
+)
+
+// Format is the name of an output format for a reference
+type Format string
+
+const (
+	MARKDOWN = Format(`markdown`)
+	JSON     = Format(`json`)
+)
+
+// Section titles for each kind of definition
+var sectionTitles = map[string]string{
+	CLASS:        `Classes`,
+	DEFINED_TYPE: `Defined types`,
+	FUNCTION:     `Functions`,
+	TYPE_ALIAS:   `Data types`,
+	PLAN:         `Plans`,
+}
+
+// Write writes the reference in the given format
+func Write(w io.Writer, format Format, definitions []*Definition) error {
+	switch format {
+	case MARKDOWN:
+		return WriteMarkdown(w, definitions)
+	case JSON:
+		return WriteJSON(w, definitions)
+	default:
+		return fmt.Errorf(`unknown reference format '%s'. Expected '%s' or '%s'`, format, MARKDOWN, JSON)
+	}
+}
+
+// WriteJSON writes the reference as a JSON array with one object per definition
+func WriteJSON(w io.Writer, definitions []*Definition) error {
+	if definitions == nil {
+		definitions = []*Definition{}
+	}
+	json.ToJson(definitions, w)
+	return nil
+}
+
+// WriteMarkdown writes the reference as a Markdown document with a table of contents followed by one
+// section for each kind of definition. The definitions are written in the given order within each section.
+func WriteMarkdown(w io.Writer, definitions []*Definition) error {
+	mw := &markdownWriter{w: w}
+	mw.printf("# Reference\n\n## Table of Contents\n")
+	for _, kind := range KINDS {
+		defs := ofKind(definitions, kind)
+		if len(defs) == 0 {
+			continue
+		}
+		mw.printf("\n### %s\n\n", sectionTitles[kind])
+		for _, d := range defs {
+			mw.printf("* [`%s`](#%s)", d.Name, anchor(d.Name))
+			if d.Summary != `` {
+				mw.printf(": %s", d.Summary)
+			}
+			mw.printf("\n")
+		}
+	}
+
+	for _, kind := range KINDS {
+		defs := ofKind(definitions, kind)
+		if len(defs) == 0 {
+			continue
+		}
+		mw.printf("\n## %s\n", sectionTitles[kind])
+		for _, d := range defs {
+			mw.writeDefinition(d)
+		}
+	}
+	return mw.err
+}
+
+type markdownWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (mw *markdownWriter) printf(format string, args ...interface{}) {
+	if mw.err == nil {
+		_, mw.err = fmt.Fprintf(mw.w, format, args...)
+	}
+}
+
+func (mw *markdownWriter) writeDefinition(d *Definition) {
+	mw.printf("\n### <a name=\"%s\"></a>`%s`\n\n", anchor(d.Name), d.Name)
+	if d.Summary != `` {
+		mw.printf("%s\n\n", d.Summary)
+	}
+	if d.Description != `` {
+		mw.printf("%s\n\n", d.Description)
+	}
+	if d.Kind == TYPE_ALIAS {
+		mw.printf("Alias of `%s`\n", d.AliasOf)
+	} else {
+		mw.printf("```puppet\n%s\n```\n", d.Signature)
+	}
+
+	if len(d.Examples) > 0 {
+		mw.printf("\n#### Examples\n")
+		for _, e := range d.Examples {
+			if e.Title != `` {
+				mw.printf("\n##### %s\n", e.Title)
+			}
+			mw.printf("\n```puppet\n%s\n```\n", e.Code)
+		}
+	}
+
+	if d.Return != nil {
+		mw.printf("\n#### Return\n\n")
+		if d.Return.Type != `` {
+			mw.printf("Returns: `%s`", d.Return.Type)
+			if d.Return.Description != `` {
+				mw.printf(" %s", d.Return.Description)
+			}
+		} else {
+			mw.printf("%s", d.Return.Description)
+		}
+		mw.printf("\n")
+	}
+
+	if len(d.Parameters) > 0 {
+		mw.printf("\n#### Parameters\n\nThe following parameters are available in the `%s` %s:\n", d.Name, kindLabel(d.Kind))
+		for _, p := range d.Parameters {
+			mw.printf("\n##### `%s`\n\nData type: `%s`\n", p.Name, p.Type)
+			if p.Description != `` {
+				mw.printf("\n%s\n", p.Description)
+			}
+			if p.Default != `` {
+				mw.printf("\nDefault value: `%s`\n", p.Default)
+			}
+		}
+	}
+}
+
+func ofKind(definitions []*Definition, kind string) []*Definition {
+	result := make([]*Definition, 0)
+	for _, d := range definitions {
+		if d.Kind == kind {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// anchor returns the name of the HTML anchor for a definition with the given name
+func anchor(name string) string {
+	return strings.Replace(strings.ToLower(name), `::`, `--`, -1)
+}
+
+func kindLabel(kind string) string {
+	return strings.Replace(kind, `_`, ` `, -1)
+}
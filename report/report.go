@@ -0,0 +1,99 @@
+// Package report writes parse and validation issues in formats understood by CI systems and code
+// scanning dashboards: SARIF 2.1, Checkstyle XML, and JUnit XML.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Format is the name of a report format
+type Format string
+
+const (
+	SARIF      = Format(`sarif`)
+	CHECKSTYLE = Format(`checkstyle`)
+	JUNIT      = Format(`junit`)
+)
+
+// Region is the precise location of an issue. Lines and columns are 1 based. The end position and the
+// byte range are only known when the location of the issue can be mapped back to the source.
+type Region struct {
+	File       string
+	Line       int
+	Column     int
+	EndLine    int
+	EndColumn  int
+	ByteOffset int
+	ByteLength int
+	HasRange   bool
+}
+
+// Write writes a report in the given format. The files are the names of all files that were checked,
+// including those that have no issues.
+func Write(w io.Writer, format Format, files []string, issues []issue.Reported) error {
+	switch format {
+	case SARIF:
+		return WriteSARIF(w, issues)
+	case CHECKSTYLE:
+		return WriteCheckstyle(w, files, issues)
+	case JUNIT:
+		return WriteJUnit(w, files, issues)
+	default:
+		return fmt.Errorf(`unknown report format '%s'. Expected one of '%s', '%s', or '%s'`, format, SARIF, CHECKSTYLE, JUNIT)
+	}
+}
+
+// RegionOf returns the region of the given issue
+func RegionOf(reported issue.Reported) Region {
+	loc := reported.Location()
+	if loc == nil {
+		return Region{}
+	}
+	r := Region{File: loc.File(), Line: loc.Line(), Column: loc.Pos()}
+	if sl, ok := loc.(parser.SourceLocation); ok && sl.Locator() != nil {
+		locator := sl.Locator()
+		end := sl.ByteOffset() + sl.ByteLength()
+		r.EndLine = locator.LineForOffset(end)
+		r.EndColumn = locator.PosOnLine(end)
+		r.ByteOffset = sl.ByteOffset()
+		r.ByteLength = sl.ByteLength()
+		r.HasRange = true
+	}
+	return r
+}
+
+// issuesByFile groups the issues by file. The returned file names contain the given files in order
+// followed by any other files mentioned by the issues, sorted by name.
+func issuesByFile(files []string, issues []issue.Reported) ([]string, map[string][]issue.Reported) {
+	byFile := make(map[string][]issue.Reported, len(files))
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		if _, ok := byFile[f]; !ok {
+			byFile[f] = nil
+			names = append(names, f)
+		}
+	}
+	extra := make([]string, 0)
+	for _, i := range issues {
+		f := RegionOf(i).File
+		if _, ok := byFile[f]; !ok {
+			extra = append(extra, f)
+		}
+		byFile[f] = append(byFile[f], i)
+	}
+	sort.Strings(extra)
+	return append(names, extra...), byFile
+}
+
+// ruleDescription returns the message format registered for the issue code
+func ruleDescription(code issue.Code) string {
+	if i, ok := issue.IssueForCode2(code); ok {
+		return i.MessageFormat()
+	}
+	return string(code)
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/validator"
+)
+
+func validate(t *testing.T, file, source string) []issue.Reported {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(file, source, false)
+	if err != nil {
+		return []issue.Reported{err.(issue.Reported)}
+	}
+	return validator.ValidatePuppet(expr, validator.STRICT_ERROR).Issues()
+}
+
+func TestRegionOf(t *testing.T) {
+	issues := validate(t, `a.pp`, "$x = 1\n$y = split('a')\n")
+	if len(issues) != 1 {
+		t.Fatalf(`expected one issue, got %v`, issues)
+	}
+	r := RegionOf(issues[0])
+	expected := Region{File: `a.pp`, Line: 2, Column: 6, EndLine: 2, EndColumn: 16, ByteOffset: 12, ByteLength: 10, HasRange: true}
+	if r != expected {
+		t.Errorf(`expected %v, got %v`, expected, r)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	issues := validate(t, `a.pp`, `$y = split('a')`)
+	b := bytes.NewBufferString(``)
+	if err := Write(b, SARIF, []string{`a.pp`}, issues); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						Id               string
+						ShortDescription struct{ Text string }
+					}
+				}
+			}
+			Results []struct {
+				RuleId    string
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ Uri string }
+						Region           struct{ StartLine, StartColumn, EndColumn int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(b.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != `2.1.0` || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf(`unexpected log %s`, b.String())
+	}
+	rules := log.Runs[0].Tool.Driver.Rules
+	if len(rules) != 1 || rules[0].Id != validator.VALIDATE_WRONG_ARGUMENT_COUNT || !strings.Contains(rules[0].ShortDescription.Text, `%{expected}`) {
+		t.Errorf(`unexpected rules %v`, rules)
+	}
+	result := log.Runs[0].Results[0]
+	if result.RuleId != validator.VALIDATE_WRONG_ARGUMENT_COUNT || result.Level != `error` {
+		t.Errorf(`unexpected result %v`, result)
+	}
+	pl := result.Locations[0].PhysicalLocation
+	if pl.ArtifactLocation.Uri != `a.pp` || pl.Region.StartLine != 1 || pl.Region.StartColumn != 6 || pl.Region.EndColumn != 16 {
+		t.Errorf(`unexpected location %v`, pl)
+	}
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	issues := validate(t, `a.pp`, `$x = 'unterminated`)
+	b := bytes.NewBufferString(``)
+	if err := Write(b, CHECKSTYLE, []string{`a.pp`, `b.pp`}, issues); err != nil {
+		t.Fatal(err)
+	}
+	var report checkstyleReport
+	if err := xml.Unmarshal(b.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Files) != 2 || report.Files[0].Name != `a.pp` || report.Files[1].Name != `b.pp` || len(report.Files[1].Errors) != 0 {
+		t.Fatalf(`unexpected report %s`, b.String())
+	}
+	e := report.Files[0].Errors
+	if len(e) != 1 || e[0].Source != `LEX_UNTERMINATED_STRING` || e[0].Severity != `error` || e[0].Line != 1 || e[0].Column != 6 {
+		t.Errorf(`unexpected errors %v`, e)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	issues := validate(t, `a.pp`, "validate_string($x)\n$y = split('a')")
+	b := bytes.NewBufferString(``)
+	if err := Write(b, JUNIT, []string{`a.pp`, `b.pp`}, issues); err != nil {
+		t.Fatal(err)
+	}
+	var report junitReport
+	if err := xml.Unmarshal(b.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Tests != 3 || report.Failures != 1 || len(report.Suites) != 2 {
+		t.Fatalf(`unexpected report %s`, b.String())
+	}
+	cases := report.Suites[0].Cases
+	if cases[0].Failure != nil || !strings.HasPrefix(cases[0].SystemOut, `warning: The function 'validate_string' is deprecated`) {
+		t.Errorf(`unexpected test case %v`, cases[0])
+	}
+	if cases[1].Failure == nil || cases[1].Failure.Type != validator.VALIDATE_WRONG_ARGUMENT_COUNT {
+		t.Errorf(`unexpected test case %v`, cases[1])
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if err := Write(bytes.NewBufferString(``), Format(`html`), nil, nil); err == nil {
+		t.Error(`expected error for unknown format`)
+	}
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/json"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+const (
+	sarifSchema  = `https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0.json`
+	sarifVersion = `2.1.0`
+	toolName     = `puppet-parser`
+	toolURI      = `https://github.com/lyraproj/puppet-parser`
+)
+
+// WriteSARIF writes the issues as a SARIF 2.1 log with one run. Each issue code that occurs in the issues
+// is described as a rule in the tool driver.
+func WriteSARIF(w io.Writer, issues []issue.Reported) error {
+	codes := make([]string, 0)
+	ruleIndex := make(map[issue.Code]int)
+	for _, i := range issues {
+		if _, ok := ruleIndex[i.Code()]; !ok {
+			ruleIndex[i.Code()] = 0
+			codes = append(codes, string(i.Code()))
+		}
+	}
+	sort.Strings(codes)
+
+	rules := make([]interface{}, len(codes))
+	for idx, c := range codes {
+		code := issue.Code(c)
+		ruleIndex[code] = idx
+		demotable := false
+		if i, ok := issue.IssueForCode2(code); ok {
+			demotable = i.IsDemotable()
+		}
+		rules[idx] = map[string]interface{}{
+			`id`:               c,
+			`shortDescription`: map[string]interface{}{`text`: ruleDescription(code)},
+			`properties`:       map[string]interface{}{`demotable`: demotable},
+		}
+	}
+
+	results := make([]interface{}, len(issues))
+	for idx, i := range issues {
+		results[idx] = map[string]interface{}{
+			`ruleId`:    string(i.Code()),
+			`ruleIndex`: ruleIndex[i.Code()],
+			`level`:     sarifLevel(i.Severity()),
+			`message`:   map[string]interface{}{`text`: parser.IssueMessage(i)},
+			`locations`: sarifLocations(RegionOf(i)),
+		}
+	}
+
+	json.ToJson(map[string]interface{}{
+		`$schema`: sarifSchema,
+		`version`: sarifVersion,
+		`runs`: []interface{}{map[string]interface{}{
+			`tool`: map[string]interface{}{`driver`: map[string]interface{}{
+				`name`:           toolName,
+				`informationUri`: toolURI,
+				`rules`:          rules,
+			}},
+			`columnKind`: `unicodeCodePoints`,
+			`results`:    results,
+		}},
+	}, w)
+	return nil
+}
+
+func sarifLevel(severity issue.Severity) string {
+	switch severity {
+	case issue.SEVERITY_ERROR:
+		return `error`
+	case issue.SEVERITY_WARNING, issue.SEVERITY_DEPRECATION:
+		return `warning`
+	default:
+		return `note`
+	}
+}
+
+func sarifLocations(r Region) []interface{} {
+	if r.File == `` && r.Line <= 0 {
+		return []interface{}{}
+	}
+	region := map[string]interface{}{}
+	if r.Line > 0 {
+		region[`startLine`] = r.Line
+		region[`startColumn`] = r.Column
+	}
+	if r.HasRange {
+		region[`endLine`] = r.EndLine
+		region[`endColumn`] = r.EndColumn
+		region[`byteOffset`] = r.ByteOffset
+		region[`byteLength`] = r.ByteLength
+	}
+	return []interface{}{map[string]interface{}{
+		`physicalLocation`: map[string]interface{}{
+			`artifactLocation`: map[string]interface{}{`uri`: filepath.ToSlash(r.File)},
+			`region`:           region,
+		},
+	}}
+}
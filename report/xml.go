@@ -0,0 +1,139 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type (
+	checkstyleReport struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}
+
+	checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+
+	checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr,omitempty"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+
+	junitReport struct {
+		XMLName  xml.Name     `xml:"testsuites"`
+		Name     string       `xml:"name,attr"`
+		Tests    int          `xml:"tests,attr"`
+		Failures int          `xml:"failures,attr"`
+		Suites   []junitSuite `xml:"testsuite"`
+	}
+
+	junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Cases    []junitCase `xml:"testcase"`
+	}
+
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// WriteCheckstyle writes the issues in Checkstyle XML format. The code of an issue is used as the source
+// of the checkstyle error.
+func WriteCheckstyle(w io.Writer, files []string, issues []issue.Reported) error {
+	names, byFile := issuesByFile(files, issues)
+	report := checkstyleReport{Version: `8.0`, Files: make([]checkstyleFile, len(names))}
+	for idx, name := range names {
+		fileIssues := byFile[name]
+		errors := make([]checkstyleError, len(fileIssues))
+		for ei, i := range fileIssues {
+			r := RegionOf(i)
+			errors[ei] = checkstyleError{
+				Line:     r.Line,
+				Column:   r.Column,
+				Severity: checkstyleSeverity(i.Severity()),
+				Message:  parser.IssueMessage(i),
+				Source:   string(i.Code())}
+		}
+		report.Files[idx] = checkstyleFile{Name: name, Errors: errors}
+	}
+	return writeXML(w, report)
+}
+
+// WriteJUnit writes the issues in JUnit XML format. Each file becomes a test suite. A file without issues
+// has one passing test case. Each issue becomes a test case of its own which fails when the issue is an
+// error. Warnings and deprecations are reported as passing test cases with the message as output.
+func WriteJUnit(w io.Writer, files []string, issues []issue.Reported) error {
+	names, byFile := issuesByFile(files, issues)
+	report := junitReport{Name: toolName, Suites: make([]junitSuite, len(names))}
+	for idx, name := range names {
+		fileIssues := byFile[name]
+		suite := junitSuite{Name: name}
+		if len(fileIssues) == 0 {
+			suite.Cases = []junitCase{{Name: `validate`, ClassName: name}}
+		} else {
+			suite.Cases = make([]junitCase, len(fileIssues))
+			for ci, i := range fileIssues {
+				r := RegionOf(i)
+				msg := parser.IssueMessage(i)
+				tc := junitCase{Name: fmt.Sprintf(`%s at line %d, column %d`, i.Code(), r.Line, r.Column), ClassName: name}
+				if i.Severity() == issue.SEVERITY_ERROR {
+					tc.Failure = &junitFailure{Message: msg, Type: string(i.Code()), Text: i.Error()}
+					suite.Failures++
+				} else {
+					tc.SystemOut = fmt.Sprintf(`%s: %s`, i.Severity(), i.Error())
+				}
+				suite.Cases[ci] = tc
+			}
+		}
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites[idx] = suite
+	}
+	return writeXML(w, report)
+}
+
+func checkstyleSeverity(severity issue.Severity) string {
+	switch severity {
+	case issue.SEVERITY_ERROR:
+		return `error`
+	case issue.SEVERITY_WARNING, issue.SEVERITY_DEPRECATION:
+		return `warning`
+	default:
+		return `info`
+	}
+}
+
+func writeXML(w io.Writer, value interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent(``, `  `)
+	if err := enc.Encode(value); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
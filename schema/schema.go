@@ -0,0 +1,351 @@
+// Package schema derives JSON Schemas from Puppet type expressions and validates YAML data against them.
+package schema
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/types"
+)
+
+// The JSON Schema dialect of the produced schemas
+const DIALECT = `https://json-schema.org/draft/2020-12/schema`
+
+type (
+	// Schema is a JSON Schema. Only the keywords that are needed to describe Puppet types are present.
+	Schema struct {
+		Dialect     string `json:"$schema,omitempty"`
+		Title       string `json:"title,omitempty"`
+		Description string `json:"description,omitempty"`
+
+		// Type is one of null, boolean, integer, number, string, array, or object
+		Type string `json:"type,omitempty"`
+
+		Enum      []interface{} `json:"enum,omitempty"`
+		Pattern   string        `json:"pattern,omitempty"`
+		MinLength *int64        `json:"minLength,omitempty"`
+		MaxLength *int64        `json:"maxLength,omitempty"`
+		Minimum   *float64      `json:"minimum,omitempty"`
+		Maximum   *float64      `json:"maximum,omitempty"`
+
+		PrefixItems []*Schema `json:"prefixItems,omitempty"`
+		Items       *Schema   `json:"items,omitempty"`
+		MinItems    *int64    `json:"minItems,omitempty"`
+		MaxItems    *int64    `json:"maxItems,omitempty"`
+
+		Properties    map[string]*Schema `json:"properties,omitempty"`
+		Required      []string           `json:"required,omitempty"`
+		PropertyNames *Schema            `json:"propertyNames,omitempty"`
+
+		// AdditionalProperties is either a *Schema or false
+		AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+		MinProperties        *int64      `json:"minProperties,omitempty"`
+		MaxProperties        *int64      `json:"maxProperties,omitempty"`
+
+		AnyOf []*Schema `json:"anyOf,omitempty"`
+
+		Default interface{} `json:"default,omitempty"`
+	}
+)
+
+// ClassSchemas returns the schemas of all classes in the given programs keyed by class name
+func ClassSchemas(programs ...*parser.Program) map[string]*Schema {
+	aliases := types.AliasesOf(programs...)
+	schemas := make(map[string]*Schema)
+	for _, program := range programs {
+		for _, d := range program.Definitions() {
+			if hc, ok := d.(*parser.HostClassDefinition); ok {
+				schemas[hc.Name()] = ClassSchema(hc, aliases)
+			}
+		}
+	}
+	return schemas
+}
+
+// ClassSchema returns the schema of an object that contains the parameters of the given class. A
+// parameter is required when it has no default value and its type does not accept undef.
+func ClassSchema(hc *parser.HostClassDefinition, aliases types.Aliases) *Schema {
+	s := &Schema{
+		Dialect:              DIALECT,
+		Title:                hc.Name(),
+		Type:                 `object`,
+		Properties:           make(map[string]*Schema, len(hc.Parameters())),
+		AdditionalProperties: false}
+
+	doc := hc.Doc()
+	if doc != nil {
+		s.Description = doc.Summary()
+		if s.Description == `` {
+			s.Description = doc.Description
+		}
+	}
+	for _, p := range hc.Parameters() {
+		param := p.(*parser.Parameter)
+		ps := ParameterSchema(param, aliases)
+		if doc != nil {
+			if tag := doc.Param(param.Name()); tag != nil {
+				ps.Description = tag.Text
+			}
+		}
+		s.Properties[param.Name()] = ps
+		if param.Value() == nil && !ps.acceptsNull() {
+			s.Required = append(s.Required, param.Name())
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// ParameterSchema returns the schema of the given parameter. The default value of the parameter is
+// included when it is a literal.
+func ParameterSchema(param *parser.Parameter, aliases types.Aliases) *Schema {
+	s := &Schema{}
+	if param.Type() != nil {
+		s = FromType(param.Type(), aliases)
+	}
+	if param.Value() != nil {
+		if value, ok := literal.ToLiteral(param.Value()); ok {
+			if value, ok = jsonValue(value); ok && value != nil {
+				s.Default = value
+			}
+		}
+	}
+	return s
+}
+
+// FromType returns the schema that corresponds to the given Puppet type expression. Type aliases are
+// resolved using the given aliases. Types that cannot be expressed, unknown types, and invalid type
+// expressions yield an empty schema which accepts all values.
+func FromType(t parser.Expression, aliases types.Aliases) *Schema {
+	typ, err := types.FromExpression(t, aliases)
+	if err != nil {
+		return &Schema{}
+	}
+	return ForType(typ)
+}
+
+// ForType returns the schema that corresponds to the given type. A recursive type alias yields an empty
+// schema where it refers to itself.
+func ForType(t types.Type) *Schema {
+	c := &converter{resolving: make(map[*types.AliasType]bool)}
+	return c.convert(t)
+}
+
+type converter struct {
+	resolving map[*types.AliasType]bool
+}
+
+func (c *converter) convert(t types.Type) *Schema {
+	switch t.(type) {
+	case *types.UndefType:
+		return &Schema{Type: `null`}
+	case *types.BooleanType:
+		return &Schema{Type: `boolean`}
+	case *types.StringType:
+		st := t.(*types.StringType)
+		s := &Schema{Type: `string`}
+		s.MinLength, s.MaxLength = sizeRange(st.Min, st.Max)
+		return s
+	case *types.IntegerType:
+		it := t.(*types.IntegerType)
+		s := &Schema{Type: `integer`}
+		if it.Min != math.MinInt64 {
+			s.Minimum = float(float64(it.Min))
+		}
+		if it.Max != math.MaxInt64 {
+			s.Maximum = float(float64(it.Max))
+		}
+		return s
+	case *types.FloatType:
+		ft := t.(*types.FloatType)
+		s := &Schema{Type: `number`}
+		if !math.IsInf(ft.Min, -1) {
+			s.Minimum = float(ft.Min)
+		}
+		if !math.IsInf(ft.Max, 1) {
+			s.Maximum = float(ft.Max)
+		}
+		return s
+	case *types.NumericType:
+		return &Schema{Type: `number`}
+	case *types.ScalarType, *types.ScalarDataType:
+		return &Schema{AnyOf: []*Schema{{Type: `string`}, {Type: `number`}, {Type: `boolean`}}}
+	case *types.RegexpType:
+		return &Schema{Type: `string`}
+	case *types.EnumType:
+		et := t.(*types.EnumType)
+		s := &Schema{Type: `string`}
+		if !et.CaseInsensitive {
+			s.Enum = make([]interface{}, len(et.Values))
+			for i, v := range et.Values {
+				s.Enum[i] = v
+			}
+		}
+		return s
+	case *types.PatternType:
+		patterns := t.(*types.PatternType).Patterns
+		alternatives := make([]*Schema, len(patterns))
+		for i, p := range patterns {
+			alternatives[i] = &Schema{Type: `string`, Pattern: p}
+		}
+		return anyOf(alternatives)
+	case *types.OptionalType:
+		if ot := t.(*types.OptionalType); ot.Type != nil {
+			return anyOf([]*Schema{c.convert(ot.Type), {Type: `null`}})
+		}
+	case *types.NotUndefType:
+		if nt := t.(*types.NotUndefType); nt.Type != nil {
+			return c.convert(nt.Type)
+		}
+	case *types.SensitiveType:
+		if st := t.(*types.SensitiveType); st.Type != nil {
+			return c.convert(st.Type)
+		}
+	case *types.VariantType:
+		variants := t.(*types.VariantType).Types
+		alternatives := make([]*Schema, len(variants))
+		for i, v := range variants {
+			alternatives[i] = c.convert(v)
+		}
+		return anyOf(alternatives)
+	case *types.ArrayType:
+		at := t.(*types.ArrayType)
+		s := &Schema{Type: `array`}
+		if at.Element != types.Any {
+			s.Items = c.convert(at.Element)
+		}
+		s.MinItems, s.MaxItems = sizeRange(at.Min, at.Max)
+		return s
+	case *types.TupleType:
+		tt := t.(*types.TupleType)
+		s := &Schema{Type: `array`, PrefixItems: make([]*Schema, len(tt.Types))}
+		for i, e := range tt.Types {
+			s.PrefixItems[i] = c.convert(e)
+		}
+		s.MinItems, s.MaxItems = sizeRange(tt.Min, tt.Max)
+		return s
+	case *types.HashType:
+		ht := t.(*types.HashType)
+		s := &Schema{Type: `object`}
+		if ht.Key != types.Any || ht.Value != types.Any {
+			if key := c.convert(ht.Key); !key.isEmpty() && !(key.Type == `string` && key.isPlain()) {
+				s.PropertyNames = key
+			}
+			s.AdditionalProperties = c.convert(ht.Value)
+		}
+		s.MinProperties, s.MaxProperties = sizeRange(ht.Min, ht.Max)
+		return s
+	case *types.StructType:
+		return c.structSchema(t.(*types.StructType))
+	case *types.CollectionType:
+		return &Schema{AnyOf: []*Schema{{Type: `array`}, {Type: `object`}}}
+	case *types.AliasType:
+		at := t.(*types.AliasType)
+		if at.Type != nil && !c.resolving[at] {
+			c.resolving[at] = true
+			defer delete(c.resolving, at)
+			return c.convert(at.Type)
+		}
+	}
+	return &Schema{}
+}
+
+// structSchema returns the schema of a Struct. Keys declared as Optional['key'] are not required, and
+// neither are keys whose value type accepts undef.
+func (c *converter) structSchema(st *types.StructType) *Schema {
+	s := &Schema{Type: `object`, Properties: make(map[string]*Schema, len(st.Elements)), AdditionalProperties: false}
+	for _, e := range st.Elements {
+		s.Properties[e.Name] = c.convert(e.Type)
+		if e.IsRequired() {
+			s.Required = append(s.Required, e.Name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+func anyOf(alternatives []*Schema) *Schema {
+	for _, a := range alternatives {
+		if a.isEmpty() {
+			return &Schema{}
+		}
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return &Schema{AnyOf: alternatives}
+}
+
+// isEmpty returns true if the schema accepts all values
+func (s *Schema) isEmpty() bool {
+	return s.Type == `` && len(s.AnyOf) == 0 && len(s.Enum) == 0
+}
+
+// isPlain returns true if the schema has no constraints other than its type
+func (s *Schema) isPlain() bool {
+	return len(s.Enum) == 0 && s.Pattern == `` && s.MinLength == nil && s.MaxLength == nil
+}
+
+func (s *Schema) acceptsNull() bool {
+	if s.isEmpty() || s.Type == `null` {
+		return true
+	}
+	for _, a := range s.AnyOf {
+		if a.acceptsNull() {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeRange returns the minimum and maximum of a size range, or nil for the limits that have their
+// default values
+func sizeRange(min, max int64) (*int64, *int64) {
+	var minp, maxp *int64
+	if min != 0 {
+		minp = &min
+	}
+	if max != math.MaxInt64 {
+		maxp = &max
+	}
+	return minp, maxp
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+// jsonValue converts a literal value into a value that can be represented in JSON
+func jsonValue(value interface{}) (interface{}, bool) {
+	switch value.(type) {
+	case nil, string, bool, int64, float64:
+		return value, true
+	case []interface{}:
+		values := value.([]interface{})
+		result := make([]interface{}, len(values))
+		for i, v := range values {
+			var ok bool
+			if result[i], ok = jsonValue(v); !ok {
+				return nil, false
+			}
+		}
+		return result, true
+	case map[interface{}]interface{}:
+		entries := value.(map[interface{}]interface{})
+		result := make(map[string]interface{}, len(entries))
+		for k, v := range entries {
+			key, ok := k.(string)
+			if !ok {
+				key = fmt.Sprint(k)
+			}
+			if result[key], ok = jsonValue(v); !ok {
+				return nil, false
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
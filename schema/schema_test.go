@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/json"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/types"
+	"github.com/lyraproj/puppet-parser/yaml"
+)
+
+func TestFromType(t *testing.T) {
+	for source, expected := range map[string]string{
+		`String`:                    `{"type":"string"}`,
+		`String[1, 10]`:             `{"type":"string","minLength":1,"maxLength":10}`,
+		`Integer[1,10]`:             `{"type":"integer","minimum":1,"maximum":10}`,
+		`Integer[default, -1]`:      `{"type":"integer","maximum":-1}`,
+		`Float[0.5]`:                `{"type":"number","minimum":0.5}`,
+		`Enum['a', b]`:              `{"type":"string","enum":["a","b"]}`,
+		`Pattern[/^x/]`:             `{"type":"string","pattern":"^x"}`,
+		`Optional[Boolean]`:         `{"anyOf":[{"type":"boolean"},{"type":"null"}]}`,
+		`Optional[Any]`:             `{}`,
+		`Array[String, 1]`:          `{"type":"array","items":{"type":"string"},"minItems":1}`,
+		`Tuple[String, Integer]`:    `{"type":"array","prefixItems":[{"type":"string"},{"type":"integer"}],"minItems":2,"maxItems":2}`,
+		`Hash[String, Integer]`:     `{"type":"object","additionalProperties":{"type":"integer"}}`,
+		`Hash[Enum[a, b], Integer]`: `{"type":"object","propertyNames":{"type":"string","enum":["a","b"]},"additionalProperties":{"type":"integer"}}`,
+		`Variant[String, Undef]`:    `{"anyOf":[{"type":"string"},{"type":"null"}]}`,
+		`Sensitive[String]`:         `{"type":"string"}`,
+		`Foo::Port`:                 `{"type":"integer","minimum":1,"maximum":65535}`,
+		`Foo::Recursive`:            `{"type":"array","items":{}}`,
+		`Stdlib::Unknown`:           `{}`,
+		`Struct[{a => String, Optional[b] => Integer, c => Optional[String]}]`: `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"integer"},"c":{"anyOf":[{"type":"string"},{"type":"null"}]}},"required":["a"],"additionalProperties":false}`,
+	} {
+		s := FromType(parseType(t, source), testAliases(t))
+		if actual := toJson(s); actual != expected {
+			t.Errorf(`expected %s for %s, got %s`, expected, source, actual)
+		}
+	}
+}
+
+func TestClassSchema(t *testing.T) {
+	program := parseProgram(t, issue.Unindent(`
+    # @summary Manages foo
+    # @param port The port
+    class foo(Integer[1] $port, Optional[String] $name, Array[String] $list = ['a'], $any = undef) {}`))
+	s := ClassSchemas(program)[`foo`]
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"foo","description":"Manages foo","type":"object",` +
+		`"properties":{"any":{},"list":{"type":"array","items":{"type":"string"},"default":["a"]},` +
+		`"name":{"anyOf":[{"type":"string"},{"type":"null"}]},"port":{"description":"The port","type":"integer","minimum":1}},` +
+		`"required":["port"],"additionalProperties":false}`
+	if actual := toJson(s); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	aliases := testAliases(t)
+	for _, tc := range []struct{ typ, value, reason string }{
+		{`Integer[1, 10]`, `5`, ``},
+		{`Integer[1, 10]`, `0`, `0 is less than the minimum 1`},
+		{`Integer`, `'5'`, `expected integer, got string`},
+		{`Float`, `5`, ``},
+		{`Enum[a, b]`, `c`, `expected one of 'a', 'b', got 'c'`},
+		{`Pattern[/^\d+$/]`, `x1`, `'x1' does not match /^\d+$/`},
+		{`String[2]`, `x`, `expected a string of at least 2 characters, got 1`},
+		{`Optional[Integer[1]]`, `~`, ``},
+		{`Optional[Integer[1]]`, `0`, `0 is less than the minimum 1`},
+		{`Variant[Integer, Boolean]`, `x`, `expected integer or boolean, got string`},
+		{`Array[Integer]`, `[1, x]`, `expected integer, got string`},
+		{`Tuple[String, Integer]`, `[a]`, `expected at least 2 items, got 1`},
+		{`Hash[String, Integer, 1]`, `{}`, `expected at least 1 entries, got 0`},
+		{`Struct[{a => Integer}]`, `{a: 1, b: 2}`, `unexpected key 'b'`},
+		{`Struct[{a => Integer}]`, `{}`, `missing required key 'a'`},
+		{`Foo::Port`, `70000`, `70000 is greater than the maximum 65535`},
+	} {
+		n, err := yaml.Parse(tc.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := FromType(parseType(t, tc.typ), aliases).Validate(n, nil)
+		reason := ``
+		if v != nil {
+			reason = v.Reason
+		}
+		if reason != tc.reason {
+			t.Errorf(`expected '%s' when validating %s against %s, got '%s'`, tc.reason, tc.value, tc.typ, reason)
+		}
+	}
+}
+
+func TestValidateUnknown(t *testing.T) {
+	n, err := yaml.Parse(`[1, '%{x}']`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := func(n *yaml.Node) bool {
+		str, ok := n.Value.(string)
+		return ok && str == `%{x}`
+	}
+	if v := FromType(parseType(t, `Array[Integer]`), nil).Validate(n, unknown); v != nil {
+		t.Errorf(`unexpected violation %s`, v.Reason)
+	}
+}
+
+func testAliases(t *testing.T) types.Aliases {
+	return types.AliasesOf(parseProgram(t, "type Foo::Port = Integer[1, 65535]\ntype Foo::Recursive = Array[Foo::Recursive]"))
+}
+
+func parseType(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	return parseProgram(t, `type X = `+source).Definitions()[0].(*parser.TypeAlias).Type()
+}
+
+func parseProgram(t *testing.T, source string) *parser.Program {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`test.pp`, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr.(*parser.Program)
+}
+
+func toJson(s *Schema) string {
+	b := bytes.NewBufferString(``)
+	json.ToJson(s, b)
+	return string(bytes.TrimSpace(b.Bytes()))
+}
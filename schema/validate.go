@@ -0,0 +1,265 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/puppet-parser/yaml"
+)
+
+// Violation describes why a node does not conform to a schema
+type Violation struct {
+	// Node is the node that does not conform. It is the validated node or a node nested within it
+	Node   *yaml.Node
+	Reason string
+}
+
+// Validate validates the given node against the schema and returns the first violation found, or nil
+// if the node conforms. Nodes for which the given unknown function returns true are assumed to
+// conform. The function is typically used for values that are computed, and it may be nil.
+func (s *Schema) Validate(n *yaml.Node, unknown func(*yaml.Node) bool) *Violation {
+	if unknown != nil && unknown(n) {
+		return nil
+	}
+
+	if len(s.AnyOf) > 0 {
+		if v := s.validateAnyOf(n, unknown); v != nil {
+			return v
+		}
+	}
+	if s.Type != `` && !matchesType(s.Type, n) {
+		return violation(n, `expected %s, got %s`, s.Type, typeName(n))
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, n) {
+		return violation(n, `expected one of %s, got %s`, enumString(s.Enum), valueString(n))
+	}
+
+	switch n.Kind {
+	case yaml.SCALAR:
+		return s.validateScalar(n)
+	case yaml.SEQUENCE:
+		return s.validateSequence(n, unknown)
+	default:
+		return s.validateMapping(n, unknown)
+	}
+}
+
+// validateAnyOf returns nil when one of the alternatives accepts the node. Otherwise, the violation of
+// the only alternative of the right type is returned so that the reason is as specific as possible.
+func (s *Schema) validateAnyOf(n *yaml.Node, unknown func(*yaml.Node) bool) *Violation {
+	var matching *Schema
+	count := 0
+	types := make([]string, 0, len(s.AnyOf))
+	for _, a := range s.AnyOf {
+		if a.Validate(n, unknown) == nil {
+			return nil
+		}
+		if a.Type == `` || matchesType(a.Type, n) {
+			matching = a
+			count++
+		}
+		if a.Type != `` {
+			types = append(types, a.Type)
+		}
+	}
+	if count == 1 {
+		return matching.Validate(n, unknown)
+	}
+	if len(types) == len(s.AnyOf) {
+		return violation(n, `expected %s, got %s`, alternatives(types), typeName(n))
+	}
+	return violation(n, `%s does not match any of the alternatives`, valueString(n))
+}
+
+func (s *Schema) validateScalar(n *yaml.Node) *Violation {
+	switch n.Value.(type) {
+	case string:
+		str := n.Value.(string)
+		length := int64(utf8.RuneCountInString(str))
+		if s.MinLength != nil && length < *s.MinLength {
+			return violation(n, `expected a string of at least %d characters, got %d`, *s.MinLength, length)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return violation(n, `expected a string of at most %d characters, got %d`, *s.MaxLength, length)
+		}
+		if s.Pattern != `` {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(str) {
+				return violation(n, `%s does not match /%s/`, valueString(n), s.Pattern)
+			}
+		}
+	case int64, float64:
+		f := toFloat(n.Value)
+		if s.Minimum != nil && f < *s.Minimum {
+			return violation(n, `%s is less than the minimum %v`, valueString(n), *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return violation(n, `%s is greater than the maximum %v`, valueString(n), *s.Maximum)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateSequence(n *yaml.Node, unknown func(*yaml.Node) bool) *Violation {
+	count := int64(len(n.Items))
+	if s.MinItems != nil && count < *s.MinItems {
+		return violation(n, `expected at least %d items, got %d`, *s.MinItems, count)
+	}
+	if s.MaxItems != nil && count > *s.MaxItems {
+		return violation(n, `expected at most %d items, got %d`, *s.MaxItems, count)
+	}
+	for i, item := range n.Items {
+		is := s.Items
+		if i < len(s.PrefixItems) {
+			is = s.PrefixItems[i]
+		}
+		if is != nil {
+			if v := is.Validate(item, unknown); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateMapping(n *yaml.Node, unknown func(*yaml.Node) bool) *Violation {
+	count := int64(len(n.Keys))
+	if s.MinProperties != nil && count < *s.MinProperties {
+		return violation(n, `expected at least %d entries, got %d`, *s.MinProperties, count)
+	}
+	if s.MaxProperties != nil && count > *s.MaxProperties {
+		return violation(n, `expected at most %d entries, got %d`, *s.MaxProperties, count)
+	}
+	for _, name := range s.Required {
+		if n.Get(name) == nil {
+			return violation(n, `missing required key '%s'`, name)
+		}
+	}
+	for i, key := range n.Keys {
+		if s.PropertyNames != nil {
+			if v := s.PropertyNames.Validate(key, unknown); v != nil {
+				return v
+			}
+		}
+		value := n.Values[i]
+		if ps, ok := s.Properties[fmt.Sprint(key.Value)]; ok {
+			if v := ps.Validate(value, unknown); v != nil {
+				return v
+			}
+			continue
+		}
+		switch s.AdditionalProperties.(type) {
+		case bool:
+			if !s.AdditionalProperties.(bool) {
+				return violation(key, `unexpected key %s`, valueString(key))
+			}
+		case *Schema:
+			if v := s.AdditionalProperties.(*Schema).Validate(value, unknown); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func violation(n *yaml.Node, format string, args ...interface{}) *Violation {
+	return &Violation{Node: n, Reason: fmt.Sprintf(format, args...)}
+}
+
+func matchesType(t string, n *yaml.Node) bool {
+	switch t {
+	case `array`:
+		return n.Kind == yaml.SEQUENCE
+	case `object`:
+		return n.Kind == yaml.MAPPING
+	case `integer`:
+		if f, ok := n.Value.(float64); ok {
+			return f == math.Trunc(f)
+		}
+	case `number`:
+		if _, ok := n.Value.(float64); ok {
+			return true
+		}
+		t = `integer`
+	}
+	return n.Kind == yaml.SCALAR && typeName(n) == t
+}
+
+// typeName returns the name of the JSON type of the node
+func typeName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SEQUENCE:
+		return `array`
+	case yaml.MAPPING:
+		return `object`
+	}
+	switch n.Value.(type) {
+	case nil:
+		return `null`
+	case bool:
+		return `boolean`
+	case int64:
+		return `integer`
+	case float64:
+		return `number`
+	default:
+		return `string`
+	}
+}
+
+func inEnum(enum []interface{}, n *yaml.Node) bool {
+	if n.Kind != yaml.SCALAR {
+		return false
+	}
+	for _, e := range enum {
+		if e == n.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func enumString(enum []interface{}) string {
+	strs := make([]string, len(enum))
+	for i, e := range enum {
+		strs[i] = fmt.Sprintf(`'%v'`, e)
+	}
+	return strings.Join(strs, `, `)
+}
+
+// valueString returns a short representation of the node for use in a reason
+func valueString(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SEQUENCE:
+		return `array`
+	case yaml.MAPPING:
+		return `object`
+	}
+	if str, ok := n.Value.(string); ok {
+		return `'` + str + `'`
+	}
+	if n.Value == nil {
+		return `null`
+	}
+	return fmt.Sprint(n.Value)
+}
+
+func alternatives(types []string) string {
+	switch len(types) {
+	case 1:
+		return types[0]
+	case 2:
+		return types[0] + ` or ` + types[1]
+	default:
+		return strings.Join(types[:len(types)-1], `, `) + `, or ` + types[len(types)-1]
+	}
+}
+
+func toFloat(value interface{}) float64 {
+	if i, ok := value.(int64); ok {
+		return float64(i)
+	}
+	return value.(float64)
+}
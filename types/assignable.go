@@ -0,0 +1,428 @@
+package types
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Names of built in types that are not rich data
+var notRichData = map[string]bool{
+	`callable`: true,
+	`iterable`: true,
+	`iterator`: true,
+	`runtime`:  true,
+}
+
+// Names of built in types that are scalars without being data
+var richScalars = map[string]bool{
+	`semver`:      true,
+	`semverrange`: true,
+	`timespan`:    true,
+	`timestamp`:   true,
+}
+
+// IsAssignable returns true if every value of type b is also a value of type a
+func IsAssignable(a, b Type) bool {
+	return (&assignability{make(map[[2]Type]bool)}).isAssignable(a, b)
+}
+
+// assignability keeps track of the pairs of aliases that are being compared so that recursive aliases
+// can be compared without endless recursion
+type assignability struct {
+	visited map[[2]Type]bool
+}
+
+func (c *assignability) isAssignable(a, b Type) bool {
+	_, aAlias := a.(*AliasType)
+	_, bAlias := b.(*AliasType)
+	if aAlias || bAlias {
+		key := [2]Type{a, b}
+		if c.visited[key] {
+			return true
+		}
+		c.visited[key] = true
+		return c.isAssignable(unalias(a), unalias(b))
+	}
+
+	switch b.(type) {
+	case *VariantType:
+		for _, t := range b.(*VariantType).Types {
+			if !c.isAssignable(a, t) {
+				return false
+			}
+		}
+		return true
+	case *OptionalType:
+		return c.isAssignable(a, Undef) && c.isAssignable(a, orAny(b.(*OptionalType).Type))
+	case *NotUndefType:
+		if _, ok := a.(*NotUndefType); !ok {
+			return c.isAssignable(a, orAny(b.(*NotUndefType).Type))
+		}
+	}
+
+	switch a.(type) {
+	case *AnyType:
+		return true
+	case *VariantType:
+		for _, t := range a.(*VariantType).Types {
+			if c.isAssignable(t, b) {
+				return true
+			}
+		}
+		return false
+	case *OptionalType:
+		if _, ok := b.(*UndefType); ok {
+			return true
+		}
+		return c.isAssignable(orAny(a.(*OptionalType).Type), b)
+	case *NotUndefType:
+		if nb, ok := b.(*NotUndefType); ok {
+			b = orAny(nb.Type)
+		} else if c.isAssignable(b, Undef) {
+			return false
+		}
+		return c.isAssignable(orAny(a.(*NotUndefType).Type), b)
+	case *UndefType:
+		_, ok := b.(*UndefType)
+		return ok
+	case *DefaultType:
+		_, ok := b.(*DefaultType)
+		return ok
+	case *BooleanType:
+		_, ok := b.(*BooleanType)
+		return ok
+	case *IntegerType:
+		ai := a.(*IntegerType)
+		bi, ok := b.(*IntegerType)
+		return ok && bi.Min >= ai.Min && bi.Max <= ai.Max
+	case *FloatType:
+		af := a.(*FloatType)
+		bf, ok := b.(*FloatType)
+		return ok && bf.Min >= af.Min && bf.Max <= af.Max
+	case *NumericType:
+		switch b.(type) {
+		case *IntegerType, *FloatType, *NumericType:
+			return true
+		}
+	case *StringType:
+		as := a.(*StringType)
+		switch b.(type) {
+		case *StringType:
+			bs := b.(*StringType)
+			return bs.Min >= as.Min && bs.Max <= as.Max
+		case *EnumType:
+			be := b.(*EnumType)
+			if len(be.Values) == 0 {
+				return as.Min == 0 && as.Max == math.MaxInt64
+			}
+			for _, v := range be.Values {
+				if l := int64(utf8.RuneCountInString(v)); l < as.Min || l > as.Max {
+					return false
+				}
+			}
+			return true
+		case *PatternType:
+			return as.Min == 0 && as.Max == math.MaxInt64
+		}
+	case *EnumType:
+		ae := a.(*EnumType)
+		if len(ae.Values) == 0 {
+			// An empty Enum accepts any string
+			return isStringType(b)
+		}
+		if be, ok := b.(*EnumType); ok {
+			if len(be.Values) == 0 || be.CaseInsensitive && !ae.CaseInsensitive {
+				return false
+			}
+			for _, v := range be.Values {
+				if !ae.contains(v) {
+					return false
+				}
+			}
+			return true
+		}
+	case *PatternType:
+		ap := a.(*PatternType)
+		if len(ap.Patterns) == 0 {
+			// An empty Pattern accepts any string
+			return isStringType(b)
+		}
+		switch b.(type) {
+		case *PatternType:
+			bp := b.(*PatternType)
+			if len(bp.Patterns) == 0 {
+				return false
+			}
+			for _, p := range bp.Patterns {
+				if !containsString(ap.Patterns, p) {
+					return false
+				}
+			}
+			return true
+		case *EnumType:
+			be := b.(*EnumType)
+			if len(be.Values) == 0 {
+				return false
+			}
+			for _, v := range be.Values {
+				if !ap.accepts(v) {
+					return false
+				}
+			}
+			return true
+		}
+	case *RegexpType:
+		br, ok := b.(*RegexpType)
+		return ok && (a.(*RegexpType).Pattern == `` || a.(*RegexpType).Pattern == br.Pattern)
+	case *ScalarDataType:
+		return isScalarData(b)
+	case *ScalarType:
+		switch b.(type) {
+		case *ScalarType, *RegexpType:
+			return true
+		case *ReferenceType:
+			return richScalars[strings.ToLower(b.(*ReferenceType).Name)]
+		}
+		return isScalarData(b)
+	case *DataType:
+		return c.isData(b)
+	case *RichDataType:
+		switch b.(type) {
+		case *AnyType:
+			return false
+		case *ReferenceType:
+			return !notRichData[strings.ToLower(b.(*ReferenceType).Name)]
+		}
+		return true
+	case *CollectionType:
+		if min, max, ok := sizeOf(b); ok {
+			ac := a.(*CollectionType)
+			return min >= ac.Min && max <= ac.Max
+		}
+	case *ArrayType:
+		aa := a.(*ArrayType)
+		switch b.(type) {
+		case *ArrayType:
+			ba := b.(*ArrayType)
+			return ba.Min >= aa.Min && ba.Max <= aa.Max && c.isAssignable(aa.Element, ba.Element)
+		case *TupleType:
+			bt := b.(*TupleType)
+			if bt.Min < aa.Min || bt.Max > aa.Max {
+				return false
+			}
+			for _, t := range bt.Types {
+				if !c.isAssignable(aa.Element, t) {
+					return false
+				}
+			}
+			return true
+		}
+	case *HashType:
+		ah := a.(*HashType)
+		switch b.(type) {
+		case *HashType:
+			bh := b.(*HashType)
+			return bh.Min >= ah.Min && bh.Max <= ah.Max && c.isAssignable(ah.Key, bh.Key) && c.isAssignable(ah.Value, bh.Value)
+		case *StructType:
+			bs := b.(*StructType)
+			if min, max, _ := sizeOf(bs); min < ah.Min || max > ah.Max {
+				return false
+			}
+			for _, e := range bs.Elements {
+				if !c.isAssignable(ah.Key, &EnumType{Values: []string{e.Name}}) || !c.isAssignable(ah.Value, e.Type) {
+					return false
+				}
+			}
+			return true
+		}
+	case *TupleType:
+		at := a.(*TupleType)
+		switch b.(type) {
+		case *TupleType:
+			bt := b.(*TupleType)
+			if bt.Min < at.Min || bt.Max > at.Max {
+				return false
+			}
+			count := len(at.Types)
+			if len(bt.Types) > count {
+				count = len(bt.Types)
+			}
+			for i := 0; i < count && int64(i) < bt.Max; i++ {
+				if !c.isAssignable(at.typeAt(i), bt.typeAt(i)) {
+					return false
+				}
+			}
+			return true
+		case *ArrayType:
+			ba := b.(*ArrayType)
+			if ba.Min < at.Min || ba.Max > at.Max {
+				return false
+			}
+			for _, t := range at.Types {
+				if !c.isAssignable(t, ba.Element) {
+					return false
+				}
+			}
+			return true
+		}
+	case *StructType:
+		if bs, ok := b.(*StructType); ok {
+			as := a.(*StructType)
+			for _, be := range bs.Elements {
+				ae := as.Get(be.Name)
+				if ae == nil || !c.isAssignable(ae.Type, be.Type) {
+					return false
+				}
+			}
+			for _, ae := range as.Elements {
+				if ae.IsRequired() {
+					if be := bs.Get(ae.Name); be == nil || !be.IsRequired() {
+						return false
+					}
+				}
+			}
+			return true
+		}
+	case *SensitiveType:
+		if bs, ok := b.(*SensitiveType); ok {
+			return c.isAssignable(orAny(a.(*SensitiveType).Type), orAny(bs.Type))
+		}
+	case *ReferenceType:
+		if br, ok := b.(*ReferenceType); ok {
+			ar := a.(*ReferenceType)
+			return strings.EqualFold(ar.Name, br.Name) && ar.Parameters == br.Parameters
+		}
+	}
+	return false
+}
+
+// isData returns true if all values of the given type are data, i.e. undef, scalar data, and arrays
+// and hashes with string keys that contain data
+func (c *assignability) isData(t Type) bool {
+	switch t.(type) {
+	case *AliasType:
+		return c.isAssignable(Data, t)
+	case *DataType, *UndefType:
+		return true
+	case *ArrayType:
+		return c.isData(t.(*ArrayType).Element)
+	case *HashType:
+		return c.isAssignable(NewString(), t.(*HashType).Key) && c.isData(t.(*HashType).Value)
+	case *TupleType:
+		for _, e := range t.(*TupleType).Types {
+			if !c.isData(e) {
+				return false
+			}
+		}
+		return true
+	case *StructType:
+		for _, e := range t.(*StructType).Elements {
+			if !c.isData(e.Type) {
+				return false
+			}
+		}
+		return true
+	case *OptionalType:
+		return c.isData(orAny(t.(*OptionalType).Type))
+	case *VariantType:
+		for _, e := range t.(*VariantType).Types {
+			if !c.isData(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return isScalarData(t)
+}
+
+func isScalarData(t Type) bool {
+	switch t.(type) {
+	case *IntegerType, *FloatType, *NumericType, *StringType, *EnumType, *PatternType, *BooleanType, *ScalarDataType:
+		return true
+	}
+	return false
+}
+
+// sizeOf returns the size range of a collection type
+func sizeOf(t Type) (min, max int64, ok bool) {
+	switch t.(type) {
+	case *CollectionType:
+		return t.(*CollectionType).Min, t.(*CollectionType).Max, true
+	case *ArrayType:
+		return t.(*ArrayType).Min, t.(*ArrayType).Max, true
+	case *HashType:
+		return t.(*HashType).Min, t.(*HashType).Max, true
+	case *TupleType:
+		return t.(*TupleType).Min, t.(*TupleType).Max, true
+	case *StructType:
+		for _, e := range t.(*StructType).Elements {
+			if e.IsRequired() {
+				min++
+			}
+		}
+		return min, int64(len(t.(*StructType).Elements)), true
+	}
+	return 0, 0, false
+}
+
+// contains returns true if the value is one of the values of the enum. An empty enum contains all
+// strings.
+func (t *EnumType) contains(value string) bool {
+	if len(t.Values) == 0 {
+		return true
+	}
+	for _, v := range t.Values {
+		if v == value || t.CaseInsensitive && strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStringType returns true if all instances of the given type are strings
+func isStringType(t Type) bool {
+	switch t.(type) {
+	case *StringType, *EnumType, *PatternType:
+		return true
+	}
+	return false
+}
+
+// typeAt returns the type of the element at the given index
+func (t *TupleType) typeAt(i int) Type {
+	switch {
+	case len(t.Types) == 0:
+		return Any
+	case i < len(t.Types):
+		return t.Types[i]
+	default:
+		return t.Types[len(t.Types)-1]
+	}
+}
+
+func unalias(t Type) Type {
+	if at, ok := t.(*AliasType); ok {
+		if at.Type == nil {
+			return Any
+		}
+		return at.Type
+	}
+	return t
+}
+
+func orAny(t Type) Type {
+	if t == nil {
+		return Any
+	}
+	return t
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
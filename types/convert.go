@@ -0,0 +1,370 @@
+package types
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Aliases maps the lower case names of type aliases to the aliased type expressions
+type Aliases map[string]parser.Expression
+
+// AliasesOf returns the type aliases that are declared in the given programs
+func AliasesOf(programs ...*parser.Program) Aliases {
+	aliases := make(Aliases)
+	for _, program := range programs {
+		for _, d := range program.Definitions() {
+			if ta, ok := d.(*parser.TypeAlias); ok {
+				aliases[strings.ToLower(ta.Name())] = ta.Type()
+			}
+		}
+	}
+	return aliases
+}
+
+// FromExpression converts the given type expression into a Type. Names that are found among the given
+// aliases become an AliasType and names that are neither aliases nor modeled built in types become a
+// ReferenceType. An issue.Reported error is returned when the expression is not a type or when a type
+// is given parameters of the wrong number or kind.
+func FromExpression(e parser.Expression, aliases Aliases) (t Type, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ri, ok := r.(issue.Reported); ok {
+				t = nil
+				err = ri
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	c := &converter{aliases: aliases, resolved: make(map[string]*AliasType)}
+	t = c.convert(e)
+	return
+}
+
+type converter struct {
+	aliases  Aliases
+	resolved map[string]*AliasType
+}
+
+func typeIssue(code issue.Code, e parser.Expression, args issue.H) issue.Reported {
+	return issue.NewReported(code, issue.SEVERITY_ERROR, args, e)
+}
+
+func (c *converter) convert(e parser.Expression) Type {
+	switch e.(type) {
+	case *parser.QualifiedReference:
+		return c.named(e, e.(*parser.QualifiedReference).Name(), nil)
+	case *parser.AccessExpression:
+		ae := e.(*parser.AccessExpression)
+		if qr, ok := ae.Operand().(*parser.QualifiedReference); ok {
+			return c.named(e, qr.Name(), ae.Keys())
+		}
+	}
+	panic(typeIssue(TYPE_NOT_A_TYPE, e, issue.H{`expression`: e}))
+}
+
+func (c *converter) named(e parser.Expression, name string, params []parser.Expression) Type {
+	switch name {
+	case `Any`, `Boolean`, `Data`, `Default`, `Numeric`, `RichData`, `Scalar`, `ScalarData`, `Undef`:
+		checkCount(e, name, params, 0, 0)
+		return map[string]Type{`Any`: Any, `Boolean`: Boolean, `Data`: Data, `Default`: Default, `Numeric`: Numeric,
+			`RichData`: RichData, `Scalar`: Scalar, `ScalarData`: ScalarData, `Undef`: Undef}[name]
+	case `Integer`:
+		checkCount(e, name, params, 0, 2)
+		t := NewInteger()
+		if len(params) > 0 {
+			t.Min = c.intParam(name, params[0], t.Min)
+		}
+		if len(params) > 1 {
+			t.Max = c.intParam(name, params[1], t.Max)
+		}
+		checkRange(e, name, t.Min > t.Max)
+		return t
+	case `Float`:
+		checkCount(e, name, params, 0, 2)
+		t := NewFloat()
+		if len(params) > 0 {
+			t.Min = c.floatParam(name, params[0], t.Min)
+		}
+		if len(params) > 1 {
+			t.Max = c.floatParam(name, params[1], t.Max)
+		}
+		checkRange(e, name, t.Min > t.Max)
+		return t
+	case `String`:
+		checkCount(e, name, params, 0, 2)
+		t := NewString()
+		t.Min, t.Max = c.sizeParams(e, name, params)
+		return t
+	case `Collection`:
+		checkCount(e, name, params, 0, 2)
+		t := &CollectionType{}
+		t.Min, t.Max = c.sizeParams(e, name, params)
+		return t
+	case `Enum`:
+		checkCount(e, name, params, 1, -1)
+		t := &EnumType{Values: make([]string, 0, len(params))}
+		for i, p := range params {
+			if lb, ok := p.(*parser.LiteralBoolean); ok && i == len(params)-1 && i > 0 {
+				t.CaseInsensitive = lb.Bool()
+				continue
+			}
+			t.Values = append(t.Values, c.stringParam(name, p))
+		}
+		return t
+	case `Pattern`:
+		checkCount(e, name, params, 1, -1)
+		t := &PatternType{Patterns: make([]string, len(params))}
+		for i, p := range params {
+			t.Patterns[i] = c.regexpParam(name, p)
+		}
+		return t
+	case `Regexp`:
+		checkCount(e, name, params, 0, 1)
+		t := &RegexpType{}
+		if len(params) > 0 {
+			t.Pattern = c.regexpParam(name, params[0])
+		}
+		return t
+	case `Array`:
+		checkCount(e, name, params, 0, 3)
+		t := NewArray(Any)
+		if len(params) > 0 && !isSize(params[0]) {
+			t.Element = c.convert(params[0])
+			params = params[1:]
+		}
+		t.Min, t.Max = c.sizeParams(e, name, params)
+		return t
+	case `Hash`:
+		checkCount(e, name, params, 0, 4)
+		t := NewHash(Any, Any)
+		if len(params) > 0 && !isSize(params[0]) {
+			if len(params) < 2 {
+				panic(typeIssue(TYPE_WRONG_PARAMETER_COUNT, e, issue.H{`type`: name, `expected`: `2 to 4`, `actual`: len(params)}))
+			}
+			t.Key = c.convert(params[0])
+			t.Value = c.convert(params[1])
+			params = params[2:]
+		}
+		t.Min, t.Max = c.sizeParams(e, name, params)
+		return t
+	case `Tuple`:
+		t := &TupleType{Types: make([]Type, 0, len(params))}
+		for len(params) > 0 && !isSize(params[0]) {
+			t.Types = append(t.Types, c.convert(params[0]))
+			params = params[1:]
+		}
+		if len(params) > 2 {
+			panic(typeIssue(TYPE_ILLEGAL_PARAMETER, params[2], issue.H{`type`: name, `expected`: `a type`}))
+		}
+		count := int64(len(t.Types))
+		t.Min, t.Max = count, count
+		if len(params) > 0 {
+			t.Min, t.Max = c.sizeParams(e, name, params)
+		}
+		return t
+	case `Struct`:
+		checkCount(e, name, params, 1, 1)
+		return c.structType(name, params[0])
+	case `Optional`, `NotUndef`, `Sensitive`:
+		checkCount(e, name, params, 0, 1)
+		var pt Type
+		if len(params) > 0 {
+			if str, ok := stringValue(params[0]); ok && name != `Sensitive` {
+				// Optional['x'] and NotUndef['x'] are shorthand for the Enum['x']
+				pt = &EnumType{Values: []string{str}}
+			} else {
+				pt = c.convert(params[0])
+			}
+		}
+		switch name {
+		case `Optional`:
+			return &OptionalType{pt}
+		case `NotUndef`:
+			return &NotUndefType{pt}
+		default:
+			return &SensitiveType{pt}
+		}
+	case `Variant`:
+		t := &VariantType{Types: make([]Type, len(params))}
+		for i, p := range params {
+			t.Types[i] = c.convert(p)
+		}
+		return t
+	}
+
+	key := strings.ToLower(name)
+	if at, ok := c.resolved[key]; ok {
+		checkCount(e, name, params, 0, 0)
+		return at
+	}
+	if ae, ok := c.aliases[key]; ok {
+		checkCount(e, name, params, 0, 0)
+		at := &AliasType{Name: name}
+		c.resolved[key] = at
+		at.Type = c.convert(ae)
+		return at
+	}
+
+	t := &ReferenceType{Name: name}
+	if len(params) > 0 {
+		source := e.String()
+		t.Parameters = source[strings.IndexByte(source, '['):]
+	}
+	return t
+}
+
+func (c *converter) structType(name string, param parser.Expression) Type {
+	hash, ok := param.(*parser.LiteralHash)
+	if !ok {
+		panic(typeIssue(TYPE_ILLEGAL_PARAMETER, param, issue.H{`type`: name, `expected`: `a hash`}))
+	}
+	t := &StructType{Elements: make([]*StructElement, 0, len(hash.Entries()))}
+	for _, e := range hash.Entries() {
+		entry := e.(*parser.KeyedEntry)
+		key := entry.Key()
+		optional := false
+		if ae, ok := key.(*parser.AccessExpression); ok && len(ae.Keys()) == 1 {
+			if qr, ok := ae.Operand().(*parser.QualifiedReference); ok && (qr.Name() == `Optional` || qr.Name() == `NotUndef`) {
+				optional = qr.Name() == `Optional`
+				key = ae.Keys()[0]
+			}
+		}
+		t.Elements = append(t.Elements, &StructElement{Name: c.stringParam(name, key), Type: c.convert(entry.Value()), OptionalKey: optional})
+	}
+	return t
+}
+
+// sizeParams returns the size range given by up to two integer parameters
+func (c *converter) sizeParams(e parser.Expression, name string, params []parser.Expression) (min, max int64) {
+	if len(params) > 2 {
+		panic(typeIssue(TYPE_ILLEGAL_PARAMETER, params[2], issue.H{`type`: name, `expected`: `no more parameters`}))
+	}
+	min, max = 0, math.MaxInt64
+	if len(params) > 0 {
+		min = c.intParam(name, params[0], 0)
+	}
+	if len(params) > 1 {
+		max = c.intParam(name, params[1], math.MaxInt64)
+	}
+	checkRange(e, name, min < 0 || min > max)
+	return
+}
+
+// intParam returns the value of an integer parameter, or the given default if the parameter is 'default'
+func (c *converter) intParam(name string, p parser.Expression, dflt int64) int64 {
+	if isDefault(p) {
+		return dflt
+	}
+	if i, ok := intValue(p); ok {
+		return i
+	}
+	panic(typeIssue(TYPE_ILLEGAL_PARAMETER, p, issue.H{`type`: name, `expected`: `an integer or default`}))
+}
+
+func (c *converter) floatParam(name string, p parser.Expression, dflt float64) float64 {
+	if isDefault(p) {
+		return dflt
+	}
+	if i, ok := intValue(p); ok {
+		return float64(i)
+	}
+	if um, ok := p.(*parser.UnaryMinusExpression); ok {
+		if lf, ok := um.Expr().(*parser.LiteralFloat); ok {
+			return -lf.Float()
+		}
+	}
+	if lf, ok := p.(*parser.LiteralFloat); ok {
+		return lf.Float()
+	}
+	panic(typeIssue(TYPE_ILLEGAL_PARAMETER, p, issue.H{`type`: name, `expected`: `a number or default`}))
+}
+
+func (c *converter) stringParam(name string, p parser.Expression) string {
+	if str, ok := stringValue(p); ok {
+		return str
+	}
+	panic(typeIssue(TYPE_ILLEGAL_PARAMETER, p, issue.H{`type`: name, `expected`: `a string`}))
+}
+
+func (c *converter) regexpParam(name string, p parser.Expression) string {
+	if re, ok := p.(*parser.RegexpExpression); ok {
+		return re.PatternString()
+	}
+	if str, ok := stringValue(p); ok {
+		return str
+	}
+	panic(typeIssue(TYPE_ILLEGAL_PARAMETER, p, issue.H{`type`: name, `expected`: `a regular expression or a string`}))
+}
+
+// checkCount checks that the number of parameters is between min and max. A max of -1 means no limit.
+// A type without parameters is always accepted since it denotes the type with default parameters.
+func checkCount(e parser.Expression, name string, params []parser.Expression, min, max int) {
+	count := len(params)
+	if _, ok := e.(*parser.QualifiedReference); ok {
+		return
+	}
+	if count >= min && (max < 0 || count <= max) {
+		return
+	}
+	var expected interface{}
+	switch {
+	case max < 0:
+		expected = `at least ` + strconv.Itoa(min)
+	case min == max:
+		expected = min
+	default:
+		expected = strconv.Itoa(min) + ` to ` + strconv.Itoa(max)
+	}
+	if max == 0 {
+		panic(typeIssue(TYPE_NOT_PARAMETERIZED, e, issue.H{`type`: name}))
+	}
+	panic(typeIssue(TYPE_WRONG_PARAMETER_COUNT, e, issue.H{`type`: name, `expected`: expected, `actual`: count}))
+}
+
+func checkRange(e parser.Expression, name string, invalid bool) {
+	if invalid {
+		panic(typeIssue(TYPE_INVALID_RANGE, e, issue.H{`type`: name}))
+	}
+}
+
+func isSize(e parser.Expression) bool {
+	_, ok := intValue(e)
+	return ok || isDefault(e)
+}
+
+func isDefault(e parser.Expression) bool {
+	_, ok := e.(*parser.LiteralDefault)
+	return ok
+}
+
+func intValue(e parser.Expression) (int64, bool) {
+	if um, ok := e.(*parser.UnaryMinusExpression); ok {
+		i, ok := intValue(um.Expr())
+		return -i, ok
+	}
+	if li, ok := e.(*parser.LiteralInteger); ok {
+		return li.Int(), true
+	}
+	return 0, false
+}
+
+func stringValue(e parser.Expression) (string, bool) {
+	if qn, ok := e.(*parser.QualifiedName); ok {
+		return qn.Name(), true
+	}
+	if _, ok := e.(*parser.QualifiedReference); ok {
+		return ``, false
+	}
+	if value, ok := literal.ToLiteral(e); ok {
+		if str, ok := value.(string); ok {
+			return str, true
+		}
+	}
+	return ``, false
+}
@@ -0,0 +1,395 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// IsInstance returns true if the given value is an instance of the type. The value is one of the values
+// produced by literal.ToLiteral or literal.ToTypedLiteral. Types that are not modeled, and patterns that cannot be compiled, are
+// assumed to accept all values.
+func IsInstance(t Type, value interface{}) bool {
+	switch t := resolve(t).(type) {
+	case *AnyType, *RichDataType, *ReferenceType:
+		return true
+	case *UndefType:
+		return value == nil
+	case *DefaultType:
+		_, ok := value.(parser.Default)
+		return ok
+	case *BooleanType:
+		_, ok := value.(bool)
+		return ok
+	case *IntegerType:
+		i, ok := value.(int64)
+		return ok && i >= t.Min && i <= t.Max
+	case *FloatType:
+		f, ok := value.(float64)
+		return ok && f >= t.Min && f <= t.Max
+	case *NumericType:
+		switch value.(type) {
+		case int64, float64:
+			return true
+		}
+	case *StringType:
+		if str, ok := value.(string); ok {
+			return inRange(int64(utf8.RuneCountInString(str)), t.Min, t.Max)
+		}
+	case *EnumType:
+		str, ok := value.(string)
+		return ok && t.contains(str)
+	case *PatternType:
+		str, ok := value.(string)
+		return ok && t.accepts(str)
+	case *ScalarType, *ScalarDataType:
+		switch value.(type) {
+		case string, int64, float64, bool:
+			return true
+		}
+	case *DataType:
+		return isDataValue(value)
+	case *CollectionType:
+		if size, ok := sizeOfValue(value); ok {
+			return inRange(size, t.Min, t.Max)
+		}
+	case *ArrayType, *TupleType, *HashType, *StructType:
+		return sameKind(t, value) && Mismatch(t, value) == ``
+	case *OptionalType:
+		return value == nil || t.Type == nil || IsInstance(t.Type, value)
+	case *NotUndefType:
+		return value != nil && (t.Type == nil || IsInstance(t.Type, value))
+	case *VariantType:
+		for _, v := range t.Types {
+			if IsInstance(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Mismatch describes why the given value is not an instance of the type, e.g. "expects a Boolean value,
+// got String" or "index 1 expects a String value, got Integer". An empty string is returned when the
+// value is an instance of the type.
+func Mismatch(t Type, value interface{}) string {
+	t = resolve(t)
+	switch t.(type) {
+	case *ArrayType:
+		if values, ok := value.([]interface{}); ok {
+			at := t.(*ArrayType)
+			if r := sizeMismatch(int64(len(values)), at.Min, at.Max); r != `` {
+				return r
+			}
+			for i, v := range values {
+				if r := Mismatch(at.Element, v); r != `` {
+					return fmt.Sprintf(`index %d %s`, i, r)
+				}
+			}
+			return ``
+		}
+	case *TupleType:
+		if values, ok := value.([]interface{}); ok {
+			tt := t.(*TupleType)
+			if r := sizeMismatch(int64(len(values)), tt.Min, tt.Max); r != `` {
+				return r
+			}
+			for i, v := range values {
+				if r := Mismatch(tt.typeAt(i), v); r != `` {
+					return fmt.Sprintf(`index %d %s`, i, r)
+				}
+			}
+			return ``
+		}
+	case *HashType:
+		if entries, ok := hashEntries(value); ok {
+			ht := t.(*HashType)
+			if r := sizeMismatch(int64(len(entries)), ht.Min, ht.Max); r != `` {
+				return r
+			}
+			for _, e := range entries {
+				if r := Mismatch(ht.Key, e.Key); r != `` {
+					return fmt.Sprintf(`key %s %s`, valueString(e.Key), r)
+				}
+				if r := Mismatch(ht.Value, e.Value); r != `` {
+					return fmt.Sprintf(`entry %s %s`, valueString(e.Key), r)
+				}
+			}
+			return ``
+		}
+	case *StructType:
+		if entries, ok := hashEntries(value); ok {
+			st := t.(*StructType)
+			for _, e := range st.Elements {
+				v, found := lookup(entries, e.Name)
+				if !found {
+					if e.IsRequired() {
+						return fmt.Sprintf(`expects a value for key '%s'`, e.Name)
+					}
+					continue
+				}
+				if r := Mismatch(e.Type, v); r != `` {
+					return fmt.Sprintf(`entry '%s' %s`, e.Name, r)
+				}
+			}
+			for _, e := range entries {
+				if name, ok := e.Key.(string); !ok || st.Get(name) == nil {
+					return fmt.Sprintf(`unrecognized key %s`, valueString(e.Key))
+				}
+			}
+			return ``
+		}
+	}
+
+	if IsInstance(t, value) {
+		return ``
+	}
+	switch t.(type) {
+	case *OptionalType:
+		if ot := t.(*OptionalType); sameKind(ot.Type, value) {
+			return Mismatch(ot.Type, value)
+		}
+	case *NotUndefType:
+		if nt := t.(*NotUndefType); value != nil && nt.Type != nil {
+			return Mismatch(nt.Type, value)
+		}
+	case *VariantType:
+		var candidate Type
+		count := 0
+		for _, v := range t.(*VariantType).Types {
+			if sameKind(v, value) {
+				candidate = v
+				count++
+			}
+		}
+		if count == 1 {
+			return Mismatch(candidate, value)
+		}
+	}
+	if sameKind(t, value) && isScalarValue(value) {
+		return fmt.Sprintf(`expects %s, got %s`, expected(t), valueString(value))
+	}
+	return fmt.Sprintf(`expects %s, got %s`, expected(t), kindName(value))
+}
+
+// expected returns a description of the values of the type, e.g. "an Integer[1] value"
+func expected(t Type) string {
+	if vt, ok := t.(*VariantType); ok {
+		names := make([]string, len(vt.Types))
+		for i, v := range vt.Types {
+			names[i] = v.String()
+		}
+		switch len(names) {
+		case 1:
+			return `a value of type ` + names[0]
+		case 2:
+			return `a value of type ` + names[0] + ` or ` + names[1]
+		default:
+			return `a value of type ` + strings.Join(names[:len(names)-1], `, `) + `, or ` + names[len(names)-1]
+		}
+	}
+	name := t.String()
+	if strings.ContainsRune(`AEIOU`, rune(name[0])) {
+		return `an ` + name + ` value`
+	}
+	return `a ` + name + ` value`
+}
+
+// sameKind returns true if the type, disregarding its parameters, accepts the kind of the value. A nil
+// type accepts all values.
+func sameKind(t Type, value interface{}) bool {
+	if t == nil {
+		return true
+	}
+	switch t := resolve(t).(type) {
+	case *IntegerType:
+		_, ok := value.(int64)
+		return ok
+	case *FloatType:
+		_, ok := value.(float64)
+		return ok
+	case *StringType, *EnumType, *PatternType:
+		_, ok := value.(string)
+		return ok
+	case *ArrayType, *TupleType:
+		_, ok := value.([]interface{})
+		return ok
+	case *HashType, *StructType:
+		_, ok := hashEntries(value)
+		return ok
+	case *CollectionType:
+		_, ok := sizeOfValue(value)
+		return ok
+	case *OptionalType:
+		return value == nil || sameKind(t.Type, value)
+	case *NotUndefType:
+		return value != nil && sameKind(t.Type, value)
+	case *VariantType:
+		for _, v := range t.Types {
+			if sameKind(v, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return IsInstance(t, value)
+}
+
+// kindName returns the name of the type of the value without parameters
+func kindName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return `Undef`
+	case parser.Default:
+		return `Default`
+	case bool:
+		return `Boolean`
+	case int64:
+		return `Integer`
+	case float64:
+		return `Float`
+	case string:
+		return `String`
+	case []interface{}:
+		return `Array`
+	case map[interface{}]interface{}, *literal.Hash:
+		return `Hash`
+	case literal.Regexp:
+		return `Regexp`
+	case literal.TypeReference:
+		return `Type`
+	}
+	return fmt.Sprintf(`%T`, value)
+}
+
+func valueString(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return quote(value.(string))
+	case float64:
+		return floatString(value.(float64))
+	}
+	return fmt.Sprint(value)
+}
+
+func sizeMismatch(size, min, max int64) string {
+	switch {
+	case size < min && max == math.MaxInt64:
+		return fmt.Sprintf(`expects size to be at least %d, got %d`, min, size)
+	case min == max && size != min:
+		return fmt.Sprintf(`expects size to be %d, got %d`, min, size)
+	case size < min || size > max:
+		return fmt.Sprintf(`expects size to be between %d and %d, got %d`, min, max, size)
+	}
+	return ``
+}
+
+func sizeOfValue(value interface{}) (int64, bool) {
+	switch value.(type) {
+	case []interface{}:
+		return int64(len(value.([]interface{}))), true
+	case map[interface{}]interface{}:
+		return int64(len(value.(map[interface{}]interface{}))), true
+	case *literal.Hash:
+		return int64(value.(*literal.Hash).Len()), true
+	}
+	return 0, false
+}
+
+func isScalarValue(value interface{}) bool {
+	switch value.(type) {
+	case string, int64, float64, bool:
+		return true
+	}
+	return false
+}
+
+func isDataValue(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, int64, float64, bool:
+		return true
+	case []interface{}:
+		for _, v := range value.([]interface{}) {
+			if !isDataValue(v) {
+				return false
+			}
+		}
+		return true
+	case map[interface{}]interface{}, *literal.Hash:
+		entries, _ := hashEntries(value)
+		for _, e := range entries {
+			if _, ok := e.Key.(string); !ok || !isDataValue(e.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hashEntries returns the entries of a hash value. The entries of a map are sorted on their keys.
+func hashEntries(value interface{}) ([]literal.HashEntry, bool) {
+	switch value.(type) {
+	case map[interface{}]interface{}:
+		m := value.(map[interface{}]interface{})
+		entries := make([]literal.HashEntry, len(m))
+		for i, k := range sortedKeys(m) {
+			entries[i] = literal.HashEntry{Key: k, Value: m[k]}
+		}
+		return entries, true
+	case *literal.Hash:
+		return value.(*literal.Hash).Entries(), true
+	}
+	return nil, false
+}
+
+func lookup(entries []literal.HashEntry, key string) (interface{}, bool) {
+	for _, e := range entries {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// accepts returns true if the string matches one of the patterns. A pattern that cannot be compiled
+// is assumed to match since the Ruby regular expression syntax is not fully supported.
+func (t *PatternType) accepts(str string) bool {
+	if len(t.Patterns) == 0 {
+		return true
+	}
+	for _, p := range t.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil || re.MatchString(str) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(entries map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	return keys
+}
+
+func inRange(value, min, max int64) bool {
+	return value >= min && value <= max
+}
+
+// resolve returns the type that an alias denotes, or the given type if it is not an alias
+func resolve(t Type) Type {
+	if at, ok := t.(*AliasType); ok {
+		return at.Resolved()
+	}
+	return t
+}
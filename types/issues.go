@@ -0,0 +1,23 @@
+package types
+
+import "github.com/lyraproj/issue/issue"
+
+const (
+	TYPE_ILLEGAL_PARAMETER     = `TYPE_ILLEGAL_PARAMETER`
+	TYPE_INVALID_RANGE         = `TYPE_INVALID_RANGE`
+	TYPE_NOT_A_TYPE            = `TYPE_NOT_A_TYPE`
+	TYPE_NOT_PARAMETERIZED     = `TYPE_NOT_PARAMETERIZED`
+	TYPE_WRONG_PARAMETER_COUNT = `TYPE_WRONG_PARAMETER_COUNT`
+)
+
+func init() {
+	issue.Hard(TYPE_ILLEGAL_PARAMETER, `Illegal parameter for %{type}. Expected %{expected}`)
+
+	issue.Hard(TYPE_INVALID_RANGE, `The parameters of %{type} do not form a valid range`)
+
+	issue.Hard2(TYPE_NOT_A_TYPE, `%{expression} is not a type`, issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard(TYPE_NOT_PARAMETERIZED, `%{type} does not accept parameters`)
+
+	issue.Hard(TYPE_WRONG_PARAMETER_COUNT, `%{type} expects %{expected} parameters, got %{actual}`)
+}
@@ -0,0 +1,396 @@
+// Package types contains a model of the Puppet type system. Type expressions found in Puppet code are
+// converted into the model with FromExpression, and IsAssignable tells if all values of one type are
+// also values of another.
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Type is a Puppet type
+	Type interface {
+		// String returns the type in Puppet syntax
+		String() string
+	}
+
+	AnyType        struct{}
+	BooleanType    struct{}
+	DataType       struct{}
+	DefaultType    struct{}
+	NumericType    struct{}
+	RichDataType   struct{}
+	ScalarDataType struct{}
+	ScalarType     struct{}
+	UndefType      struct{}
+
+	// IntegerType is an integer range. Min and Max are math.MinInt64 and math.MaxInt64 when unbounded
+	IntegerType struct {
+		Min int64
+		Max int64
+	}
+
+	// FloatType is a float range. Min and Max are negative and positive infinity when unbounded
+	FloatType struct {
+		Min float64
+		Max float64
+	}
+
+	// StringType is a string with a length between Min and Max characters
+	StringType struct {
+		Min int64
+		Max int64
+	}
+
+	// EnumType is a string that is one of the given values
+	EnumType struct {
+		Values          []string
+		CaseInsensitive bool
+	}
+
+	// PatternType is a string that matches at least one of the given regular expressions
+	PatternType struct {
+		Patterns []string
+	}
+
+	// RegexpType is a regular expression. An empty pattern denotes all regular expressions
+	RegexpType struct {
+		Pattern string
+	}
+
+	// CollectionType is an array or a hash with a size between Min and Max
+	CollectionType struct {
+		Min int64
+		Max int64
+	}
+
+	// ArrayType is an array of elements of the given type with a size between Min and Max
+	ArrayType struct {
+		Element Type
+		Min     int64
+		Max     int64
+	}
+
+	// HashType is a hash with keys and values of the given types and a size between Min and Max
+	HashType struct {
+		Key   Type
+		Value Type
+		Min   int64
+		Max   int64
+	}
+
+	// TupleType is an array where each element has its own type. When the array is larger than the
+	// number of types, the last type applies to the remaining elements.
+	TupleType struct {
+		Types []Type
+		Min   int64
+		Max   int64
+	}
+
+	// StructType is a hash with string keys where each key has its own value type
+	StructType struct {
+		Elements []*StructElement
+	}
+
+	// StructElement is a key of a StructType. A key declared as Optional['key'] has OptionalKey set.
+	StructElement struct {
+		Name        string
+		Type        Type
+		OptionalKey bool
+	}
+
+	// OptionalType is the given type or undef. A nil Type denotes any value
+	OptionalType struct {
+		Type Type
+	}
+
+	// NotUndefType is the given type except undef. A nil Type denotes any value except undef
+	NotUndefType struct {
+		Type Type
+	}
+
+	// SensitiveType is a sensitive value of the given type. A nil Type denotes any sensitive value
+	SensitiveType struct {
+		Type Type
+	}
+
+	// VariantType is a value of any of the given types
+	VariantType struct {
+		Types []Type
+	}
+
+	// AliasType is a named type alias. The Type is resolved when the alias is converted and may refer
+	// back to the alias itself.
+	AliasType struct {
+		Name string
+		Type Type
+	}
+
+	// ReferenceType is a type that is not modeled, either because it is an unknown name or because it
+	// is a built in type such as Timestamp or Resource. Parameters holds the source of its parameters,
+	// e.g. "['file']".
+	ReferenceType struct {
+		Name       string
+		Parameters string
+	}
+)
+
+// Singletons of the types that take no parameters
+var (
+	Any        = &AnyType{}
+	Boolean    = &BooleanType{}
+	Data       = &DataType{}
+	Default    = &DefaultType{}
+	Numeric    = &NumericType{}
+	RichData   = &RichDataType{}
+	Scalar     = &ScalarType{}
+	ScalarData = &ScalarDataType{}
+	Undef      = &UndefType{}
+)
+
+func (t *AnyType) String() string        { return `Any` }
+func (t *BooleanType) String() string    { return `Boolean` }
+func (t *DataType) String() string       { return `Data` }
+func (t *DefaultType) String() string    { return `Default` }
+func (t *NumericType) String() string    { return `Numeric` }
+func (t *RichDataType) String() string   { return `RichData` }
+func (t *ScalarDataType) String() string { return `ScalarData` }
+func (t *ScalarType) String() string     { return `Scalar` }
+func (t *UndefType) String() string      { return `Undef` }
+
+// NewInteger creates an unbounded IntegerType
+func NewInteger() *IntegerType {
+	return &IntegerType{math.MinInt64, math.MaxInt64}
+}
+
+func (t *IntegerType) String() string {
+	return rangeString(`Integer`, t.Min != math.MinInt64, t.Max != math.MaxInt64,
+		strconv.FormatInt(t.Min, 10), strconv.FormatInt(t.Max, 10))
+}
+
+// NewFloat creates an unbounded FloatType
+func NewFloat() *FloatType {
+	return &FloatType{math.Inf(-1), math.Inf(1)}
+}
+
+func (t *FloatType) String() string {
+	return rangeString(`Float`, !math.IsInf(t.Min, -1), !math.IsInf(t.Max, 1), floatString(t.Min), floatString(t.Max))
+}
+
+// NewString creates a StringType of any length
+func NewString() *StringType {
+	return &StringType{0, math.MaxInt64}
+}
+
+func (t *StringType) String() string {
+	return sizeString(`String`, ``, t.Min, t.Max)
+}
+
+func (t *EnumType) String() string {
+	b := bytes.NewBufferString(`Enum[`)
+	for i, v := range t.Values {
+		if i > 0 {
+			b.WriteString(`, `)
+		}
+		b.WriteString(quote(v))
+	}
+	if t.CaseInsensitive {
+		b.WriteString(`, true`)
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func (t *PatternType) String() string {
+	b := bytes.NewBufferString(`Pattern[`)
+	for i, p := range t.Patterns {
+		if i > 0 {
+			b.WriteString(`, `)
+		}
+		b.WriteString(regexpString(p))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func (t *RegexpType) String() string {
+	if t.Pattern == `` {
+		return `Regexp`
+	}
+	return `Regexp[` + regexpString(t.Pattern) + `]`
+}
+
+func (t *CollectionType) String() string {
+	return sizeString(`Collection`, ``, t.Min, t.Max)
+}
+
+// NewArray creates an ArrayType of any size with elements of the given type
+func NewArray(element Type) *ArrayType {
+	return &ArrayType{element, 0, math.MaxInt64}
+}
+
+func (t *ArrayType) String() string {
+	if t.Element == Any {
+		return sizeString(`Array`, ``, t.Min, t.Max)
+	}
+	return sizeString(`Array`, t.Element.String(), t.Min, t.Max)
+}
+
+// NewHash creates a HashType of any size with keys and values of the given types
+func NewHash(key, value Type) *HashType {
+	return &HashType{key, value, 0, math.MaxInt64}
+}
+
+func (t *HashType) String() string {
+	if t.Key == Any && t.Value == Any {
+		return sizeString(`Hash`, ``, t.Min, t.Max)
+	}
+	return sizeString(`Hash`, t.Key.String()+`, `+t.Value.String(), t.Min, t.Max)
+}
+
+func (t *TupleType) String() string {
+	b := bytes.NewBufferString(``)
+	for i, e := range t.Types {
+		if i > 0 {
+			b.WriteString(`, `)
+		}
+		b.WriteString(e.String())
+	}
+	count := int64(len(t.Types))
+	if t.Min == count && t.Max == count {
+		if count == 0 {
+			return `Tuple`
+		}
+		return `Tuple[` + b.String() + `]`
+	}
+	return sizeString(`Tuple`, b.String(), t.Min, t.Max)
+}
+
+func (t *StructType) String() string {
+	b := bytes.NewBufferString(`Struct[{`)
+	for i, e := range t.Elements {
+		if i > 0 {
+			b.WriteString(`, `)
+		}
+		if e.OptionalKey {
+			b.WriteString(`Optional[` + quote(e.Name) + `]`)
+		} else {
+			b.WriteString(quote(e.Name))
+		}
+		b.WriteString(` => `)
+		b.WriteString(e.Type.String())
+	}
+	b.WriteString(`}]`)
+	return b.String()
+}
+
+// Get returns the element with the given name, or nil if there is no such element
+func (t *StructType) Get(name string) *StructElement {
+	for _, e := range t.Elements {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// IsRequired returns true unless the key is declared optional or the value type accepts undef
+func (e *StructElement) IsRequired() bool {
+	return !e.OptionalKey && !IsAssignable(e.Type, Undef)
+}
+
+func (t *OptionalType) String() string  { return wrapperString(`Optional`, t.Type) }
+func (t *NotUndefType) String() string  { return wrapperString(`NotUndef`, t.Type) }
+func (t *SensitiveType) String() string { return wrapperString(`Sensitive`, t.Type) }
+
+func (t *VariantType) String() string {
+	strs := make([]string, len(t.Types))
+	for i, v := range t.Types {
+		strs[i] = v.String()
+	}
+	return `Variant[` + strings.Join(strs, `, `) + `]`
+}
+
+func (t *AliasType) String() string { return t.Name }
+
+// Resolved returns the type that the alias denotes. Aliases of aliases are followed and an alias that
+// refers only to itself resolves to Any.
+func (t *AliasType) Resolved() Type {
+	seen := map[*AliasType]bool{}
+	var r Type = t
+	for {
+		at, ok := r.(*AliasType)
+		if !ok {
+			return r
+		}
+		if seen[at] || at.Type == nil {
+			return Any
+		}
+		seen[at] = true
+		r = at.Type
+	}
+}
+
+func (t *ReferenceType) String() string { return t.Name + t.Parameters }
+
+func wrapperString(name string, t Type) string {
+	if t == nil {
+		return name
+	}
+	return name + `[` + t.String() + `]`
+}
+
+// sizeString returns the name followed by the given parameters and the size range, omitting the parts
+// that have default values
+func sizeString(name, params string, min, max int64) string {
+	var size string
+	switch {
+	case max != math.MaxInt64:
+		size = fmt.Sprintf(`%d, %d`, min, max)
+	case min != 0:
+		size = strconv.FormatInt(min, 10)
+	}
+	switch {
+	case params == `` && size == ``:
+		return name
+	case params == ``:
+		return name + `[` + size + `]`
+	case size == ``:
+		return name + `[` + params + `]`
+	default:
+		return name + `[` + params + `, ` + size + `]`
+	}
+}
+
+func rangeString(name string, hasMin, hasMax bool, min, max string) string {
+	switch {
+	case hasMax && hasMin:
+		return name + `[` + min + `, ` + max + `]`
+	case hasMax:
+		return name + `[default, ` + max + `]`
+	case hasMin:
+		return name + `[` + min + `]`
+	default:
+		return name
+	}
+}
+
+func floatString(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, `.eInf`) {
+		s += `.0`
+	}
+	return s
+}
+
+func quote(s string) string {
+	return `'` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `'`, `\'`, -1) + `'`
+}
+
+func regexpString(p string) string {
+	return `/` + strings.Replace(p, `/`, `\/`, -1) + `/`
+}
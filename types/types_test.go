@@ -0,0 +1,243 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestFromExpression(t *testing.T) {
+	for source, expected := range map[string]string{
+		`Any`:                         `Any`,
+		`Integer[1]`:                  `Integer[1]`,
+		`Integer[default, -1]`:        `Integer[default, -1]`,
+		`Integer[-5, 5]`:              `Integer[-5, 5]`,
+		`Float[0.5, 2]`:               `Float[0.5, 2.0]`,
+		`String[1]`:                   `String[1]`,
+		`String[0, default]`:          `String`,
+		`Enum[a, 'b', true]`:          `Enum['a', 'b', true]`,
+		`Pattern[/^a/, 'b']`:          `Pattern[/^a/, /b/]`,
+		`Regexp`:                      `Regexp`,
+		`Array[String, 1]`:            `Array[String, 1]`,
+		`Array[1, 2]`:                 `Array[1, 2]`,
+		`Hash[String, Integer]`:       `Hash[String, Integer]`,
+		`Tuple[String, Integer]`:      `Tuple[String, Integer]`,
+		`Tuple[String, 1, default]`:   `Tuple[String, 1]`,
+		`Optional[String]`:            `Optional[String]`,
+		`Optional['x']`:               `Optional[Enum['x']]`,
+		`NotUndef`:                    `NotUndef`,
+		`Variant[String, Array[Foo]]`: `Variant[String, Array[Foo]]`,
+		`Sensitive[String]`:           `Sensitive[String]`,
+		`Foo::Port`:                   `Foo::Port`,
+		`Timestamp['2020-01-01']`:     `Timestamp['2020-01-01']`,
+		`Struct[{a => String, Optional[b] => Integer}]`: `Struct[{'a' => String, Optional['b'] => Integer}]`,
+	} {
+		typ, err := FromExpression(parseType(t, source), testAliases(t))
+		if err != nil {
+			t.Errorf(`unexpected error for %s: %s`, source, err)
+			continue
+		}
+		if actual := typ.String(); actual != expected {
+			t.Errorf(`expected %s for %s, got %s`, expected, source, actual)
+		}
+	}
+}
+
+func TestFromExpressionAlias(t *testing.T) {
+	typ, err := FromExpression(parseType(t, `Foo::Recursive`), testAliases(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	at, ok := typ.(*AliasType)
+	if !ok {
+		t.Fatalf(`expected an AliasType, got %T`, typ)
+	}
+	if array, ok := at.Resolved().(*ArrayType); !ok || array.Element != at {
+		t.Errorf(`expected the alias to resolve to an array of itself, got %s`, at.Resolved())
+	}
+}
+
+func TestFromExpressionErrors(t *testing.T) {
+	for source, expected := range map[string]string{
+		`Integer[1, 2, 3]`:         `Integer expects 0 to 2 parameters, got 3`,
+		`Integer[a]`:               `Illegal parameter for Integer. Expected an integer or default`,
+		`Integer[5, 1]`:            `The parameters of Integer do not form a valid range`,
+		`String[-1]`:               `The parameters of String do not form a valid range`,
+		`Boolean[1]`:               `Boolean does not accept parameters`,
+		`Enum[]`:                   `Enum expects at least 1 parameters, got 0`,
+		`Struct[{a => String}, 1]`: `Struct expects 1 parameters, got 2`,
+		`Struct[String]`:           `Illegal parameter for Struct. Expected a hash`,
+		`Hash[String]`:             `Hash expects 2 to 4 parameters, got 1`,
+		`Array[1, 2, 3]`:           `Illegal parameter for Array. Expected no more parameters`,
+		`Tuple[String, 1, 2, 3]`:   `Illegal parameter for Tuple. Expected a type`,
+		`Foo::Port[1]`:             `Foo::Port does not accept parameters`,
+		`Array['x']`:               `A Literal String is not a type`,
+	} {
+		_, err := FromExpression(parseType(t, source), testAliases(t))
+		if err == nil {
+			t.Errorf(`expected an error for %s`, source)
+			continue
+		}
+		if reported, ok := err.(issue.Reported); !ok || !strings.HasPrefix(reported.Error(), expected) {
+			t.Errorf(`expected '%s' for %s, got '%s'`, expected, source, err)
+		}
+	}
+}
+
+func TestIsAssignable(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected bool
+	}{
+		{`Any`, `Undef`, true},
+		{`Integer`, `Integer[1, 10]`, true},
+		{`Integer[1, 10]`, `Integer`, false},
+		{`Integer[0]`, `Integer[1, 10]`, true},
+		{`Numeric`, `Float[0.5]`, true},
+		{`Integer`, `Float`, false},
+		{`String`, `Enum[a, b]`, true},
+		{`String[2]`, `Enum[a, bb]`, false},
+		{`Enum[a, b, c]`, `Enum[a, b]`, true},
+		{`Enum[a, b]`, `Enum[a, 'B']`, false},
+		{`Enum[a, b, true]`, `Enum[a, 'B']`, true},
+		{`Pattern[/^a/]`, `Enum[abc, ax]`, true},
+		{`Pattern[/^a/]`, `Enum[abc, b]`, false},
+		{`String`, `Pattern[/^a/]`, true},
+		{`Enum`, `String`, true},
+		{`Enum`, `Pattern[/^a/]`, true},
+		{`Enum[a]`, `Enum`, false},
+		{`String[1]`, `Enum`, false},
+		{`Pattern`, `String`, true},
+		{`Pattern[/^a/]`, `Pattern`, false},
+		{`Pattern[/^a/]`, `Enum`, false},
+		{`Pattern[/\h/]`, `Enum[x]`, true},
+		{`Optional[String]`, `Undef`, true},
+		{`Optional[String]`, `Optional[Enum[a]]`, true},
+		{`String`, `Optional[String]`, false},
+		{`NotUndef`, `String`, true},
+		{`NotUndef`, `Optional[String]`, false},
+		{`NotUndef[String]`, `NotUndef[Enum[a]]`, true},
+		{`Variant[String, Integer]`, `Integer[1]`, true},
+		{`String`, `Variant[String, Integer]`, false},
+		{`Variant[String, Integer, Boolean]`, `Variant[Boolean, String]`, true},
+		{`Scalar`, `Regexp`, true},
+		{`ScalarData`, `Regexp`, false},
+		{`Data`, `Hash[String, Array[Integer]]`, true},
+		{`Data`, `Hash[Integer, String]`, false},
+		{`Data`, `Optional[Struct[{a => Integer}]]`, true},
+		{`Data`, `Any`, false},
+		{`RichData`, `Timestamp`, true},
+		{`RichData`, `Callable`, false},
+		{`Array[String]`, `Array[Enum[a], 1]`, true},
+		{`Array[String, 1]`, `Array[String]`, false},
+		{`Array[Numeric]`, `Tuple[Integer, Float]`, true},
+		{`Collection[1]`, `Hash[String, String, 1]`, true},
+		{`Collection[1]`, `Struct[{a => Optional[String]}]`, false},
+		{`Hash[String, Integer]`, `Struct[{a => Integer}]`, true},
+		{`Hash[Enum[b], Integer]`, `Struct[{a => Integer}]`, false},
+		{`Tuple[Integer, String]`, `Tuple[Integer[0], Enum[a]]`, true},
+		{`Tuple[Integer, String]`, `Tuple[String, Integer]`, false},
+		{`Tuple[String, 0, 3]`, `Array[String, 1, 2]`, true},
+		{`Struct[{a => String, Optional[b] => Integer}]`, `Struct[{a => Enum[x]}]`, true},
+		{`Struct[{a => String}]`, `Struct[{a => String, b => Integer}]`, false},
+		{`Struct[{a => String, b => Integer}]`, `Struct[{a => String}]`, false},
+		{`Struct[{a => Optional[String]}]`, `Struct[{Optional[a] => String}]`, true},
+		{`Sensitive[String]`, `Sensitive[Enum[a]]`, true},
+		{`Foo::Port`, `Integer[80, 443]`, true},
+		{`Integer[80, 443]`, `Foo::Port`, false},
+		{`Foo::Recursive`, `Array[Foo::Recursive]`, true},
+		{`Foo::Recursive`, `Array[String]`, false},
+		{`Timestamp`, `Timestamp`, true},
+		{`Timestamp`, `Timespan`, false},
+		{`Timestamp`, `Timestamp['2020-01-01']`, false},
+	} {
+		aliases := testAliases(t)
+		a, err := FromExpression(parseType(t, tc.a), aliases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := FromExpression(parseType(t, tc.b), aliases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := IsAssignable(a, b); actual != tc.expected {
+			t.Errorf(`expected IsAssignable(%s, %s) to be %t`, tc.a, tc.b, tc.expected)
+		}
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	for _, tc := range []struct {
+		typ    string
+		value  interface{}
+		reason string
+	}{
+		{`Boolean`, true, ``},
+		{`Boolean`, `yes`, `expects a Boolean value, got String`},
+		{`Integer[1, 10]`, int64(11), `expects an Integer[1, 10] value, got 11`},
+		{`Float`, int64(1), `expects a Float value, got Integer`},
+		{`Numeric`, 1.5, ``},
+		{`String[2]`, `x`, `expects a String[2] value, got 'x'`},
+		{`Enum[a, b]`, `c`, `expects an Enum['a', 'b'] value, got 'c'`},
+		{`Enum[a, b, true]`, `A`, ``},
+		{`Pattern[/^a/]`, `ba`, `expects a Pattern[/^a/] value, got 'ba'`},
+		{`Enum`, `x`, ``},
+		{`Pattern`, `x`, ``},
+		{`Pattern[/\h/]`, `x`, ``},
+		{`Optional[String]`, nil, ``},
+		{`Optional[String[1]]`, ``, `expects a String[1] value, got ''`},
+		{`NotUndef`, nil, `expects a NotUndef value, got Undef`},
+		{`Variant[String, Array[String]]`, []interface{}{int64(1)}, `index 0 expects a String value, got Integer`},
+		{`Variant[String, Integer]`, true, `expects a value of type String or Integer, got Boolean`},
+		{`Array[String, 2]`, []interface{}{`a`}, `expects size to be at least 2, got 1`},
+		{`Tuple[String, Integer]`, []interface{}{`a`, `b`}, `index 1 expects an Integer value, got String`},
+		{`Hash[String, Integer]`, map[interface{}]interface{}{`a`: int64(1), `b`: `x`}, `entry 'b' expects an Integer value, got String`},
+		{`Struct[{a => Integer, Optional[b] => String}]`, map[interface{}]interface{}{`a`: int64(1)}, ``},
+		{`Struct[{a => Integer}]`, map[interface{}]interface{}{`a`: int64(1), `c`: int64(2)}, `unrecognized key 'c'`},
+		{`Data`, []interface{}{map[interface{}]interface{}{`a`: nil}}, ``},
+		{`Hash[String, Integer]`, orderedHash(`b`, `x`, `a`, true), `entry 'b' expects an Integer value, got String`},
+		{`Struct[{a => Integer}]`, orderedHash(`a`, int64(1)), ``},
+		{`Foo::Port`, int64(0), `expects an Integer[1, 65535] value, got 0`},
+		{`Stdlib::Unknown`, int64(0), ``},
+	} {
+		typ, err := FromExpression(parseType(t, tc.typ), testAliases(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reason := Mismatch(typ, tc.value); reason != tc.reason {
+			t.Errorf(`expected '%s' for %v and %s, got '%s'`, tc.reason, tc.value, tc.typ, reason)
+		}
+		if IsInstance(typ, tc.value) != (tc.reason == ``) {
+			t.Errorf(`IsInstance and Mismatch disagree for %v and %s`, tc.value, tc.typ)
+		}
+	}
+}
+
+func orderedHash(keysAndValues ...interface{}) *literal.Hash {
+	h := literal.NewHash(len(keysAndValues) / 2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		h.Put(keysAndValues[i], keysAndValues[i+1])
+	}
+	return h
+}
+
+func testAliases(t *testing.T) Aliases {
+	return AliasesOf(parseProgram(t, "type Foo::Port = Integer[1, 65535]\ntype Foo::Recursive = Array[Foo::Recursive]"))
+}
+
+func parseType(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	return parseProgram(t, `type X = `+source).Definitions()[0].(*parser.TypeAlias).Type()
+}
+
+func parseProgram(t *testing.T, source string) *parser.Program {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`test.pp`, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr.(*parser.Program)
+}
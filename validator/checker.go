@@ -1,12 +1,14 @@
 package validator
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/lyraproj/issue/issue"
 	"github.com/lyraproj/puppet-parser/literal"
 	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/types"
 )
 
 var DOUBLE_COLON_EXPR = regexp.MustCompile(`::`)
@@ -76,11 +78,30 @@ var RESERVED_PARAMETERS = map[string]bool{
 
 type basicChecker struct {
 	AbstractValidator
+	functions      *FunctionRegistry
+	resourceTypes  *ResourceTypeRegistry
+	syntaxCheckers *SyntaxCheckerRegistry
+	aliases        types.Aliases
+
+	// Names of parameters and variables that are assigned in the validated program
+	locals map[string]bool
 }
 
 type Checker interface {
 	Validator
 
+	// Functions returns the registry used when validating function calls. Signatures of functions
+	// found in the validated program are added to this registry during validation.
+	Functions() *FunctionRegistry
+
+	// ResourceTypes returns the registry used when validating resource attributes. Schemas of resource
+	// types defined in the validated program are added to this registry during validation.
+	ResourceTypes() *ResourceTypeRegistry
+
+	// SyntaxCheckers returns the registry used when validating the text of heredocs that declare a
+	// syntax, e.g. @(END:json). Heredocs with a syntax that has no checker are not validated.
+	SyntaxCheckers() *SyntaxCheckerRegistry
+
 	check_ActivityExpression(e *parser.ActivityExpression)
 	check_Application(e *parser.Application)
 	check_AssignmentExpression(e *parser.AssignmentExpression)
@@ -88,6 +109,7 @@ type Checker interface {
 	check_AttributesOperation(e *parser.AttributesOperation)
 	check_BinaryExpression(e parser.BinaryExpression)
 	check_BlockExpression(e *parser.BlockExpression)
+	check_CallMethodExpression(e *parser.CallMethodExpression)
 	check_CallNamedFunctionExpression(e *parser.CallNamedFunctionExpression)
 	check_CapabilityMapping(e *parser.CapabilityMapping)
 	check_CaseExpression(e *parser.CaseExpression)
@@ -95,6 +117,7 @@ type Checker interface {
 	check_CollectExpression(e *parser.CollectExpression)
 	check_EppExpression(e *parser.EppExpression)
 	check_FunctionDefinition(e *parser.FunctionDefinition)
+	check_HeredocExpression(e *parser.HeredocExpression)
 	check_HostClassDefinition(e *parser.HostClassDefinition)
 	check_IfExpression(e *parser.IfExpression)
 	check_KeyedEntry(e *parser.KeyedEntry)
@@ -105,6 +128,7 @@ type Checker interface {
 	check_NamedDefinition(e parser.NamedDefinition)
 	check_NodeDefinition(e *parser.NodeDefinition)
 	check_Parameter(e *parser.Parameter)
+	check_Program(e *parser.Program)
 	check_QueryExpression(e parser.QueryExpression)
 	check_RelationshipExpression(e *parser.RelationshipExpression)
 	check_ReservedWord(e *parser.ReservedWord)
@@ -120,6 +144,7 @@ type Checker interface {
 	check_TypeMapping(e *parser.TypeMapping)
 	check_UnaryExpression(e parser.UnaryExpression)
 	check_UnlessExpression(e *parser.UnlessExpression)
+	check_VariableExpression(e *parser.VariableExpression)
 }
 
 func NewChecker(strict Strictness) Checker {
@@ -142,6 +167,8 @@ func Check(v Checker, e parser.Expression) {
 		v.check_AttributesOperation(e.(*parser.AttributesOperation))
 	case *parser.BlockExpression:
 		v.check_BlockExpression(e.(*parser.BlockExpression))
+	case *parser.CallMethodExpression:
+		v.check_CallMethodExpression(e.(*parser.CallMethodExpression))
 	case *parser.CallNamedFunctionExpression:
 		v.check_CallNamedFunctionExpression(e.(*parser.CallNamedFunctionExpression))
 	case *parser.CapabilityMapping:
@@ -156,6 +183,8 @@ func Check(v Checker, e parser.Expression) {
 		v.check_EppExpression(e.(*parser.EppExpression))
 	case *parser.FunctionDefinition:
 		v.check_FunctionDefinition(e.(*parser.FunctionDefinition))
+	case *parser.HeredocExpression:
+		v.check_HeredocExpression(e.(*parser.HeredocExpression))
 	case *parser.HostClassDefinition:
 		v.check_HostClassDefinition(e.(*parser.HostClassDefinition))
 	case *parser.IfExpression:
@@ -174,6 +203,8 @@ func Check(v Checker, e parser.Expression) {
 		v.check_NodeDefinition(e.(*parser.NodeDefinition))
 	case *parser.Parameter:
 		v.check_Parameter(e.(*parser.Parameter))
+	case *parser.Program:
+		v.check_Program(e.(*parser.Program))
 	case *parser.RelationshipExpression:
 		v.check_RelationshipExpression(e.(*parser.RelationshipExpression))
 	case *parser.ReservedWord:
@@ -200,6 +231,8 @@ func Check(v Checker, e parser.Expression) {
 		v.check_TypeMapping(e.(*parser.TypeMapping))
 	case *parser.UnlessExpression:
 		v.check_UnlessExpression(e.(*parser.UnlessExpression))
+	case *parser.VariableExpression:
+		v.check_VariableExpression(e.(*parser.VariableExpression))
 
 	// Interface switches
 	case parser.BinaryExpression:
@@ -217,9 +250,40 @@ func (v *basicChecker) Validate(e parser.Expression) {
 
 func (v *basicChecker) initialize(strict Strictness) {
 	v.severities = make(map[issue.Code]issue.Severity, 5)
+	v.functions = BuiltinFunctions()
+	v.resourceTypes = CoreResourceTypes()
+	v.syntaxCheckers = BuiltinSyntaxCheckers()
+	v.aliases = make(types.Aliases)
+	v.locals = make(map[string]bool)
 	v.Demote(VALIDATE_FUTURE_RESERVED_WORD, issue.SEVERITY_DEPRECATION)
+	v.Demote(VALIDATE_DEPRECATED_FUNCTION, issue.SEVERITY_DEPRECATION)
+
+	// Legacy facts are still available in older agents
+	v.Demote(VALIDATE_LEGACY_FACT, issue.SEVERITY_WARNING)
+
+	// Functions may be defined in modules that are unknown to the validator
+	v.Demote(VALIDATE_UNKNOWN_FUNCTION, issue.SEVERITY_IGNORE)
 	v.Demote(VALIDATE_DUPLICATE_KEY, issue.Severity(strict))
 	v.Demote(VALIDATE_IDEM_EXPRESSION_NOT_LAST, issue.Severity(strict))
+
+	// Code that can never run, or that always runs
+	v.Demote(VALIDATE_CONSTANT_CONDITION, issue.Severity(strict))
+	v.Demote(VALIDATE_DUPLICATE_OPTION, issue.Severity(strict))
+	v.Demote(VALIDATE_OPTION_AFTER_DEFAULT, issue.Severity(strict))
+	v.Demote(VALIDATE_SELECTOR_WITHOUT_DEFAULT, issue.Severity(strict))
+	v.Demote(VALIDATE_UNREACHABLE_CODE, issue.Severity(strict))
+}
+
+func (v *basicChecker) Functions() *FunctionRegistry {
+	return v.functions
+}
+
+func (v *basicChecker) ResourceTypes() *ResourceTypeRegistry {
+	return v.resourceTypes
+}
+
+func (v *basicChecker) SyntaxCheckers() *SyntaxCheckerRegistry {
+	return v.syntaxCheckers
 }
 
 func (v *basicChecker) illegalWorkflowOperation(e parser.Expression) {
@@ -276,11 +340,22 @@ func (v *basicChecker) check_BlockExpression(e *parser.BlockExpression) {
 			break
 		}
 	}
+	v.checkUnreachable(e)
+}
+
+func (v *basicChecker) check_CallMethodExpression(e *parser.CallMethodExpression) {
+	if na, ok := e.Functor().(*parser.NamedAccessExpression); ok {
+		if qn, ok := na.Rhs().(*parser.QualifiedName); ok {
+			// The receiver is passed as the first argument
+			v.checkFunctionCall(e, qn.Name(), append([]parser.Expression{na.Lhs()}, e.Arguments()...), e.Lambda())
+		}
+	}
 }
 
 func (v *basicChecker) check_CallNamedFunctionExpression(e *parser.CallNamedFunctionExpression) {
 	switch e.Functor().(type) {
 	case *parser.QualifiedName:
+		v.checkFunctionCall(e, e.Functor().(*parser.QualifiedName).Name(), e.Arguments(), e.Lambda())
 		return
 	case *parser.QualifiedReference:
 		// Call to type
@@ -340,6 +415,7 @@ func (v *basicChecker) check_CaseExpression(e *parser.CaseExpression) {
 			}
 		}
 	}
+	v.checkCaseOptions(e)
 }
 
 func (v *basicChecker) check_CaseOption(e *parser.CaseOption) {
@@ -369,6 +445,26 @@ func (v *basicChecker) check_FunctionDefinition(e *parser.FunctionDefinition) {
 	v.checkReturnType(e, e.ReturnType())
 }
 
+// The text of a heredoc that declares a syntax is checked unless it is interpolated. An error is
+// reported at its location within the heredoc.
+func (v *basicChecker) check_HeredocExpression(e *parser.HeredocExpression) {
+	if e.Syntax() == `` {
+		return
+	}
+	text, ok := e.Text().(*parser.LiteralString)
+	if !ok {
+		return
+	}
+	checker, ok := v.syntaxCheckers.Lookup(e.Syntax())
+	if !ok {
+		return
+	}
+	if se := checker(text.StringValue()); se != nil {
+		v.AcceptAt(VALIDATE_HEREDOC_SYNTAX_ERROR, heredocLocation(text, se.Offset),
+			issue.H{`syntax`: e.Syntax(), `message`: se.Message})
+	}
+}
+
 func (v *basicChecker) check_HostClassDefinition(e *parser.HostClassDefinition) {
 	v.check_NamedDefinition(e)
 	v.checkNoCapture(e, e.Parameters())
@@ -378,6 +474,7 @@ func (v *basicChecker) check_HostClassDefinition(e *parser.HostClassDefinition)
 
 func (v *basicChecker) check_IfExpression(e *parser.IfExpression) {
 	v.checkRValue(e.Test())
+	v.checkConstantCondition(e, e.Test())
 }
 
 func (v *basicChecker) check_KeyedEntry(e *parser.KeyedEntry) {
@@ -445,9 +542,21 @@ func (v *basicChecker) check_Parameter(e *parser.Parameter) {
 	}
 	if e.Value() != nil {
 		v.checkIllegalAssignment(e.Value())
+		if !e.CapturesRest() {
+			v.checkValueType(VALIDATE_DEFAULT_TYPE_MISMATCH, e.Type(), e.Value(), issue.H{`param`: e.Name()})
+		}
 	}
 }
 
+func (v *basicChecker) check_Program(e *parser.Program) {
+	v.functions.AddDefinitions(e)
+	v.resourceTypes.AddDefinitions(e)
+	for name, t := range types.AliasesOf(e) {
+		v.aliases[name] = t
+	}
+	v.collectLocals(e)
+}
+
 func (v *basicChecker) check_QueryExpression(e parser.QueryExpression) {
 	if e.Expr() != nil {
 		v.checkQuery(e.Expr())
@@ -484,6 +593,11 @@ func (v *basicChecker) check_ResourceDefaultsExpression(e *parser.ResourceDefaul
 	if e.Form() != parser.REGULAR {
 		v.Accept(VALIDATE_NOT_VIRTUALIZABLE, e, issue.NO_ARGS)
 	}
+	if typeRef, ok := e.TypeRef().(*parser.QualifiedReference); ok {
+		if schema, ok := v.resourceTypes.Lookup(typeRef.Name()); ok {
+			v.checkAttributes(schema, e, e.Operations(), false)
+		}
+	}
 }
 
 func (v *basicChecker) check_ResourceExpression(e *parser.ResourceExpression) {
@@ -496,12 +610,26 @@ func (v *basicChecker) check_ResourceExpression(e *parser.ResourceExpression) {
 			v.Accept(VALIDATE_NOT_VIRTUALIZABLE, e, issue.NO_ARGS)
 		}
 	}
+	if typeName, ok := e.TypeName().(*parser.QualifiedName); ok {
+		if schema, ok := v.resourceTypes.Lookup(typeName.Name()); ok {
+			for _, body := range e.Bodies() {
+				v.checkAttributes(schema, body, body.(*parser.ResourceBody).Operations(), true)
+			}
+		}
+	}
 }
 
 func (v *basicChecker) check_ResourceOverrideExpression(e *parser.ResourceOverrideExpression) {
 	if e.Form() != parser.REGULAR {
 		v.Accept(VALIDATE_NOT_VIRTUALIZABLE, e, issue.NO_ARGS)
 	}
+	if ae, ok := e.Resources().(*parser.AccessExpression); ok {
+		if typeRef, ok := ae.Operand().(*parser.QualifiedReference); ok {
+			if schema, ok := v.resourceTypes.Lookup(typeRef.Name()); ok {
+				v.checkAttributes(schema, e, e.Operations(), false)
+			}
+		}
+	}
 }
 
 func (v *basicChecker) check_ResourceTypeDefinition(e *parser.ResourceTypeDefinition) {
@@ -528,6 +656,7 @@ func (v *basicChecker) check_SelectorExpression(e *parser.SelectorExpression) {
 			}
 		}
 	}
+	v.checkSelectorOptions(e)
 }
 
 func (v *basicChecker) check_SiteDefinition(e *parser.SiteDefinition) {
@@ -585,6 +714,11 @@ func (v *basicChecker) check_UnaryExpression(e parser.UnaryExpression) {
 
 func (v *basicChecker) check_UnlessExpression(e *parser.UnlessExpression) {
 	v.checkRValue(e.Test())
+	v.checkConstantCondition(e, e.Test())
+}
+
+func (v *basicChecker) check_VariableExpression(e *parser.VariableExpression) {
+	v.checkLegacyFact(e)
 }
 
 // TODO: Add more validations here
@@ -613,6 +747,59 @@ func (v *basicChecker) checkAssign(e parser.Expression) {
 	}
 }
 
+// checkAttributes validates the given attribute operations against the schema of a resource type. Missing
+// required attributes are only reported when checkRequired is true and no attributes are unfolded from a hash.
+func (v *basicChecker) checkAttributes(schema *ResourceTypeSchema, owner parser.Expression, operations []parser.Expression, checkRequired bool) {
+	seen := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		ao, ok := op.(*parser.AttributeOperation)
+		if !ok {
+			// Attributes unfolded from a hash cannot be known statically
+			checkRequired = false
+			continue
+		}
+		name := ao.Name()
+		seen[name] = true
+		if METAPARAMETERS[name] {
+			continue
+		}
+		attr, ok := schema.Attribute(name)
+		if !ok {
+			v.Accept(VALIDATE_UNKNOWN_ATTRIBUTE, ao, issue.H{
+				`type`: schema.Name, `attribute`: name, `suggestions`: parser.Suggestions(name, schema.AttributeNames())})
+			continue
+		}
+		if ao.Operator() == `=>` {
+			v.checkValueType(VALIDATE_ATTRIBUTE_TYPE_MISMATCH, attr.Type, ao.Value(), issue.H{`type`: schema.Name, `attribute`: name})
+		}
+		if len(attr.Values) == 0 {
+			continue
+		}
+		if value, ok := literal.ToLiteral(ao.Value()); ok {
+			switch value.(type) {
+			case string, bool:
+				str := fmt.Sprint(value)
+				if !attr.AcceptsValue(str) {
+					expected := `'` + strings.Join(attr.Values, `', '`) + `'`
+					if attr.Path {
+						expected += ` or an absolute path`
+					}
+					v.Accept(VALIDATE_INVALID_ATTRIBUTE_VALUE, ao.Value(), issue.H{
+						`type`: schema.Name, `attribute`: name, `value`: str,
+						`expected`: expected, `suggestions`: parser.Suggestions(str, attr.Values)})
+				}
+			}
+		}
+	}
+	if checkRequired {
+		for _, name := range schema.RequiredAttributeNames() {
+			if !seen[name] {
+				v.Accept(VALIDATE_MISSING_REQUIRED_ATTRIBUTE, owner, issue.H{`type`: schema.Name, `attribute`: name})
+			}
+		}
+	}
+}
+
 func (v *basicChecker) checkCaptureLast(container parser.Expression, parameters []parser.Expression) {
 	last := len(parameters) - 1
 	for idx := 0; idx < last; idx++ {
@@ -622,12 +809,104 @@ func (v *basicChecker) checkCaptureLast(container parser.Expression, parameters
 	}
 }
 
+// checkLegacyFact checks for legacy facts such as $osfamily that are removed from newer agents. A legacy
+// fact that is not qualified is only reported when there is no local variable with that name.
+func (v *basicChecker) checkLegacyFact(e *parser.VariableExpression) {
+	name, ok := e.Name()
+	if !ok {
+		return
+	}
+	fact := strings.TrimPrefix(name, `::`)
+	if path, ok := LegacyFact(fact); ok && (fact != name || !v.locals[fact]) {
+		access := FactAccess(path)
+		v.acceptFact(VALIDATE_LEGACY_FACT, e, name, access, issue.H{`name`: name, `replacement`: `$` + access})
+	}
+}
+
+// collectLocals collects the names of all parameters and assigned variables in the program
+func (v *basicChecker) collectLocals(e *parser.Program) {
+	var addAssigned func(lhs parser.Expression)
+	addAssigned = func(lhs parser.Expression) {
+		switch lhs.(type) {
+		case *parser.VariableExpression:
+			if name, ok := lhs.(*parser.VariableExpression).Name(); ok {
+				v.locals[name] = true
+			}
+		case *parser.LiteralList:
+			for _, element := range lhs.(*parser.LiteralList).Elements() {
+				addAssigned(element)
+			}
+		}
+	}
+	e.AllContents(nil, func(path []parser.Expression, expr parser.Expression) {
+		switch expr.(type) {
+		case *parser.Parameter:
+			v.locals[expr.(*parser.Parameter).Name()] = true
+		case *parser.AssignmentExpression:
+			addAssigned(expr.(*parser.AssignmentExpression).Lhs())
+		}
+	})
+}
+
 func (v *basicChecker) checkFutureReservedWord(e parser.Expression, w string) {
 	if _, ok := FUTURE_RESERVED_WORDS[w]; ok {
 		v.Accept(VALIDATE_FUTURE_RESERVED_WORD, e, issue.H{`word`: w})
 	}
 }
 
+func (v *basicChecker) checkFunctionCall(e parser.Expression, name string, args []parser.Expression, lambda parser.Expression) {
+	sig, ok := v.functions.Lookup(name)
+	if !ok {
+		v.Accept(VALIDATE_UNKNOWN_FUNCTION, e, issue.H{`name`: name})
+		return
+	}
+	if sig.Deprecated() {
+		v.Accept(VALIDATE_DEPRECATED_FUNCTION, e, issue.H{`name`: name, `replacement`: sig.Replacement})
+	}
+
+	// The number of arguments is unknown when one of them is unfolded
+	unfolded := false
+	for _, arg := range args {
+		if _, ok := arg.(*parser.UnfoldExpression); ok {
+			unfolded = true
+			break
+		}
+	}
+	if !(unfolded || sig.AcceptsArgCount(len(args))) {
+		v.Accept(VALIDATE_WRONG_ARGUMENT_COUNT, e, issue.H{`name`: name, `expected`: sig.ArgCountString(), `actual`: len(args)})
+	}
+	if !unfolded {
+		v.checkArgumentTypes(sig, args)
+	}
+
+	switch sig.Lambda {
+	case LAMBDA_REQUIRED:
+		if lambda == nil {
+			v.Accept(VALIDATE_LAMBDA_REQUIRED, e, issue.H{`name`: name})
+		}
+	case LAMBDA_NOT_ACCEPTED:
+		if lambda != nil {
+			v.Accept(VALIDATE_LAMBDA_NOT_ACCEPTED, lambda, issue.H{`name`: name})
+		}
+	}
+}
+
+// checkArgumentTypes checks literal arguments against the declared types of the parameters of a function
+// that is defined in Puppet. The type of a parameter that captures rest applies to each remaining argument.
+func (v *basicChecker) checkArgumentTypes(sig *FunctionSignature, args []parser.Expression) {
+	params := sig.Parameters
+	for _, arg := range args {
+		if len(params) == 0 {
+			return
+		}
+		param := params[0].(*parser.Parameter)
+		if !param.CapturesRest() {
+			params = params[1:]
+		}
+		v.checkValueType(VALIDATE_ARGUMENT_TYPE_MISMATCH, param.Type(), arg, issue.H{`name`: sig.Name, `param`: param.Name()})
+	}
+}
+
 func (v *basicChecker) checkHostname(e parser.Expression, hostMatches []parser.Expression) {
 	for _, hostMatch := range hostMatches {
 		// Parser syntax prevents a hostMatch from being something other
@@ -664,6 +943,26 @@ func (v *basicChecker) checkIllegalAssignment(e parser.Expression) {
 	}
 }
 
+// checkValueType reports the given issue when the value is a literal that is not an instance of the given
+// type expression. Nothing is reported when there is no type or when the type is not valid.
+func (v *basicChecker) checkValueType(code issue.Code, t parser.Expression, value parser.Expression, args issue.H) {
+	if t == nil {
+		return
+	}
+	lit, ok := typedLiteral(value)
+	if !ok {
+		return
+	}
+	typ, err := types.FromExpression(t, v.aliases)
+	if err != nil {
+		return
+	}
+	if reason := types.Mismatch(typ, lit); reason != `` {
+		args[`reason`] = reason
+		v.Accept(code, value, args)
+	}
+}
+
 func (v *basicChecker) checkNoCapture(container parser.Expression, parameters []parser.Expression) {
 	for _, parameter := range parameters {
 		if param, ok := parameter.(*parser.Parameter); ok && param.CapturesRest() {
@@ -888,3 +1187,21 @@ func (v *basicChecker) idem_CaseOption(e *parser.CaseOption) bool {
 func (v *basicChecker) idem_IfExpression(e *parser.IfExpression) bool {
 	return v.isIdem(e.Test()) && v.isIdem(e.Then()) && v.isIdem(e.Else())
 }
+
+// typedLiteral returns the literal value of the expression. Regular expressions are not considered
+// literal since their values cannot be told apart from strings.
+func typedLiteral(e parser.Expression) (interface{}, bool) {
+	if _, ok := e.(*parser.RegexpExpression); ok {
+		return nil, false
+	}
+	hasRegexp := false
+	e.AllContents(nil, func(path []parser.Expression, e parser.Expression) {
+		if _, ok := e.(*parser.RegexpExpression); ok {
+			hasRegexp = true
+		}
+	})
+	if hasRegexp {
+		return nil, false
+	}
+	return literal.ToLiteral(e)
+}
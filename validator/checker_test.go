@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/lyraproj/issue/issue"
@@ -660,6 +661,96 @@ func TestReservedWordValidation(t *testing.T) {
 	expectIssues(t, `$x = private`, VALIDATE_RESERVED_WORD)
 }
 
+func TestUnreachableCode(t *testing.T) {
+	expectNoIssues(t,
+		issue.Unindent(`
+      function foo($x) {
+        if $x { return(1) }
+        2
+      }`))
+
+	expectIssues(t,
+		issue.Unindent(`
+      function foo($x) {
+        return(1)
+        notice($x)
+      }`),
+		VALIDATE_UNREACHABLE_CODE)
+
+	expectIssues(t,
+		issue.Unindent(`
+      fail('not supported')
+      notice('x')`),
+		VALIDATE_UNREACHABLE_CODE)
+
+	expectIssues(t,
+		issue.Unindent(`
+      [1, 2].each |$x| {
+        next()
+        notice($x)
+      }`),
+		VALIDATE_UNREACHABLE_CODE)
+}
+
+func TestConstantCondition(t *testing.T) {
+	expectNoIssues(t, `if $x { notice('x') }`)
+	expectIssues(t, `if true { notice('x') }`, VALIDATE_CONSTANT_CONDITION)
+	expectIssues(t, `unless undef { notice('x') }`, VALIDATE_CONSTANT_CONDITION)
+	expectIssues(t, `if $x { notice('x') } elsif ('y') { notice('y') }`, VALIDATE_CONSTANT_CONDITION)
+
+	issues := parseAndValidate(t, `unless undef { notice('x') }`)
+	if len(issues) == 1 && issues[0].Argument(`value`) != false {
+		t.Errorf(`expected condition to always be false, got %s`, issues[0].String())
+	}
+}
+
+func TestDuplicateOption(t *testing.T) {
+	expectNoIssues(t,
+		issue.Unindent(`
+      case $x {
+        'a', /a/, A: { 1 }
+        'b': { 2 }
+        default: { 3 }
+      }`))
+
+	expectIssues(t,
+		issue.Unindent(`
+      case $x {
+        'RedHat', 'CentOS': { 1 }
+        'redhat': { 2 }
+        default: { 3 }
+      }`),
+		VALIDATE_DUPLICATE_OPTION)
+
+	expectIssues(t,
+		issue.Unindent(`
+      $y = $x ? {
+        1       => 'a',
+        1       => 'b',
+        default => 'c',
+      }`),
+		VALIDATE_DUPLICATE_OPTION)
+
+	expectIssues(t, `case $x { 1: {} 1.0: {} }`, VALIDATE_DUPLICATE_OPTION)
+	expectNoIssues(t, `case $x { 1: {} 1.5: {} default: {} }`)
+}
+
+func TestOptionAfterDefault(t *testing.T) {
+	expectIssues(t,
+		issue.Unindent(`
+      case $x {
+        default: { 1 }
+        'a': { 2 }
+      }`),
+		VALIDATE_OPTION_AFTER_DEFAULT)
+}
+
+func TestSelectorWithoutDefault(t *testing.T) {
+	expectNoIssues(t, `$y = $x ? { /a/ => 1, 'b' => 2 }`)
+	expectNoIssues(t, `$y = $x ? { String => 1, 'b' => 2 }`)
+	expectIssues(t, `$y = $x ? { 'a' => 1, 'b' => 2 }`, VALIDATE_SELECTOR_WITHOUT_DEFAULT)
+}
+
 func TestSelectorExpressionValidation(t *testing.T) {
 	expectNoIssues(t,
 		issue.Unindent(`
@@ -677,7 +768,43 @@ func TestSelectorExpressionValidation(t *testing.T) {
         default             => role::generic,
         'RedHat'            => role::redhat,
         default             => role::generic,
-      }`), VALIDATE_DUPLICATE_DEFAULT)
+      }`), VALIDATE_DUPLICATE_DEFAULT, VALIDATE_OPTION_AFTER_DEFAULT)
+}
+
+func TestTypeMismatch(t *testing.T) {
+	expectNoIssues(t, issue.Unindent(`
+    type Foo::Port = Integer[1, 65535]
+    class foo(
+      Boolean $enable = true,
+      Optional[String] $owner = undef,
+      Foo::Port $port = 8080,
+      Pattern[/^a/] $p = 'abc',
+      String $re = /x/,
+      Stdlib::Absolutepath $path = 'relative',
+      Array[String] $list = ['a', $x],
+    ) {}`))
+	expectNoIssues(t, `class foo(Enum $x = 'a') {}`)
+
+	for source, expected := range map[string]string{
+		`class foo(Boolean $enable = 'yes') {}`:                                  `The default value of parameter 'enable' expects a Boolean value, got String`,
+		`class foo(Integer[1] $x = 0) {}`:                                        `The default value of parameter 'x' expects an Integer[1] value, got 0`,
+		`class foo(Array[String] $x = ['a', 1]) {}`:                              `The default value of parameter 'x' index 1 expects a String value, got Integer`,
+		`class foo(Struct[{a => Integer}] $x = {b => 1}) {}`:                     `The default value of parameter 'x' expects a value for key 'a'`,
+		`class foo(Variant[String, Integer] $x = true) {}`:                       `The default value of parameter 'x' expects a value of type String or Integer, got Boolean`,
+		"type Foo::Port = Integer[1, 65535]\nclass foo(Foo::Port $x = 70000) {}": `The default value of parameter 'x' expects an Integer[1, 65535] value, got 70000`,
+		"define foo(Boolean $enable) {}\nfoo { 'x': enable => 'yes' }":           `Attribute 'enable' of resource type 'foo' expects a Boolean value, got String`,
+		"function foo(String $a, Integer *$b) {}\nfoo('x', 1, 'y')":              `Parameter 'b' of function 'foo' expects an Integer value, got String`,
+		"function foo(String $a) {}\nfoo(1)":                                     `Parameter 'a' of function 'foo' expects a String value, got Integer`,
+	} {
+		issues := parseAndValidate(t, source)
+		if len(issues) != 1 {
+			t.Errorf(`expected one issue for %s, got %v`, source, issues)
+			continue
+		}
+		if actual := issues[0].Error(); !strings.HasPrefix(actual, expected+` (`) {
+			t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+		}
+	}
 }
 
 func TestTypeAliasValidation(t *testing.T) {
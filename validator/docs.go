@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// documented is implemented by the definitions that can have puppet-strings documentation
+type documented interface {
+	parser.NamedDefinition
+	Doc() *parser.Doc
+}
+
+type docChecker struct {
+	AbstractValidator
+}
+
+// NewDocChecker creates a validator that checks the puppet-strings documentation of classes, defined
+// types, functions, and plans against their parameters
+func NewDocChecker() Validator {
+	v := &docChecker{}
+	v.severities = make(map[issue.Code]issue.Severity, 3)
+	for _, code := range []issue.Code{
+		DOC_PARAMETER_TYPE_MISMATCH,
+		DOC_UNDOCUMENTED_PARAMETER,
+		DOC_UNKNOWN_PARAMETER,
+	} {
+		v.Demote(code, issue.SEVERITY_WARNING)
+	}
+	return v
+}
+
+// Validate the documentation of the definitions in the expression
+func ValidateDocs(e parser.Expression) Validator {
+	v := NewDocChecker()
+	Validate(v, e)
+	return v
+}
+
+func (v *docChecker) Validate(e parser.Expression) {
+	if d, ok := e.(documented); ok {
+		v.checkDoc(d)
+	}
+}
+
+func (v *docChecker) checkDoc(d documented) {
+	label := DefinitionLabel(d)
+	doc := d.Doc()
+	params := make(map[string]bool, len(d.Parameters()))
+	for _, p := range d.Parameters() {
+		param := p.(*parser.Parameter)
+		params[param.Name()] = true
+		var tag *parser.DocTag
+		if doc != nil {
+			tag = doc.Param(param.Name())
+		}
+		if tag == nil {
+			v.Accept(DOC_UNDOCUMENTED_PARAMETER, param, issue.H{`param`: param.Name(), `definition`: label})
+			continue
+		}
+		if tag.Type != `` && param.Type() != nil && normalizeType(tag.Type) != normalizeType(param.Type().String()) {
+			v.AcceptAt(DOC_PARAMETER_TYPE_MISMATCH, parser.NewLocation(d.Locator(), tag.Offset, tag.Length), issue.H{
+				`param`: param.Name(), `tag_type`: tag.Type, `param_type`: param.Type().String()})
+		}
+	}
+
+	if doc == nil {
+		return
+	}
+	for _, tag := range doc.TagsNamed(`param`) {
+		if !params[tag.Name] {
+			v.AcceptAt(DOC_UNKNOWN_PARAMETER, parser.NewLocation(d.Locator(), tag.Offset, tag.Length), issue.H{
+				`param`: tag.Name, `definition`: label})
+		}
+	}
+}
+
+// DefinitionLabel returns a label such as "class 'foo'" for the given definition
+func DefinitionLabel(d parser.Definition) string {
+	var kind, name string
+	switch d.(type) {
+	case *parser.HostClassDefinition:
+		kind = `class`
+	case *parser.ResourceTypeDefinition:
+		kind = `defined type`
+	case *parser.PlanDefinition:
+		kind = `plan`
+	case *parser.FunctionDefinition:
+		kind = `function`
+	case *parser.TypeAlias:
+		return fmt.Sprintf(`type alias '%s'`, d.(*parser.TypeAlias).Name())
+	default:
+		return d.Label()
+	}
+	name = d.(parser.NamedDefinition).Name()
+	return fmt.Sprintf(`%s '%s'`, kind, name)
+}
+
+func normalizeType(t string) string {
+	return strings.Join(strings.Fields(t), ``)
+}
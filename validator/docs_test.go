@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestDocParameters(t *testing.T) {
+	expectNoDocIssues(t, issue.Unindent(`
+    # @param x The x
+    # @param y The y
+    class foo(Integer $x, $y) {}`))
+
+	expectDocIssues(t, issue.Unindent(`
+    # @param x The x
+    class foo(Integer $x, $y) {}`), DOC_UNDOCUMENTED_PARAMETER)
+
+	expectDocIssues(t, `define foo($x) {}`, DOC_UNDOCUMENTED_PARAMETER)
+
+	expectDocIssues(t, issue.Unindent(`
+    # @param x The x
+    # @param z The z
+    function foo($x) {}`), DOC_UNKNOWN_PARAMETER)
+}
+
+func TestDocParameterType(t *testing.T) {
+	expectNoDocIssues(t, issue.Unindent(`
+    # @param [Array[ String ]] x The x
+    # @param y [String] The y
+    class foo(Array[String] $x, $y) {}`))
+
+	issues := docIssues(t, issue.Unindent(`
+    # @param x [String] The x
+    function foo(Integer $x) {}`))
+	if len(issues) != 1 || issues[0].Code() != DOC_PARAMETER_TYPE_MISMATCH {
+		t.Fatalf(`expected one %s issue, got %v`, DOC_PARAMETER_TYPE_MISMATCH, issues)
+	}
+	if issues[0].Location().Line() != 1 || issues[0].Location().Pos() != 1 || issues[0].Argument(`param_type`) != `Integer` {
+		t.Errorf(`unexpected issue %s`, issues[0].String())
+	}
+}
+
+func docIssues(t *testing.T, source string) []issue.Reported {
+	t.Helper()
+	expr := parse(t, source)
+	if expr == nil {
+		return nil
+	}
+	return ValidateDocs(expr).Issues()
+}
+
+func expectNoDocIssues(t *testing.T, source string) {
+	t.Helper()
+	expectDocIssues(t, source)
+}
+
+func expectDocIssues(t *testing.T, source string, expected ...issue.Code) {
+	t.Helper()
+	issues := docIssues(t, source)
+	if len(issues) != len(expected) {
+		t.Errorf(`expected %v, got %v`, expected, issues)
+		return
+	}
+	for i, code := range expected {
+		if issues[i].Code() != code {
+			t.Errorf(`expected %v, got %v`, expected, issues)
+			return
+		}
+	}
+}
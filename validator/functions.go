@@ -0,0 +1,247 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+const (
+	LAMBDA_NOT_ACCEPTED = LambdaUse(iota)
+	LAMBDA_OPTIONAL
+	LAMBDA_REQUIRED
+)
+
+// UNLIMITED is used as the MaxArgs of a FunctionSignature that accepts any number of arguments
+const UNLIMITED = -1
+
+type (
+	// LambdaUse describes if a function accepts or requires a block (lambda)
+	LambdaUse int
+
+	// FunctionSignature describes what a function accepts in terms of arguments and lambda
+	FunctionSignature struct {
+		Name    string
+		MinArgs int
+		MaxArgs int
+		Lambda  LambdaUse
+
+		// Replacement is set for deprecated functions. It names the function or construct
+		// that should be used instead
+		Replacement string
+
+		// Parameters holds the parameters of a function that is defined in Puppet. It is nil for
+		// other functions.
+		Parameters []parser.Expression
+	}
+
+	// FunctionRegistry is a set of function signatures, keyed by function name
+	FunctionRegistry struct {
+		functions map[string]*FunctionSignature
+	}
+)
+
+// Signatures of the functions that are built into Puppet. Deprecated functions from stdlib are
+// included so that their use can be reported.
+var builtinSignatures = []*FunctionSignature{
+	{Name: `abs`, MinArgs: 1, MaxArgs: 1},
+	{Name: `alert`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `all`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `annotate`, MinArgs: 2, MaxArgs: 3, Lambda: LAMBDA_OPTIONAL},
+	{Name: `any`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `assert_type`, MinArgs: 2, MaxArgs: 2, Lambda: LAMBDA_OPTIONAL},
+	{Name: `binary_file`, MinArgs: 1, MaxArgs: 1},
+	{Name: `break`, MinArgs: 0, MaxArgs: 0},
+	{Name: `call`, MinArgs: 1, MaxArgs: UNLIMITED, Lambda: LAMBDA_OPTIONAL},
+	{Name: `camelcase`, MinArgs: 1, MaxArgs: 1},
+	{Name: `capitalize`, MinArgs: 1, MaxArgs: 1},
+	{Name: `ceiling`, MinArgs: 1, MaxArgs: 1},
+	{Name: `chomp`, MinArgs: 1, MaxArgs: 1},
+	{Name: `chop`, MinArgs: 1, MaxArgs: 1},
+	{Name: `compare`, MinArgs: 2, MaxArgs: 3},
+	{Name: `contain`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `convert_to`, MinArgs: 2, MaxArgs: UNLIMITED, Lambda: LAMBDA_OPTIONAL},
+	{Name: `create_resources`, MinArgs: 2, MaxArgs: 3},
+	{Name: `crit`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `debug`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `defined`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `dig`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `digest`, MinArgs: 1, MaxArgs: 1},
+	{Name: `downcase`, MinArgs: 1, MaxArgs: 1},
+	{Name: `each`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `emerg`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `empty`, MinArgs: 1, MaxArgs: 1},
+	{Name: `epp`, MinArgs: 1, MaxArgs: 2},
+	{Name: `err`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `fail`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `file`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `filter`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `find_file`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `flatten`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `floor`, MinArgs: 1, MaxArgs: 1},
+	{Name: `fqdn_rand`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `generate`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `get`, MinArgs: 2, MaxArgs: 3, Lambda: LAMBDA_OPTIONAL},
+	{Name: `getvar`, MinArgs: 1, MaxArgs: 2},
+	{Name: `group_by`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `hiera`, MinArgs: 1, MaxArgs: 3, Lambda: LAMBDA_OPTIONAL, Replacement: `lookup`},
+	{Name: `hiera_array`, MinArgs: 1, MaxArgs: 3, Lambda: LAMBDA_OPTIONAL, Replacement: `lookup`},
+	{Name: `hiera_hash`, MinArgs: 1, MaxArgs: 3, Lambda: LAMBDA_OPTIONAL, Replacement: `lookup`},
+	{Name: `hiera_include`, MinArgs: 1, MaxArgs: 3, Lambda: LAMBDA_OPTIONAL, Replacement: `lookup`},
+	{Name: `import`, MinArgs: 1, MaxArgs: UNLIMITED, Replacement: `include`},
+	{Name: `include`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `index`, MinArgs: 1, MaxArgs: 2, Lambda: LAMBDA_OPTIONAL},
+	{Name: `info`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `inline_epp`, MinArgs: 1, MaxArgs: 2},
+	{Name: `inline_template`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `join`, MinArgs: 1, MaxArgs: 2},
+	{Name: `keys`, MinArgs: 1, MaxArgs: 1},
+	{Name: `length`, MinArgs: 1, MaxArgs: 1},
+	{Name: `lest`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `lookup`, MinArgs: 1, MaxArgs: 4, Lambda: LAMBDA_OPTIONAL},
+	{Name: `lstrip`, MinArgs: 1, MaxArgs: 1},
+	{Name: `map`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `match`, MinArgs: 2, MaxArgs: 2},
+	{Name: `max`, MinArgs: 1, MaxArgs: UNLIMITED, Lambda: LAMBDA_OPTIONAL},
+	{Name: `md5`, MinArgs: 1, MaxArgs: 1},
+	{Name: `min`, MinArgs: 1, MaxArgs: UNLIMITED, Lambda: LAMBDA_OPTIONAL},
+	{Name: `module_directory`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `new`, MinArgs: 1, MaxArgs: UNLIMITED, Lambda: LAMBDA_OPTIONAL},
+	{Name: `next`, MinArgs: 0, MaxArgs: 1},
+	{Name: `notice`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `partition`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `realize`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `reduce`, MinArgs: 1, MaxArgs: 2, Lambda: LAMBDA_REQUIRED},
+	{Name: `regsubst`, MinArgs: 3, MaxArgs: 5},
+	{Name: `require`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `return`, MinArgs: 0, MaxArgs: 1},
+	{Name: `reverse_each`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_OPTIONAL},
+	{Name: `round`, MinArgs: 1, MaxArgs: 1},
+	{Name: `rstrip`, MinArgs: 1, MaxArgs: 1},
+	{Name: `scanf`, MinArgs: 2, MaxArgs: 2, Lambda: LAMBDA_OPTIONAL},
+	{Name: `sha1`, MinArgs: 1, MaxArgs: 1},
+	{Name: `sha256`, MinArgs: 1, MaxArgs: 1},
+	{Name: `shellquote`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `size`, MinArgs: 1, MaxArgs: 1},
+	{Name: `slice`, MinArgs: 2, MaxArgs: 2, Lambda: LAMBDA_OPTIONAL},
+	{Name: `sort`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_OPTIONAL},
+	{Name: `split`, MinArgs: 2, MaxArgs: 2},
+	{Name: `sprintf`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `step`, MinArgs: 2, MaxArgs: 2, Lambda: LAMBDA_OPTIONAL},
+	{Name: `strftime`, MinArgs: 1, MaxArgs: 3},
+	{Name: `strip`, MinArgs: 1, MaxArgs: 1},
+	{Name: `tag`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `tagged`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `template`, MinArgs: 1, MaxArgs: UNLIMITED},
+	{Name: `then`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_REQUIRED},
+	{Name: `tree_each`, MinArgs: 1, MaxArgs: 2, Lambda: LAMBDA_OPTIONAL},
+	{Name: `type`, MinArgs: 1, MaxArgs: 2},
+	{Name: `unique`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_OPTIONAL},
+	{Name: `unwrap`, MinArgs: 1, MaxArgs: 1, Lambda: LAMBDA_OPTIONAL},
+	{Name: `upcase`, MinArgs: 1, MaxArgs: 1},
+	{Name: `values`, MinArgs: 1, MaxArgs: 1},
+	{Name: `versioncmp`, MinArgs: 2, MaxArgs: 3},
+	{Name: `warning`, MinArgs: 0, MaxArgs: UNLIMITED},
+	{Name: `with`, MinArgs: 0, MaxArgs: UNLIMITED, Lambda: LAMBDA_REQUIRED},
+
+	// Deprecated stdlib functions
+	{Name: `validate_absolute_path`, MinArgs: 1, MaxArgs: UNLIMITED, Replacement: `assert_type(Stdlib::Absolutepath, ...)`},
+	{Name: `validate_array`, MinArgs: 1, MaxArgs: UNLIMITED, Replacement: `assert_type(Array, ...)`},
+	{Name: `validate_bool`, MinArgs: 1, MaxArgs: UNLIMITED, Replacement: `assert_type(Boolean, ...)`},
+	{Name: `validate_hash`, MinArgs: 1, MaxArgs: UNLIMITED, Replacement: `assert_type(Hash, ...)`},
+	{Name: `validate_integer`, MinArgs: 1, MaxArgs: 3, Replacement: `assert_type(Integer, ...)`},
+	{Name: `validate_ip_address`, MinArgs: 1, MaxArgs: UNLIMITED, Replacement: `assert_type(Stdlib::IP::Address, ...)`},
+	{Name: `validate_numeric`, MinArgs: 1, MaxArgs: 3, Replacement: `assert_type(Numeric, ...)`},
+	{Name: `validate_re`, MinArgs: 2, MaxArgs: 3, Replacement: `assert_type(Pattern[...], ...)`},
+	{Name: `validate_slength`, MinArgs: 2, MaxArgs: 3, Replacement: `assert_type(String[...], ...)`},
+	{Name: `validate_string`, MinArgs: 1, MaxArgs: UNLIMITED, Replacement: `assert_type(String, ...)`},
+}
+
+// NewFunctionRegistry creates an empty registry
+func NewFunctionRegistry() *FunctionRegistry {
+	return &FunctionRegistry{make(map[string]*FunctionSignature, 32)}
+}
+
+// BuiltinFunctions creates a new registry that contains the signatures of the functions built
+// into Puppet
+func BuiltinFunctions() *FunctionRegistry {
+	r := NewFunctionRegistry()
+	for _, sig := range builtinSignatures {
+		r.Add(sig)
+	}
+	return r
+}
+
+// Add adds, or replaces, the given signature
+func (r *FunctionRegistry) Add(sig *FunctionSignature) {
+	r.functions[sig.Name] = sig
+}
+
+// AddDefinitions adds signatures for all function definitions found in the given expression. The
+// expression is typically a Program.
+func (r *FunctionRegistry) AddDefinitions(e parser.Expression) {
+	if p, ok := e.(*parser.Program); ok {
+		for _, d := range p.Definitions() {
+			if fd, ok := d.(*parser.FunctionDefinition); ok {
+				r.Add(SignatureOf(fd))
+			}
+		}
+		return
+	}
+	visit := func(path []parser.Expression, e parser.Expression) {
+		if fd, ok := e.(*parser.FunctionDefinition); ok {
+			r.Add(SignatureOf(fd))
+		}
+	}
+	visit(nil, e)
+	e.AllContents(nil, visit)
+}
+
+// Lookup returns the signature for the given function name. A leading '::' in the name is ignored.
+func (r *FunctionRegistry) Lookup(name string) (sig *FunctionSignature, ok bool) {
+	sig, ok = r.functions[strings.TrimPrefix(name, `::`)]
+	return
+}
+
+// SignatureOf returns the signature of the given function definition. Parameters with
+// a default value are optional and a parameter that captures rest makes the number of
+// arguments unlimited. A function definition never accepts a lambda.
+func SignatureOf(fd *parser.FunctionDefinition) *FunctionSignature {
+	sig := &FunctionSignature{Name: fd.Name(), Lambda: LAMBDA_NOT_ACCEPTED, Parameters: fd.Parameters()}
+	for _, p := range fd.Parameters() {
+		param := p.(*parser.Parameter)
+		if param.CapturesRest() {
+			sig.MaxArgs = UNLIMITED
+			break
+		}
+		if param.Value() == nil {
+			sig.MinArgs++
+		}
+		sig.MaxArgs++
+	}
+	return sig
+}
+
+// Deprecated returns true if the signature has a replacement
+func (s *FunctionSignature) Deprecated() bool {
+	return s.Replacement != ``
+}
+
+// AcceptsArgCount returns true if the given number of arguments is within the min and max
+// bounds of the signature
+func (s *FunctionSignature) AcceptsArgCount(count int) bool {
+	return count >= s.MinArgs && (s.MaxArgs == UNLIMITED || count <= s.MaxArgs)
+}
+
+// ArgCountString returns a human readable string describing the number of expected arguments
+func (s *FunctionSignature) ArgCountString() string {
+	switch {
+	case s.MaxArgs == UNLIMITED:
+		return fmt.Sprintf(`at least %d`, s.MinArgs)
+	case s.MinArgs == s.MaxArgs:
+		return fmt.Sprintf(`%d`, s.MinArgs)
+	default:
+		return fmt.Sprintf(`between %d and %d`, s.MinArgs, s.MaxArgs)
+	}
+}
@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestFunctionArgumentCount(t *testing.T) {
+	expectNoIssues(t, `notice('a', 'b')`)
+	expectNoIssues(t, `$x = split('a,b', ',')`)
+	expectNoIssues(t, `$x = regsubst($y, 'a', 'b', 'G')`)
+	expectNoIssues(t, `$x = split(*$args)`)
+
+	expectIssues(t, `$x = split('a,b')`, VALIDATE_WRONG_ARGUMENT_COUNT)
+	expectIssues(t, `$x = regsubst($y, 'a')`, VALIDATE_WRONG_ARGUMENT_COUNT)
+	expectIssues(t, `include()`, VALIDATE_WRONG_ARGUMENT_COUNT)
+	expectIssues(t, `$x = 'a,b'.split()`, VALIDATE_WRONG_ARGUMENT_COUNT)
+	expectIssues(t, `$x = get($y)`, VALIDATE_WRONG_ARGUMENT_COUNT)
+	expectNoIssues(t, `$x = $y.get('a.b')`)
+}
+
+func TestFunctionLambda(t *testing.T) {
+	expectNoIssues(t, `[1,2].each |$x| { notice($x) }`)
+	expectNoIssues(t, `$x = [1,2].sort`)
+	expectNoIssues(t, `$x = [1,2].sort |$a, $b| { compare($a, $b) }`)
+
+	expectIssues(t, `$x = [1,2].map`, VALIDATE_LAMBDA_REQUIRED)
+	expectIssues(t, `each([1,2])`, VALIDATE_LAMBDA_REQUIRED)
+	expectIssues(t, `$x = split('a,b', ',') |$x| { $x }`, VALIDATE_LAMBDA_NOT_ACCEPTED)
+}
+
+func TestDemoteFunctionCallIssues(t *testing.T) {
+	expr := parse(t, issue.Unindent(`
+    $x = split('a,b')
+    $y = [1,2].map
+    $z = split('a,b', ',') |$x| { $x }`))
+	if expr == nil {
+		return
+	}
+	v := NewChecker(STRICT_ERROR)
+	v.Demote(VALIDATE_WRONG_ARGUMENT_COUNT, issue.SEVERITY_WARNING)
+	v.Demote(VALIDATE_LAMBDA_REQUIRED, issue.SEVERITY_WARNING)
+	v.Demote(VALIDATE_LAMBDA_NOT_ACCEPTED, issue.SEVERITY_IGNORE)
+	Validate(v, expr)
+	issues := v.Issues()
+	if len(issues) != 2 || issues[0].Severity() != issue.SEVERITY_WARNING || issues[1].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`expected two warnings, got %v`, issues)
+	}
+}
+
+func TestDeprecatedFunction(t *testing.T) {
+	issues := parseAndValidate(t, `validate_string($x)`)
+	if len(issues) != 1 || issues[0].Code() != VALIDATE_DEPRECATED_FUNCTION {
+		t.Fatalf(`expected one %s issue, got %v`, VALIDATE_DEPRECATED_FUNCTION, issues)
+	}
+	if issues[0].Severity() != issue.SEVERITY_DEPRECATION {
+		t.Errorf(`expected severity %s, got %s`, issue.SEVERITY_DEPRECATION, issues[0].Severity())
+	}
+}
+
+func TestDefinedFunctionSignature(t *testing.T) {
+	expectNoIssues(t, issue.Unindent(`
+    function foo(String $a, Integer $b = 1) {}
+    foo('x')
+    foo('x', 2)`))
+
+	expectIssues(t, issue.Unindent(`
+    function foo(String $a, Integer $b = 1) {}
+    foo('x', 2, 3)`),
+		VALIDATE_WRONG_ARGUMENT_COUNT)
+
+	expectIssues(t, issue.Unindent(`
+    function foo(String $a) {}
+    foo('x') |$y| { $y }`),
+		VALIDATE_LAMBDA_NOT_ACCEPTED)
+
+	expectNoIssues(t, issue.Unindent(`
+    function foo(String $a, *$rest) {}
+    foo('x', 2, 3, 4)`))
+}
+
+func TestUnknownFunction(t *testing.T) {
+	expectNoIssues(t, `no_such_function()`)
+
+	expr := parse(t, `no_such_function() notice('x')`)
+	if expr == nil {
+		return
+	}
+	v := NewChecker(STRICT_ERROR)
+	v.Demote(VALIDATE_UNKNOWN_FUNCTION, issue.SEVERITY_WARNING)
+	Validate(v, expr)
+	issues := v.Issues()
+	if len(issues) != 1 || issues[0].Code() != VALIDATE_UNKNOWN_FUNCTION {
+		t.Errorf(`expected one %s issue, got %v`, VALIDATE_UNKNOWN_FUNCTION, issues)
+	}
+
+	// Functions registered from other sources are known
+	v.Functions().Add(&FunctionSignature{Name: `no_such_function`})
+	Validate(v, expr)
+	if len(v.Issues()) != 0 {
+		t.Errorf(`expected no issues, got %v`, v.Issues())
+	}
+}
@@ -2,18 +2,61 @@ package validator
 
 import (
 	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+const (
+	DOC_PARAMETER_TYPE_MISMATCH = `DOC_PARAMETER_TYPE_MISMATCH`
+	DOC_UNDOCUMENTED_PARAMETER  = `DOC_UNDOCUMENTED_PARAMETER`
+	DOC_UNKNOWN_PARAMETER       = `DOC_UNKNOWN_PARAMETER`
+)
+
+const (
+	METRICS_THRESHOLD_EXCEEDED = `METRICS_THRESHOLD_EXCEEDED`
+)
+
+const (
+	REGEXP_ANCHOR_DIFFERENCE = `REGEXP_ANCHOR_DIFFERENCE`
+	REGEXP_ENGINE_DIFFERENCE = `REGEXP_ENGINE_DIFFERENCE`
+	REGEXP_SYNTAX_ERROR      = `REGEXP_SYNTAX_ERROR`
+)
+
+const (
+	SECURITY_EXEC_INTERPOLATION    = `SECURITY_EXEC_INTERPOLATION`
+	SECURITY_INTERPOLATED_TEMPLATE = `SECURITY_INTERPOLATED_TEMPLATE`
+	SECURITY_PIPE_TO_SHELL         = `SECURITY_PIPE_TO_SHELL`
+	SECURITY_PLAINTEXT_SECRET      = `SECURITY_PLAINTEXT_SECRET`
+	SECURITY_WORLD_WRITABLE_MODE   = `SECURITY_WORLD_WRITABLE_MODE`
+)
+
+const (
+	STYLE_ARROW_ALIGNMENT         = `STYLE_ARROW_ALIGNMENT`
+	STYLE_DOUBLE_QUOTED_STRING    = `STYLE_DOUBLE_QUOTED_STRING`
+	STYLE_ENSURE_NOT_FIRST        = `STYLE_ENSURE_NOT_FIRST`
+	STYLE_HARD_TAB                = `STYLE_HARD_TAB`
+	STYLE_LINE_TOO_LONG           = `STYLE_LINE_TOO_LONG`
+	STYLE_TOP_SCOPE_FACT          = `STYLE_TOP_SCOPE_FACT`
+	STYLE_TRAILING_WHITESPACE     = `STYLE_TRAILING_WHITESPACE`
+	STYLE_UNQUOTED_RESOURCE_TITLE = `STYLE_UNQUOTED_RESOURCE_TITLE`
 )
 
 const (
 	VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED = `VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED`
+	VALIDATE_ARGUMENT_TYPE_MISMATCH              = `VALIDATE_ARGUMENT_TYPE_MISMATCH`
+	VALIDATE_ATTRIBUTE_TYPE_MISMATCH             = `VALIDATE_ATTRIBUTE_TYPE_MISMATCH`
 	VALIDATE_CAPTURES_REST_NOT_LAST              = `VALIDATE_CAPTURES_REST_NOT_LAST`
 	VALIDATE_CAPTURES_REST_NOT_SUPPORTED         = `VALIDATE_CAPTURES_REST_NOT_SUPPORTED`
 	VALIDATE_CATALOG_OPERATION_NOT_SUPPORTED     = `VALIDATE_CATALOG_OPERATION_NOT_SUPPORTED`
+	VALIDATE_CONSTANT_CONDITION                  = `VALIDATE_CONSTANT_CONDITION`
 	VALIDATE_CROSS_SCOPE_ASSIGNMENT              = `VALIDATE_CROSS_SCOPE_ASSIGNMENT`
+	VALIDATE_DEFAULT_TYPE_MISMATCH               = `VALIDATE_DEFAULT_TYPE_MISMATCH`
+	VALIDATE_DEPRECATED_FUNCTION                 = `VALIDATE_DEPRECATED_FUNCTION`
 	VALIDATE_DUPLICATE_DEFAULT                   = `VALIDATE_DUPLICATE_DEFAULT`
 	VALIDATE_DUPLICATE_KEY                       = `VALIDATE_DUPLICATE_KEY`
+	VALIDATE_DUPLICATE_OPTION                    = `VALIDATE_DUPLICATE_OPTION`
 	VALIDATE_DUPLICATE_PARAMETER                 = `VALIDATE_DUPLICATE_PARAMETER`
 	VALIDATE_FUTURE_RESERVED_WORD                = `VALIDATE_FUTURE_RESERVED_WORD`
+	VALIDATE_HEREDOC_SYNTAX_ERROR                = `VALIDATE_HEREDOC_SYNTAX_ERROR`
 	VALIDATE_IDEM_EXPRESSION_NOT_LAST            = `VALIDATE_IDEM_EXPRESSION_NOT_LAST`
 	VALIDATE_IDEM_NOT_ALLOWED_LAST               = `VALIDATE_IDEM_NOT_ALLOWED_LAST`
 	VALIDATE_ILLEGAL_ASSIGNMENT_CONTEXT          = `VALIDATE_ILLEGAL_ASSIGNMENT_CONTEXT`
@@ -31,22 +74,77 @@ const (
 	VALIDATE_ILLEGAL_REGEXP_TYPE_MAPPING         = `VALIDATE_ILLEGAL_REGEXP_TYPE_MAPPING`
 	VALIDATE_ILLEGAL_SINGLE_TYPE_MAPPING         = `VALIDATE_ILLEGAL_SINGLE_TYPE_MAPPING`
 	VALIDATE_INVALID_ACTIVITY_STYLE              = `VALIDATE_INVALID_ACTIVITY_STYLE`
+	VALIDATE_INVALID_ATTRIBUTE_VALUE             = `VALIDATE_INVALID_ATTRIBUTE_VALUE`
+	VALIDATE_LAMBDA_NOT_ACCEPTED                 = `VALIDATE_LAMBDA_NOT_ACCEPTED`
+	VALIDATE_LAMBDA_REQUIRED                     = `VALIDATE_LAMBDA_REQUIRED`
+	VALIDATE_LEGACY_FACT                         = `VALIDATE_LEGACY_FACT`
+	VALIDATE_MISSING_REQUIRED_ATTRIBUTE          = `VALIDATE_MISSING_REQUIRED_ATTRIBUTE`
 	VALIDATE_MULTIPLE_ATTRIBUTES_UNFOLD          = `VALIDATE_MULTIPLE_ATTRIBUTES_UNFOLD`
 	VALIDATE_NOT_ABSOLUTE_TOP_LEVEL              = `VALIDATE_NOT_ABSOLUTE_TOP_LEVEL`
 	VALIDATE_NOT_RVALUE                          = `VALIDATE_NOT_RVALUE`
 	VALIDATE_NOT_TOP_LEVEL                       = `VALIDATE_NOT_TOP_LEVEL`
 	VALIDATE_NOT_VIRTUALIZABLE                   = `VALIDATE_NOT_VIRTUALIZABLE`
+	VALIDATE_OPTION_AFTER_DEFAULT                = `VALIDATE_OPTION_AFTER_DEFAULT`
 	VALIDATE_RESERVED_PARAMETER                  = `VALIDATE_RESERVED_PARAMETER`
 	VALIDATE_RESERVED_TYPE_NAME                  = `VALIDATE_RESERVED_TYPE_NAME`
 	VALIDATE_RESERVED_WORD                       = `VALIDATE_RESERVED_WORD`
+	VALIDATE_SELECTOR_WITHOUT_DEFAULT            = `VALIDATE_SELECTOR_WITHOUT_DEFAULT`
+	VALIDATE_UNKNOWN_ATTRIBUTE                   = `VALIDATE_UNKNOWN_ATTRIBUTE`
+	VALIDATE_UNKNOWN_FUNCTION                    = `VALIDATE_UNKNOWN_FUNCTION`
+	VALIDATE_UNREACHABLE_CODE                    = `VALIDATE_UNREACHABLE_CODE`
 	VALIDATE_UNSUPPORTED_EXPRESSION              = `VALIDATE_UNSUPPORTED_EXPRESSION`
 	VALIDATE_UNSUPPORTED_OPERATOR_IN_CONTEXT     = `VALIDATE_UNSUPPORTED_OPERATOR_IN_CONTEXT`
 	VALIDATE_WORKFLOW_OPERATION_NOT_SUPPORTED    = `VALIDATE_WORKFLOW_OPERATION_NOT_SUPPORTED`
+	VALIDATE_WRONG_ARGUMENT_COUNT                = `VALIDATE_WRONG_ARGUMENT_COUNT`
 )
 
 func init() {
+	issue.Soft(DOC_PARAMETER_TYPE_MISMATCH, `The @param tag of parameter '%{param}' has type %{tag_type} but the parameter is declared as %{param_type}`)
+
+	issue.Soft(DOC_UNDOCUMENTED_PARAMETER, `Parameter '%{param}' of %{definition} has no @param tag`)
+
+	issue.Soft(DOC_UNKNOWN_PARAMETER, `The @param tag '%{param}' does not match any parameter of %{definition}`)
+
+	issue.Soft(METRICS_THRESHOLD_EXCEEDED, `The %{metric} of %{definition} is %{value}, which exceeds the maximum of %{max}`)
+
+	issue.Soft(REGEXP_ANCHOR_DIFFERENCE, `The %{construct} in /%{pattern}/ %{difference}`)
+
+	issue.Soft(REGEXP_ENGINE_DIFFERENCE, `The %{construct} in /%{pattern}/ %{difference}`)
+
+	issue.Hard(REGEXP_SYNTAX_ERROR, `Invalid regular expression /%{pattern}/: %{message} at character %{position}`)
+
+	issue.Soft(SECURITY_EXEC_INTERPOLATION, `Interpolated value in exec attribute '%{attribute}' is not escaped. Use shell_escape() to prevent command injection`)
+
+	issue.Soft(SECURITY_INTERPOLATED_TEMPLATE, `The template given to %{function}() is an interpolated string. Interpolated values become template code`)
+
+	issue.Soft(SECURITY_PIPE_TO_SHELL, `Exec attribute '%{attribute}' pipes a downloaded script to a shell`)
+
+	issue.Soft(SECURITY_PLAINTEXT_SECRET, `Attribute '%{attribute}' is assigned a plaintext value. Wrap the value in Sensitive()`)
+
+	issue.Soft(SECURITY_WORLD_WRITABLE_MODE, `File mode '%{mode}' is world writable`)
+
+	issue.Soft(STYLE_ARROW_ALIGNMENT, `Arrow is not aligned. Expected it in column %{expected}, found it in column %{actual}`)
+
+	issue.Soft(STYLE_DOUBLE_QUOTED_STRING, `Double quoted string "%{value}" contains no interpolation or escapes. Use single quotes`)
+
+	issue.Soft(STYLE_ENSURE_NOT_FIRST, `The 'ensure' attribute should be the first attribute of the resource`)
+
+	issue.Soft(STYLE_HARD_TAB, `Hard tab character found. Use spaces for indentation and alignment`)
+
+	issue.Soft(STYLE_LINE_TOO_LONG, `Line has %{length} characters. The maximum is %{max}`)
+
+	issue.Soft(STYLE_TOP_SCOPE_FACT, `Top scope variable $%{name} used to access a fact. Use $facts['%{fact}'] instead`)
+
+	issue.Soft(STYLE_TRAILING_WHITESPACE, `Trailing whitespace found`)
+
+	issue.Soft(STYLE_UNQUOTED_RESOURCE_TITLE, `Unquoted resource title '%{title}'. Resource titles should be quoted`)
+
 	issue.Hard(VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED, `The operator '%{operator}' is no longer supported. See http://links.puppet.com/remove-plus-equals`)
 
+	issue.Hard(VALIDATE_ARGUMENT_TYPE_MISMATCH, `Parameter '%{param}' of function '%{name}' %{reason}`)
+
+	issue.Hard(VALIDATE_ATTRIBUTE_TYPE_MISMATCH, `Attribute '%{attribute}' of resource type '%{type}' %{reason}`)
+
 	issue.Hard(VALIDATE_CAPTURES_REST_NOT_LAST, `Parameter $%{param} is not last, and has 'captures rest'`)
 
 	issue.Hard2(VALIDATE_CAPTURES_REST_NOT_SUPPORTED,
@@ -55,18 +153,30 @@ func init() {
 
 	issue.Hard(VALIDATE_CATALOG_OPERATION_NOT_SUPPORTED, `The catalog operation '%{operation}' is only available when compiling a catalog`)
 
+	issue.Soft2(VALIDATE_CONSTANT_CONDITION, `The condition of this %{expression} is always %{value}`,
+		issue.HF{`expression`: issue.Label})
+
 	issue.Hard(VALIDATE_CROSS_SCOPE_ASSIGNMENT, `Illegal attempt to assign to '%{name}'. Cannot assign to variables in other namespaces`)
 
+	issue.Hard(VALIDATE_DEFAULT_TYPE_MISMATCH, `The default value of parameter '%{param}' %{reason}`)
+
+	issue.Soft(VALIDATE_DEPRECATED_FUNCTION, `The function '%{name}' is deprecated. Use %{replacement} instead`)
+
 	issue.Hard2(VALIDATE_DUPLICATE_DEFAULT,
 		`This %{container} already has a 'default' entry - this is a duplicate`,
 		issue.HF{`container`: issue.Label})
 
 	issue.Soft(VALIDATE_DUPLICATE_KEY, `The key '%{key}' is declared more than once`)
 
+	issue.Soft2(VALIDATE_DUPLICATE_OPTION, `The option value %{value} is already matched by a previous option of this %{container}`,
+		issue.HF{`container`: issue.Label})
+
 	issue.Hard(VALIDATE_DUPLICATE_PARAMETER, `The parameter '%{param}' is declared more than once in the parameter list`)
 
 	issue.Soft(VALIDATE_FUTURE_RESERVED_WORD, `Use of future reserved word: '%{word}'`)
 
+	issue.Soft(VALIDATE_HEREDOC_SYNTAX_ERROR, `Heredoc with syntax '%{syntax}' is not valid: %{message}`)
+
 	issue.Soft2(VALIDATE_IDEM_EXPRESSION_NOT_LAST,
 		`This %{expression} has no effect. A value was produced and then forgotten (one or more preceding expressions may have the wrong form)`,
 		issue.HF{`expression`: issue.Label})
@@ -118,6 +228,18 @@ func init() {
 
 	issue.Hard(VALIDATE_INVALID_ACTIVITY_STYLE, `Expected one of 'for', 'function', 'guard', 'resource', or 'workflow'. Got '%{style}'`)
 
+	issue.Soft2(VALIDATE_INVALID_ATTRIBUTE_VALUE,
+		`Invalid value '%{value}' for attribute '%{attribute}' of resource type '%{type}'. Expected one of %{expected}.%{suggestions}`,
+		issue.HF{`suggestions`: parser.DidYouMean})
+
+	issue.Soft(VALIDATE_LAMBDA_NOT_ACCEPTED, `The function '%{name}' does not accept a block`)
+
+	issue.Soft(VALIDATE_LAMBDA_REQUIRED, `The function '%{name}' requires a block`)
+
+	issue.Soft(VALIDATE_LEGACY_FACT, `Legacy fact $%{name} is not available in newer agents. Use %{replacement} instead`)
+
+	issue.Soft(VALIDATE_MISSING_REQUIRED_ATTRIBUTE, `The resource type '%{type}' requires the attribute '%{attribute}'`)
+
 	issue.Hard(VALIDATE_MULTIPLE_ATTRIBUTES_UNFOLD, `Unfolding of attributes from Hash can only be used once per resource body`)
 
 	issue.Hard2(VALIDATE_NOT_ABSOLUTE_TOP_LEVEL,
@@ -132,6 +254,10 @@ func init() {
 
 	issue.Hard(VALIDATE_NOT_VIRTUALIZABLE, `Resource Defaults/Overrides are not virtualizable`)
 
+	issue.Soft2(VALIDATE_OPTION_AFTER_DEFAULT,
+		`This option follows the default option of the %{container}. The default option is only used when no other option matches and should be last`,
+		issue.HF{`container`: issue.Label})
+
 	issue.Hard2(VALIDATE_RESERVED_PARAMETER,
 		`The parameter $%{param} redefines a built in parameter in %{container}`,
 		issue.HF{`container`: issue.AnOrA})
@@ -142,6 +268,16 @@ func init() {
 
 	issue.Hard(VALIDATE_RESERVED_WORD, `Use of reserved word: %{word}, must be quoted if intended to be a String value`)
 
+	issue.Soft(VALIDATE_SELECTOR_WITHOUT_DEFAULT, `This selector has no default option and fails when no option matches`)
+
+	issue.Soft2(VALIDATE_UNKNOWN_ATTRIBUTE,
+		`The resource type '%{type}' has no attribute named '%{attribute}'.%{suggestions}`,
+		issue.HF{`suggestions`: parser.DidYouMean})
+
+	issue.Soft(VALIDATE_UNKNOWN_FUNCTION, `Unknown function: '%{name}'`)
+
+	issue.Soft(VALIDATE_UNREACHABLE_CODE, `Unreachable code. It follows a call to '%{name}' which never returns`)
+
 	issue.Hard2(VALIDATE_UNSUPPORTED_EXPRESSION,
 		`Expressions of type %{expression} are not supported in this version of Puppet`,
 		issue.HF{`expression`: issue.AnOrA})
@@ -151,4 +287,6 @@ func init() {
 		issue.HF{`value`: issue.AnOrA})
 
 	issue.Hard(VALIDATE_WORKFLOW_OPERATION_NOT_SUPPORTED, `The workflow operation '%{operation}' is only available when compiling workflows`)
+
+	issue.Soft(VALIDATE_WRONG_ARGUMENT_COUNT, `The function '%{name}' expects %{expected} arguments, got %{actual}`)
 }
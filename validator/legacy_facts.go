@@ -0,0 +1,285 @@
+package validator
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Legacy facts and the path to the corresponding value in the structured $facts hash
+var LEGACY_FACTS = map[string][]interface{}{
+	`architecture`:              {`os`, `architecture`},
+	`augeasversion`:             {`augeas`, `version`},
+	`bios_release_date`:         {`dmi`, `bios`, `release_date`},
+	`bios_vendor`:               {`dmi`, `bios`, `vendor`},
+	`bios_version`:              {`dmi`, `bios`, `version`},
+	`boardassettag`:             {`dmi`, `board`, `asset_tag`},
+	`boardmanufacturer`:         {`dmi`, `board`, `manufacturer`},
+	`boardproductname`:          {`dmi`, `board`, `product`},
+	`boardserialnumber`:         {`dmi`, `board`, `serial_number`},
+	`chassisassettag`:           {`dmi`, `chassis`, `asset_tag`},
+	`chassistype`:               {`dmi`, `chassis`, `type`},
+	`domain`:                    {`networking`, `domain`},
+	`fqdn`:                      {`networking`, `fqdn`},
+	`gid`:                       {`identity`, `group`},
+	`hardwareisa`:               {`processors`, `isa`},
+	`hardwaremodel`:             {`os`, `hardware`},
+	`hostname`:                  {`networking`, `hostname`},
+	`id`:                        {`identity`, `user`},
+	`ipaddress`:                 {`networking`, `ip`},
+	`ipaddress6`:                {`networking`, `ip6`},
+	`lsbdistcodename`:           {`os`, `distro`, `codename`},
+	`lsbdistdescription`:        {`os`, `distro`, `description`},
+	`lsbdistid`:                 {`os`, `distro`, `id`},
+	`lsbdistrelease`:            {`os`, `distro`, `release`, `full`},
+	`lsbmajdistrelease`:         {`os`, `distro`, `release`, `major`},
+	`lsbminordistrelease`:       {`os`, `distro`, `release`, `minor`},
+	`macaddress`:                {`networking`, `mac`},
+	`manufacturer`:              {`dmi`, `manufacturer`},
+	`memoryfree`:                {`memory`, `system`, `available`},
+	`memorysize`:                {`memory`, `system`, `total`},
+	`netmask`:                   {`networking`, `netmask`},
+	`netmask6`:                  {`networking`, `netmask6`},
+	`network`:                   {`networking`, `network`},
+	`network6`:                  {`networking`, `network6`},
+	`operatingsystem`:           {`os`, `name`},
+	`operatingsystemmajrelease`: {`os`, `release`, `major`},
+	`operatingsystemrelease`:    {`os`, `release`, `full`},
+	`osfamily`:                  {`os`, `family`},
+	`physicalprocessorcount`:    {`processors`, `physicalcount`},
+	`processorcount`:            {`processors`, `count`},
+	`productname`:               {`dmi`, `product`, `name`},
+	`rubyplatform`:              {`ruby`, `platform`},
+	`rubysitedir`:               {`ruby`, `sitedir`},
+	`rubyversion`:               {`ruby`, `version`},
+	`selinux`:                   {`os`, `selinux`, `enabled`},
+	`selinux_config_mode`:       {`os`, `selinux`, `config_mode`},
+	`selinux_config_policy`:     {`os`, `selinux`, `config_policy`},
+	`selinux_current_mode`:      {`os`, `selinux`, `current_mode`},
+	`selinux_enforced`:          {`os`, `selinux`, `enforced`},
+	`selinux_policyversion`:     {`os`, `selinux`, `policy_version`},
+	`serialnumber`:              {`dmi`, `product`, `serial_number`},
+	`swapencrypted`:             {`memory`, `swap`, `encrypted`},
+	`swapfree`:                  {`memory`, `swap`, `available`},
+	`swapsize`:                  {`memory`, `swap`, `total`},
+	`system32`:                  {`os`, `windows`, `system32`},
+	`uptime`:                    {`system_uptime`, `uptime`},
+	`uptime_days`:               {`system_uptime`, `days`},
+	`uptime_hours`:              {`system_uptime`, `hours`},
+	`uptime_seconds`:            {`system_uptime`, `seconds`},
+	`uuid`:                      {`dmi`, `product`, `uuid`},
+	`xendomains`:                {`xen`, `domains`},
+	`zonename`:                  {`solaris_zones`, `current`},
+}
+
+type (
+	// FactKeys are the keys of a structured fact, and the keys of their values in turn. A key with a nil
+	// value has no keys, and ANY_KEYS stands for keys that vary between nodes.
+	FactKeys map[string]FactKeys
+)
+
+// The keys of a hash with keys that vary between nodes, e.g. the names of the network interfaces
+var ANY_KEYS = FactKeys{`*`: nil}
+
+// The top level facts of newer agents and their keys. A top scope variable with one of these names is most
+// likely the fact, whereas other top scope variables may be set by an ENC, site.pp, or the server.
+var FACT_KEYS = FactKeys{
+	`aio_agent_version`: nil,
+	`augeas`:            {`version`: nil},
+	`az_metadata`:       ANY_KEYS,
+	`cloud`:             {`provider`: nil},
+	`disks`:             ANY_KEYS,
+	`dmi`: {
+		`bios`:         {`release_date`: nil, `vendor`: nil, `version`: nil},
+		`board`:        {`asset_tag`: nil, `manufacturer`: nil, `product`: nil, `serial_number`: nil},
+		`chassis`:      {`asset_tag`: nil, `type`: nil},
+		`manufacturer`: nil,
+		`product`:      {`name`: nil, `serial_number`: nil, `uuid`: nil},
+	},
+	`ec2_metadata`:           ANY_KEYS,
+	`ec2_userdata`:           nil,
+	`env_windows_installdir`: nil,
+	`facterversion`:          nil,
+	`filesystems`:            nil,
+	`fips_enabled`:           nil,
+	`gce`:                    ANY_KEYS,
+	`hypervisors`:            ANY_KEYS,
+	`identity`:               {`gid`: nil, `group`: nil, `privileged`: nil, `uid`: nil, `user`: nil},
+	`is_virtual`:             nil,
+	`kernel`:                 nil,
+	`kernelmajversion`:       nil,
+	`kernelrelease`:          nil,
+	`kernelversion`:          nil,
+	`ldom`:                   ANY_KEYS,
+	`load_averages`:          {`15m`: nil, `1m`: nil, `5m`: nil},
+	`memory`: {
+		`swap`:   {`available`: nil, `available_bytes`: nil, `capacity`: nil, `encrypted`: nil, `total`: nil, `total_bytes`: nil, `used`: nil, `used_bytes`: nil},
+		`system`: {`available`: nil, `available_bytes`: nil, `capacity`: nil, `total`: nil, `total_bytes`: nil, `used`: nil, `used_bytes`: nil},
+	},
+	`mountpoints`: ANY_KEYS,
+	`networking`: {
+		`dhcp`: nil, `domain`: nil, `fqdn`: nil, `hostname`: nil, `interfaces`: ANY_KEYS, `ip`: nil, `ip6`: nil, `mac`: nil,
+		`mtu`: nil, `netmask`: nil, `netmask6`: nil, `network`: nil, `network6`: nil, `primary`: nil, `scope6`: nil,
+	},
+	`os`: {
+		`architecture`: nil,
+		`distro`: {
+			`codename`: nil, `description`: nil, `id`: nil, `release`: {`full`: nil, `major`: nil, `minor`: nil}, `specification`: nil,
+		},
+		`family`:   nil,
+		`hardware`: nil,
+		`macosx`:   {`build`: nil, `product`: nil, `version`: {`full`: nil, `major`: nil, `minor`: nil, `patch`: nil}},
+		`name`:     nil,
+		`release`:  {`full`: nil, `major`: nil, `minor`: nil},
+		`selinux`: {
+			`config_mode`: nil, `config_policy`: nil, `current_mode`: nil, `enabled`: nil, `enforced`: nil, `policy_version`: nil,
+		},
+		`windows`: {
+			`display_version`: nil, `edition_id`: nil, `installation_type`: nil, `product_name`: nil, `release_id`: nil, `system32`: nil,
+		},
+	},
+	`partitions`: ANY_KEYS,
+	`path`:       nil,
+	`processors`: {
+		`cores`: nil, `count`: nil, `extensions`: ANY_KEYS, `isa`: nil, `models`: ANY_KEYS, `physicalcount`: nil, `speed`: nil, `threads`: nil,
+	},
+	`puppetversion`:        nil,
+	`ruby`:                 {`platform`: nil, `sitedir`: nil, `version`: nil},
+	`solaris_zones`:        {`current`: nil, `zones`: ANY_KEYS},
+	`ssh`:                  ANY_KEYS,
+	`system_profiler`:      ANY_KEYS,
+	`system_uptime`:        {`days`: nil, `hours`: nil, `seconds`: nil, `uptime`: nil},
+	`timezone`:             nil,
+	`virtual`:              nil,
+	`xen`:                  {`domains`: ANY_KEYS},
+	`zfs_featurenumbers`:   nil,
+	`zfs_version`:          nil,
+	`zpool_featurenumbers`: nil,
+	`zpool_version`:        nil,
+}
+
+// The keys of the $trusted hash
+var TRUSTED_KEYS = FactKeys{
+	`authenticated`: nil,
+	`certname`:      nil,
+	`domain`:        nil,
+	`extensions`:    ANY_KEYS,
+	`external`:      ANY_KEYS,
+	`hostname`:      nil,
+}
+
+// Legacy facts that are specific to a network interface, and the key of the corresponding value in the
+// structured networking.interfaces hash
+var LEGACY_INTERFACE_FACTS = map[string]string{
+	`ipaddress`:  `ip`,
+	`ipaddress6`: `ip6`,
+	`macaddress`: `mac`,
+	`mtu`:        `mtu`,
+	`netmask`:    `netmask`,
+	`netmask6`:   `netmask6`,
+	`network`:    `network`,
+	`network6`:   `network6`,
+}
+
+var legacyProcessorFact = regexp.MustCompile(`\Aprocessor([0-9]+)\z`)
+
+var legacyBlockDeviceFact = regexp.MustCompile(`\Ablockdevice_([a-z0-9]+)_(model|size|vendor)\z`)
+
+// LegacyFact returns the path to the value in the structured $facts hash that replaces the given legacy
+// fact. The elements of the path are strings or, for array indexes, integers.
+func LegacyFact(name string) (path []interface{}, ok bool) {
+	if path, ok = LEGACY_FACTS[name]; ok {
+		return
+	}
+	if m := legacyProcessorFact.FindStringSubmatch(name); m != nil {
+		index, _ := strconv.Atoi(m[1])
+		return []interface{}{`processors`, `models`, index}, true
+	}
+	if m := legacyBlockDeviceFact.FindStringSubmatch(name); m != nil {
+		return []interface{}{`disks`, m[1], m[2]}, true
+	}
+	if idx := strings.IndexByte(name, '_'); idx > 0 && idx < len(name)-1 {
+		if key, found := LEGACY_INTERFACE_FACTS[name[:idx]]; found {
+			return []interface{}{`networking`, `interfaces`, name[idx+1:], key}, true
+		}
+	}
+	return nil, false
+}
+
+// Lookup returns the keys of the value of the given key, and false if the key is unknown
+func (k FactKeys) Lookup(key string) (FactKeys, bool) {
+	if _, ok := k[`*`]; ok {
+		return ANY_KEYS, true
+	}
+	keys, ok := k[key]
+	return keys, ok
+}
+
+// Names returns the sorted names of the keys
+func (k FactKeys) Names() []string {
+	names := make([]string, 0, len(k))
+	for name := range k {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FactAccess returns the access expression, without the leading '$', that reads the value at the given
+// path in the $facts hash, e.g. facts['os']['family']
+func FactAccess(path []interface{}) string {
+	b := bytes.NewBufferString(`facts`)
+	for _, key := range path {
+		if index, ok := key.(int); ok {
+			b.WriteString(`[` + strconv.Itoa(index) + `]`)
+		} else {
+			b.WriteString(`['` + key.(string) + `']`)
+		}
+	}
+	return b.String()
+}
+
+// acceptFact reports an issue for the variable with the given name together with an edit that replaces it
+// with the given access expression, provided that the variable can be found in the source
+func (v *AbstractValidator) acceptFact(code issue.Code, e *parser.VariableExpression, name, access string, args issue.H) {
+	source := e.Locator().String()
+	start := e.ByteOffset()
+	var edit *parser.Edit
+	var length int
+	if v.isInterpolated() {
+		switch {
+		case strings.HasPrefix(source[start:], `${`+name):
+			// Only the name is replaced when the variable is enclosed in braces
+			edit = &parser.Edit{Offset: start + 2, Length: len(name), Text: access}
+			length = len(name) + 2
+		case strings.HasPrefix(source[start:], `$`+name):
+			edit = &parser.Edit{Offset: start, Length: len(name) + 1, Text: `${` + access + `}`}
+			length = len(name) + 1
+		}
+	} else if strings.HasPrefix(source[start:], `$`+name) {
+		edit = &parser.Edit{Offset: start, Length: len(name) + 1, Text: `$` + access}
+		length = len(name) + 1
+	}
+
+	if edit == nil {
+		v.Accept(code, e, args)
+		return
+	}
+	args[`edits`] = []*parser.Edit{edit}
+	v.AcceptAt(code, parser.NewLocation(e.Locator(), start, length), args)
+}
+
+// isInterpolated returns true if the currently validated expression is part of an interpolated string
+func (v *AbstractValidator) isInterpolated() bool {
+	for _, p := range v.path {
+		if _, ok := p.(*parser.ConcatenatedString); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package validator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestLegacyFactValidation(t *testing.T) {
+	expectNoIssues(t, `notice($facts['os']['family'])`)
+	expectIssues(t, `notice($::osfamily)`, VALIDATE_LEGACY_FACT)
+	expectIssues(t, `notice($operatingsystem, $ipaddress_eth0, $processor0)`, VALIDATE_LEGACY_FACT)
+
+	// Local variables and parameters that share the name of a legacy fact are not reported
+	expectNoIssues(t, "$hostname = 'x'\nnotice($hostname)")
+	expectNoIssues(t, "class foo(String $domain) {\n  notice($domain)\n}")
+	expectIssues(t, "$hostname = 'x'\nnotice($::hostname)", VALIDATE_LEGACY_FACT)
+
+	issues := parseAndValidate(t, `notice($::osfamily)`)
+	if len(issues) != 1 || issues[0].Argument(`replacement`) != `$facts['os']['family']` || issues[0].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`unexpected issues %v`, issues)
+	}
+
+	expr := parse(t, `notice($::osfamily)`)
+	if expr == nil {
+		return
+	}
+	v := NewChecker(STRICT_ERROR)
+	v.Demote(VALIDATE_LEGACY_FACT, issue.SEVERITY_IGNORE)
+	Validate(v, expr)
+	if len(v.Issues()) != 0 {
+		t.Errorf(`expected no issues, got %v`, v.Issues())
+	}
+}
+
+func TestLegacyFactFixes(t *testing.T) {
+	expectLegacyFactFix(t, `notice($::osfamily, $operatingsystem)`, `notice($facts['os']['family'], $facts['os']['name'])`)
+	expectLegacyFactFix(t, `notice("${::osfamily}-$osfamily ${osfamily.upcase}")`,
+		`notice("${facts['os']['family']}-${facts['os']['family']} ${facts['os']['family'].upcase}")`)
+}
+
+func TestLegacyFact(t *testing.T) {
+	for name, expected := range map[string]string{
+		`osfamily`:             `facts['os']['family']`,
+		`lsbdistrelease`:       `facts['os']['distro']['release']['full']`,
+		`macaddress_eth0`:      `facts['networking']['interfaces']['eth0']['mac']`,
+		`processor3`:           `facts['processors']['models'][3]`,
+		`blockdevice_sda_size`: `facts['disks']['sda']['size']`,
+	} {
+		path, ok := LegacyFact(name)
+		if !ok {
+			t.Errorf(`expected %s to be a legacy fact`, name)
+		} else if actual := FactAccess(path); actual != expected {
+			t.Errorf(`expected %s to be replaced by %s, got %s`, name, expected, actual)
+		}
+	}
+	if _, ok := LegacyFact(`kernel`); ok {
+		t.Errorf(`did not expect kernel to be a legacy fact`)
+	}
+}
+
+func TestFactKeys(t *testing.T) {
+	// The replacement of each legacy fact must be a known fact
+	for name := range LEGACY_FACTS {
+		path, _ := LegacyFact(name)
+		keys := FACT_KEYS
+		for _, key := range path {
+			var ok bool
+			if keys, ok = keys.Lookup(fmt.Sprint(key)); !ok {
+				t.Errorf(`the replacement %s of %s is not a known fact`, FactAccess(path), name)
+				break
+			}
+		}
+	}
+
+	if keys, ok := FACT_KEYS.Lookup(`networking`); !ok {
+		t.Error(`expected networking to be a known fact`)
+	} else if _, ok = keys.Lookup(`hostnme`); ok {
+		t.Error(`did not expect hostnme to be a known key of networking`)
+	} else if keys, ok = keys.Lookup(`interfaces`); !ok {
+		t.Error(`expected interfaces to be a known key of networking`)
+	} else if _, ok = keys.Lookup(`eth0`); !ok {
+		t.Error(`expected any key of networking.interfaces to be known`)
+	}
+	if _, ok := FACT_KEYS[`kernel`].Lookup(`x`); ok {
+		t.Error(`did not expect kernel to have keys`)
+	}
+}
+
+// expectLegacyFactFix applies the edits of all issues found in the source and checks that the result is
+// equal to the expected source and free from issues
+func expectLegacyFactFix(t *testing.T, source, expected string) {
+	t.Helper()
+	var edits []*parser.Edit
+	for _, i := range parseAndValidate(t, source) {
+		edits = append(edits, parser.IssueEdits(i)...)
+	}
+	result, _ := parser.ApplyEdits(source, edits)
+	if result != expected {
+		t.Errorf(`expected fix of %q to produce %q, got %q`, source, expected, result)
+		return
+	}
+	expectNoIssues(t, result)
+}
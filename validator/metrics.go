@@ -0,0 +1,42 @@
+package validator
+
+import (
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/metrics"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type metricsChecker struct {
+	AbstractValidator
+	thresholds metrics.Thresholds
+}
+
+// NewMetricsChecker creates a validator that reports a warning for each metric of a definition that
+// exceeds its threshold. The metrics are computed when the validated expression is a Program.
+func NewMetricsChecker(thresholds metrics.Thresholds) Validator {
+	v := &metricsChecker{thresholds: thresholds}
+	v.severities = make(map[issue.Code]issue.Severity, 1)
+	v.Demote(METRICS_THRESHOLD_EXCEEDED, issue.SEVERITY_WARNING)
+	return v
+}
+
+// Validate the metrics of the expression against the given thresholds
+func ValidateMetrics(e parser.Expression, thresholds metrics.Thresholds) Validator {
+	v := NewMetricsChecker(thresholds)
+	Validate(v, e)
+	return v
+}
+
+func (v *metricsChecker) Validate(e parser.Expression) {
+	program, ok := e.(*parser.Program)
+	if !ok {
+		return
+	}
+	for _, m := range metrics.Compute(program) {
+		for _, name := range v.thresholds.Exceeded(m) {
+			value, _ := m.Value(name)
+			v.Accept(METRICS_THRESHOLD_EXCEEDED, m.Expression, issue.H{
+				`metric`: name, `definition`: m.Label(), `value`: value, `max`: v.thresholds[name]})
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/metrics"
+)
+
+func TestMetricsThresholds(t *testing.T) {
+	expr := parse(t, issue.Unindent(`
+    class foo($a, $b) {
+      if $a { if $b { notice('x') } }
+    }`))
+	if expr == nil {
+		return
+	}
+	issues := ValidateMetrics(expr, metrics.Thresholds{metrics.DEPTH: 1, metrics.PARAMETERS: 2}).Issues()
+	if len(issues) != 1 || issues[0].Code() != METRICS_THRESHOLD_EXCEEDED || issues[0].Severity() != issue.SEVERITY_WARNING {
+		t.Fatalf(`expected one %s warning, got %v`, METRICS_THRESHOLD_EXCEEDED, issues)
+	}
+	if issues[0].Argument(`metric`) != metrics.DEPTH || issues[0].Argument(`value`) != 2 || issues[0].Location().Line() != 1 {
+		t.Errorf(`unexpected issue %s`, issues[0].String())
+	}
+}
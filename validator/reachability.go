@@ -0,0 +1,175 @@
+package validator
+
+import (
+	"math"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Functions that never return control to the statement that follows the call
+var NON_RETURNING_FUNCTIONS = map[string]bool{
+	`break`:  true,
+	`fail`:   true,
+	`next`:   true,
+	`return`: true,
+}
+
+type (
+	// Keys used when comparing the values of case and selector options. Values of different kinds are never
+	// equal even when their textual representation is.
+	regexpOptionKey string
+	typeOptionKey   string
+)
+
+// checkUnreachable reports the first statement in the block that follows a call that never returns
+func (v *basicChecker) checkUnreachable(e *parser.BlockExpression) {
+	statements := e.Statements()
+	if len(statements) < 2 {
+		return
+	}
+	for idx, statement := range statements[:len(statements)-1] {
+		if name, ok := nonReturningCall(statement); ok {
+			v.Accept(VALIDATE_UNREACHABLE_CODE, statements[idx+1], issue.H{`name`: name})
+			return
+		}
+	}
+}
+
+// checkConstantCondition reports if or unless expressions that have a literal condition
+func (v *basicChecker) checkConstantCondition(e parser.Expression, test parser.Expression) {
+	if value, ok := literalTruth(test); ok {
+		v.Accept(VALIDATE_CONSTANT_CONDITION, test, issue.H{`expression`: e, `value`: value})
+	}
+}
+
+// checkCaseOptions reports duplicate option values and options that follow the default option
+func (v *basicChecker) checkCaseOptions(e *parser.CaseExpression) {
+	seen := make(map[interface{}]bool)
+	afterDefault := false
+	for _, option := range e.Options() {
+		co := option.(*parser.CaseOption)
+		isDefault := false
+		for _, value := range co.Values() {
+			if _, ok := value.(*parser.LiteralDefault); ok {
+				isDefault = true
+				continue
+			}
+			v.checkDuplicateOption(e, value, seen)
+		}
+
+		// A duplicate default is reported elsewhere
+		if afterDefault && !isDefault {
+			v.Accept(VALIDATE_OPTION_AFTER_DEFAULT, co, issue.H{`container`: e})
+			afterDefault = false
+		}
+		afterDefault = afterDefault || isDefault
+	}
+}
+
+// checkSelectorOptions reports duplicate option values, options that follow the default option, and
+// selectors that lack a default option although all options are literal values
+func (v *basicChecker) checkSelectorOptions(e *parser.SelectorExpression) {
+	seen := make(map[interface{}]bool)
+	hasDefault := false
+	afterDefault := false
+	allLiteral := true
+	for _, entry := range e.Selectors() {
+		se := entry.(*parser.SelectorEntry)
+		matching := se.Matching()
+		if _, ok := matching.(*parser.LiteralDefault); ok {
+			hasDefault = true
+			afterDefault = true
+			continue
+		}
+		if afterDefault {
+			v.Accept(VALIDATE_OPTION_AFTER_DEFAULT, se, issue.H{`container`: e})
+			afterDefault = false
+		}
+		key := v.checkDuplicateOption(e, matching, seen)
+		switch key.(type) {
+		case nil, regexpOptionKey, typeOptionKey:
+			allLiteral = false
+		}
+	}
+	if !hasDefault && allLiteral {
+		v.Accept(VALIDATE_SELECTOR_WITHOUT_DEFAULT, e, issue.NO_ARGS)
+	}
+}
+
+// checkDuplicateOption reports the given option value if it is equal to a value that has been seen
+// before. The key of the value is returned, or nil if the value is not a literal.
+func (v *basicChecker) checkDuplicateOption(container, value parser.Expression, seen map[interface{}]bool) interface{} {
+	key := optionKey(value)
+	if key == nil {
+		return nil
+	}
+	if seen[key] {
+		v.Accept(VALIDATE_DUPLICATE_OPTION, value, issue.H{`value`: value.String(), `container`: container})
+	} else {
+		seen[key] = true
+	}
+	return key
+}
+
+// nonReturningCall returns the name of the called function if the expression is a call to a function
+// that never returns
+func nonReturningCall(e parser.Expression) (name string, ok bool) {
+	if call, isCall := e.(*parser.CallNamedFunctionExpression); isCall {
+		if qn, isName := call.Functor().(*parser.QualifiedName); isName && NON_RETURNING_FUNCTIONS[qn.Name()] {
+			return qn.Name(), true
+		}
+	}
+	return ``, false
+}
+
+// literalTruth returns the truth of the given expression if it is a literal value. Only undef and false
+// are considered false.
+func literalTruth(e parser.Expression) (value bool, ok bool) {
+	if pe, isParen := e.(*parser.ParenthesizedExpression); isParen {
+		return literalTruth(pe.Expr())
+	}
+	switch e.(type) {
+	case *parser.LiteralBoolean:
+		return e.(*parser.LiteralBoolean).Bool(), true
+	case *parser.LiteralUndef:
+		return false, true
+	case *parser.LiteralFloat, *parser.LiteralInteger, *parser.LiteralString:
+		return true, true
+	case *parser.ConcatenatedString:
+		_, ok = literal.ToLiteral(e)
+		return ok, ok
+	}
+	return false, false
+}
+
+// optionKey returns a key that is equal for option values that match the same values, or nil if the
+// option value is not a literal. String matching is case insensitive and numbers match when their
+// values are equal.
+func optionKey(e parser.Expression) interface{} {
+	switch e.(type) {
+	case *parser.ParenthesizedExpression:
+		return optionKey(e.(*parser.ParenthesizedExpression).Expr())
+	case *parser.RegexpExpression:
+		return regexpOptionKey(e.(*parser.RegexpExpression).PatternString())
+	case *parser.QualifiedReference:
+		return typeOptionKey(strings.ToLower(e.(*parser.QualifiedReference).Name()))
+	case *parser.LiteralBoolean, *parser.LiteralFloat, *parser.LiteralInteger, *parser.LiteralString,
+		*parser.ConcatenatedString, *parser.QualifiedName:
+		if value, ok := literal.ToLiteral(e); ok {
+			switch value.(type) {
+			case string:
+				return strings.ToLower(value.(string))
+			case float64:
+				// A float with an integral value matches the same values as the integer
+				if f := value.(float64); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+					return int64(f)
+				}
+			}
+			return value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package validator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type regexpChecker struct {
+	AbstractValidator
+}
+
+// NewRegexpChecker creates a validator that checks regular expressions using the syntax of Ruby, which is
+// what Puppet uses to compile them. The checked expressions are regexp literals, the string arguments
+// of the Pattern and Regexp types, and strings on the right hand side of a match operator. Besides
+// syntax errors, it reports constructs whose meaning differs between Ruby and other regular expression
+// engines. The '^' and '$' anchors are reported with a code of their own so that they can be demoted
+// separately.
+func NewRegexpChecker() Validator {
+	v := &regexpChecker{}
+	v.severities = make(map[issue.Code]issue.Severity, 5)
+	v.Demote(REGEXP_ANCHOR_DIFFERENCE, issue.SEVERITY_WARNING)
+	v.Demote(REGEXP_ENGINE_DIFFERENCE, issue.SEVERITY_WARNING)
+	return v
+}
+
+// Validate the expression using the regular expression checker
+func ValidateRegexps(e parser.Expression) Validator {
+	v := NewRegexpChecker()
+	Validate(v, e)
+	return v
+}
+
+func (v *regexpChecker) Validate(e parser.Expression) {
+	switch e.(type) {
+	case *parser.RegexpExpression:
+		v.checkPattern(e, e.(*parser.RegexpExpression).PatternString())
+	case *parser.AccessExpression:
+		ae := e.(*parser.AccessExpression)
+		if ref, ok := ae.Operand().(*parser.QualifiedReference); ok && (ref.Name() == `Pattern` || ref.Name() == `Regexp`) {
+			for _, key := range ae.Keys() {
+				if str, ok := key.(*parser.LiteralString); ok {
+					v.checkPattern(str, str.StringValue())
+				}
+			}
+		}
+	case *parser.MatchExpression:
+		if str, ok := e.(*parser.MatchExpression).Rhs().(*parser.LiteralString); ok {
+			v.checkPattern(str, str.StringValue())
+		}
+	}
+}
+
+func (v *regexpChecker) checkPattern(e parser.Expression, pattern string) {
+	err, notes := parseRubyRegexp(pattern)
+
+	// The pattern is shown between slashes
+	shown := strings.Replace(pattern, `/`, `\/`, -1)
+	for _, note := range notes {
+		v.AcceptAt(note.code, patternLocation(e, pattern, note.offset),
+			issue.H{`construct`: note.construct, `pattern`: shown, `difference`: note.difference})
+	}
+	if err != nil {
+		v.AcceptAt(REGEXP_SYNTAX_ERROR, patternLocation(e, pattern, err.Offset), issue.H{
+			`pattern`: shown, `message`: err.Message, `position`: utf8.RuneCountInString(pattern[:err.Offset]) + 1})
+	}
+}
+
+// patternLocation returns the location in the source of the byte at the given offset in the pattern of
+// a regexp literal or a string. Escapes in the source, such as \/ in a regexp literal, are skipped
+// since they are not part of the pattern.
+func patternLocation(e parser.Expression, pattern string, offset int) issue.Location {
+	source := e.Locator().String()
+	start := e.ByteOffset() + 1
+	end := e.ByteOffset() + e.ByteLength() - 1
+	if start > end || end > len(source) {
+		return e
+	}
+	source = source[start:end]
+	si := 0
+	for pi := 0; pi < offset && si < len(source); si++ {
+		if pattern[pi] == source[si] {
+			pi++
+		}
+	}
+	if si < len(source) && source[si] == '\\' && (offset >= len(pattern) || pattern[offset] != '\\') {
+		// Skip the escape of an escaped delimiter
+		si++
+	}
+	return parser.NewLocation(e.Locator(), start+si, 0)
+}
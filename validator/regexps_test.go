@@ -0,0 +1,169 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestRubyRegexpSyntax(t *testing.T) {
+	for _, pattern := range []string{
+		`\Aabc\z`,
+		`a{2,3}b{,2}c{2}d{2,}`,
+		`a{x}b{}c{,}`,
+		`[a-z&&[^aeiou]][[:alpha:]][[:^digit:]][]a][\]\-]`,
+		`(?<year>\d{4})-(?<month>\d\d)\k<year>\g<month>`,
+		`(a)(b)\2\1`,
+		`(?i:abc)(?mx-i) a b # comment`,
+		`(?# a comment )x*`,
+		`(?=a)(?!b)(?<=c)(?<!d)(?>e)`,
+		`\x41é\u{1F600}\p{Alpha}\p{^Digit}\0\e\cA\C-a\M-a`,
+		`a*?b+?c??`,
+		`\/etc\/.*`,
+	} {
+		if err, _ := parseRubyRegexp(pattern); err != nil {
+			t.Errorf(`unexpected error in /%s/: %s at %d`, pattern, err.Message, err.Offset)
+		}
+	}
+
+	for _, tc := range []struct {
+		pattern string
+		message string
+		offset  int
+	}{
+		{`*a`, `target of repeat operator is not specified`, 0},
+		{`a|+b`, `target of repeat operator is not specified`, 2},
+		{`^*`, `target of repeat operator is invalid`, 1},
+		{`(?=a)*`, `target of repeat operator is invalid`, 5},
+		{`a{3,2}`, `upper bound must be greater than lower bound`, 1},
+		{`a{100001}`, `too big number for repeat range`, 1},
+		{`ab)`, `unmatched close parenthesis`, 2},
+		{`a(b(c)`, `end pattern with unmatched parenthesis`, 1},
+		{`(?`, `end pattern in group`, 0},
+		{`(?P<name>a)`, `undefined group option`, 0},
+		{`(?s)a`, `undefined group option`, 0},
+		{`(?<1a>x)`, `invalid group name <1a>`, 0},
+		{`[a-z`, `premature end of char-class`, 0},
+		{`x[z-a]`, `empty range in char class`, 2},
+		{`[[:alfa:]]`, `invalid POSIX bracket type`, 1},
+		{`a\`, `too short escape sequence`, 1},
+		{`\xZ`, `invalid hex escape`, 0},
+		{`\u12`, `invalid Unicode escape`, 0},
+		{`\pL`, `invalid character property name {p}`, 0},
+		{`(a)\2`, `invalid backref number/name`, 3},
+		{`(?<a>x)\k<b>`, `undefined name <b> reference`, 7},
+		{`(?<a>x)\1`, `numbered backref/call is not allowed. (use name)`, 7},
+		{`\g<x>`, `undefined name <x> call`, 0},
+	} {
+		err, _ := parseRubyRegexp(tc.pattern)
+		if err == nil {
+			t.Errorf(`expected an error in /%s/`, tc.pattern)
+			continue
+		}
+		if err.Message != tc.message || err.Offset != tc.offset {
+			t.Errorf(`expected '%s' at %d in /%s/, got '%s' at %d`, tc.message, tc.offset, tc.pattern, err.Message, err.Offset)
+		}
+	}
+}
+
+func TestRubyRegexpDifferences(t *testing.T) {
+	for pattern, expected := range map[string][]string{
+		`^a.*$`:          {`anchors '^' and '$'`},
+		`^a|^b`:          {`anchor '^'`},
+		`a$`:             {`anchor '$'`},
+		`a++b*+`:         {`possessive quantifier '++'`, `possessive quantifier '*+'`},
+		`a{2}?b{1,2}?`:   {`quantifier '{2}?'`},
+		`a{1,2}+`:        {`quantifier '{1,2}+'`},
+		`(?<x>a)(b)`:     {`named group`},
+		`(?<x>a)(?:b)`:   {},
+		`(?<x>a)(?<y>b)`: {},
+		`(?m:.)\h`:       {`option 'm'`, `escape '\h'`},
+		`(a)\1(?>b)`:     {`atomic group`, `backreference`},
+		`\Aa(?=b)\z`:     {`lookahead`},
+		`[\^$]\$\^[\h]x`: {`escape '\h'`},
+	} {
+		_, notes := parseRubyRegexp(pattern)
+		actual := make([]string, len(notes))
+		for i, note := range notes {
+			actual[i] = note.construct
+		}
+		if strings.Join(actual, `, `) != strings.Join(expected, `, `) {
+			t.Errorf(`expected %v in /%s/, got %v`, expected, pattern, actual)
+		}
+	}
+}
+
+func TestRegexpChecker(t *testing.T) {
+	expectNoRegexpIssues(t, `$x = $y =~ /\Aa+\z/`)
+	expectNoRegexpIssues(t, `type Id = Pattern[/\A\d+\z/, '\A[a-f]+\z']`)
+	expectNoRegexpIssues(t, `$x = $y =~ "a${z}"`)
+
+	expectRegexpIssues(t, `$x = $y =~ /a(b/`, REGEXP_SYNTAX_ERROR)
+	expectRegexpIssues(t, `$x = $y =~ '[b'`, REGEXP_SYNTAX_ERROR)
+	expectRegexpIssues(t, `type Id = Pattern['a**', /^a/]`, REGEXP_ANCHOR_DIFFERENCE)
+	expectRegexpIssues(t, `type Id = Regexp['(?P<a>x)']`, REGEXP_SYNTAX_ERROR)
+	expectRegexpIssues(t, `node /^web\d+$/ {}`, REGEXP_ANCHOR_DIFFERENCE)
+
+	issues := regexpIssues(t, issue.Unindent(`
+    $a = 1
+    if $x =~ /\/x\/(?<n>y)\k<m>(z)/ {}`))
+	if len(issues) != 2 {
+		t.Fatalf(`unexpected issues %v`, issues)
+	}
+	if issues[0].Severity() != issue.SEVERITY_WARNING || issues[1].Severity() != issue.SEVERITY_ERROR {
+		t.Errorf(`unexpected severities %v`, issues)
+	}
+	ri := issues[1]
+	if !strings.HasPrefix(ri.Error(), `Invalid regular expression /\/x\/(?<n>y)\k<m>(z)/: undefined name <m> reference at character 11`) {
+		t.Errorf(`unexpected message %s`, ri)
+	}
+	if loc := ri.Location(); loc.Line() != 2 || loc.Pos() != 23 {
+		t.Errorf(`expected the error at line 2, column 23, got %s`, ri)
+	}
+	if loc := issues[0].Location(); loc.Line() != 2 || loc.Pos() != 16 {
+		t.Errorf(`expected the warning at line 2, column 16, got %s`, issues[0])
+	}
+}
+
+func TestDemoteRegexpAnchorDifference(t *testing.T) {
+	expr := parse(t, `node /^web\h+$/ {}`)
+	if expr == nil {
+		return
+	}
+	v := NewRegexpChecker()
+	v.Demote(REGEXP_ANCHOR_DIFFERENCE, issue.SEVERITY_IGNORE)
+	Validate(v, expr)
+	if issues := v.Issues(); len(issues) != 1 || issues[0].Code() != REGEXP_ENGINE_DIFFERENCE {
+		t.Errorf(`expected only the engine difference, got %v`, issues)
+	}
+}
+
+func regexpIssues(t *testing.T, source string) []issue.Reported {
+	t.Helper()
+	expr := parse(t, source)
+	if expr == nil {
+		return nil
+	}
+	return ValidateRegexps(expr).Issues()
+}
+
+func expectNoRegexpIssues(t *testing.T, source string) {
+	t.Helper()
+	expectRegexpIssues(t, source)
+}
+
+func expectRegexpIssues(t *testing.T, source string, expected ...issue.Code) {
+	t.Helper()
+	issues := regexpIssues(t, source)
+	if len(issues) != len(expected) {
+		t.Errorf(`expected %v, got %v`, expected, issues)
+		return
+	}
+	for i, code := range expected {
+		if issues[i].Code() != code {
+			t.Errorf(`expected %v, got %v`, expected, issues)
+			return
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package validator
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type (
+	// AttributeSchema describes one attribute of a resource type. When Values is non empty, a literal
+	// value assigned to the attribute must be one of those values, or an absolute path when Path is
+	// true. Type is the declared type of a parameter of a defined type.
+	AttributeSchema struct {
+		Required bool              `json:"required,omitempty"`
+		Values   []string          `json:"values,omitempty"`
+		Path     bool              `json:"path,omitempty"`
+		Type     parser.Expression `json:"-"`
+	}
+
+	// ResourceTypeSchema describes the attributes of a resource type
+	ResourceTypeSchema struct {
+		Name       string                      `json:"name"`
+		Attributes map[string]*AttributeSchema `json:"attributes"`
+	}
+
+	// ResourceTypeRegistry is a set of resource type schemas keyed by lower case type name
+	ResourceTypeRegistry struct {
+		types map[string]*ResourceTypeSchema
+	}
+)
+
+// Matches an absolute POSIX or Windows path
+var absolutePath = regexp.MustCompile(`\A(?:/|[A-Za-z]:[/\\]|\\\\)`)
+
+// Metaparameters are valid for all resource types
+var METAPARAMETERS = map[string]bool{
+	`alias`:     true,
+	`audit`:     true,
+	`before`:    true,
+	`loglevel`:  true,
+	`noop`:      true,
+	`notify`:    true,
+	`require`:   true,
+	`schedule`:  true,
+	`stage`:     true,
+	`subscribe`: true,
+	`tag`:       true,
+}
+
+// NewResourceTypeRegistry creates an empty registry
+func NewResourceTypeRegistry() *ResourceTypeRegistry {
+	return &ResourceTypeRegistry{make(map[string]*ResourceTypeSchema, 16)}
+}
+
+// CoreResourceTypes creates a new registry that contains the schemas of the core resource types
+func CoreResourceTypes() *ResourceTypeRegistry {
+	r, err := LoadResourceTypes(strings.NewReader(coreResourceTypes))
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadResourceTypes reads a JSON array of resource type schemas from the given reader and returns
+// a registry that contains them.
+func LoadResourceTypes(rdr io.Reader) (*ResourceTypeRegistry, error) {
+	var schemas []*ResourceTypeSchema
+	if err := json.NewDecoder(rdr).Decode(&schemas); err != nil {
+		return nil, err
+	}
+	r := NewResourceTypeRegistry()
+	for _, schema := range schemas {
+		r.Add(schema)
+	}
+	return r, nil
+}
+
+// Add adds, or replaces, the given schema
+func (r *ResourceTypeRegistry) Add(schema *ResourceTypeSchema) {
+	r.types[strings.ToLower(schema.Name)] = schema
+}
+
+// AddDefinitions adds schemas for all resource type definitions ('define') found in the given
+// expression. The expression is typically a Program.
+func (r *ResourceTypeRegistry) AddDefinitions(e parser.Expression) {
+	if p, ok := e.(*parser.Program); ok {
+		for _, d := range p.Definitions() {
+			if rd, ok := d.(*parser.ResourceTypeDefinition); ok {
+				r.Add(SchemaOf(rd))
+			}
+		}
+		return
+	}
+	visit := func(path []parser.Expression, e parser.Expression) {
+		if rd, ok := e.(*parser.ResourceTypeDefinition); ok {
+			r.Add(SchemaOf(rd))
+		}
+	}
+	visit(nil, e)
+	e.AllContents(nil, visit)
+}
+
+// Lookup returns the schema for the given type name. The lookup is case insensitive and a
+// leading '::' in the name is ignored.
+func (r *ResourceTypeRegistry) Lookup(name string) (schema *ResourceTypeSchema, ok bool) {
+	schema, ok = r.types[strings.ToLower(strings.TrimPrefix(name, `::`))]
+	return
+}
+
+// SchemaOf returns the schema of the given resource type definition. A parameter is required
+// unless it has a default value or is declared with an Optional type.
+func SchemaOf(rd *parser.ResourceTypeDefinition) *ResourceTypeSchema {
+	attrs := make(map[string]*AttributeSchema, len(rd.Parameters())+1)
+	attrs[`name`] = &AttributeSchema{}
+	for _, p := range rd.Parameters() {
+		param := p.(*parser.Parameter)
+		attrs[param.Name()] = &AttributeSchema{Required: param.Value() == nil && !isOptionalType(param.Type()), Type: param.Type()}
+	}
+	return &ResourceTypeSchema{Name: rd.Name(), Attributes: attrs}
+}
+
+// Attribute returns the schema for the attribute with the given name
+func (s *ResourceTypeSchema) Attribute(name string) (attr *AttributeSchema, ok bool) {
+	attr, ok = s.Attributes[name]
+	return
+}
+
+// AttributeNames returns the sorted names of all attributes of the schema
+func (s *ResourceTypeSchema) AttributeNames() []string {
+	names := make([]string, 0, len(s.Attributes))
+	for name := range s.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RequiredAttributeNames returns the sorted names of all required attributes of the schema
+func (s *ResourceTypeSchema) RequiredAttributeNames() []string {
+	names := make([]string, 0)
+	for name, attr := range s.Attributes {
+		if attr.Required {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AcceptsValue returns true if the given string is an accepted value for the attribute
+func (a *AttributeSchema) AcceptsValue(value string) bool {
+	if len(a.Values) == 0 {
+		return true
+	}
+	for _, v := range a.Values {
+		if v == value {
+			return true
+		}
+	}
+	return a.Path && absolutePath.MatchString(value)
+}
+
+func isOptionalType(t parser.Expression) bool {
+	if ae, ok := t.(*parser.AccessExpression); ok {
+		t = ae.Operand()
+	}
+	if qr, ok := t.(*parser.QualifiedReference); ok {
+		switch qr.Name() {
+		case `Optional`, `Undef`, `Any`:
+			return true
+		}
+	}
+	return false
+}
+
+// Schemas for the core resource types in JSON form
+const coreResourceTypes = `[
+  {
+    "name": "cron",
+    "attributes": {
+      "name": {}, "command": {}, "ensure": {"values": ["present", "absent"]},
+      "environment": {}, "hour": {}, "minute": {}, "month": {}, "monthday": {}, "provider": {},
+      "special": {}, "target": {}, "user": {}, "weekday": {}
+    }
+  },
+  {
+    "name": "exec",
+    "attributes": {
+      "name": {}, "command": {}, "creates": {}, "cwd": {}, "environment": {}, "group": {},
+      "logoutput": {"values": ["true", "false", "on_failure"]}, "onlyif": {}, "path": {}, "provider": {},
+      "refresh": {}, "refreshonly": {"values": ["true", "false"]}, "returns": {}, "timeout": {}, "tries": {},
+      "try_sleep": {}, "umask": {}, "unless": {}, "user": {}
+    }
+  },
+  {
+    "name": "file",
+    "attributes": {
+      "name": {}, "path": {}, "ensure": {"values": ["present", "absent", "file", "directory", "link"], "path": true},
+      "backup": {}, "checksum": {}, "checksum_value": {}, "content": {}, "ctime": {},
+      "force": {"values": ["true", "false", "yes", "no"]}, "group": {}, "ignore": {},
+      "links": {"values": ["follow", "manage", "copy"]}, "max_files": {}, "mode": {}, "mtime": {}, "owner": {},
+      "provider": {}, "purge": {"values": ["true", "false", "yes", "no"]},
+      "recurse": {"values": ["true", "false", "remote"]}, "recurselimit": {},
+      "replace": {"values": ["true", "false", "yes", "no"]}, "selinux_ignore_defaults": {}, "selrange": {},
+      "selrole": {}, "seltype": {}, "seluser": {}, "show_diff": {}, "source": {}, "source_permissions": {},
+      "sourceselect": {"values": ["first", "all"]}, "target": {}, "type": {}, "validate_cmd": {},
+      "validate_replacement": {}
+    }
+  },
+  {
+    "name": "group",
+    "attributes": {
+      "name": {}, "ensure": {"values": ["present", "absent"]}, "allowdupe": {}, "attribute_membership": {},
+      "attributes": {}, "auth_membership": {}, "forcelocal": {}, "gid": {}, "ia_load_module": {},
+      "members": {}, "provider": {}, "system": {}
+    }
+  },
+  {
+    "name": "host",
+    "attributes": {
+      "name": {}, "ensure": {"values": ["present", "absent"]}, "comment": {}, "host_aliases": {}, "ip": {},
+      "provider": {}, "target": {}
+    }
+  },
+  {
+    "name": "mount",
+    "attributes": {
+      "name": {}, "ensure": {"values": ["defined", "present", "unmounted", "absent", "mounted"]},
+      "atboot": {}, "blockdevice": {}, "device": {}, "dump": {}, "fstype": {}, "options": {}, "pass": {},
+      "provider": {}, "remounts": {}, "target": {}
+    }
+  },
+  {
+    "name": "notify",
+    "attributes": {
+      "name": {}, "message": {}, "withpath": {"values": ["true", "false"]}
+    }
+  },
+  {
+    "name": "package",
+    "attributes": {
+      "name": {}, "ensure": {}, "adminfile": {}, "allow_virtual": {}, "allowcdrom": {}, "category": {},
+      "command": {}, "configfiles": {"values": ["keep", "replace"]}, "description": {}, "enable_only": {},
+      "flavor": {}, "install_only": {}, "install_options": {}, "instance": {}, "package_settings": {},
+      "platform": {}, "provider": {}, "reinstall_on_refresh": {}, "responsefile": {}, "root": {},
+      "source": {}, "status": {}, "uninstall_options": {}, "vendor": {}
+    }
+  },
+  {
+    "name": "service",
+    "attributes": {
+      "name": {}, "ensure": {"values": ["running", "stopped", "true", "false"]}, "binary": {}, "control": {},
+      "enable": {"values": ["true", "false", "manual", "mask", "delayed"]}, "flags": {},
+      "hasrestart": {"values": ["true", "false"]}, "hasstatus": {"values": ["true", "false"]},
+      "logonaccount": {}, "logonpassword": {}, "manifest": {}, "path": {}, "pattern": {}, "provider": {},
+      "restart": {}, "start": {}, "status": {}, "stop": {}, "timeout": {}
+    }
+  },
+  {
+    "name": "ssh_authorized_key",
+    "attributes": {
+      "name": {}, "ensure": {"values": ["present", "absent"]}, "key": {}, "options": {},
+      "provider": {}, "target": {}, "type": {}, "user": {}
+    }
+  },
+  {
+    "name": "user",
+    "attributes": {
+      "name": {}, "ensure": {"values": ["present", "absent", "role"]}, "allowdupe": {},
+      "attribute_membership": {}, "attributes": {}, "auth_membership": {}, "auths": {}, "comment": {},
+      "expiry": {}, "forcelocal": {}, "gid": {}, "groups": {}, "home": {}, "ia_load_module": {},
+      "iterations": {}, "key_membership": {}, "keys": {}, "loginclass": {},
+      "managehome": {"values": ["true", "false", "yes", "no"]}, "membership": {"values": ["inclusive", "minimum"]},
+      "password": {}, "password_max_age": {}, "password_min_age": {}, "password_warn_days": {},
+      "profile_membership": {}, "profiles": {}, "project": {}, "provider": {}, "purge_ssh_keys": {},
+      "role_membership": {}, "roles": {}, "salt": {}, "shell": {}, "system": {}, "uid": {}
+    }
+  }
+]`
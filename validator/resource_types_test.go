@@ -0,0 +1,114 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestUnknownAttribute(t *testing.T) {
+	expectNoIssues(t, `file { '/tmp/x': ensure => file, mode => '0644', require => Package['x'] }`)
+	expectNoIssues(t, `no_such_type { 'x': whatever => 1 }`)
+	expectNoIssues(t, `File { owner => 'root' }`)
+	expectNoIssues(t, `file { 'motd': name => '/etc/motd', ensure => file }`)
+	expectNoIssues(t, `exec { 'update': name => 'apt-get update', path => '/usr/bin' }`)
+
+	expectIssues(t, `file { '/tmp/x': mdoe => '0644' }`, VALIDATE_UNKNOWN_ATTRIBUTE)
+	expectIssues(t, `File { onwer => 'root' }`, VALIDATE_UNKNOWN_ATTRIBUTE)
+	expectIssues(t, `File['/tmp/x'] { onwer => 'root' }`, VALIDATE_UNKNOWN_ATTRIBUTE)
+
+	issues := parseAndValidate(t, `file { '/tmp/x': mdoe => '0644' }`)
+	if len(issues) == 1 && !strings.HasSuffix(issues[0].Error(), `Did you mean 'mode'? (line: 1, column: 18)`) {
+		t.Errorf(`unexpected message: %s`, issues[0].Error())
+	}
+}
+
+func TestInvalidAttributeValue(t *testing.T) {
+	expectNoIssues(t, `service { 'x': ensure => running, enable => true }`)
+	expectNoIssues(t, `service { 'x': ensure => $state }`)
+	expectNoIssues(t, `package { 'x': ensure => '1.2.3' }`)
+
+	expectNoIssues(t, `file { '/tmp/x': ensure => '/tmp/y', links => copy }`)
+	expectNoIssues(t, `file { 'C:/x': ensure => 'C:\\y' }`)
+
+	expectIssues(t, `service { 'x': ensure => runing }`, VALIDATE_INVALID_ATTRIBUTE_VALUE)
+	expectIssues(t, `file { '/tmp/x': ensure => 'dir' }`, VALIDATE_INVALID_ATTRIBUTE_VALUE)
+
+	issues := parseAndValidate(t, `file { '/tmp/x': ensure => 'tmp/y' }`)
+	if len(issues) != 1 || !strings.Contains(issues[0].Error(), `'link' or an absolute path.`) {
+		t.Errorf(`unexpected issues: %v`, issues)
+	}
+}
+
+func TestMissingRequiredAttribute(t *testing.T) {
+	expectNoIssues(t, `cron { 'x': command => '/bin/true' }`)
+	expectNoIssues(t, `cron { 'x': ensure => absent }`)
+	expectNoIssues(t, `ssh_authorized_key { 'x': ensure => absent, user => 'root' }`)
+
+	expectNoIssues(t, issue.Unindent(`
+    define foo::bar(String $a) {}
+    foo::bar { 'x': * => $attrs }`))
+
+	expectIssues(t, issue.Unindent(`
+    define foo::bar(String $a) {}
+    foo::bar { 'x': }`),
+		VALIDATE_MISSING_REQUIRED_ATTRIBUTE)
+}
+
+func TestDemoteAttributeIssues(t *testing.T) {
+	expr := parse(t, issue.Unindent(`
+    define foo::bar(String $a) {}
+    foo::bar { 'x': b => 1 }
+    service { 'x': ensure => runing }`))
+	if expr == nil {
+		return
+	}
+	v := NewChecker(STRICT_ERROR)
+	v.Demote(VALIDATE_UNKNOWN_ATTRIBUTE, issue.SEVERITY_WARNING)
+	v.Demote(VALIDATE_INVALID_ATTRIBUTE_VALUE, issue.SEVERITY_WARNING)
+	v.Demote(VALIDATE_MISSING_REQUIRED_ATTRIBUTE, issue.SEVERITY_IGNORE)
+	Validate(v, expr)
+	issues := v.Issues()
+	if len(issues) != 2 || issues[0].Severity() != issue.SEVERITY_WARNING || issues[1].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`expected two warnings, got %v`, issues)
+	}
+}
+
+func TestDefinedResourceType(t *testing.T) {
+	expectNoIssues(t, issue.Unindent(`
+    define foo::bar(String $a, Optional[String] $b, $c = 1) {}
+    foo::bar { 'x': a => 'a' }`))
+
+	expectIssues(t, issue.Unindent(`
+    define foo::bar(String $a, $c = 1) {}
+    foo::bar { 'x': a => 'a', d => 2 }`),
+		VALIDATE_UNKNOWN_ATTRIBUTE)
+
+	expectIssues(t, issue.Unindent(`
+    define foo::bar(String $a, $c = 1) {}
+    foo::bar { 'x': c => 2 }`),
+		VALIDATE_MISSING_REQUIRED_ATTRIBUTE)
+}
+
+func TestLoadResourceTypes(t *testing.T) {
+	r, err := LoadResourceTypes(strings.NewReader(`[{"name": "Thing", "attributes": {"size": {"required": true, "values": ["small", "large"]}}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, ok := r.Lookup(`thing`)
+	if !ok {
+		t.Fatal(`expected schema for 'thing'`)
+	}
+	expr := parse(t, `thing { 'x': size => 'huge' }`)
+	if expr == nil {
+		return
+	}
+	v := NewChecker(STRICT_ERROR)
+	v.ResourceTypes().Add(schema)
+	Validate(v, expr)
+	issues := v.Issues()
+	if len(issues) != 1 || issues[0].Code() != VALIDATE_INVALID_ATTRIBUTE_VALUE {
+		t.Errorf(`expected one %s issue, got %v`, VALIDATE_INVALID_ATTRIBUTE_VALUE, issues)
+	}
+}
@@ -0,0 +1,724 @@
+package validator
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+// The maximum repeat count that Ruby accepts in an interval such as {n,m}
+const rubyRepeatMax = 100000
+
+// The names that are valid in a POSIX bracket such as [:alpha:]
+var POSIX_BRACKETS = map[string]bool{
+	`alnum`: true, `alpha`: true, `ascii`: true, `blank`: true, `cntrl`: true, `digit`: true, `graph`: true,
+	`lower`: true, `print`: true, `punct`: true, `space`: true, `upper`: true, `word`: true, `xdigit`: true,
+}
+
+type (
+	// regexpNote is a construct that is valid in Ruby but means something different, or is unsupported,
+	// in other regular expression engines
+	regexpNote struct {
+		code       issue.Code
+		construct  string
+		difference string
+		offset     int
+	}
+
+	// regexpReference is a backreference, or a subexpression call, that cannot be verified until all
+	// groups are known
+	regexpReference struct {
+		name   string
+		number int
+		call   bool
+		offset int
+	}
+
+	// regexpGroup is an open group. The extended flag is the one in effect when the group was opened.
+	regexpGroup struct {
+		offset     int
+		lookaround bool
+		extended   bool
+	}
+
+	// rubyRegexp scans a pattern using the syntax of Onigmo, the regular expression engine of Ruby
+	rubyRegexp struct {
+		pattern    string
+		pos        int
+		extended   bool
+		groups     []regexpGroup
+		captures   int
+		unnamed    int
+		named      int
+		names      map[string]bool
+		references []*regexpReference
+		notes      []*regexpNote
+		noted      map[string]bool
+	}
+)
+
+// What a quantifier applies to. A comment group leaves the target unchanged.
+const (
+	noTarget = iota
+	invalidTarget
+	validTarget
+	unchangedTarget
+)
+
+// parseRubyRegexp checks the pattern using the syntax of Ruby regular expressions. It returns the first
+// syntax error, or nil, together with the constructs found in the pattern that behave differently in
+// other engines.
+func parseRubyRegexp(pattern string) (err *SyntaxError, notes []*regexpNote) {
+	r := &rubyRegexp{pattern: pattern, named: -1, names: make(map[string]bool), noted: make(map[string]bool)}
+	defer func() {
+		if x := recover(); x != nil {
+			if se, ok := x.(*SyntaxError); ok {
+				err = se
+				notes = r.notes
+				return
+			}
+			panic(x)
+		}
+	}()
+	r.parse()
+	if r.named >= 0 && r.unnamed > 0 {
+		r.note(`named group`, `turns all unnamed groups into non-capturing groups in Ruby. Other engines keep `+
+			`capturing them, and some, such as Python, use the syntax (?P<name>...)`, r.named)
+	}
+	r.checkReferences()
+	return nil, r.notes
+}
+
+func (r *rubyRegexp) fail(offset int, message string) {
+	panic(&SyntaxError{message, offset})
+}
+
+func (r *rubyRegexp) note(construct, difference string, offset int) {
+	if !r.noted[construct] {
+		r.noted[construct] = true
+		r.notes = append(r.notes, &regexpNote{REGEXP_ENGINE_DIFFERENCE, construct, difference, offset})
+	}
+}
+
+// noteAnchor notes the first '^' and the first '$'. A pattern that uses both gets one note.
+func (r *rubyRegexp) noteAnchor(anchor byte, offset int) {
+	if r.noted[string(anchor)] {
+		return
+	}
+	r.noted[string(anchor)] = true
+	for _, n := range r.notes {
+		if n.code == REGEXP_ANCHOR_DIFFERENCE {
+			n.construct = `anchors '^' and '$'`
+			n.difference = `match at the start and end of every line in Ruby, but only at the start and end of ` +
+				`the string in most other engines. Use \A and \z to match at the start and end of the string`
+			return
+		}
+	}
+	note := &regexpNote{REGEXP_ANCHOR_DIFFERENCE, `anchor '^'`, `matches at the start of every line in Ruby, but ` +
+		`only at the start of the string in most other engines. Use \A to match at the start of the string`, offset}
+	if anchor == '$' {
+		note.construct = `anchor '$'`
+		note.difference = `matches at the end of every line in Ruby, but only at the end of the string in most ` +
+			`other engines. Use \z to match at the end of the string`
+	}
+	r.notes = append(r.notes, note)
+}
+
+func (r *rubyRegexp) atEnd() bool {
+	return r.pos >= len(r.pattern)
+}
+
+func (r *rubyRegexp) peek() byte {
+	if r.atEnd() {
+		return 0
+	}
+	return r.pattern[r.pos]
+}
+
+func (r *rubyRegexp) parse() {
+	target := noTarget
+	for !r.atEnd() {
+		start := r.pos
+		c := r.pattern[r.pos]
+		switch {
+		case c == '\\':
+			target = r.escape()
+		case c == '(':
+			if t := r.group(); t != unchangedTarget {
+				target = t
+			}
+		case c == ')':
+			if len(r.groups) == 0 {
+				r.fail(start, `unmatched close parenthesis`)
+			}
+			g := r.groups[len(r.groups)-1]
+			r.groups = r.groups[:len(r.groups)-1]
+			r.extended = g.extended
+			r.pos++
+			target = validTarget
+			if g.lookaround {
+				target = invalidTarget
+			}
+		case c == '|':
+			r.pos++
+			target = noTarget
+		case c == '[':
+			r.pos++
+			r.charClass()
+			target = validTarget
+		case c == '*' || c == '+' || c == '?':
+			r.checkTarget(start, target)
+			r.pos++
+			switch r.peek() {
+			case '?':
+				r.pos++
+			case '+':
+				r.note(`possessive quantifier '`+r.pattern[start:r.pos+1]+`'`,
+					`is not supported by RE2 based engines, such as the regexp package of Go`, start)
+				r.pos++
+			}
+			target = validTarget
+		case c == '{':
+			if !r.interval() {
+				r.pos++
+				target = validTarget
+				break
+			}
+			r.checkTarget(start, target)
+			switch r.peek() {
+			case '?':
+				if !strings.Contains(r.pattern[start:r.pos], `,`) {
+					r.note(`quantifier '`+r.pattern[start:r.pos+1]+`'`,
+						`makes the repetition optional in Ruby, but is a lazy repetition in most other engines`, start)
+				}
+				r.pos++
+			case '+':
+				r.note(`quantifier '`+r.pattern[start:r.pos+1]+`'`,
+					`is a repetition of a repetition in Ruby, but a possessive quantifier in most other engines`, start)
+			}
+			target = validTarget
+		case c == '^':
+			r.noteAnchor('^', start)
+			r.pos++
+			target = invalidTarget
+		case c == '$':
+			r.noteAnchor('$', start)
+			r.pos++
+			target = invalidTarget
+		case r.extended && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'):
+			r.pos++
+		case r.extended && c == '#':
+			if nl := strings.IndexByte(r.pattern[r.pos:], '\n'); nl >= 0 {
+				r.pos += nl + 1
+			} else {
+				r.pos = len(r.pattern)
+			}
+		default:
+			_, n := utf8.DecodeRuneInString(r.pattern[r.pos:])
+			r.pos += n
+			target = validTarget
+		}
+	}
+	if len(r.groups) > 0 {
+		r.fail(r.groups[len(r.groups)-1].offset, `end pattern with unmatched parenthesis`)
+	}
+}
+
+func (r *rubyRegexp) checkTarget(offset, target int) {
+	switch target {
+	case noTarget:
+		r.fail(offset, `target of repeat operator is not specified`)
+	case invalidTarget:
+		r.fail(offset, `target of repeat operator is invalid`)
+	}
+}
+
+// interval consumes an interval such as {2}, {2,}, {,3}, or {2,3} and returns true. Nothing is consumed,
+// and false is returned, when the text that starts with '{' is not an interval. Ruby then treats the
+// '{' as a literal.
+func (r *rubyRegexp) interval() bool {
+	start := r.pos
+	end := strings.IndexByte(r.pattern[start:], '}')
+	if end < 0 {
+		return false
+	}
+	body := r.pattern[start+1 : start+end]
+	lower, upper := body, body
+	if comma := strings.IndexByte(body, ','); comma >= 0 {
+		lower, upper = body[:comma], body[comma+1:]
+		if lower == `` && upper == `` {
+			return false
+		}
+	} else if body == `` {
+		return false
+	}
+	min, ok := repeatCount(lower)
+	if !ok {
+		return false
+	}
+	max, ok := repeatCount(upper)
+	if !ok {
+		return false
+	}
+	if min > rubyRepeatMax || max > rubyRepeatMax {
+		r.fail(start, `too big number for repeat range`)
+	}
+	if upper != `` && min > max {
+		r.fail(start, `upper bound must be greater than lower bound`)
+	}
+	r.pos = start + end + 1
+	return true
+}
+
+// repeatCount returns the number in an interval. An empty string is a valid, omitted, count.
+func repeatCount(s string) (int, bool) {
+	if s == `` {
+		return 0, true
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return rubyRepeatMax + 1, true
+	}
+	return n, true
+}
+
+func (r *rubyRegexp) group() int {
+	start := r.pos
+	r.pos++
+	if r.peek() != '?' {
+		r.captures++
+		r.unnamed++
+		r.groups = append(r.groups, regexpGroup{start, false, r.extended})
+		return noTarget
+	}
+	r.pos++
+	if r.atEnd() {
+		r.fail(start, `end pattern in group`)
+	}
+	lookaround := false
+	switch c := r.pattern[r.pos]; c {
+	case '#':
+		end := strings.IndexByte(r.pattern[r.pos:], ')')
+		if end < 0 {
+			r.fail(start, `end pattern in group`)
+		}
+		r.pos += end + 1
+		return unchangedTarget
+	case ':', '~':
+		r.pos++
+	case '=', '!':
+		r.note(`lookahead`, `is not supported by RE2 based engines, such as the regexp package of Go`, start)
+		lookaround = true
+		r.pos++
+	case '>':
+		r.note(`atomic group`, `is not supported by RE2 based engines, such as the regexp package of Go`, start)
+		r.pos++
+	case '<', '\'':
+		r.pos++
+		if c == '<' && (r.peek() == '=' || r.peek() == '!') {
+			r.note(`lookbehind`, `is not supported by RE2 based engines, such as the regexp package of Go`, start)
+			lookaround = true
+			r.pos++
+			break
+		}
+		name := r.groupName(start, c)
+		if name == `` || name[0] >= '0' && name[0] <= '9' {
+			r.fail(start, `invalid group name <`+name+`>`)
+		}
+		r.names[name] = true
+		r.captures++
+		if r.named < 0 {
+			r.named = start
+		}
+	default:
+		r.options(start)
+		if r.pattern[r.pos] == ')' {
+			// Options apply to the rest of the enclosing group
+			r.pos++
+			return noTarget
+		}
+		r.pos++
+	}
+	r.groups = append(r.groups, regexpGroup{start, lookaround, r.extended})
+	return noTarget
+}
+
+// groupName consumes a group name that is terminated by '>', or by a quote when it was opened by a quote
+func (r *rubyRegexp) groupName(start int, open byte) string {
+	close := byte('>')
+	if open == '\'' {
+		close = '\''
+	}
+	end := strings.IndexByte(r.pattern[r.pos:], close)
+	if end < 0 {
+		r.fail(start, `invalid group name <`+r.pattern[r.pos:]+`>`)
+	}
+	name := r.pattern[r.pos : r.pos+end]
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !(c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80) {
+			r.fail(start, `invalid group name <`+name+`>`)
+		}
+	}
+	r.pos += end + 1
+	return name
+}
+
+// options consumes the options of a group such as (?mi-x) or (?i:...) up to, but not including, the
+// terminating ')' or ':'
+func (r *rubyRegexp) options(start int) {
+	on := true
+	extended := r.extended
+	for ; !r.atEnd(); r.pos++ {
+		switch c := r.pattern[r.pos]; c {
+		case ')', ':':
+			r.extended = extended
+			return
+		case '-':
+			if !on {
+				r.fail(start, `undefined group option`)
+			}
+			on = false
+		case 'i':
+		case 'm':
+			r.note(`option 'm'`, `makes '.' match a newline in Ruby, but makes '^' and '$' match at every line `+
+				`in most other engines, where option 's' makes '.' match a newline`, start)
+		case 'x':
+			extended = on
+		case 'a', 'd', 'u':
+			if !on {
+				r.fail(start, `undefined group option`)
+			}
+		default:
+			r.fail(start, `undefined group option`)
+		}
+	}
+	r.fail(start, `end pattern in group`)
+}
+
+// escape consumes an escape sequence outside of a character class and returns what kind of repeat
+// target it is
+func (r *rubyRegexp) escape() int {
+	start := r.pos
+	r.pos++
+	if r.atEnd() {
+		r.fail(start, `too short escape sequence`)
+	}
+	switch c := r.pattern[r.pos]; c {
+	case 'A', 'z', 'Z', 'b', 'B', 'G', 'K':
+		r.pos++
+		return invalidTarget
+	case 'k', 'g':
+		r.pos++
+		r.reference(start, c == 'g')
+		return validTarget
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		end := r.pos
+		for end < len(r.pattern) && r.pattern[end] >= '0' && r.pattern[end] <= '9' {
+			end++
+		}
+		n, _ := strconv.Atoi(r.pattern[r.pos:end])
+		if end-r.pos == 1 {
+			r.references = append(r.references, &regexpReference{number: n, offset: start})
+		} else {
+			// A multi digit escape is a backreference when there is such a group, and octal otherwise
+			r.references = append(r.references, &regexpReference{number: -n, offset: start})
+		}
+		r.pos = end
+		return validTarget
+	}
+	r.pos = start
+	r.charEscape()
+	return validTarget
+}
+
+// reference consumes a \k<name> backreference or a \g<name> subexpression call
+func (r *rubyRegexp) reference(start int, call bool) {
+	kind := `backreference`
+	if call {
+		kind = `subexpression call`
+	}
+	open := r.peek()
+	if open != '<' && open != '\'' {
+		if call {
+			// A \g that is not a call is a literal 'g'
+			return
+		}
+		r.fail(start, `invalid backref number/name`)
+	}
+	r.pos++
+	close := byte('>')
+	if open == '\'' {
+		close = '\''
+	}
+	end := strings.IndexByte(r.pattern[r.pos:], close)
+	if end < 0 {
+		r.fail(start, `invalid `+kind+` name`)
+	}
+	name := r.pattern[r.pos : r.pos+end]
+	r.pos += end + 1
+	ref := &regexpReference{call: call, offset: start}
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 0 {
+			// Relative to the groups opened so far
+			n = r.captures + 1 + n
+			if n <= 0 {
+				r.fail(start, `invalid backref number/name`)
+			}
+		}
+		ref.number = n
+	} else {
+		if levels := strings.LastIndexAny(name, `+-`); levels > 0 {
+			// A backreference with a nest level, e.g. \k<name+0>
+			name = name[:levels]
+		}
+		ref.name = name
+	}
+	r.references = append(r.references, ref)
+}
+
+// checkReferences checks that all backreferences and subexpression calls refer to existing groups
+func (r *rubyRegexp) checkReferences() {
+	for _, ref := range r.references {
+		if ref.name != `` {
+			if !r.names[ref.name] {
+				if ref.call {
+					r.fail(ref.offset, `undefined name <`+ref.name+`> call`)
+				}
+				r.fail(ref.offset, `undefined name <`+ref.name+`> reference`)
+			}
+		} else {
+			n := ref.number
+			if n < 0 {
+				// Multi digit escape
+				if -n > r.captures {
+					continue
+				}
+				n = -n
+			}
+			if len(r.names) > 0 {
+				r.fail(ref.offset, `numbered backref/call is not allowed. (use name)`)
+			}
+			if n > r.captures {
+				r.fail(ref.offset, `invalid backref number/name`)
+			}
+		}
+		if !ref.call {
+			r.note(`backreference`, `is not supported by RE2 based engines, such as the regexp package of Go`, ref.offset)
+		}
+	}
+}
+
+// charEscape consumes an escape that denotes a character, or a set of characters, and that is valid both
+// inside and outside of character classes. It returns the character when the escape denotes exactly one
+// character, and -1 otherwise.
+func (r *rubyRegexp) charEscape() rune {
+	start := r.pos
+	r.pos++
+	if r.atEnd() {
+		r.fail(start, `too short escape sequence`)
+	}
+	c := r.pattern[r.pos]
+	r.pos++
+	switch c {
+	case 'x':
+		end := r.pos
+		for end < len(r.pattern) && end < r.pos+2 && isHexDigit(r.pattern[end]) {
+			end++
+		}
+		if end == r.pos {
+			r.fail(start, `invalid hex escape`)
+		}
+		n, _ := strconv.ParseInt(r.pattern[r.pos:end], 16, 32)
+		r.pos = end
+		return rune(n)
+	case 'u':
+		if r.peek() == '{' {
+			end := strings.IndexByte(r.pattern[r.pos:], '}')
+			if end < 0 {
+				r.fail(start, `invalid Unicode escape`)
+			}
+			for _, cp := range strings.Fields(r.pattern[r.pos+1 : r.pos+end]) {
+				if _, err := strconv.ParseUint(cp, 16, 32); err != nil || len(cp) > 6 {
+					r.fail(start, `invalid Unicode escape`)
+				}
+			}
+			r.pos += end + 1
+			return -1
+		}
+		if r.pos+4 > len(r.pattern) {
+			r.fail(start, `invalid Unicode escape`)
+		}
+		n, err := strconv.ParseUint(r.pattern[r.pos:r.pos+4], 16, 32)
+		if err != nil {
+			r.fail(start, `invalid Unicode escape`)
+		}
+		r.pos += 4
+		return rune(n)
+	case 'p', 'P':
+		if r.peek() != '{' {
+			r.fail(start, `invalid character property name {`+string(c)+`}`)
+		}
+		end := strings.IndexByte(r.pattern[r.pos:], '}')
+		if end < 0 || end == 1 || end == 2 && r.pattern[r.pos+1] == '^' {
+			r.fail(start, `invalid character property name {`+r.pattern[r.pos:]+`}`)
+		}
+		r.pos += end + 1
+		return -1
+	case 'h', 'H':
+		r.note(`escape '\`+string(c)+`'`, `matches a hexadecimal digit in Ruby, but a horizontal whitespace `+
+			`character in PCRE and Java`, start)
+		return -1
+	case 'c':
+		if r.atEnd() {
+			r.fail(start, `end pattern at control`)
+		}
+		r.pos++
+		return -1
+	case 'C', 'M':
+		if r.peek() != '-' {
+			r.fail(start, `invalid `+map[byte]string{'C': `control`, 'M': `meta`}[c]+`-code syntax`)
+		}
+		r.pos++
+		if r.atEnd() {
+			r.fail(start, `end pattern at `+map[byte]string{'C': `control`, 'M': `meta`}[c])
+		}
+		if r.pattern[r.pos] == '\\' {
+			r.charEscape()
+		} else {
+			r.pos++
+		}
+		return -1
+	case 'w', 'W', 'd', 'D', 's', 'S', 'R', 'X':
+		return -1
+	case 't':
+		return '\t'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 'f':
+		return '\f'
+	case 'v':
+		return '\v'
+	case 'a':
+		return '\a'
+	case 'e':
+		return 0x1b
+	case '0':
+		end := r.pos
+		for end < len(r.pattern) && end < r.pos+2 && r.pattern[end] >= '0' && r.pattern[end] <= '7' {
+			end++
+		}
+		n, _ := strconv.ParseInt(`0`+r.pattern[r.pos:end], 8, 32)
+		r.pos = end
+		return rune(n)
+	}
+	r.pos--
+	ch, n := utf8.DecodeRuneInString(r.pattern[r.pos:])
+	r.pos += n
+	return ch
+}
+
+// charClass consumes a character class. The opening '[' has been consumed.
+func (r *rubyRegexp) charClass() {
+	start := r.pos - 1
+	if r.peek() == '^' {
+		r.pos++
+	}
+	if r.peek() == ']' {
+		// A ']' first in the class is a literal
+		r.pos++
+	}
+	prev := rune(-1)
+	for {
+		if r.atEnd() {
+			r.fail(start, `premature end of char-class`)
+		}
+		cs := r.pos
+		c := r.pattern[r.pos]
+		switch {
+		case c == ']':
+			r.pos++
+			return
+		case c == '[':
+			if r.posixBracket() {
+				prev = -1
+				continue
+			}
+			r.pos++
+			r.charClass()
+			prev = -1
+			continue
+		case c == '&' && strings.HasPrefix(r.pattern[r.pos:], `&&`):
+			r.pos += 2
+			prev = -1
+			continue
+		case c == '-' && prev >= 0 && r.pos+1 < len(r.pattern) && r.pattern[r.pos+1] != ']':
+			r.pos++
+			var last rune
+			if r.pattern[r.pos] == '\\' {
+				last = r.classEscape()
+			} else if r.pattern[r.pos] == '[' {
+				// A range cannot end with a class. Ruby treats the '-' as a literal
+				continue
+			} else {
+				var n int
+				last, n = utf8.DecodeRuneInString(r.pattern[r.pos:])
+				r.pos += n
+			}
+			if last >= 0 && last < prev {
+				r.fail(cs-1, `empty range in char class`)
+			}
+			prev = -1
+			continue
+		}
+		if c == '\\' {
+			prev = r.classEscape()
+		} else {
+			var n int
+			prev, n = utf8.DecodeRuneInString(r.pattern[r.pos:])
+			r.pos += n
+		}
+	}
+}
+
+// classEscape consumes an escape inside of a character class
+func (r *rubyRegexp) classEscape() rune {
+	if r.pos+1 < len(r.pattern) && r.pattern[r.pos+1] == 'b' {
+		r.pos += 2
+		return '\b'
+	}
+	return r.charEscape()
+}
+
+// posixBracket consumes a POSIX bracket such as [:alpha:] or [:^digit:] and returns true. Nothing is
+// consumed, and false is returned, when the text that starts with '[' is not a POSIX bracket.
+func (r *rubyRegexp) posixBracket() bool {
+	if !strings.HasPrefix(r.pattern[r.pos:], `[:`) {
+		return false
+	}
+	end := strings.Index(r.pattern[r.pos+2:], `:]`)
+	if end < 0 {
+		return false
+	}
+	name := r.pattern[r.pos+2 : r.pos+2+end]
+	if strings.ContainsAny(name, `[]`) {
+		return false
+	}
+	if !POSIX_BRACKETS[strings.TrimPrefix(name, `^`)] {
+		r.fail(r.pos, `invalid POSIX bracket type`)
+	}
+	r.pos += end + 4
+	return true
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,204 @@
+package validator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Attributes of an exec resource that are executed as commands
+var EXEC_COMMAND_ATTRIBUTES = map[string]bool{
+	`command`: true,
+	`onlyif`:  true,
+	`unless`:  true,
+}
+
+// Parts of an attribute name, separated by underscore, that indicates that the attribute holds a secret
+var SECRET_NAME_PARTS = map[string]bool{
+	`passwd`:   true,
+	`password`: true,
+	`secret`:   true,
+	`token`:    true,
+}
+
+// Matches a download that is piped to a shell, e.g. "curl -s http://example.com/install.sh | bash"
+var PIPE_TO_SHELL = regexp.MustCompile(`\b(?:curl|wget)\b[^|;&]*\|\s*(?:sudo\s+)?(?:\S*/)?(?:ba|da|k|z)?sh\b`)
+
+type securityChecker struct {
+	AbstractValidator
+}
+
+// NewSecurityChecker creates a validator that reports patterns that are known to be risky, such as
+// commands that are built from unescaped interpolated strings, world writable files, and secrets in
+// plain text.
+func NewSecurityChecker() Validator {
+	v := &securityChecker{}
+	v.severities = make(map[issue.Code]issue.Severity, 5)
+	for _, code := range []issue.Code{
+		SECURITY_EXEC_INTERPOLATION,
+		SECURITY_INTERPOLATED_TEMPLATE,
+		SECURITY_PIPE_TO_SHELL,
+		SECURITY_PLAINTEXT_SECRET,
+		SECURITY_WORLD_WRITABLE_MODE,
+	} {
+		v.Demote(code, issue.SEVERITY_WARNING)
+	}
+	return v
+}
+
+// Validate the expression using the security checker
+func ValidateSecurity(e parser.Expression) Validator {
+	v := NewSecurityChecker()
+	Validate(v, e)
+	return v
+}
+
+func (v *securityChecker) Validate(e parser.Expression) {
+	switch e.(type) {
+	case *parser.ResourceExpression:
+		re := e.(*parser.ResourceExpression)
+		if typeName, ok := re.TypeName().(*parser.QualifiedName); ok {
+			for _, body := range re.Bodies() {
+				rb := body.(*parser.ResourceBody)
+				v.checkResource(typeName.Name(), rb.Title(), rb.Operations())
+			}
+		}
+	case *parser.ResourceDefaultsExpression:
+		rd := e.(*parser.ResourceDefaultsExpression)
+		if typeRef, ok := rd.TypeRef().(*parser.QualifiedReference); ok {
+			v.checkResource(typeRef.Name(), nil, rd.Operations())
+		}
+	case *parser.CallNamedFunctionExpression:
+		v.checkTemplateCall(e.(*parser.CallNamedFunctionExpression))
+	}
+}
+
+// checkResource checks the title and the attribute operations of a resource body, or of resource defaults
+// when the title is nil
+func (v *securityChecker) checkResource(typeName string, title parser.Expression, operations []parser.Expression) {
+	typeName = strings.ToLower(strings.TrimPrefix(typeName, `::`))
+	hasCommand := false
+	for _, op := range operations {
+		ao, ok := op.(*parser.AttributeOperation)
+		if !ok {
+			continue
+		}
+		name := ao.Name()
+		value := unwrapHeredoc(ao.Value())
+		switch {
+		case typeName == `exec` && EXEC_COMMAND_ATTRIBUTES[name]:
+			hasCommand = hasCommand || name == `command`
+			v.checkCommand(name, value)
+		case typeName == `file` && name == `mode`:
+			if mode, ok := value.(*parser.LiteralString); ok && isWorldWritable(mode.StringValue()) {
+				v.Accept(SECURITY_WORLD_WRITABLE_MODE, value, issue.H{`mode`: mode.StringValue()})
+			}
+		}
+		if isSecretName(name) {
+			if _, ok := value.(*parser.LiteralString); ok {
+				v.Accept(SECURITY_PLAINTEXT_SECRET, value, issue.H{`attribute`: name})
+			}
+		}
+	}
+
+	// The title of an exec is the command unless the command is given explicitly
+	if typeName == `exec` && title != nil && !hasCommand {
+		v.checkCommand(`command`, unwrapHeredoc(title))
+	}
+}
+
+// checkCommand checks the value of an exec attribute that is executed as a command
+func (v *securityChecker) checkCommand(attribute string, value parser.Expression) {
+	args := issue.H{`attribute`: attribute}
+	switch value.(type) {
+	case *parser.LiteralString:
+		if PIPE_TO_SHELL.MatchString(value.(*parser.LiteralString).StringValue()) {
+			v.Accept(SECURITY_PIPE_TO_SHELL, value, args)
+		}
+	case *parser.ConcatenatedString:
+		// Interpolated values are represented by a '$' when searching for a pipe to a shell
+		text := make([]string, 0, 4)
+		for _, segment := range value.(*parser.ConcatenatedString).Segments() {
+			if ls, ok := segment.(*parser.LiteralString); ok {
+				text = append(text, ls.StringValue())
+				continue
+			}
+			text = append(text, `$`)
+			if te, ok := segment.(*parser.TextExpression); ok && isShellEscaped(te.Expr()) {
+				continue
+			}
+			v.Accept(SECURITY_EXEC_INTERPOLATION, segment, args)
+		}
+		if PIPE_TO_SHELL.MatchString(strings.Join(text, ``)) {
+			v.Accept(SECURITY_PIPE_TO_SHELL, value, args)
+		}
+	}
+}
+
+// checkTemplateCall checks that calls to inline_template and inline_epp are not given interpolated strings
+func (v *securityChecker) checkTemplateCall(e *parser.CallNamedFunctionExpression) {
+	functor, ok := e.Functor().(*parser.QualifiedName)
+	if !ok || !(functor.Name() == `inline_template` || functor.Name() == `inline_epp`) {
+		return
+	}
+	for _, arg := range e.Arguments() {
+		if cs, ok := unwrapHeredoc(arg).(*parser.ConcatenatedString); ok {
+			v.Accept(SECURITY_INTERPOLATED_TEMPLATE, cs, issue.H{`function`: functor.Name()})
+			return
+		}
+	}
+}
+
+// isShellEscaped returns true if the expression is a call to shell_escape, using either function or method
+// call syntax
+func isShellEscaped(e parser.Expression) bool {
+	switch e.(type) {
+	case *parser.CallNamedFunctionExpression:
+		qn, ok := e.(*parser.CallNamedFunctionExpression).Functor().(*parser.QualifiedName)
+		return ok && qn.Name() == `shell_escape`
+	case *parser.CallMethodExpression:
+		if na, ok := e.(*parser.CallMethodExpression).Functor().(*parser.NamedAccessExpression); ok {
+			qn, ok := na.Rhs().(*parser.QualifiedName)
+			return ok && qn.Name() == `shell_escape`
+		}
+	}
+	return false
+}
+
+// isSecretName returns true if one of the underscore separated parts of the name indicates a secret
+func isSecretName(name string) bool {
+	for _, part := range strings.Split(strings.ToLower(name), `_`) {
+		if SECRET_NAME_PARTS[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// isWorldWritable returns true if the given file mode, in numeric or symbolic form, grants write
+// permission to others
+func isWorldWritable(mode string) bool {
+	if len(mode) >= 3 && len(mode) <= 4 && strings.Trim(mode, `01234567`) == `` {
+		return (mode[len(mode)-1]-'0')&2 != 0
+	}
+	for _, clause := range strings.Split(mode, `,`) {
+		idx := strings.IndexAny(clause, `+=`)
+		if idx < 0 {
+			continue
+		}
+		who := clause[:idx]
+		if (who == `` || strings.ContainsAny(who, `ao`)) && strings.Contains(clause[idx+1:], `w`) {
+			return true
+		}
+	}
+	return false
+}
+
+func unwrapHeredoc(e parser.Expression) parser.Expression {
+	if hd, ok := e.(*parser.HeredocExpression); ok {
+		return hd.Text()
+	}
+	return e
+}
@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestSecurityExecInterpolation(t *testing.T) {
+	expectNoSecurityIssues(t, `exec { 'x': command => "/bin/rm ${shell_escape($f)}" }`)
+	expectNoSecurityIssues(t, `exec { 'x': command => "/bin/rm ${f.shell_escape}" }`)
+	expectNoSecurityIssues(t, `exec { '/bin/true': }`)
+	expectSecurityIssues(t, `exec { 'x': command => "/bin/rm ${f}" }`, SECURITY_EXEC_INTERPOLATION)
+	expectSecurityIssues(t, `exec { 'x': command => '/bin/true', unless => "/bin/test -f $f" }`, SECURITY_EXEC_INTERPOLATION)
+
+	// The title is the command unless the command is given
+	expectSecurityIssues(t, `exec { "/bin/rm $f": }`, SECURITY_EXEC_INTERPOLATION)
+	expectNoSecurityIssues(t, `exec { "remove $f": command => '/bin/true' }`)
+}
+
+func TestSecurityPipeToShell(t *testing.T) {
+	expectSecurityIssues(t, `exec { 'x': command => 'curl -s https://example.com/install | bash' }`, SECURITY_PIPE_TO_SHELL)
+	expectSecurityIssues(t, `exec { 'wget -qO- https://example.com/i.sh | sudo /bin/sh': }`, SECURITY_PIPE_TO_SHELL)
+	expectSecurityIssues(t, `exec { 'x': command => "curl ${shell_escape($url)} | sh" }`, SECURITY_PIPE_TO_SHELL)
+	expectNoSecurityIssues(t, `exec { 'x': command => 'curl -o /tmp/x https://example.com/x' }`)
+	expectNoSecurityIssues(t, `exec { 'x': command => 'curl https://example.com/x | grep shell' }`)
+}
+
+func TestSecurityWorldWritableMode(t *testing.T) {
+	expectNoSecurityIssues(t, `file { '/tmp/x': mode => '0644' }`)
+	expectNoSecurityIssues(t, `file { '/tmp/x': mode => 'u=rw,go=r' }`)
+	expectSecurityIssues(t, `file { '/tmp/x': mode => '0777' }`, SECURITY_WORLD_WRITABLE_MODE)
+	expectSecurityIssues(t, `file { '/tmp/x': mode => '666' }`, SECURITY_WORLD_WRITABLE_MODE)
+	expectSecurityIssues(t, `file { '/tmp/x': mode => 'u=rw,o+w' }`, SECURITY_WORLD_WRITABLE_MODE)
+	expectSecurityIssues(t, `File { mode => 'a=rwx' }`, SECURITY_WORLD_WRITABLE_MODE)
+
+	// Only file resources are checked
+	expectNoSecurityIssues(t, `foo { '/tmp/x': mode => '0777' }`)
+}
+
+func TestSecurityPlaintextSecret(t *testing.T) {
+	expectNoSecurityIssues(t, `user { 'x': password => Sensitive('secret') }`)
+	expectNoSecurityIssues(t, `user { 'x': password => $password }`)
+	expectNoSecurityIssues(t, `foo { 'x': tokenizer => 'x' }`)
+	expectSecurityIssues(t, `user { 'x': password => 'secret' }`, SECURITY_PLAINTEXT_SECRET)
+	expectSecurityIssues(t, `class { 'db': admin_password => 'secret' }`, SECURITY_PLAINTEXT_SECRET)
+	expectSecurityIssues(t, `foo { 'x': api_token => 'abc', client_secret => 'def' }`, SECURITY_PLAINTEXT_SECRET, SECURITY_PLAINTEXT_SECRET)
+}
+
+func TestSecurityInterpolatedTemplate(t *testing.T) {
+	expectNoSecurityIssues(t, `inline_epp('<%= $x %>')`)
+	expectSecurityIssues(t, `$a = inline_epp("<%= ${x} %>")`, SECURITY_INTERPOLATED_TEMPLATE)
+	expectSecurityIssues(t, `inline_template("<%= ${x} %>")`, SECURITY_INTERPOLATED_TEMPLATE)
+}
+
+func TestSecurityIssueArguments(t *testing.T) {
+	issues := securityIssues(t, `exec { 'x': command => '/bin/true', onlyif => "/bin/test -f $f" }`)
+	if len(issues) != 1 || issues[0].Argument(`attribute`) != `onlyif` || issues[0].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`unexpected issues %v`, issues)
+	}
+}
+
+func securityIssues(t *testing.T, source string) []issue.Reported {
+	t.Helper()
+	expr := parse(t, source)
+	if expr == nil {
+		return nil
+	}
+	return ValidateSecurity(expr).Issues()
+}
+
+func expectNoSecurityIssues(t *testing.T, source string) {
+	t.Helper()
+	expectSecurityIssues(t, source)
+}
+
+func expectSecurityIssues(t *testing.T, source string, expected ...issue.Code) {
+	t.Helper()
+	issues := securityIssues(t, source)
+	if len(issues) != len(expected) {
+		t.Errorf(`expected %v, got %v`, expected, issues)
+		return
+	}
+	for i, code := range expected {
+		if issues[i].Code() != code {
+			t.Errorf(`expected %v, got %v`, expected, issues)
+			return
+		}
+	}
+}
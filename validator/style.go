@@ -0,0 +1,370 @@
+package validator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// MAX_LINE_LENGTH is the maximum number of characters permitted on one line
+const MAX_LINE_LENGTH = 140
+
+type styleChecker struct {
+	AbstractValidator
+}
+
+// NewStyleChecker creates a validator that checks the layout and style of the source against rules that
+// are similar to those of puppet-lint. The checks that concern the raw source text are performed when the
+// validated expression is a Program.
+func NewStyleChecker() Validator {
+	v := &styleChecker{}
+	v.severities = make(map[issue.Code]issue.Severity, 8)
+	for _, code := range []issue.Code{
+		STYLE_ARROW_ALIGNMENT,
+		STYLE_DOUBLE_QUOTED_STRING,
+		STYLE_ENSURE_NOT_FIRST,
+		STYLE_HARD_TAB,
+		STYLE_LINE_TOO_LONG,
+		STYLE_TOP_SCOPE_FACT,
+		STYLE_TRAILING_WHITESPACE,
+		STYLE_UNQUOTED_RESOURCE_TITLE,
+	} {
+		v.Demote(code, issue.SEVERITY_WARNING)
+	}
+	return v
+}
+
+// Validate the style of the expression using the style checker
+func ValidateStyle(e parser.Expression) Validator {
+	v := NewStyleChecker()
+	Validate(v, e)
+	return v
+}
+
+func (v *styleChecker) Validate(e parser.Expression) {
+	switch e.(type) {
+	case *parser.Program:
+		v.checkSource(e.(*parser.Program))
+	case *parser.ResourceBody:
+		body := e.(*parser.ResourceBody)
+		v.checkTitle(body.Title())
+		v.checkEnsureFirst(body.Operations())
+		v.checkArrowAlignment(body.Operations())
+	case *parser.ResourceDefaultsExpression:
+		v.checkArrowAlignment(e.(*parser.ResourceDefaultsExpression).Operations())
+	case *parser.ResourceOverrideExpression:
+		v.checkArrowAlignment(e.(*parser.ResourceOverrideExpression).Operations())
+	case *parser.VariableExpression:
+		v.checkFact(e.(*parser.VariableExpression))
+	}
+}
+
+// checkSource checks the source text and the token stream of the program
+func (v *styleChecker) checkSource(e *parser.Program) {
+	locator := e.Locator()
+	source := locator.String()
+
+	// Whitespace within strings and heredocs is significant and must not be reported
+	var protected []parser.Expression
+	e.AllContents(nil, func(path []parser.Expression, expr parser.Expression) {
+		if hd, ok := expr.(*parser.HeredocExpression); ok {
+			protected = append(protected, hd.Text())
+		}
+	})
+	inProtected := func(offset int) bool {
+		for _, p := range protected {
+			if offset >= p.ByteOffset() && offset < p.ByteOffset()+p.ByteLength() {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The output text of a template is rendered verbatim and must not be reported either. Only the code
+	// is checked.
+	code := CodeRanges(e)
+	inText := func(offset int) bool {
+		for _, c := range code {
+			if offset >= c[0] && offset < c[1] {
+				return false
+			}
+		}
+		return true
+	}
+
+	var stringRanges [][2]int
+	for _, c := range code {
+		stringRanges = append(stringRanges, v.checkTokens(locator, c[0], c[1])...)
+	}
+	inString := func(offset int) bool {
+		for _, s := range stringRanges {
+			if offset >= s[0] && offset < s[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	lineStart := 0
+	for lineStart <= len(source) {
+		lineEnd := lineStart
+		for lineEnd < len(source) && source[lineEnd] != '\n' {
+			lineEnd++
+		}
+		hasCode := false
+		for _, c := range code {
+			if c[0] <= lineEnd && c[1] > lineStart {
+				hasCode = true
+				break
+			}
+		}
+		v.checkLine(locator, source, lineStart, lineEnd, hasCode, func(offset int) bool {
+			return inString(offset) || inProtected(offset) || inText(offset)
+		})
+		lineStart = lineEnd + 1
+	}
+}
+
+// checkLine checks one line of the source. The line starts at the given offset and ends before the line
+// terminator at lineEnd. The length of a line is only checked when the line contains code.
+func (v *styleChecker) checkLine(locator *parser.Locator, source string, lineStart, lineEnd int, hasCode bool, significant func(int) bool) {
+	line := strings.TrimSuffix(source[lineStart:lineEnd], "\r")
+	if n := utf8.RuneCountInString(line); hasCode && n > MAX_LINE_LENGTH {
+		v.AcceptAt(STYLE_LINE_TOO_LONG, parser.NewLocation(locator, lineStart, len(line)), issue.H{`length`: n, `max`: MAX_LINE_LENGTH})
+	}
+
+	trimmed := strings.TrimRight(line, " \t")
+	if len(trimmed) < len(line) && !significant(lineStart+len(trimmed)) {
+		v.AcceptAt(STYLE_TRAILING_WHITESPACE, parser.NewLocation(locator, lineStart+len(trimmed), len(line)-len(trimmed)), issue.NO_ARGS)
+	}
+
+	for i := 0; i < len(trimmed); i++ {
+		if trimmed[i] == '\t' && !significant(lineStart+i) {
+			v.AcceptAt(STYLE_HARD_TAB, parser.NewLocation(locator, lineStart+i, 1), issue.NO_ARGS)
+		}
+	}
+}
+
+// checkTokens checks the token stream of the source between the given offsets and returns the start and
+// end offsets of all string tokens. The scan ends silently at the first lexical error.
+func (v *styleChecker) checkTokens(locator *parser.Locator, codeStart, codeEnd int) (stringRanges [][2]int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(issue.Reported); !ok {
+				panic(r)
+			}
+		}
+	}()
+
+	source := locator.String()
+	lexer := parser.NewSimpleLexer(locator.File(), source[codeStart:codeEnd])
+	for token := lexer.NextToken(); token != parser.TOKEN_END; token = lexer.NextToken() {
+		if token != parser.TOKEN_STRING {
+			continue
+		}
+		start := codeStart + lexer.TokenStartPos()
+		end := codeStart + lexer.(parser.PositionedLexer).Pos()
+		stringRanges = append(stringRanges, [2]int{start, end})
+		if source[start] != '"' {
+			continue
+		}
+		raw := source[start+1 : end-1]
+		if !strings.ContainsAny(raw, `$\'`) {
+			v.AcceptAt(STYLE_DOUBLE_QUOTED_STRING, parser.NewLocation(locator, start, end-start), issue.H{
+				`value`: raw, `edits`: []*parser.Edit{{Offset: start, Length: end - start, Text: `'` + raw + `'`}}})
+		}
+	}
+	return
+}
+
+// CodeRanges returns the start and end offsets of the code in the source of the given program. The code
+// of an EPP template is the code within its tags. All other source is code.
+func CodeRanges(e *parser.Program) [][2]int {
+	source := e.Locator().String()
+	if le, ok := e.Body().(*parser.LambdaExpression); !ok {
+		return [][2]int{{0, len(source)}}
+	} else if _, ok = le.Body().(*parser.EppExpression); !ok {
+		return [][2]int{{0, len(source)}}
+	}
+
+	var code [][2]int
+	for pos := 0; ; {
+		idx := strings.Index(source[pos:], `<%`)
+		if idx < 0 {
+			return code
+		}
+		start := pos + idx + 2
+		if start == len(source) {
+			return code
+		}
+		switch source[start] {
+		case '%':
+			// <%% is verbatim <%
+			pos = start + 1
+			continue
+		case '#':
+			// A comment is not code
+			idx = strings.Index(source[start:], `%>`)
+			if idx < 0 {
+				return code
+			}
+			pos = start + idx + 2
+			continue
+		case '=', '-':
+			start++
+		}
+		end := tagEnd(e.Locator().File(), source, start)
+		if end < 0 {
+			return code
+		}
+		code = append(code, [2]int{start, end})
+		pos = end + 2
+		if source[end] == '-' {
+			pos++
+		}
+	}
+}
+
+// tagEnd returns the offset of the %> or -%> that ends the tag with code that starts at the given offset,
+// or -1 if that end cannot be found
+func tagEnd(file, source string, start int) (end int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(issue.Reported); !ok {
+				panic(r)
+			}
+			end = -1
+		}
+	}()
+
+	// The lexer reads %> as the remainder operator followed by a greater than
+	lexer := parser.NewSimpleLexer(file, source[start:])
+	prev := parser.TOKEN_END
+	for token := lexer.NextToken(); token != parser.TOKEN_END; token = lexer.NextToken() {
+		pos := lexer.(parser.PositionedLexer).Pos()
+		if token == parser.TOKEN_REMAINDER && pos < len(source)-start && source[start+pos] == '>' {
+			end = start + lexer.TokenStartPos()
+			if prev == parser.TOKEN_SUBTRACT && end > start && source[end-1] == '-' {
+				end--
+			}
+			return
+		}
+		prev = token
+	}
+	return -1
+}
+
+// checkFact checks for facts that are accessed as top scope variables, e.g. $::kernel, rather than
+// through the $facts hash. Other top scope variables, such as $::role or $::environment, are not facts
+// and are not reported. Legacy facts are reported by the validator.
+func (v *styleChecker) checkFact(e *parser.VariableExpression) {
+	name, ok := e.Name()
+	if !ok {
+		return
+	}
+	fact := strings.TrimPrefix(name, `::`)
+	if _, ok := FACT_KEYS[fact]; ok && fact != name {
+		v.acceptFact(STYLE_TOP_SCOPE_FACT, e, name, `facts['`+fact+`']`, issue.H{`name`: name, `fact`: fact})
+	}
+}
+
+func (v *styleChecker) checkTitle(title parser.Expression) {
+	if qn, ok := title.(*parser.QualifiedName); ok {
+		v.Accept(STYLE_UNQUOTED_RESOURCE_TITLE, qn, issue.H{`title`: qn.Name()})
+	}
+}
+
+func (v *styleChecker) checkEnsureFirst(operations []parser.Expression) {
+	for i, op := range operations {
+		if ao, ok := op.(*parser.AttributeOperation); ok && ao.Name() == `ensure` && i > 0 {
+			v.Accept(STYLE_ENSURE_NOT_FIRST, ao, issue.NO_ARGS)
+			return
+		}
+	}
+}
+
+// checkArrowAlignment checks that the arrows of attribute operations that are on lines of their own are
+// aligned one space after the longest attribute name
+func (v *styleChecker) checkArrowAlignment(operations []parser.Expression) {
+	if len(operations) < 2 {
+		return
+	}
+	arrows := make([]ArrowPosition, 0, len(operations))
+	seenLines := make(map[int]bool, len(operations))
+	for _, op := range operations {
+		arrow, ok := ArrowOf(op)
+		if !ok {
+			return
+		}
+		line := op.Line()
+		if seenLines[line] {
+			// Several operations on one line. Alignment is not applicable
+			return
+		}
+		seenLines[line] = true
+		arrows = append(arrows, arrow)
+	}
+
+	expected := ExpectedArrowColumn(arrows)
+	for _, arrow := range arrows {
+		if arrow.Column != expected {
+			// Replace the whitespace between the name and the arrow
+			edit := &parser.Edit{Offset: arrow.NameEnd, Length: arrow.Offset - arrow.NameEnd, Text: strings.Repeat(` `, expected-arrow.NameEndColumn)}
+			v.AcceptAt(STYLE_ARROW_ALIGNMENT, parser.NewLocation(arrow.Locator, arrow.Offset, 2), issue.H{
+				`expected`: expected, `actual`: arrow.Column, `edits`: []*parser.Edit{edit}})
+		}
+	}
+}
+
+// ArrowPosition describes the position of the arrow of an attribute operation
+type ArrowPosition struct {
+	Locator *parser.Locator
+
+	// Offset of the arrow in the source
+	Offset int
+
+	// Column of the arrow
+	Column int
+
+	// NameEnd is the offset just after the end of the attribute name
+	NameEnd int
+
+	// NameEndColumn is the column just after the end of the attribute name
+	NameEndColumn int
+}
+
+// ArrowOf returns the position of the arrow in the given attribute operation
+func ArrowOf(op parser.Expression) (arrow ArrowPosition, ok bool) {
+	locator := op.Locator()
+	source := locator.String()
+	start := op.ByteOffset()
+	end := start + op.ByteLength()
+	if end > len(source) {
+		end = len(source)
+	}
+	text := source[start:end]
+	idx := strings.Index(text, `=>`)
+	if pidx := strings.Index(text, `+>`); pidx >= 0 && (idx < 0 || pidx < idx) {
+		idx = pidx
+	}
+	if idx < 0 {
+		return
+	}
+	offset := start + idx
+	nameEnd := start + len(strings.TrimRight(text[:idx], " \t"))
+	return ArrowPosition{locator, offset, locator.PosOnLine(offset), nameEnd, locator.PosOnLine(nameEnd)}, true
+}
+
+// ExpectedArrowColumn returns the column where the arrows should be, which is one space after the
+// longest name
+func ExpectedArrowColumn(arrows []ArrowPosition) int {
+	expected := 0
+	for _, arrow := range arrows {
+		if arrow.NameEndColumn+1 > expected {
+			expected = arrow.NameEndColumn + 1
+		}
+	}
+	return expected
+}
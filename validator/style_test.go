@@ -0,0 +1,169 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestStyleWhitespace(t *testing.T) {
+	expectNoStyleIssues(t, "$a = 1\n$b = 2\n")
+	expectStyleIssues(t, "$a = 1  \n$b = 2\n", STYLE_TRAILING_WHITESPACE)
+	expectStyleIssues(t, "class foo {\n\t$a = 1\n}\n", STYLE_HARD_TAB)
+
+	// Whitespace in strings and heredocs is significant
+	expectNoStyleIssues(t, "$a = 'x\t  \ny'\n")
+	expectNoStyleIssues(t, "$a = @(END)\n  x\t  \n  END\n")
+}
+
+func TestStyleLineLength(t *testing.T) {
+	expectNoStyleIssues(t, `$a = '`+strings.Repeat(`x`, 133)+`'`)
+	expectStyleIssues(t, `$a = '`+strings.Repeat(`x`, 134)+`'`, STYLE_LINE_TOO_LONG)
+}
+
+func TestStyleDoubleQuotedString(t *testing.T) {
+	expectNoStyleIssues(t, `$a = "x${b}"`)
+	expectNoStyleIssues(t, `$a = "x\n"`)
+	expectNoStyleIssues(t, `$a = "it's"`)
+	expectStyleIssues(t, `$a = "x"`, STYLE_DOUBLE_QUOTED_STRING)
+}
+
+func TestStyleTemplate(t *testing.T) {
+	epp := []parser.Option{parser.PARSER_EPP_MODE}
+
+	// The output text of a template is never reported
+	expectStyleIssuesX(t, "Hello \"world\" <%= $x %>\n", epp)
+	expectStyleIssuesX(t, "Hello\t<%= $x %>  \n  it's <%= $y -%>\t\n", epp)
+	expectStyleIssuesX(t, "<%- | $x | -%>\n<%# \"comment\" -%>\nHello \"world\"  \n", epp)
+	expectStyleIssuesX(t, strings.Repeat(`x`, 150)+"\n<%= $x %>\n", epp)
+	expectStyleIssuesX(t, "<%% \"x\" %%> <%= '%>' %>\t\"y\"\n", epp)
+
+	// The code is
+	expectStyleIssuesX(t, "Hello \"world\" <%= \"x\" %> \"y\" <%= \"z\" %>", epp, STYLE_DOUBLE_QUOTED_STRING, STYLE_DOUBLE_QUOTED_STRING)
+	expectStyleIssuesX(t, "<% if $x {\t-%>\nHello\n<% } -%>\n", epp, STYLE_HARD_TAB)
+	expectStyleIssuesX(t, strings.Repeat(`x`, 140)+"<%= $x %>\n", epp, STYLE_LINE_TOO_LONG)
+}
+
+func TestStyleResourceBody(t *testing.T) {
+	expectNoStyleIssues(t, issue.Unindent(`
+    file { '/tmp/x':
+      ensure => file,
+      mode   => '0644',
+      owner  => 'root',
+    }`))
+
+	expectStyleIssues(t, `file { foo: ensure => file }`, STYLE_UNQUOTED_RESOURCE_TITLE)
+	expectStyleIssues(t, `file { '/tmp/x': mode => '0644', ensure => file }`, STYLE_ENSURE_NOT_FIRST)
+
+	issues := styleIssues(t, issue.Unindent(`
+    file { '/tmp/x':
+      ensure => file,
+      mode => '0644',
+      owner  => 'root',
+    }`))
+	if len(issues) != 1 || issues[0].Code() != STYLE_ARROW_ALIGNMENT {
+		t.Fatalf(`expected one %s issue, got %v`, STYLE_ARROW_ALIGNMENT, issues)
+	}
+	if issues[0].Location().Line() != 3 || issues[0].Argument(`expected`) != 10 || issues[0].Argument(`actual`) != 8 {
+		t.Errorf(`unexpected issue %s`, issues[0].String())
+	}
+}
+
+func TestStyleSeverity(t *testing.T) {
+	issues := styleIssues(t, `$a = "x"`)
+	if len(issues) != 1 || issues[0].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`expected one warning, got %v`, issues)
+	}
+
+	v := NewStyleChecker()
+	v.Demote(STYLE_DOUBLE_QUOTED_STRING, issue.SEVERITY_IGNORE)
+	if expr := parse(t, `$a = "x"`); expr != nil {
+		Validate(v, expr)
+		if len(v.Issues()) != 0 {
+			t.Errorf(`expected no issues, got %v`, v.Issues())
+		}
+	}
+}
+
+func styleIssues(t *testing.T, source string, parserOptions ...parser.Option) []issue.Reported {
+	t.Helper()
+	expr := parse(t, source, parserOptions...)
+	if expr == nil {
+		return nil
+	}
+	return ValidateStyle(expr).Issues()
+}
+
+func expectNoStyleIssues(t *testing.T, source string) {
+	t.Helper()
+	expectStyleIssues(t, source)
+}
+
+func expectStyleIssues(t *testing.T, source string, expected ...issue.Code) {
+	t.Helper()
+	expectStyleIssuesX(t, source, nil, expected...)
+}
+
+func expectStyleIssuesX(t *testing.T, source string, parserOptions []parser.Option, expected ...issue.Code) {
+	t.Helper()
+	issues := styleIssues(t, source, parserOptions...)
+	if len(issues) != len(expected) {
+		t.Errorf(`expected %v, got %v`, expected, issues)
+		return
+	}
+	for i, code := range expected {
+		if issues[i].Code() != code {
+			t.Errorf(`expected %v, got %v`, expected, issues)
+			return
+		}
+	}
+}
+
+func TestStyleTopScopeFact(t *testing.T) {
+	expectNoStyleIssues(t, `notice($facts['role'])`)
+	expectNoStyleIssues(t, `notice($::trusted['certname'])`)
+	expectNoStyleIssues(t, `notice($::foo::bar)`)
+	expectStyleIssues(t, `notice($::kernel)`, STYLE_TOP_SCOPE_FACT)
+	expectStyleIssues(t, `notice("${::os}")`, STYLE_TOP_SCOPE_FACT)
+
+	// Variables set by an ENC, site.pp, or the server are not facts
+	expectNoStyleIssues(t, `notice($::role, $::environment, $::clientcert)`)
+}
+
+func TestStyleFixes(t *testing.T) {
+	expectStyleFix(t, `$a = "x"`, `$a = 'x'`)
+	expectStyleFix(t, `notice($::kernel, $::role)`, `notice($facts['kernel'], $::role)`)
+	expectStyleFix(t, `notice("${::kernel}-$::kernel ${::kernel.upcase}")`,
+		`notice("${facts['kernel']}-${facts['kernel']} ${facts['kernel'].upcase}")`)
+	expectStyleFix(t,
+		issue.Unindent(`
+      file { '/tmp/x':
+        ensure => file,
+        mode => '0644',
+        owner     => 'root',
+      }`),
+		issue.Unindent(`
+      file { '/tmp/x':
+        ensure => file,
+        mode   => '0644',
+        owner  => 'root',
+      }`))
+}
+
+// expectStyleFix applies the edits of all style issues found in the source and checks that the result is
+// equal to the expected source and free from style issues
+func expectStyleFix(t *testing.T, source, expected string) {
+	t.Helper()
+	var edits []*parser.Edit
+	for _, i := range styleIssues(t, source) {
+		edits = append(edits, parser.IssueEdits(i)...)
+	}
+	result, _ := parser.ApplyEdits(source, edits)
+	if result != expected {
+		t.Errorf(`expected fix of %q to produce %q, got %q`, source, expected, result)
+		return
+	}
+	expectNoStyleIssues(t, result)
+}
@@ -0,0 +1,195 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/yaml"
+)
+
+type (
+	// SyntaxChecker checks that the text of a heredoc is valid in the syntax that the heredoc declares,
+	// e.g. 'json' in @(END:json). It returns nil when the text is valid.
+	SyntaxChecker func(text string) *SyntaxError
+
+	// SyntaxError describes the first error that a SyntaxChecker found. Offset is the byte offset of the
+	// error in the checked text.
+	SyntaxError struct {
+		Message string
+		Offset  int
+	}
+
+	// SyntaxCheckerRegistry is a set of syntax checkers, keyed by lower case syntax name
+	SyntaxCheckerRegistry struct {
+		checkers map[string]SyntaxChecker
+	}
+)
+
+// NewSyntaxCheckerRegistry creates an empty registry
+func NewSyntaxCheckerRegistry() *SyntaxCheckerRegistry {
+	return &SyntaxCheckerRegistry{make(map[string]SyntaxChecker, 8)}
+}
+
+// BuiltinSyntaxCheckers creates a new registry that contains checkers for the 'json', 'yaml', 'epp',
+// and 'pp' syntaxes
+func BuiltinSyntaxCheckers() *SyntaxCheckerRegistry {
+	r := NewSyntaxCheckerRegistry()
+	r.Add(`json`, checkJson)
+	r.Add(`yaml`, checkYaml)
+	r.Add(`epp`, func(text string) *SyntaxError { return checkPuppet(text, parser.PARSER_EPP_MODE) })
+	r.Add(`pp`, func(text string) *SyntaxError { return checkPuppet(text) })
+	return r
+}
+
+// Add adds, or replaces, the checker for the given syntax
+func (r *SyntaxCheckerRegistry) Add(syntax string, checker SyntaxChecker) {
+	r.checkers[strings.ToLower(syntax)] = checker
+}
+
+// Lookup returns the checker for the given syntax. The lookup is case insensitive.
+func (r *SyntaxCheckerRegistry) Lookup(syntax string) (checker SyntaxChecker, ok bool) {
+	checker, ok = r.checkers[strings.ToLower(syntax)]
+	return
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Message
+}
+
+func checkJson(text string) *SyntaxError {
+	var value interface{}
+	err := json.Unmarshal([]byte(text), &value)
+	switch err.(type) {
+	case nil:
+		return nil
+	case *json.SyntaxError:
+		// The offset of a json.SyntaxError is the number of bytes read, i.e. it is just past the error
+		offset := int(err.(*json.SyntaxError).Offset)
+		if offset > 0 {
+			offset--
+		}
+		return &SyntaxError{err.Error(), offset}
+	}
+	return &SyntaxError{err.Error(), len(text)}
+}
+
+func checkYaml(text string) *SyntaxError {
+	if _, err := yaml.Parse(text); err != nil {
+		se := err.(*yaml.SyntaxError)
+		return &SyntaxError{se.Message, se.Offset}
+	}
+	return nil
+}
+
+func checkPuppet(text string, options ...parser.Option) *SyntaxError {
+	_, err := parser.CreateParser(options...).Parse(``, text, false)
+	if err == nil {
+		return nil
+	}
+	ri, ok := err.(issue.Reported)
+	if !ok {
+		return &SyntaxError{err.Error(), 0}
+	}
+	sl, ok := ri.Location().(parser.SourceLocation)
+	if !ok {
+		return &SyntaxError{ri.Error(), 0}
+	}
+	// The message must not contain the location in the parsed text
+	suffix := fmt.Sprintf(` (line: %d, column: %d)`, sl.Line(), sl.Pos())
+	if sl.Pos() <= 0 {
+		suffix = fmt.Sprintf(` (line: %d)`, sl.Line())
+	}
+	return &SyntaxError{strings.TrimSuffix(ri.Error(), suffix), sl.ByteOffset()}
+}
+
+// heredocLocation maps an offset in the text of a heredoc to the location in the source. The text is
+// the source with the margin of the heredoc removed from each line and with its escapes applied.
+func heredocLocation(text *parser.LiteralString, offset int) issue.Location {
+	value := text.StringValue()
+	if offset > len(value) {
+		offset = len(value)
+	}
+	source := text.Locator().String()
+	end := text.ByteOffset() + text.ByteLength()
+	margin := heredocMargin(source[end:])
+	pos := stripMargin(source, text.ByteOffset(), margin)
+	for i := 0; i < offset && pos < end; {
+		if source[pos] == value[i] {
+			pos++
+			i++
+			if value[i-1] == '\n' {
+				pos = stripMargin(source, pos, margin)
+			}
+			continue
+		}
+		sn, vn := escapeLength(source[pos:end], value[i:])
+		if sn == 0 {
+			break
+		}
+		pos += sn
+		i += vn
+		if vn == 0 {
+			// An escaped line break
+			pos = stripMargin(source, pos, margin)
+		}
+	}
+	return parser.NewLocation(text.Locator(), pos, 0)
+}
+
+// heredocEscapes maps the escapes that the flags of a heredoc can enable to the characters they produce
+var heredocEscapes = map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', 's': ' ', '$': '$'}
+
+// escapeLength returns the length in the source and the length in the text of an escape at the start of
+// the source, or zero when the source doesn't start with an escape that produced the start of the text.
+func escapeLength(source, text string) (int, int) {
+	if len(source) < 2 || source[0] != '\\' {
+		return 0, 0
+	}
+	switch source[1] {
+	case '\n':
+		return 2, 0
+	case 'u':
+		r, vn := utf8.DecodeRuneInString(text)
+		if r == utf8.RuneError {
+			break
+		}
+		sn := 6
+		if len(source) > 2 && source[2] == '{' {
+			sn = strings.IndexByte(source, '}') + 1
+		}
+		if sn > 0 && sn <= len(source) {
+			return sn, vn
+		}
+	default:
+		if c, ok := heredocEscapes[source[1]]; ok && len(text) > 0 && text[0] == c {
+			return 2, 1
+		}
+	}
+	return 0, 0
+}
+
+// heredocMargin returns the number of whitespace characters in front of the '|' on the line that ends
+// a heredoc, or zero when the line has no '|'. The given source starts at the end of the heredoc text.
+func heredocMargin(source string) int {
+	source = strings.TrimPrefix(strings.TrimPrefix(source, "\r"), "\n")
+	margin := len(source) - len(strings.TrimLeft(source, " \t"))
+	if margin < len(source) && source[margin] == '|' {
+		return margin
+	}
+	return 0
+}
+
+// stripMargin returns the position after the margin of the line that starts at the given position. Like
+// the lexer, it strips nothing from a line that is indented less than the margin.
+func stripMargin(source string, pos, margin int) int {
+	for i := 0; i < margin; i++ {
+		if pos+i >= len(source) || source[pos+i] != ' ' && source[pos+i] != '\t' {
+			return pos
+		}
+	}
+	return pos + margin
+}
@@ -0,0 +1,191 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestHeredocSyntaxValidation(t *testing.T) {
+	expectNoIssues(t, issue.Unindent(`
+    $x = @(END:json)
+      {"a": [1, 2], "b": null}
+      |- END
+    notice($x)`))
+
+	expectNoIssues(t, issue.Unindent(`
+    $x = @(END:yaml)
+      a:
+        - 1
+        - 2
+      |- END
+    notice($x)`))
+
+	expectNoIssues(t, issue.Unindent(`
+    $x = @(END:yaml)
+      # Defaults
+      base: &base
+        url: http://example.com/#top
+        description: a description
+          that spans two lines
+      site:
+        <<: *base
+        name: site
+      |- END
+    notice($x)`))
+
+	// Interpolated text is not checked
+	expectNoIssues(t, issue.Unindent(`
+    $x = @("END":json)
+      {"a": ${y}
+      |- END
+    notice($x)`))
+
+	// No checker is registered for the syntax
+	expectNoIssues(t, issue.Unindent(`
+    $x = @(END:xml)
+      <a>
+      |- END
+    notice($x)`))
+
+	expectIssues(t, issue.Unindent(`
+    $x = @(END:json)
+      {"a": 1,}
+      |- END
+    notice($x)`),
+		VALIDATE_HEREDOC_SYNTAX_ERROR)
+}
+
+func TestHeredocSyntaxErrorLocation(t *testing.T) {
+	for _, tc := range []struct {
+		source   string
+		expected string
+		line     int
+		pos      int
+	}{
+		{
+			issue.Unindent(`
+        $x = @(END:JSON)
+          {
+            "a": 1,
+            "b" 2
+          }
+          |- END
+        notice($x)`),
+			`Heredoc with syntax 'JSON' is not valid: invalid character '2' after object key`, 4, 9,
+		},
+		{
+			issue.Unindent(`
+        $x = @(END:yaml)
+          a: 1
+          b: [1, 2
+          |- END
+        notice($x)`),
+			`Heredoc with syntax 'yaml' is not valid`, 3, 0,
+		},
+		{
+			issue.Unindent(`
+        $x = @(END:pp)
+          notice('a')
+          $y = = 2
+          |- END
+        notice($x)`),
+			`Heredoc with syntax 'pp' is not valid: unexpected token '='`, 3, 8,
+		},
+		{
+			issue.Unindent(`
+        $x = @(END:epp)
+          <%| String $a |%>
+          <%= $a %> <% if { %>
+          |- END
+        notice($x)`),
+			`Heredoc with syntax 'epp' is not valid`, 3, 0,
+		},
+		{
+			issue.Unindent(`
+        $x = @(END:json/s)
+          {
+            "a\s": 1,
+            "b\s" 2
+          }
+          |- END
+        notice($x)`),
+			`Heredoc with syntax 'json' is not valid: invalid character '2' after object key`, 4, 11,
+		},
+		{
+			issue.Unindent(`
+        $x = @(END:json/Lu)
+          {"a": 1, \
+          "b": \u{32}, \
+          "c" 3}
+          |- END
+        notice($x)`),
+			`Heredoc with syntax 'json' is not valid: invalid character '3' after object key`, 4, 7,
+		},
+	} {
+		issues := parseAndValidate(t, tc.source)
+		if len(issues) != 1 {
+			t.Errorf(`expected one issue for %s, got %v`, tc.source, issues)
+			continue
+		}
+		ri := issues[0]
+		if ri.Code() != VALIDATE_HEREDOC_SYNTAX_ERROR || !strings.HasPrefix(ri.Error(), tc.expected) {
+			t.Errorf(`expected '%s', got '%s'`, tc.expected, ri)
+		}
+		loc := ri.Location()
+		if loc.Line() != tc.line || tc.pos > 0 && loc.Pos() != tc.pos {
+			t.Errorf(`expected the error at line %d, column %d, got %s`, tc.line, tc.pos, ri)
+		}
+	}
+}
+
+func TestDemoteHeredocSyntaxError(t *testing.T) {
+	expr := parse(t, issue.Unindent(`
+    $x = @(END:json)
+      {"a": 1,}
+      |- END
+    notice($x)`))
+	if expr == nil {
+		return
+	}
+	v := NewChecker(STRICT_ERROR)
+	v.Demote(VALIDATE_HEREDOC_SYNTAX_ERROR, issue.SEVERITY_WARNING)
+	Validate(v, expr)
+	if issues := v.Issues(); len(issues) != 1 || issues[0].Severity() != issue.SEVERITY_WARNING {
+		t.Errorf(`expected one warning, got %v`, issues)
+	}
+}
+
+func TestCustomSyntaxChecker(t *testing.T) {
+	expr := parse(t, issue.Unindent(`
+    $x = @(END:csv)
+      a,b
+      c
+      |- END
+    notice($x)`))
+	if expr == nil {
+		return
+	}
+	v := NewChecker(STRICT_ERROR)
+	v.SyntaxCheckers().Add(`CSV`, func(text string) *SyntaxError {
+		lines := strings.Split(text, "\n")
+		offset := 0
+		for _, line := range lines {
+			if strings.Count(line, `,`) != strings.Count(lines[0], `,`) {
+				return &SyntaxError{`wrong number of columns`, offset}
+			}
+			offset += len(line) + 1
+		}
+		return nil
+	})
+	Validate(v, expr)
+
+	issues := v.Issues()
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Error(), `Heredoc with syntax 'csv' is not valid: wrong number of columns`) {
+		t.Fatalf(`unexpected issues %v`, issues)
+	}
+	if loc := issues[0].Location(); loc.Line() != 3 || loc.Pos() != 3 {
+		t.Errorf(`expected the error at line 3, column 3, got %s`, issues[0])
+	}
+}
@@ -18,6 +18,10 @@ type (
 	Validator interface {
 		Clear()
 
+		// Demote changes the severity of the given issue. Soft issues that are ignored by default can
+		// be enabled by demoting them to a warning or an error
+		Demote(code issue.Code, severity issue.Severity)
+
 		// Validate the semantics of the given expression
 		Validate(e parser.Expression)
 
@@ -86,12 +90,18 @@ func (v *AbstractValidator) Demote(code issue.Code, severity issue.Severity) {
 
 // Accept an issue during validation
 func (v *AbstractValidator) Accept(code issue.Code, e parser.Expression, args issue.H) {
+	v.AcceptAt(code, e, args)
+}
+
+// AcceptAt accepts an issue that is located at something other than an expression, such as a
+// token or a range of the source text
+func (v *AbstractValidator) AcceptAt(code issue.Code, location issue.Location, args issue.H) {
 	severity, ok := v.severities[code]
 	if !ok {
 		severity = issue.SEVERITY_ERROR
 	}
 	if severity != issue.SEVERITY_IGNORE {
-		v.issues = append(v.issues, issue.NewReported(code, severity, args, e))
+		v.issues = append(v.issues, issue.NewReported(code, severity, args, location))
 	}
 }
 
@@ -0,0 +1,951 @@
+// Package yaml contains a reader for the subset of YAML that is used in Hiera configuration and data files.
+//
+// The reader supports block and flow mappings and sequences, plain, quoted, and block scalars, comments,
+// anchors, aliases, and merge keys. Tags are accepted but ignored. Only the first document of a stream is
+// read.
+// Plain scalars are resolved the way Ruby's YAML parser resolves them, since that is what Puppet uses.
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a node
+type Kind int
+
+const (
+	SCALAR = Kind(iota)
+	SEQUENCE
+	MAPPING
+)
+
+type (
+	// Node is a node in a YAML document
+	Node struct {
+		Kind Kind
+
+		// Value is the value of a scalar. It is a string, int64, float64, bool, or nil
+		Value interface{}
+
+		// Items are the items of a sequence
+		Items []*Node
+
+		// Keys and Values are the entries of a mapping in the order that they appear
+		Keys   []*Node
+		Values []*Node
+
+		// Offset and Length denote the node in the source
+		Offset int
+		Length int
+	}
+
+	// SyntaxError is returned when the source is not valid YAML
+	SyntaxError struct {
+		Message string
+		Offset  int
+		Line    int
+		Column  int
+	}
+)
+
+var (
+	intPattern     = regexp.MustCompile(`\A[-+]?(?:0|[1-9][0-9_]*)\z`)
+	octalPattern   = regexp.MustCompile(`\A[-+]?0o?[0-7_]+\z`)
+	hexPattern     = regexp.MustCompile(`\A[-+]?0x[0-9a-fA-F_]+\z`)
+	binaryPattern  = regexp.MustCompile(`\A[-+]?0b[01_]+\z`)
+	floatPattern   = regexp.MustCompile(`\A[-+]?(?:[0-9][0-9_]*)?\.[0-9_]*(?:[eE][-+]?[0-9]+)?\z|\A[-+]?[0-9][0-9_]*[eE][-+]?[0-9]+\z`)
+	infPattern     = regexp.MustCompile(`\A[-+]?\.(?:inf|Inf|INF)\z`)
+	nanPattern     = regexp.MustCompile(`\A\.(?:nan|NaN|NAN)\z`)
+	booleanStrings = map[string]bool{
+		`true`: true, `True`: true, `TRUE`: true, `yes`: true, `Yes`: true, `YES`: true, `on`: true, `On`: true, `ON`: true,
+		`false`: false, `False`: false, `FALSE`: false, `no`: false, `No`: false, `NO`: false, `off`: false, `Off`: false, `OFF`: false,
+	}
+)
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf(`%s at line %d, column %d`, e.Message, e.Line, e.Column)
+}
+
+// Parse parses the first document of the given source. An empty document yields a scalar node with a
+// nil value. A *SyntaxError is returned when the source is not valid YAML.
+func Parse(source string) (node *Node, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if se, ok := r.(*SyntaxError); ok {
+				node = nil
+				err = se
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	p := &yamlParser{src: source, anchors: make(map[string]*Node)}
+	node = p.parseDocument()
+	return
+}
+
+// Get returns the value of the entry with the given key in a mapping, or nil if the node is not a
+// mapping or has no such entry
+func (n *Node) Get(key string) *Node {
+	for i, k := range n.Keys {
+		if k.Kind == SCALAR && fmt.Sprint(k.Value) == key {
+			return n.Values[i]
+		}
+	}
+	return nil
+}
+
+// Data returns the value of the node as a string, int64, float64, bool, nil, []interface{}, or
+// map[string]interface{}. Keys of mappings are converted to strings.
+func (n *Node) Data() interface{} {
+	switch n.Kind {
+	case SEQUENCE:
+		items := make([]interface{}, len(n.Items))
+		for i, item := range n.Items {
+			items[i] = item.Data()
+		}
+		return items
+	case MAPPING:
+		entries := make(map[string]interface{}, len(n.Keys))
+		for i, k := range n.Keys {
+			entries[fmt.Sprint(k.Data())] = n.Values[i].Data()
+		}
+		return entries
+	default:
+		return n.Value
+	}
+}
+
+type yamlParser struct {
+	src     string
+	pos     int
+	anchors map[string]*Node
+}
+
+func (p *yamlParser) fail(offset int, format string, args ...interface{}) {
+	lineStart := strings.LastIndexByte(p.src[:offset], '\n') + 1
+	panic(&SyntaxError{
+		Message: fmt.Sprintf(format, args...),
+		Offset:  offset,
+		Line:    strings.Count(p.src[:offset], "\n") + 1,
+		Column:  utf8.RuneCountInString(p.src[lineStart:offset]) + 1})
+}
+
+func (p *yamlParser) parseDocument() *Node {
+	for p.skipBlank() && p.src[p.pos] == '%' {
+		// Directives
+		p.skipToLineEnd()
+	}
+	if p.atMarker(`---`) {
+		p.pos += 3
+	}
+	if !p.skipBlank() {
+		return &Node{Kind: SCALAR, Offset: p.pos}
+	}
+	node := p.parseValue(-1, true)
+	if p.skipBlank() {
+		p.fail(p.pos, `unexpected content`)
+	}
+	return node
+}
+
+// parseValue parses the value that follows a mapping key, a sequence entry indicator, or the start of a
+// document. The parent is the column of the key or indicator. A compact value is a mapping or
+// sequence that starts on the same line as a sequence entry indicator.
+func (p *yamlParser) parseValue(parent int, compact bool) *Node {
+	p.skipSpaces()
+	anchor := p.parseProperties()
+	start := p.pos
+	var node *Node
+	if p.atLineEnd() {
+		if p.skipBlank() {
+			col := p.column(p.pos)
+			if col > parent {
+				node = p.parseBlockNode(col, parent)
+			} else if col == parent && !compact && p.atSequenceEntry() {
+				// A sequence that is the value of a mapping entry may have the same indentation as the key
+				node = p.parseSequence(col)
+			}
+		}
+		if node == nil {
+			node = &Node{Kind: SCALAR, Offset: start}
+		}
+	} else {
+		switch {
+		case compact && (p.atSequenceEntry() || p.keyEnd() >= 0):
+			node = p.parseBlockNode(p.column(p.pos), parent)
+		case p.src[p.pos] == '|' || p.src[p.pos] == '>':
+			node = p.parseBlockScalar(parent)
+		default:
+			node = p.parseInline(parent)
+			p.skipSpaces()
+			if !p.atLineEnd() {
+				p.fail(p.pos, `unexpected content after value`)
+			}
+		}
+	}
+	if anchor != `` {
+		p.anchors[anchor] = node
+	}
+	return node
+}
+
+// parseBlockNode parses a node that starts at the given column. A plain scalar may continue on lines
+// that are indented more than the parent column.
+func (p *yamlParser) parseBlockNode(col, parent int) *Node {
+	if p.atSequenceEntry() {
+		return p.parseSequence(col)
+	}
+	if p.keyEnd() >= 0 {
+		return p.parseMapping(col)
+	}
+	if p.src[p.pos] == '|' || p.src[p.pos] == '>' {
+		return p.parseBlockScalar(col - 1)
+	}
+	node := p.parseInline(parent)
+	p.skipSpaces()
+	if !p.atLineEnd() {
+		p.fail(p.pos, `unexpected content after value`)
+	}
+	return node
+}
+
+func (p *yamlParser) parseSequence(col int) *Node {
+	node := &Node{Kind: SEQUENCE, Offset: p.pos, Items: make([]*Node, 0)}
+	for {
+		p.pos++
+		item := p.parseValue(col, true)
+		node.Items = append(node.Items, item)
+		node.Length = end(item, p.pos) - node.Offset
+		if !p.skipBlank() {
+			break
+		}
+		c := p.column(p.pos)
+		if c < col || c == col && !p.atSequenceEntry() {
+			break
+		}
+		if c > col || !p.atSequenceEntry() {
+			p.fail(p.pos, `bad indentation of a sequence entry`)
+		}
+	}
+	return node
+}
+
+func (p *yamlParser) parseMapping(col int) *Node {
+	node := &Node{Kind: MAPPING, Offset: p.pos, Keys: make([]*Node, 0), Values: make([]*Node, 0)}
+	for {
+		keyEnd := p.keyEnd()
+		if keyEnd < 0 {
+			p.fail(p.pos, `expected a mapping key`)
+		}
+		var key *Node
+		if c := p.src[p.pos]; c == '"' || c == '\'' {
+			key = p.parseQuoted()
+		} else {
+			text := strings.TrimRight(p.src[p.pos:keyEnd], " \t")
+			key = &Node{Kind: SCALAR, Value: resolvePlain(text), Offset: p.pos, Length: len(text)}
+		}
+		p.pos = keyEnd + 1
+		value := p.parseValue(col, false)
+		node.Keys = append(node.Keys, key)
+		node.Values = append(node.Values, value)
+		node.Length = end(value, p.pos) - node.Offset
+		if !p.skipBlank() {
+			break
+		}
+		c := p.column(p.pos)
+		if c < col || c == col && p.atSequenceEntry() {
+			break
+		}
+		if c > col {
+			p.fail(p.pos, `bad indentation of a mapping entry`)
+		}
+	}
+	p.merge(node)
+	return node
+}
+
+// merge replaces the entries of a mapping that have the merge key '<<' with the entries of the mapping,
+// or list of mappings, that is their value. Entries that are given explicitly take precedence over merged
+// entries, and a mapping that comes first in a list takes precedence over the ones that follow it.
+func (p *yamlParser) merge(node *Node) {
+	var sources []*Node
+	keys := make([]*Node, 0, len(node.Keys))
+	values := make([]*Node, 0, len(node.Values))
+	for i, k := range node.Keys {
+		if k.Value != `<<` || p.src[k.Offset] == '"' || p.src[k.Offset] == '\'' {
+			keys = append(keys, k)
+			values = append(values, node.Values[i])
+			continue
+		}
+		v := node.Values[i]
+		switch v.Kind {
+		case MAPPING:
+			sources = append(sources, v)
+		case SEQUENCE:
+			for _, item := range v.Items {
+				if item.Kind != MAPPING {
+					p.fail(item.Offset, `expected a mapping to merge`)
+				}
+				sources = append(sources, item)
+			}
+		default:
+			p.fail(v.Offset, `expected a mapping or a list of mappings to merge`)
+		}
+	}
+	if sources == nil {
+		return
+	}
+	present := make(map[interface{}]bool, len(keys))
+	for _, k := range keys {
+		present[k.Value] = true
+	}
+	for _, source := range sources {
+		for i, k := range source.Keys {
+			if k.Kind == SCALAR && !present[k.Value] {
+				present[k.Value] = true
+				keys = append(keys, k)
+				values = append(values, source.Values[i])
+			}
+		}
+	}
+	node.Keys = keys
+	node.Values = values
+}
+
+// parseInline parses a flow collection, a quoted or plain scalar, or an alias. A plain scalar continues
+// on the lines that follow it when they are indented more than the given parent column.
+func (p *yamlParser) parseInline(parent int) *Node {
+	start := p.pos
+	switch p.src[p.pos] {
+	case '[', '{':
+		return p.parseFlow()
+	case '"', '\'':
+		return p.parseQuoted()
+	case '*':
+		return p.parseAlias()
+	case '@', '`':
+		p.fail(p.pos, `reserved character '%c' cannot start a plain scalar`, p.src[p.pos])
+	}
+	text := p.plainLine()
+	length := len(text)
+	for {
+		next, breaks := p.plainContinuation(parent)
+		if next < 0 {
+			break
+		}
+		p.pos = next
+		if p.keyEnd() >= 0 {
+			p.fail(next, `bad indentation of a mapping entry`)
+		}
+		if breaks == 1 {
+			text += ` `
+		} else {
+			text += strings.Repeat("\n", breaks-1)
+		}
+		line := p.plainLine()
+		text += line
+		length = next + len(line) - start
+	}
+	return &Node{Kind: SCALAR, Value: resolvePlain(text), Offset: start, Length: length}
+}
+
+// plainLine reads the part of a plain scalar that is on the current line and returns it without trailing
+// white space
+func (p *yamlParser) plainLine() string {
+	start := p.pos
+	for !p.atLineEnd() {
+		if p.src[p.pos] == ':' && p.atSeparator(p.pos+1) {
+			p.fail(p.pos, `mapping values are not allowed here`)
+		}
+		p.pos++
+	}
+	return strings.TrimRight(p.src[start:p.pos], " \t")
+}
+
+// plainContinuation returns the offset of the next line of a multi-line plain scalar together with the
+// number of line breaks that precede it. The offset is -1 when the scalar ends on the current line, i.e.
+// when the line ends with a comment, or when the next line that is not empty is not indented more than
+// the parent column, starts a comment, or is a document marker.
+func (p *yamlParser) plainContinuation(parent int) (int, int) {
+	i := p.pos
+	if i < len(p.src) && p.src[i] == '#' {
+		return -1, 0
+	}
+	breaks := 0
+	for ; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\n':
+			breaks++
+		case ' ', '\t', '\r':
+		default:
+			save := p.pos
+			p.pos = i
+			marker := p.atMarker(`---`) || p.atMarker(`...`)
+			p.pos = save
+			if breaks == 0 || p.column(i) <= parent || p.src[i] == '#' || marker {
+				return -1, 0
+			}
+			return i, breaks
+		}
+	}
+	return -1, 0
+}
+
+func (p *yamlParser) parseFlow() *Node {
+	p.skipFlowSpace()
+	anchor := p.parseProperties()
+	p.skipFlowSpace()
+	if p.pos >= len(p.src) {
+		p.fail(p.pos, `unexpected end of input in flow collection`)
+	}
+
+	var node *Node
+	start := p.pos
+	switch p.src[p.pos] {
+	case '[':
+		node = &Node{Kind: SEQUENCE, Offset: start, Items: make([]*Node, 0)}
+		p.pos++
+		for p.nextFlowEntry(']') {
+			node.Items = append(node.Items, p.parseFlow())
+			p.endFlowEntry(']')
+		}
+		node.Length = p.pos - start
+	case '{':
+		node = &Node{Kind: MAPPING, Offset: start, Keys: make([]*Node, 0), Values: make([]*Node, 0)}
+		p.pos++
+		for p.nextFlowEntry('}') {
+			key := p.parseFlow()
+			p.skipFlowSpace()
+			value := &Node{Kind: SCALAR, Offset: p.pos}
+			if p.pos < len(p.src) && p.src[p.pos] == ':' {
+				p.pos++
+				p.skipFlowSpace()
+				if p.pos < len(p.src) && p.src[p.pos] != ',' && p.src[p.pos] != '}' {
+					value = p.parseFlow()
+				}
+			}
+			node.Keys = append(node.Keys, key)
+			node.Values = append(node.Values, value)
+			p.endFlowEntry('}')
+		}
+		node.Length = p.pos - start
+		p.merge(node)
+	case '"', '\'':
+		node = p.parseQuoted()
+	case '*':
+		node = p.parseAlias()
+	default:
+		for p.pos < len(p.src) {
+			c := p.src[p.pos]
+			if c == '\n' || c == ',' || c == '[' || c == ']' || c == '{' || c == '}' ||
+				c == ':' && (p.atSeparator(p.pos+1) || strings.IndexByte(`,[]{}`, p.peek(1)) >= 0) ||
+				c == '#' && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+				break
+			}
+			p.pos++
+		}
+		text := strings.TrimRight(p.src[start:p.pos], " \t\r")
+		node = &Node{Kind: SCALAR, Value: resolvePlain(text), Offset: start, Length: len(text)}
+	}
+	if anchor != `` {
+		p.anchors[anchor] = node
+	}
+	return node
+}
+
+// nextFlowEntry returns true if there is another entry before the given closing character. The closing
+// character is consumed when it is found.
+func (p *yamlParser) nextFlowEntry(close byte) bool {
+	p.skipFlowSpace()
+	if p.pos >= len(p.src) {
+		p.fail(p.pos, `unexpected end of input in flow collection`)
+	}
+	if p.src[p.pos] == close {
+		p.pos++
+		return false
+	}
+	return true
+}
+
+func (p *yamlParser) endFlowEntry(close byte) {
+	p.skipFlowSpace()
+	if p.pos >= len(p.src) {
+		p.fail(p.pos, `unexpected end of input in flow collection`)
+	}
+	switch p.src[p.pos] {
+	case ',':
+		p.pos++
+		return
+	case close:
+		return
+	}
+	p.fail(p.pos, `expected ',' or '%c'`, close)
+}
+
+func (p *yamlParser) parseQuoted() *Node {
+	start := p.pos
+	quote := p.src[p.pos]
+	p.pos++
+	b := bytes.NewBufferString(``)
+	for {
+		if p.pos >= len(p.src) {
+			p.fail(start, `unterminated quoted scalar`)
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			if quote == '\'' && p.peek(1) == '\'' {
+				b.WriteByte('\'')
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			return &Node{Kind: SCALAR, Value: b.String(), Offset: start, Length: p.pos - start}
+		case c == '\\' && quote == '"':
+			p.parseEscape(b)
+		case c == '\n' || c == '\r':
+			p.foldLines(b)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// foldLines folds the line breaks in a quoted scalar. A single line break becomes a space and each
+// empty line becomes a newline.
+func (p *yamlParser) foldLines(b *bytes.Buffer) {
+	trimmed := bytes.TrimRight(b.Bytes(), " \t")
+	b.Truncate(len(trimmed))
+	breaks := 0
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '\n' {
+			breaks++
+		} else if c != ' ' && c != '\t' && c != '\r' {
+			break
+		}
+		p.pos++
+	}
+	if breaks == 1 {
+		b.WriteByte(' ')
+	} else {
+		b.WriteString(strings.Repeat("\n", breaks-1))
+	}
+}
+
+func (p *yamlParser) parseEscape(b *bytes.Buffer) {
+	start := p.pos
+	p.pos += 2
+	switch c := p.src[p.pos-1]; c {
+	case '0':
+		b.WriteByte(0)
+	case 'a':
+		b.WriteByte('\a')
+	case 'b':
+		b.WriteByte('\b')
+	case 't', '\t':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'v':
+		b.WriteByte('\v')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case ' ', '"', '/', '\\':
+		b.WriteByte(c)
+	case 'N':
+		b.WriteRune('\u0085')
+	case '_':
+		b.WriteRune('\u00a0')
+	case 'L':
+		b.WriteRune('\u2028')
+	case 'P':
+		b.WriteRune('\u2029')
+	case 'x', 'u', 'U':
+		n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+		if p.pos+n > len(p.src) {
+			p.fail(start, `invalid escape sequence`)
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil {
+			p.fail(start, `invalid escape sequence`)
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	case '\n', '\r':
+		// Escaped line break. The break and the leading white space of the next line are removed
+		for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+	default:
+		p.fail(start, `invalid escape sequence '\%c'`, c)
+	}
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar. The content must be indented more
+// than the given parent column.
+func (p *yamlParser) parseBlockScalar(parent int) *Node {
+	start := p.pos
+	literal := p.src[p.pos] == '|'
+	p.pos++
+	chomp := byte(0)
+	indent := 0
+	for i := 0; i < 2 && p.pos < len(p.src); i++ {
+		c := p.src[p.pos]
+		if (c == '-' || c == '+') && chomp == 0 {
+			chomp = c
+		} else if c >= '1' && c <= '9' && indent == 0 {
+			indent = int(c - '0')
+			if parent > 0 {
+				indent += parent
+			}
+		} else {
+			break
+		}
+		p.pos++
+	}
+	p.skipSpaces()
+	if !p.atLineEnd() {
+		p.fail(p.pos, `unexpected content after block scalar indicator`)
+	}
+	p.skipToLineEnd()
+	contentEnd := p.pos
+	if p.pos < len(p.src) {
+		p.pos++
+	}
+
+	lines := make([]string, 0)
+	for p.pos < len(p.src) {
+		lineEnd := strings.IndexByte(p.src[p.pos:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(p.src)
+		} else {
+			lineEnd += p.pos
+		}
+		line := strings.TrimRight(p.src[p.pos:lineEnd], "\r")
+		spaces := len(line) - len(strings.TrimLeft(line, ` `))
+		if strings.TrimSpace(line) == `` {
+			if indent > 0 && spaces > indent {
+				lines = append(lines, line[indent:])
+			} else {
+				lines = append(lines, ``)
+			}
+		} else {
+			if indent == 0 {
+				if spaces <= parent {
+					break
+				}
+				indent = spaces
+			}
+			if spaces < indent {
+				break
+			}
+			lines = append(lines, line[indent:])
+			contentEnd = lineEnd
+		}
+		p.pos = lineEnd
+		if p.pos < len(p.src) {
+			p.pos++
+		}
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == `` {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var text string
+	if literal {
+		text = strings.Join(lines, "\n")
+	} else {
+		text = fold(lines)
+	}
+	switch chomp {
+	case '-':
+	case '+':
+		if len(lines) > 0 {
+			text += "\n"
+		}
+		text += strings.Repeat("\n", trailing)
+	default:
+		if len(lines) > 0 {
+			text += "\n"
+		}
+	}
+	return &Node{Kind: SCALAR, Value: text, Offset: start, Length: contentEnd - start}
+}
+
+// fold joins the lines of a folded block scalar. Lines are joined with a space unless they are more
+// indented, and each empty line becomes a newline.
+func fold(lines []string) string {
+	b := bytes.NewBufferString(``)
+	breaks := 0
+	prev := ``
+	for _, line := range lines {
+		if line == `` {
+			breaks++
+			continue
+		}
+		if prev != `` {
+			moreIndented := isIndented(line) || isIndented(prev)
+			if breaks == 0 && !moreIndented {
+				b.WriteByte(' ')
+			} else if moreIndented {
+				breaks++
+			}
+		}
+		b.WriteString(strings.Repeat("\n", breaks))
+		b.WriteString(line)
+		prev = line
+		breaks = 0
+	}
+	return b.String()
+}
+
+func isIndented(line string) bool {
+	return line[0] == ' ' || line[0] == '\t'
+}
+
+func (p *yamlParser) parseAlias() *Node {
+	start := p.pos
+	name := p.readName()
+	node, ok := p.anchors[name]
+	if !ok {
+		p.fail(start, `unknown alias '%s'`, name)
+	}
+	return node
+}
+
+// parseProperties skips a tag and returns the name of an anchor that precedes a node, or an empty
+// string if there is no anchor
+func (p *yamlParser) parseProperties() (anchor string) {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '&':
+			anchor = p.readName()
+		case '!':
+			p.readName()
+		default:
+			return
+		}
+		p.skipSpaces()
+	}
+	return
+}
+
+// readName reads the name of an anchor, alias, or tag. The leading indicator is skipped
+func (p *yamlParser) readName() string {
+	p.pos++
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n,[]{}", p.src[p.pos]) < 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		p.fail(start, `expected a name`)
+	}
+	return p.src[start:p.pos]
+}
+
+// keyEnd returns the offset of the ':' that ends a mapping key on the current line, or -1 if the line
+// does not start with a mapping key
+func (p *yamlParser) keyEnd() int {
+	i := p.pos
+	if i >= len(p.src) {
+		return -1
+	}
+	switch c := p.src[i]; c {
+	case '"', '\'':
+		save := p.pos
+		p.parseQuoted()
+		i = p.pos
+		p.pos = save
+		for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t') {
+			i++
+		}
+		if i < len(p.src) && p.src[i] == ':' && p.atSeparator(i+1) {
+			return i
+		}
+		return -1
+	case '[', '{', '#', '&', '*', '!', '|', '>', '%', '@', '`':
+		return -1
+	}
+	for ; i < len(p.src) && p.src[i] != '\n'; i++ {
+		switch p.src[i] {
+		case ':':
+			if p.atSeparator(i + 1) {
+				return i
+			}
+		case '#':
+			if p.src[i-1] == ' ' || p.src[i-1] == '\t' {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+func (p *yamlParser) atSequenceEntry() bool {
+	return p.pos < len(p.src) && p.src[p.pos] == '-' && p.atSeparator(p.pos+1)
+}
+
+// atSeparator returns true if the given offset is at white space, a line end, or the end of input
+func (p *yamlParser) atSeparator(i int) bool {
+	return i >= len(p.src) || strings.IndexByte(" \t\r\n", p.src[i]) >= 0
+}
+
+// atMarker returns true if the position is at the start of a line that starts with the given document
+// marker
+func (p *yamlParser) atMarker(marker string) bool {
+	return p.column(p.pos) == 0 && strings.HasPrefix(p.src[p.pos:], marker) && p.atSeparator(p.pos+len(marker))
+}
+
+// atLineEnd returns true if the rest of the line is empty or a comment. A '#' only starts a comment at
+// the start of a line or after white space.
+func (p *yamlParser) atLineEnd() bool {
+	if p.pos >= len(p.src) {
+		return true
+	}
+	switch p.src[p.pos] {
+	case '\r', '\n':
+		return true
+	case '#':
+		return p.pos == 0 || strings.IndexByte(" \t\r\n", p.src[p.pos-1]) >= 0
+	}
+	return false
+}
+
+func (p *yamlParser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *yamlParser) skipToLineEnd() {
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// skipBlank skips white space, comments, and line breaks. It returns false when the end of the input
+// or the end of the document is reached
+func (p *yamlParser) skipBlank() bool {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\r', '\n':
+			p.pos++
+		case '\t':
+			lineStart := strings.LastIndexByte(p.src[:p.pos], '\n') + 1
+			if strings.TrimSpace(p.src[lineStart:p.pos]) == `` {
+				save := p.pos
+				p.skipSpaces()
+				if !p.atLineEnd() {
+					p.fail(save, `tab characters must not be used for indentation`)
+				}
+			} else {
+				p.pos++
+			}
+		case '#':
+			p.skipToLineEnd()
+		default:
+			return !(p.atMarker(`---`) || p.atMarker(`...`))
+		}
+	}
+	return false
+}
+
+// skipFlowSpace skips white space, comments, and line breaks within a flow collection
+func (p *yamlParser) skipFlowSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			p.skipToLineEnd()
+		default:
+			return
+		}
+	}
+}
+
+func (p *yamlParser) column(offset int) int {
+	return offset - (strings.LastIndexByte(p.src[:offset], '\n') + 1)
+}
+
+func (p *yamlParser) peek(n int) byte {
+	if p.pos+n < len(p.src) {
+		return p.src[p.pos+n]
+	}
+	return 0
+}
+
+// end returns the end offset of the given node. A value that is absent ends at the given position
+func end(n *Node, pos int) int {
+	if n.Length == 0 && n.Kind == SCALAR && n.Value == nil {
+		return n.Offset
+	}
+	return n.Offset + n.Length
+}
+
+// resolvePlain returns the value of a plain scalar
+func resolvePlain(text string) interface{} {
+	switch text {
+	case ``, `~`, `null`, `Null`, `NULL`:
+		return nil
+	}
+	if b, ok := booleanStrings[text]; ok {
+		return b
+	}
+	c := text[0]
+	if !(c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.') {
+		return text
+	}
+
+	clean := strings.Replace(text, `_`, ``, -1)
+	sign := int64(1)
+	digits := clean
+	if digits[0] == '-' || digits[0] == '+' {
+		if digits[0] == '-' {
+			sign = -1
+		}
+		digits = digits[1:]
+	}
+	parseInt := func(s string, base int) interface{} {
+		if i, err := strconv.ParseInt(s, base, 64); err == nil {
+			return sign * i
+		}
+		return text
+	}
+	switch {
+	case intPattern.MatchString(text):
+		return parseInt(digits, 10)
+	case hexPattern.MatchString(text):
+		return parseInt(digits[2:], 16)
+	case binaryPattern.MatchString(text):
+		return parseInt(digits[2:], 2)
+	case octalPattern.MatchString(text):
+		return parseInt(strings.TrimPrefix(digits[1:], `o`), 8)
+	case infPattern.MatchString(text):
+		return float64(sign) * math.Inf(1)
+	case nanPattern.MatchString(text):
+		return math.NaN()
+	case floatPattern.MatchString(text) && strings.IndexAny(text, `0123456789`) >= 0:
+		if f, err := strconv.ParseFloat(clean, 64); err == nil {
+			return f
+		}
+	}
+	return text
+}
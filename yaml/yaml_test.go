@@ -0,0 +1,261 @@
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestParseMapping(t *testing.T) {
+	expectData(t, issue.Unindent(`
+    ---
+    # A comment
+    foo::port: 8080
+    foo::name: 'web'   # trailing comment
+    "foo::enabled": yes
+    foo::ratio: 0.5
+    foo::mode: 0755
+    foo::empty:
+    foo::list:
+    - a
+    - "b"
+    foo::hash:
+      x: 1
+      y: ~
+    bar: baz`),
+		map[string]interface{}{
+			`foo::port`:    int64(8080),
+			`foo::name`:    `web`,
+			`foo::enabled`: true,
+			`foo::ratio`:   0.5,
+			`foo::mode`:    int64(493),
+			`foo::empty`:   nil,
+			`foo::list`:    []interface{}{`a`, `b`},
+			`foo::hash`:    map[string]interface{}{`x`: int64(1), `y`: nil},
+			`bar`:          `baz`,
+		})
+}
+
+func TestParseSequence(t *testing.T) {
+	expectData(t, issue.Unindent(`
+    - a: 1
+      b: [x, 'y, z', {c: d}]
+    - - nested
+    -
+      e: f
+    - http://example.com`),
+		[]interface{}{
+			map[string]interface{}{`a`: int64(1), `b`: []interface{}{`x`, `y, z`, map[string]interface{}{`c`: `d`}}},
+			[]interface{}{`nested`},
+			map[string]interface{}{`e`: `f`},
+			`http://example.com`,
+		})
+}
+
+func TestParseScalars(t *testing.T) {
+	for source, expected := range map[string]interface{}{
+		`~`:                  nil,
+		`-12`:                int64(-12),
+		`0x1F`:               int64(31),
+		`1_000`:              int64(1000),
+		`1.5e3`:              1500.0,
+		`.5`:                 0.5,
+		`Off`:                false,
+		`y`:                  `y`,
+		`1.2.3`:              `1.2.3`,
+		`'it''s'`:            `it's`,
+		`"tab\there \u00e9"`: "tab\there \u00e9",
+		"'folded\n  line'":   `folded line`,
+		`a: %{hiera('x')}`:   map[string]interface{}{`a`: `%{hiera('x')}`},
+	} {
+		expectData(t, source, expected)
+	}
+	n, err := Parse(`-.inf`)
+	if err != nil || !math.IsInf(n.Value.(float64), -1) {
+		t.Errorf(`expected negative infinity, got %v`, n.Value)
+	}
+}
+
+func TestParseBlockScalars(t *testing.T) {
+	expectData(t, issue.Unindent(`
+    literal: |
+      line 1
+        indented
+
+      line 3
+    folded: >-
+      a
+      b
+
+      c
+    keep: |+
+      x
+
+    next: 1`),
+		map[string]interface{}{
+			`literal`: "line 1\n  indented\n\nline 3\n",
+			`folded`:  "a b\nc",
+			`keep`:    "x\n\n",
+			`next`:    int64(1),
+		})
+}
+
+func TestParseComments(t *testing.T) {
+	expectData(t, issue.Unindent(`
+    # A comment
+    url: http://example.com/#fragment
+    pw: ab#c   # a comment
+    list: [a#b, c] # a comment
+    empty: # a comment
+    `),
+		map[string]interface{}{
+			`url`:   `http://example.com/#fragment`,
+			`pw`:    `ab#c`,
+			`list`:  []interface{}{`a#b`, `c`},
+			`empty`: nil,
+		})
+}
+
+func TestParseMultiLinePlainScalars(t *testing.T) {
+	expectData(t, issue.Unindent(`
+    a: this is
+      continued
+
+      on more lines
+    b:
+      first
+      second # a comment
+    c:
+    - one
+      two
+    - three
+    d: 1`),
+		map[string]interface{}{
+			`a`: "this is continued\non more lines",
+			`b`: `first second`,
+			`c`: []interface{}{`one two`, `three`},
+			`d`: int64(1),
+		})
+	expectData(t, "first\nsecond\n", `first second`)
+
+	source := "a: x\n  y\nb: 1\n"
+	n, err := Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := n.Get(`a`)
+	if actual := source[a.Offset : a.Offset+a.Length]; actual != "x\n  y" {
+		t.Errorf(`unexpected node text '%s'`, actual)
+	}
+}
+
+func TestParseMergeKeys(t *testing.T) {
+	expectData(t, issue.Unindent(`
+    defaults: &defaults
+      a: 1
+      b: 2
+    x:
+      <<: *defaults
+      b: 3
+    y:
+      <<: [{c: 4, a: 0}, *defaults]
+    z: {<<: *defaults, a: 5}
+    '<<': quoted`),
+		map[string]interface{}{
+			`defaults`: map[string]interface{}{`a`: int64(1), `b`: int64(2)},
+			`x`:        map[string]interface{}{`a`: int64(1), `b`: int64(3)},
+			`y`:        map[string]interface{}{`a`: int64(0), `b`: int64(2), `c`: int64(4)},
+			`z`:        map[string]interface{}{`a`: int64(5), `b`: int64(2)},
+			`<<`:       `quoted`,
+		})
+
+	n, err := Parse("d: &d\n  a: 1\nx:\n  b: 2\n  <<: *d\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := n.Get(`x`); len(x.Keys) != 2 || x.Keys[0].Value != `b` || x.Keys[1].Value != `a` {
+		t.Errorf(`expected the merged key to follow the explicit key, got %v`, x.Data())
+	}
+}
+
+func TestParseAnchors(t *testing.T) {
+	expectData(t, issue.Unindent(`
+    defaults: &defaults
+      a: 1
+    other: *defaults`),
+		map[string]interface{}{
+			`defaults`: map[string]interface{}{`a`: int64(1)},
+			`other`:    map[string]interface{}{`a`: int64(1)},
+		})
+}
+
+func TestPositions(t *testing.T) {
+	source := "a:\n  b: [1, 2]\n  c: 'x'\n"
+	n, err := Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := n.Get(`a`).Get(`b`)
+	if actual := source[b.Offset : b.Offset+b.Length]; actual != `[1, 2]` {
+		t.Errorf(`unexpected node text '%s'`, actual)
+	}
+	c := n.Get(`a`).Get(`c`)
+	if actual := source[c.Offset : c.Offset+c.Length]; actual != `'x'` {
+		t.Errorf(`unexpected node text '%s'`, actual)
+	}
+	key := n.Get(`a`).Keys[1]
+	if actual := source[key.Offset : key.Offset+key.Length]; actual != `c` {
+		t.Errorf(`unexpected key text '%s'`, actual)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	for source, expected := range map[string]string{
+		"a: 1\n  b: 2":  `bad indentation of a mapping entry at line 2, column 3`,
+		"a: [1, 2":      `unexpected end of input in flow collection at line 1, column 9`,
+		"a: 'x":         `unterminated quoted scalar at line 1, column 4`,
+		"a: b: c":       `mapping values are not allowed here at line 1, column 5`,
+		"a: *x":         `unknown alias 'x' at line 1, column 4`,
+		"a:\n\t- b":     `tab characters must not be used for indentation at line 2, column 1`,
+		"- a\nb: 1":     `unexpected content at line 2, column 1`,
+		"a: \"x\\q\"":   `invalid escape sequence '\q' at line 1, column 6`,
+		"a: [1, 2] 3":   `unexpected content after value at line 1, column 11`,
+		"a: {b: 1]":     `expected ',' or '}' at line 1, column 9`,
+		"a: x\n  b: 2":  `bad indentation of a mapping entry at line 2, column 3`,
+		"a: x # c\n  y": `bad indentation of a mapping entry at line 2, column 3`,
+		"a:\n  <<: 1":   `expected a mapping or a list of mappings to merge at line 2, column 7`,
+		"a:\n  <<: [1]": `expected a mapping to merge at line 2, column 8`,
+	} {
+		_, err := Parse(source)
+		if err == nil {
+			t.Errorf(`expected error for %q`, source)
+		} else if err.Error() != expected {
+			t.Errorf(`expected error '%s' for %q, got '%s'`, expected, source, err.Error())
+		}
+	}
+}
+
+func TestEmptyDocument(t *testing.T) {
+	n, err := Parse("---\n# nothing here\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Kind != SCALAR || n.Value != nil {
+		t.Errorf(`expected null scalar, got %v`, n.Data())
+	}
+}
+
+func expectData(t *testing.T, source string, expected interface{}) {
+	t.Helper()
+	n, err := Parse(source)
+	if err != nil {
+		t.Errorf(`unexpected error for %q: %s`, source, err.Error())
+		return
+	}
+	if actual := n.Data(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %s, got %s for %q", fmt.Sprintf(`%#v`, expected), fmt.Sprintf(`%#v`, actual), source)
+	}
+}